@@ -31,10 +31,12 @@ func initializeFlags() {
 	leafPartitionData = flag.Bool("leaf-partition-data", false, "For partition tables, create one data file per leaf partition instead of one data file for the whole table")
 	metadataOnly = flag.Bool("metadata-only", false, "Only back up metadata, do not back up data")
 	noCompression = flag.Bool("no-compression", false, "Disable compression of data files")
+	objectDefinitions = flag.String("object-definitions", "", "Also write the structured definition of each backed-up object, keyed by table of contents entry, to a file in the specified format. Valid values are yaml and json.")
 	pluginConfigFile = flag.String("plugin-config", "", "The configuration file to use for a plugin")
 	printVersion = flag.Bool("version", false, "Print version number and exit")
 	quiet = flag.Bool("quiet", false, "Suppress non-warning, non-error log messages")
 	singleDataFile = flag.Bool("single-data-file", false, "Back up all data to a single file instead of one per table")
+	streamMetadata = flag.Bool("stream-metadata", false, "Stream metadata, table of contents, and statistics files directly to the plugin instead of writing them to the master first")
 	verbose = flag.Bool("verbose", false, "Print verbose log messages")
 	withStats = flag.Bool("with-stats", false, "Back up query plan statistics")
 }
@@ -82,13 +84,18 @@ func DoSetup() {
 	CreateBackupDirectoriesOnAllHosts()
 	globalTOC = &utils.TOC{}
 	globalTOC.InitializeEntryMap()
+	if *objectDefinitions != "" {
+		globalTOC.InitializeObjectDefinitions()
+	}
 
 	if *pluginConfigFile != "" {
 		pluginConfig = utils.ReadPluginConfig(*pluginConfigFile)
 		pluginConfig.CheckPluginExistsOnAllHosts(globalCluster)
+		pluginConfig.ValidateOptions(pluginConfig.GetPluginSchema())
 		pluginConfig.CopyPluginConfigToAllHosts(globalCluster, *pluginConfigFile)
 		pluginConfig.SetupPluginForBackupOnAllHosts(globalCluster, pluginConfig.ConfigPath, globalFPInfo.GetDirForContent(-1))
 		backupReport.Plugin = pluginConfig.ExecutablePath
+		backupReport.StreamedMetadata = *streamMetadata
 	}
 }
 
@@ -101,7 +108,7 @@ func DoBackup() {
 	CheckTablesContainData(dataTables, tableDefs)
 	metadataFilename := globalFPInfo.GetMetadataFilePath()
 	gplog.Info("Metadata will be written to %s", metadataFilename)
-	metadataFile := utils.NewFileWithByteCountFromFile(metadataFilename)
+	metadataFile := openMetadataFileForWriting(metadataFilename)
 	defer metadataFile.Close()
 
 	BackupSessionGUCs(metadataFile)
@@ -114,6 +121,7 @@ func DoBackup() {
 		}
 		backupPostdata(metadataFile)
 	}
+	metadataFile.Close()
 
 	if !backupReport.MetadataOnly {
 		backupData(dataTables, tableDefs)
@@ -123,15 +131,62 @@ func DoBackup() {
 		backupStatistics(metadataTables)
 	}
 
-	globalTOC.WriteToFileAndMakeReadOnly(globalFPInfo.GetTOCFilePath())
+	if *streamMetadata {
+		globalTOC.WriteToPlugin(pluginConfig, globalFPInfo.GetTOCFilePath())
+	} else {
+		globalTOC.WriteToFileAndMakeReadOnly(globalFPInfo.GetTOCFilePath())
+	}
+	if *objectDefinitions != "" {
+		definitionsFilename := globalFPInfo.GetObjectDefinitionsFilePath(*objectDefinitions)
+		if *streamMetadata {
+			globalTOC.WriteObjectDefinitionsToPlugin(pluginConfig, definitionsFilename, *objectDefinitions)
+		} else {
+			globalTOC.WriteObjectDefinitionsToFile(definitionsFilename, *objectDefinitions)
+		}
+	}
 	connection.MustCommit()
-	if *pluginConfigFile != "" {
+	if *pluginConfigFile != "" && !*streamMetadata {
 		pluginConfig.BackupFile(metadataFilename)
 		pluginConfig.BackupFile(globalFPInfo.GetTOCFilePath())
 		if *withStats {
 			pluginConfig.BackupFile(globalFPInfo.GetStatisticsFilePath())
 		}
+		if *objectDefinitions != "" {
+			pluginConfig.BackupFile(globalFPInfo.GetObjectDefinitionsFilePath(*objectDefinitions))
+		}
+	}
+}
+
+/*
+ * This function writes the metadata of the given database to metadataFile as a
+ * metadata-only backup would and returns the corresponding TOC, without creating
+ * any backup files, so that a live database can be compared against a backup.
+ */
+func BackupMetadataForComparison(dbName string, metadataFile *utils.FileWithByteCount) *utils.TOC {
+	dbname = &dbName
+	if leafPartitionData == nil {
+		leafPartitionData = new(bool)
+	}
+	InitializeConnection()
+	defer connection.Close()
+	objectCounts = make(map[string]int, 0)
+	globalTOC = &utils.TOC{}
+	globalTOC.InitializeEntryMap()
+
+	metadataTables, _, tableDefs := RetrieveAndProcessTables()
+	BackupSessionGUCs(metadataFile)
+	backupGlobal(metadataFile)
+	backupPredata(metadataFile, metadataTables, tableDefs)
+	backupPostdata(metadataFile)
+	connection.MustCommit()
+	return globalTOC
+}
+
+func openMetadataFileForWriting(filename string) *utils.FileWithByteCount {
+	if *streamMetadata {
+		return utils.NewFileWithByteCountFromPlugin(pluginConfig, filename)
 	}
+	return utils.NewFileWithByteCountFromFile(filename)
 }
 
 func backupGlobal(metadataFile *utils.FileWithByteCount) {
@@ -149,6 +204,9 @@ func backupGlobal(metadataFile *utils.FileWithByteCount) {
 		BackupRoles(metadataFile)
 		BackupRoleGrants(metadataFile)
 	}
+	if connection.Version.AtLeast("6") {
+		BackupSharedSecurityLabels(metadataFile)
+	}
 	if wasTerminated {
 		gplog.Info("Global database metadata backup incomplete")
 	} else {
@@ -166,6 +224,9 @@ func backupPredata(metadataFile *utils.FileWithByteCount, tables []Relation, tab
 	if len(includeSchemas) == 0 && connection.Version.AtLeast("5") {
 		BackupExtensions(metadataFile)
 	}
+	if connection.Version.AtLeast("6") {
+		BackupCollations(metadataFile)
+	}
 
 	procLangs := GetProceduralLanguages(connection)
 	langFuncs, otherFuncs, functionMetadata := RetrieveFunctions(procLangs)
@@ -186,36 +247,47 @@ func backupPredata(metadataFile *utils.FileWithByteCount, tables []Relation, tab
 
 	constraints, conMetadata := RetrieveConstraints()
 
-	BackupFunctionsAndTypesAndTables(metadataFile, otherFuncs, types, tables, functionMetadata, typeMetadata, relationMetadata, tableDefs, constraints)
-	BackupAlterSequences(metadataFile, sequences)
-
-	if len(includeSchemas) == 0 {
-		BackupProtocols(metadataFile, funcInfoMap)
-		if connection.Version.AtLeast("6") {
-			BackupForeignDataWrappers(metadataFile, funcInfoMap)
-			BackupForeignServers(metadataFile)
-			BackupUserMappings(metadataFile)
+	sortables := make([]Sortable, 0)
+	for _, function := range otherFuncs {
+		sortables = append(sortables, function)
+	}
+	for _, typ := range types {
+		if typ.Type != "e" && typ.Type != "p" {
+			sortables = append(sortables, typ)
 		}
 	}
-
+	metadataMap := make(UniqueMetadataMap)
+	metadataMap.Add(PG_PROC_OID, functionMetadata)
+	metadataMap.Add(PG_TYPE_OID, typeMetadata)
+	metadataMap.Add(PG_CLASS_OID, relationMetadata)
+	if len(includeSchemas) == 0 {
+		RetrieveProtocols(&sortables, metadataMap)
+	}
 	if connection.Version.AtLeast("5") {
-		BackupTSParsers(metadataFile)
-		BackupTSTemplates(metadataFile)
-		BackupTSDictionaries(metadataFile)
-		BackupTSConfigurations(metadataFile)
+		RetrieveTSParsers(&sortables, metadataMap)
+		RetrieveTSTemplates(&sortables, metadataMap)
+		RetrieveTSDictionaries(&sortables, metadataMap)
+		RetrieveTSConfigurations(&sortables, metadataMap)
+		RetrieveOperatorFamilies(&sortables, metadataMap)
 	}
+	RetrieveOperators(&sortables, metadataMap)
+	RetrieveOperatorClasses(&sortables, metadataMap)
+	RetrieveConversions(&sortables, metadataMap)
+	RetrieveAggregates(&sortables, metadataMap)
+	RetrieveCasts(&sortables, metadataMap)
+	RetrieveViews(&sortables)
+
+	BackupDependentObjects(metadataFile, tables, tableDefs, sortables, metadataMap, constraints, funcInfoMap)
+	BackupAlterSequences(metadataFile, sequences)
 
-	BackupOperators(metadataFile)
-	if connection.Version.AtLeast("5") {
-		BackupOperatorFamilies(metadataFile)
+	if len(includeSchemas) == 0 && connection.Version.AtLeast("6") {
+		BackupForeignDataWrappers(metadataFile, funcInfoMap)
+		BackupForeignServers(metadataFile)
+		BackupUserMappings(metadataFile)
 	}
-	BackupOperatorClasses(metadataFile)
 
-	BackupConversions(metadataFile)
-	BackupAggregates(metadataFile, funcInfoMap)
-	BackupCasts(metadataFile)
-	BackupViews(metadataFile, relationMetadata)
 	BackupConstraints(metadataFile, constraints, conMetadata)
+	globalTOC.SetSerialDependencies("predata")
 	if wasTerminated {
 		gplog.Info("Pre-data metadata backup incomplete")
 	} else {
@@ -235,13 +307,15 @@ func backupTablePredata(metadataFile *utils.FileWithByteCount, tables []Relation
 
 	BackupTables(metadataFile, tables, relationMetadata, tableDefs, constraints)
 	BackupConstraints(metadataFile, constraints, conMetadata)
+	globalTOC.SetSerialDependencies("predata")
 	gplog.Info("Table metadata backup complete")
 }
 
 func backupData(tables []Relation, tableDefs map[uint32]TableDefinition) {
 	gplog.Info("Writing data to file")
 	rowsCopiedMap := BackupData(tables, tableDefs)
-	AddTableDataEntriesToTOC(tables, tableDefs, rowsCopiedMap)
+	tableSizes := GetTableSizes(connection, tables)
+	AddTableDataEntriesToTOC(tables, tableDefs, rowsCopiedMap, tableSizes)
 	if *singleDataFile {
 		MoveSegmentTOCsAndMakeReadOnly()
 		if *pluginConfigFile != "" {
@@ -264,6 +338,13 @@ func backupPostdata(metadataFile *utils.FileWithByteCount) {
 	BackupIndexes(metadataFile)
 	BackupRules(metadataFile)
 	BackupTriggers(metadataFile)
+	if len(includeTables) == 0 && connection.Version.AtLeast("6") {
+		BackupSecurityLabels(metadataFile)
+		BackupDefaultPrivileges(metadataFile)
+		if len(includeSchemas) == 0 {
+			BackupEventTriggers(metadataFile)
+		}
+	}
 	if wasTerminated {
 		gplog.Info("Post-data metadata backup incomplete")
 	} else {
@@ -277,7 +358,7 @@ func backupStatistics(tables []Relation) {
 	}
 	statisticsFilename := globalFPInfo.GetStatisticsFilePath()
 	gplog.Info("Writing query planner statistics to %s", statisticsFilename)
-	statisticsFile := utils.NewFileWithByteCountFromFile(statisticsFilename)
+	statisticsFile := openMetadataFileForWriting(statisticsFilename)
 	defer statisticsFile.Close()
 	BackupStatistics(statisticsFile, tables)
 	if wasTerminated {
@@ -335,6 +416,7 @@ func DoTeardown() {
 			pluginConfig.BackupFile(configFilename, true)
 			pluginConfig.BackupFile(reportFilename, true)
 			pluginConfig.CleanupPluginForBackupOnAllHosts(globalCluster)
+			pluginConfig.DeletePluginConfigFromAllHosts(globalCluster)
 		}
 	}
 
@@ -29,11 +29,11 @@ func ConstructTableAttributesList(columnDefs []ColumnDefinition) string {
 	return ""
 }
 
-func AddTableDataEntriesToTOC(tables []Relation, tableDefs map[uint32]TableDefinition, rowsCopiedMap map[uint32]int64) {
+func AddTableDataEntriesToTOC(tables []Relation, tableDefs map[uint32]TableDefinition, rowsCopiedMap map[uint32]int64, tableSizes map[uint32]int64) {
 	for _, table := range tables {
 		if !tableDefs[table.Oid].IsExternal {
 			attributes := ConstructTableAttributesList(tableDefs[table.Oid].ColumnDefs)
-			globalTOC.AddMasterDataEntry(table.Schema, table.Name, table.Oid, attributes, rowsCopiedMap[table.Oid])
+			globalTOC.AddMasterDataEntry(table.Schema, table.Name, table.Oid, attributes, rowsCopiedMap[table.Oid], tableSizes[table.Oid], tableDefs[table.Oid].DistPolicy)
 		}
 	}
 }
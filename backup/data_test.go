@@ -32,24 +32,36 @@ var _ = Describe("backup/data tests", func() {
 	Describe("AddTableDataEntriesToTOC", func() {
 		var toc *utils.TOC
 		var rowsCopiedMap map[uint32]int64
+		var tableSizes map[uint32]int64
 		BeforeEach(func() {
 			toc = &utils.TOC{}
 			backup.SetTOC(toc)
 			rowsCopiedMap = make(map[uint32]int64, 0)
+			tableSizes = make(map[uint32]int64, 0)
 		})
 		It("adds an entry for a regular table to the TOC", func() {
 			columnDefs := []backup.ColumnDefinition{{Oid: 1, Name: "a"}}
-			tableDefs := map[uint32]backup.TableDefinition{1: {ColumnDefs: columnDefs}}
+			tableDefs := map[uint32]backup.TableDefinition{1: {ColumnDefs: columnDefs, DistPolicy: "DISTRIBUTED BY (a)"}}
 			tables := []backup.Relation{{Oid: 1, Schema: "public", Name: "table"}}
-			backup.AddTableDataEntriesToTOC(tables, tableDefs, rowsCopiedMap)
-			expectedDataEntries := []utils.MasterDataEntry{{Schema: "public", Name: "table", Oid: 1, AttributeString: "(a)"}}
+			backup.AddTableDataEntriesToTOC(tables, tableDefs, rowsCopiedMap, tableSizes)
+			expectedDataEntries := []utils.MasterDataEntry{{Schema: "public", Name: "table", Oid: 1, AttributeString: "(a)", DistributionPolicy: "DISTRIBUTED BY (a)"}}
+			Expect(toc.DataEntries).To(Equal(expectedDataEntries))
+		})
+		It("records the number of rows and bytes backed up for a table in the TOC", func() {
+			columnDefs := []backup.ColumnDefinition{{Oid: 1, Name: "a"}}
+			tableDefs := map[uint32]backup.TableDefinition{1: {ColumnDefs: columnDefs, DistPolicy: "DISTRIBUTED BY (a)"}}
+			tables := []backup.Relation{{Oid: 1, Schema: "public", Name: "table"}}
+			rowsCopiedMap[1] = 10
+			tableSizes[1] = 32768
+			backup.AddTableDataEntriesToTOC(tables, tableDefs, rowsCopiedMap, tableSizes)
+			expectedDataEntries := []utils.MasterDataEntry{{Schema: "public", Name: "table", Oid: 1, AttributeString: "(a)", RowsCopied: 10, TableSize: 32768, DistributionPolicy: "DISTRIBUTED BY (a)"}}
 			Expect(toc.DataEntries).To(Equal(expectedDataEntries))
 		})
 		It("does not add an entry for an external table to the TOC", func() {
 			columnDefs := []backup.ColumnDefinition{{Oid: 1, Name: "a"}}
 			tableDefs := map[uint32]backup.TableDefinition{1: {ColumnDefs: columnDefs, IsExternal: true}}
 			tables := []backup.Relation{{Oid: 1, Schema: "public", Name: "table"}}
-			backup.AddTableDataEntriesToTOC(tables, tableDefs, rowsCopiedMap)
+			backup.AddTableDataEntriesToTOC(tables, tableDefs, rowsCopiedMap, tableSizes)
 			Expect(toc.DataEntries).To(BeNil())
 		})
 	})
@@ -57,7 +69,7 @@ var _ = Describe("backup/data tests", func() {
 		It("will back up a table to its own file with compression", func() {
 			backup.SetSingleDataFile(false)
 			utils.SetCompressionParameters(true, utils.Compression{Name: "gzip", CompressCommand: "gzip -c -8", DecompressCommand: "gzip -d -c", Extension: ".gz"})
-			testTable := backup.Relation{SchemaOid: 2345, Oid: 3456, Schema: "public", Name: "foo", DependsUpon: nil, Inherits: nil}
+			testTable := backup.Relation{SchemaOid: 2345, Oid: 3456, Schema: "public", Name: "foo", Inherits: nil}
 			execStr := regexp.QuoteMeta("COPY public.foo TO PROGRAM 'gzip -c -8 > <SEG_DATA_DIR>/backups/20170101/20170101010101/gpbackup_<SEGID>_20170101010101_3456.gz' WITH CSV DELIMITER ',' ON SEGMENT IGNORE EXTERNAL PARTITIONS;")
 			mock.ExpectExec(execStr).WillReturnResult(sqlmock.NewResult(10, 0))
 			filename := "<SEG_DATA_DIR>/backups/20170101/20170101010101/gpbackup_<SEGID>_20170101010101_3456.gz"
@@ -66,7 +78,7 @@ var _ = Describe("backup/data tests", func() {
 		It("will back up a table to its own file without compression", func() {
 			backup.SetSingleDataFile(false)
 			utils.SetCompressionParameters(false, utils.Compression{})
-			testTable := backup.Relation{SchemaOid: 2345, Oid: 3456, Schema: "public", Name: "foo", DependsUpon: nil, Inherits: nil}
+			testTable := backup.Relation{SchemaOid: 2345, Oid: 3456, Schema: "public", Name: "foo", Inherits: nil}
 			execStr := regexp.QuoteMeta("COPY public.foo TO '<SEG_DATA_DIR>/backups/20170101/20170101010101/gpbackup_<SEGID>_20170101010101_3456' WITH CSV DELIMITER ',' ON SEGMENT IGNORE EXTERNAL PARTITIONS;")
 			mock.ExpectExec(execStr).WillReturnResult(sqlmock.NewResult(10, 0))
 			filename := "<SEG_DATA_DIR>/backups/20170101/20170101010101/gpbackup_<SEGID>_20170101010101_3456"
@@ -75,7 +87,7 @@ var _ = Describe("backup/data tests", func() {
 		It("will back up a table to a single file", func() {
 			backup.SetSingleDataFile(true)
 			utils.SetCompressionParameters(false, utils.Compression{})
-			testTable := backup.Relation{SchemaOid: 2345, Oid: 3456, Schema: "public", Name: "foo", DependsUpon: nil, Inherits: nil}
+			testTable := backup.Relation{SchemaOid: 2345, Oid: 3456, Schema: "public", Name: "foo", Inherits: nil}
 			execStr := regexp.QuoteMeta(`COPY public.foo TO PROGRAM '([[ -p <SEG_DATA_DIR>/backups/20170101/20170101010101/gpbackup_<SEGID>_20170101010101 ]] || (echo "Pipe not found">&2; exit 1)) && $GPHOME/bin/gpbackup_helper --oid=3456 --toc-file=<SEG_DATA_DIR>/gpbackup_<SEGID>_20170101010101_toc.yaml --content=<SEGID> >> <SEG_DATA_DIR>/backups/20170101/20170101010101/gpbackup_<SEGID>_20170101010101' WITH CSV DELIMITER ',' ON SEGMENT IGNORE EXTERNAL PARTITIONS;`)
 			mock.ExpectExec(execStr).WillReturnResult(sqlmock.NewResult(10, 0))
 			filename := "<SEG_DATA_DIR>/backups/20170101/20170101010101/gpbackup_<SEGID>_20170101010101"
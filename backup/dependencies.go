@@ -2,41 +2,58 @@ package backup
 
 import (
 	"fmt"
+	"sort"
 
+	"github.com/greenplum-db/gp-common-go-libs/dbconn"
 	"github.com/greenplum-db/gp-common-go-libs/gplog"
 	"github.com/greenplum-db/gpbackup/utils"
 	"github.com/pkg/errors"
 )
 
-func SortFunctionsAndTypesAndTablesInDependencyOrder(functions []Function, types []Type, tables []Relation) []Sortable {
-	objects := make([]Sortable, 0)
-	for _, function := range functions {
-		objects = append(objects, function)
-	}
-	for _, typ := range types {
-		if typ.Type != "e" && typ.Type != "p" {
-			objects = append(objects, typ)
-		}
-	}
-	for _, table := range tables {
-		objects = append(objects, table)
-	}
-	sorted := TopologicalSort(objects)
-	return sorted
+/*
+ * Objects are identified in pg_depend by the oid of the catalog table that
+ * contains them together with their own oid, as oids are only guaranteed to be
+ * unique within a single catalog table.  The catalog table oids are fixed
+ * across all supported GPDB versions.
+ */
+const (
+	PG_CAST_OID        uint32 = 2605
+	PG_CLASS_OID       uint32 = 1259
+	PG_CONVERSION_OID  uint32 = 2607
+	PG_EXTPROTOCOL_OID uint32 = 7175
+	PG_OPCLASS_OID     uint32 = 2616
+	PG_OPERATOR_OID    uint32 = 2617
+	PG_OPFAMILY_OID    uint32 = 2753
+	PG_PROC_OID        uint32 = 1255
+	PG_TS_CONFIG_OID   uint32 = 3602
+	PG_TS_DICT_OID     uint32 = 3600
+	PG_TS_PARSER_OID   uint32 = 3601
+	PG_TS_TEMPLATE_OID uint32 = 3764
+	PG_TYPE_OID        uint32 = 1247
+)
+
+type UniqueID struct {
+	ClassID uint32
+	Oid     uint32
 }
 
-func ConstructFunctionAndTypeAndTableMetadataMap(functions MetadataMap, types MetadataMap, tables MetadataMap) MetadataMap {
-	metadataMap := make(MetadataMap, 0)
-	for k, v := range functions {
-		metadataMap[k] = v
-	}
-	for k, v := range types {
-		metadataMap[k] = v
-	}
-	for k, v := range tables {
-		metadataMap[k] = v
+/*
+ * A DependencyMap maps each object to the set of objects that must be created
+ * before it can be created.
+ */
+type DependencyMap map[UniqueID]map[UniqueID]bool
+
+/*
+ * Metadata for objects from several catalog tables that are sorted together is
+ * kept in one map, which is keyed by UniqueID because objects in different
+ * catalog tables may have the same oid.
+ */
+type UniqueMetadataMap map[UniqueID]ObjectMetadata
+
+func (metadataMap UniqueMetadataMap) Add(classID uint32, objectMetadata MetadataMap) {
+	for oid, metadata := range objectMetadata {
+		metadataMap[UniqueID{ClassID: classID, Oid: oid}] = metadata
 	}
-	return metadataMap
 }
 
 /*
@@ -45,7 +62,7 @@ func ConstructFunctionAndTypeAndTableMetadataMap(functions MetadataMap, types Me
 
 type Sortable interface {
 	FQN() string
-	Dependencies() []string
+	GetUniqueID() UniqueID
 }
 
 func (r Relation) FQN() string {
@@ -68,50 +85,223 @@ func (t Type) FQN() string {
 	return utils.MakeFQN(t.Schema, t.Name)
 }
 
-func (r Relation) Dependencies() []string {
-	return r.DependsUpon
+func (a Aggregate) FQN() string {
+	return fmt.Sprintf("%s(%s)", utils.MakeFQN(a.Schema, a.Name), a.Arguments)
+}
+
+func (c Cast) FQN() string {
+	return fmt.Sprintf("(%s AS %s)", c.SourceTypeFQN, c.TargetTypeFQN)
+}
+
+func (c Conversion) FQN() string {
+	return utils.MakeFQN(c.Schema, c.Name)
+}
+
+func (p ExternalProtocol) FQN() string {
+	return p.Name
+}
+
+func (o Operator) FQN() string {
+	return fmt.Sprintf("%s(%s, %s)", utils.MakeFQN(o.Schema, o.Name), o.LeftArgType, o.RightArgType)
+}
+
+func (opc OperatorClass) FQN() string {
+	return fmt.Sprintf("%s USING %s", utils.MakeFQN(opc.Schema, opc.Name), opc.IndexMethod)
+}
+
+func (opf OperatorFamily) FQN() string {
+	return fmt.Sprintf("%s USING %s", utils.MakeFQN(opf.Schema, opf.Name), opf.IndexMethod)
+}
+
+func (tsp TextSearchParser) FQN() string {
+	return utils.MakeFQN(tsp.Schema, tsp.Name)
+}
+
+func (tst TextSearchTemplate) FQN() string {
+	return utils.MakeFQN(tst.Schema, tst.Name)
+}
+
+func (tsd TextSearchDictionary) FQN() string {
+	return utils.MakeFQN(tsd.Schema, tsd.Name)
+}
+
+func (tsc TextSearchConfiguration) FQN() string {
+	return utils.MakeFQN(tsc.Schema, tsc.Name)
+}
+
+func (r Relation) GetUniqueID() UniqueID {
+	return UniqueID{ClassID: PG_CLASS_OID, Oid: r.Oid}
+}
+
+func (v View) GetUniqueID() UniqueID {
+	return UniqueID{ClassID: PG_CLASS_OID, Oid: v.Oid}
 }
 
-func (v View) Dependencies() []string {
-	return v.DependsUpon
+func (f Function) GetUniqueID() UniqueID {
+	return UniqueID{ClassID: PG_PROC_OID, Oid: f.Oid}
 }
 
-func (f Function) Dependencies() []string {
-	return f.DependsUpon
+func (t Type) GetUniqueID() UniqueID {
+	return UniqueID{ClassID: PG_TYPE_OID, Oid: t.Oid}
 }
 
-func (t Type) Dependencies() []string {
-	return t.DependsUpon
+func (a Aggregate) GetUniqueID() UniqueID {
+	return UniqueID{ClassID: PG_PROC_OID, Oid: a.Oid}
 }
 
-func SortViews(views []View) []View {
-	sortable := make([]Sortable, len(views))
-	for i := range views {
-		sortable[i] = views[i]
+func (c Cast) GetUniqueID() UniqueID {
+	return UniqueID{ClassID: PG_CAST_OID, Oid: c.Oid}
+}
+
+func (c Conversion) GetUniqueID() UniqueID {
+	return UniqueID{ClassID: PG_CONVERSION_OID, Oid: c.Oid}
+}
+
+func (p ExternalProtocol) GetUniqueID() UniqueID {
+	return UniqueID{ClassID: PG_EXTPROTOCOL_OID, Oid: p.Oid}
+}
+
+func (o Operator) GetUniqueID() UniqueID {
+	return UniqueID{ClassID: PG_OPERATOR_OID, Oid: o.Oid}
+}
+
+func (opc OperatorClass) GetUniqueID() UniqueID {
+	return UniqueID{ClassID: PG_OPCLASS_OID, Oid: opc.Oid}
+}
+
+func (opf OperatorFamily) GetUniqueID() UniqueID {
+	return UniqueID{ClassID: PG_OPFAMILY_OID, Oid: opf.Oid}
+}
+
+func (tsp TextSearchParser) GetUniqueID() UniqueID {
+	return UniqueID{ClassID: PG_TS_PARSER_OID, Oid: tsp.Oid}
+}
+
+func (tst TextSearchTemplate) GetUniqueID() UniqueID {
+	return UniqueID{ClassID: PG_TS_TEMPLATE_OID, Oid: tst.Oid}
+}
+
+func (tsd TextSearchDictionary) GetUniqueID() UniqueID {
+	return UniqueID{ClassID: PG_TS_DICT_OID, Oid: tsd.Oid}
+}
+
+func (tsc TextSearchConfiguration) GetUniqueID() UniqueID {
+	return UniqueID{ClassID: PG_TS_CONFIG_OID, Oid: tsc.Oid}
+}
+
+func CreateBackupSet(objects []Sortable) map[UniqueID]bool {
+	backupSet := make(map[UniqueID]bool, len(objects))
+	for _, object := range objects {
+		backupSet[object.GetUniqueID()] = true
 	}
-	sortable = TopologicalSort(sortable)
-	for i := range views {
-		views[i] = sortable[i].(View)
+	return backupSet
+}
+
+/*
+ * This function retrieves every dependency recorded in pg_depend and keeps those
+ * where both the dependent object and the referenced object are in the backup
+ * set.  Several kinds of catalog entries are folded into the object that the
+ * user actually created before the dependency is recorded:
+ *   - an entry with an internal dependency (an array type, the rowtype of a
+ *     table or view, the rewrite rule of a view, an operator or support function
+ *     belonging to an operator class) is replaced by the object it belongs to
+ *   - a column default is replaced by its table
+ *   - a domain constraint is replaced by its domain
+ *   - the pg_class entry of a composite type is replaced by the type itself
+ *   - the rowtype of a table or view, reached through its array type, is
+ *     replaced by the table or view
+ *
+ * We don't record a dependency of a function on a base type if the function is
+ * one of the base type's support functions, as we print out shell types for all
+ * base types at the beginning of the backup.
+ */
+func GetDependencies(connection *dbconn.DBConn, backupSet map[UniqueID]bool) DependencyMap {
+	modStr := ""
+	if connection.Version.AtLeast("5") {
+		modStr = ", t.typmodin, t.typmodout"
+	}
+	query := fmt.Sprintf(`
+SELECT
+	CASE WHEN c1.relkind = 'c' THEN 'pg_type'::regclass::oid ELSE dep.classid END AS classid,
+	CASE WHEN c1.relkind = 'c' THEN c1.reltype ELSE dep.objid END AS objid,
+	CASE WHEN c2.relkind = 'c' THEN 'pg_type'::regclass::oid
+		WHEN tc.oid IS NOT NULL THEN 'pg_class'::regclass::oid
+		ELSE dep.refclassid END AS refclassid,
+	CASE WHEN c2.relkind = 'c' THEN c2.reltype
+		WHEN tc.oid IS NOT NULL THEN tc.oid
+		ELSE dep.refobjid END AS refobjid
+FROM (
+	SELECT
+		CASE WHEN id1.refobjid IS NOT NULL THEN id1.refclassid
+			WHEN ad.oid IS NOT NULL THEN 'pg_class'::regclass::oid
+			WHEN co.oid IS NOT NULL THEN 'pg_type'::regclass::oid
+			ELSE d.classid END AS classid,
+		CASE WHEN id1.refobjid IS NOT NULL THEN id1.refobjid
+			WHEN ad.oid IS NOT NULL THEN ad.adrelid
+			WHEN co.oid IS NOT NULL THEN co.contypid
+			ELSE d.objid END AS objid,
+		coalesce(id2.refclassid, d.refclassid) AS refclassid,
+		coalesce(id2.refobjid, d.refobjid) AS refobjid
+	FROM pg_depend d
+	LEFT JOIN pg_depend id1 ON (id1.classid = d.classid AND id1.objid = d.objid AND id1.deptype = 'i')
+	LEFT JOIN pg_depend id2 ON (id2.classid = d.refclassid AND id2.objid = d.refobjid AND id2.deptype = 'i')
+	LEFT JOIN pg_attrdef ad ON (d.classid = 'pg_attrdef'::regclass AND ad.oid = d.objid)
+	LEFT JOIN pg_constraint co ON (d.classid = 'pg_constraint'::regclass AND co.oid = d.objid AND co.contypid != 0)
+	WHERE d.classid != 0
+	AND d.deptype NOT IN ('i', 'e')
+	AND NOT EXISTS (
+		SELECT 1 FROM pg_type t
+		WHERE d.classid = 'pg_proc'::regclass
+		AND d.refclassid = 'pg_type'::regclass
+		AND t.oid = d.refobjid
+		AND d.objid IN (t.typinput, t.typoutput, t.typreceive, t.typsend%s)
+	)
+) dep
+LEFT JOIN pg_class c1 ON (dep.classid = 'pg_class'::regclass AND c1.oid = dep.objid)
+LEFT JOIN pg_class c2 ON (dep.refclassid = 'pg_class'::regclass AND c2.oid = dep.refobjid)
+LEFT JOIN pg_type t2 ON (dep.refclassid = 'pg_type'::regclass AND t2.oid = dep.refobjid)
+LEFT JOIN pg_class tc ON (tc.oid = t2.typrelid AND tc.relkind != 'c');`, modStr)
+
+	results := make([]struct {
+		ClassID    uint32
+		ObjID      uint32
+		RefClassID uint32
+		RefObjID   uint32
+	}, 0)
+	err := connection.Select(&results, query)
+	gplog.FatalOnError(err)
+
+	dependencyMap := make(DependencyMap, 0)
+	for _, dependency := range results {
+		object := UniqueID{ClassID: dependency.ClassID, Oid: dependency.ObjID}
+		referenceObject := UniqueID{ClassID: dependency.RefClassID, Oid: dependency.RefObjID}
+		if object == referenceObject || !backupSet[object] || !backupSet[referenceObject] {
+			continue
+		}
+		if _, ok := dependencyMap[object]; !ok {
+			dependencyMap[object] = make(map[UniqueID]bool, 0)
+		}
+		dependencyMap[object][referenceObject] = true
 	}
-	return views
+	return dependencyMap
 }
 
-func TopologicalSort(slice []Sortable) []Sortable {
-	inDegrees := make(map[string]int, 0)
-	dependencyIndexes := make(map[string]int, 0)
-	isDependentOn := make(map[string][]string, 0)
+func TopologicalSort(slice []Sortable, dependencies DependencyMap) []Sortable {
+	inDegrees := make(map[UniqueID]int, 0)
+	dependencyIndexes := make(map[UniqueID]int, 0)
+	isDependentOn := make(map[UniqueID][]UniqueID, 0)
 	queue := make([]Sortable, 0)
 	sorted := make([]Sortable, 0)
-	notVisited := utils.NewEmptyIncludeSet()
+	notVisited := make(map[UniqueID]bool, 0)
 	for i, item := range slice {
-		name := item.FQN()
-		deps := item.Dependencies()
-		notVisited.Add(name)
-		inDegrees[name] = len(deps)
-		for _, dep := range deps {
-			isDependentOn[dep] = append(isDependentOn[dep], name)
+		uniqueID := item.GetUniqueID()
+		deps := dependencies[uniqueID]
+		notVisited[uniqueID] = true
+		inDegrees[uniqueID] = len(deps)
+		for dep := range deps {
+			isDependentOn[dep] = append(isDependentOn[dep], uniqueID)
 		}
-		dependencyIndexes[name] = i
+		dependencyIndexes[uniqueID] = i
 		if len(deps) == 0 {
 			queue = append(queue, item)
 		}
@@ -120,8 +310,8 @@ func TopologicalSort(slice []Sortable) []Sortable {
 		item := queue[0]
 		queue = queue[1:]
 		sorted = append(sorted, item)
-		notVisited.Delete(item.FQN())
-		for _, dep := range isDependentOn[item.FQN()] {
+		notVisited[item.GetUniqueID()] = false
+		for _, dep := range isDependentOn[item.GetUniqueID()] {
 			inDegrees[dep]--
 			if inDegrees[dep] == 0 {
 				queue = append(queue, slice[dependencyIndexes[dep]])
@@ -132,11 +322,61 @@ func TopologicalSort(slice []Sortable) []Sortable {
 		gplog.Verbose("Failed to sort dependencies.")
 		gplog.Verbose("Not yet visited:")
 		for _, item := range slice {
-			if notVisited.MatchesFilter(item.FQN()) {
-				gplog.Verbose("Object: %s; Dependencies: %s", item.FQN(), item.Dependencies())
+			if notVisited[item.GetUniqueID()] {
+				depNames := make([]string, 0)
+				for dep := range dependencies[item.GetUniqueID()] {
+					if index, ok := dependencyIndexes[dep]; ok {
+						depNames = append(depNames, slice[index].FQN())
+					} else {
+						depNames = append(depNames, fmt.Sprintf("%d/%d", dep.ClassID, dep.Oid))
+					}
+				}
+				gplog.Verbose("Object: %s; Dependencies: %s", item.FQN(), depNames)
 			}
 		}
 		gplog.Fatal(errors.Errorf("Dependency resolution failed; see log file %s for details. This is a bug, please report.", gplog.GetLogFilePath()), "")
 	}
 	return sorted
 }
+
+/*
+ * This function records the dependencies between the TOC entries of objects
+ * printed in dependency order, so that gprestore can create them in parallel.
+ * The entries of an object depend on the last entry of each object it depends
+ * on; if one of those objects produced no entries, the dependencies of that
+ * object are used instead.  An object with multiple entries has each one depend
+ * on the one before it, and entries with no dependencies in the graph depend on
+ * the entry immediately preceding the sorted block so that everything printed
+ * before the block is created first.
+ */
+func SetEntryDependencies(toc *utils.TOC, sortedObjects []Sortable, objectEntries map[UniqueID][]int, dependencies DependencyMap, blockStart int) {
+	lastEntries := make(map[UniqueID][]int, 0)
+	for _, object := range sortedObjects {
+		uniqueID := object.GetUniqueID()
+		dependencySet := make(map[int]bool, 0)
+		for dependency := range dependencies[uniqueID] {
+			for _, entry := range lastEntries[dependency] {
+				dependencySet[entry] = true
+			}
+		}
+		entryDependencies := make([]int, 0)
+		for entry := range dependencySet {
+			entryDependencies = append(entryDependencies, entry)
+		}
+		sort.Ints(entryDependencies)
+
+		entries := objectEntries[uniqueID]
+		if len(entries) == 0 {
+			lastEntries[uniqueID] = entryDependencies
+			continue
+		}
+		if len(entryDependencies) == 0 && blockStart > 0 {
+			entryDependencies = []int{blockStart - 1}
+		}
+		toc.PredataEntries[entries[0]].Dependencies = entryDependencies
+		for i := 1; i < len(entries); i++ {
+			toc.PredataEntries[entries[i]].Dependencies = []int{entries[i-1]}
+		}
+		lastEntries[uniqueID] = []int{entries[len(entries)-1]}
+	}
+}
@@ -14,283 +14,256 @@ import (
 
 var _ = Describe("backup/dependencies tests", func() {
 	var (
-		function1 backup.Function
-		function2 backup.Function
-		function3 backup.Function
-		relation1 backup.Relation
-		relation2 backup.Relation
-		relation3 backup.Relation
-		type1     backup.Type
-		type2     backup.Type
-		type3     backup.Type
-		view1     backup.View
-		view2     backup.View
-		view3     backup.View
+		function1   backup.Function
+		function2   backup.Function
+		relation1   backup.Relation
+		relation2   backup.Relation
+		relation3   backup.Relation
+		type1       backup.Type
+		type2       backup.Type
+		type3       backup.Type
+		view1       backup.View
+		view2       backup.View
+		view3       backup.View
+		operator1   backup.Operator
+		aggregate1  backup.Aggregate
+		depMap      backup.DependencyMap
+		relation1ID backup.UniqueID
+		relation2ID backup.UniqueID
+		relation3ID backup.UniqueID
+		type1ID     backup.UniqueID
+		type2ID     backup.UniqueID
+		type3ID     backup.UniqueID
+		function2ID backup.UniqueID
 	)
 
 	BeforeEach(func() {
-		function1 = backup.Function{Schema: "public", Name: "function1", Arguments: "integer, integer", DependsUpon: []string{}}
-		function2 = backup.Function{Schema: "public", Name: "function1", Arguments: "numeric, text", DependsUpon: []string{}}
-		function3 = backup.Function{Schema: "public", Name: "function2", Arguments: "integer, integer", DependsUpon: []string{}}
-		relation1 = backup.Relation{Schema: "public", Name: "relation1", DependsUpon: []string{}}
-		relation2 = backup.Relation{Schema: "public", Name: "relation2", DependsUpon: []string{}}
-		relation3 = backup.Relation{Schema: "public", Name: "relation3", DependsUpon: []string{}}
-		type1 = backup.Type{Schema: "public", Name: "type1", DependsUpon: []string{}}
-		type2 = backup.Type{Schema: "public", Name: "type2", DependsUpon: []string{}}
-		type3 = backup.Type{Schema: "public", Name: "type3", DependsUpon: []string{}}
-		view1 = backup.View{Schema: "public", Name: "view1", DependsUpon: []string{}}
-		view2 = backup.View{Schema: "public", Name: "view2", DependsUpon: []string{}}
-		view3 = backup.View{Schema: "public", Name: "view3", DependsUpon: []string{}}
+		function1 = backup.Function{Oid: 1, Schema: "public", Name: "function1", Arguments: "integer, integer"}
+		function2 = backup.Function{Oid: 2, Schema: "public", Name: "function2", Arguments: "integer, integer"}
+		relation1 = backup.Relation{Oid: 1, Schema: "public", Name: "relation1"}
+		relation2 = backup.Relation{Oid: 2, Schema: "public", Name: "relation2"}
+		relation3 = backup.Relation{Oid: 3, Schema: "public", Name: "relation3"}
+		type1 = backup.Type{Oid: 1, Schema: "public", Name: "type1"}
+		type2 = backup.Type{Oid: 2, Schema: "public", Name: "type2"}
+		type3 = backup.Type{Oid: 3, Schema: "public", Name: "type3"}
+		view1 = backup.View{Oid: 4, Schema: "public", Name: "view1"}
+		view2 = backup.View{Oid: 5, Schema: "public", Name: "view2"}
+		view3 = backup.View{Oid: 6, Schema: "public", Name: "view3"}
+		operator1 = backup.Operator{Oid: 1, Schema: "public", Name: "##", LeftArgType: "integer", RightArgType: "integer"}
+		aggregate1 = backup.Aggregate{Oid: 3, Schema: "public", Name: "agg1", Arguments: "integer"}
+		depMap = backup.DependencyMap{}
+		relation1ID = backup.UniqueID{ClassID: backup.PG_CLASS_OID, Oid: 1}
+		relation2ID = backup.UniqueID{ClassID: backup.PG_CLASS_OID, Oid: 2}
+		relation3ID = backup.UniqueID{ClassID: backup.PG_CLASS_OID, Oid: 3}
+		type1ID = backup.UniqueID{ClassID: backup.PG_TYPE_OID, Oid: 1}
+		type2ID = backup.UniqueID{ClassID: backup.PG_TYPE_OID, Oid: 2}
+		type3ID = backup.UniqueID{ClassID: backup.PG_TYPE_OID, Oid: 3}
+		function2ID = backup.UniqueID{ClassID: backup.PG_PROC_OID, Oid: 2}
 	})
 	Describe("TopologicalSort", func() {
 		It("returns the original slice if there are no dependencies among objects", func() {
 			relations := []backup.Sortable{relation1, relation2, relation3}
 
-			relations = backup.TopologicalSort(relations)
+			relations = backup.TopologicalSort(relations, depMap)
 
 			Expect(relations[0].FQN()).To(Equal("public.relation1"))
 			Expect(relations[1].FQN()).To(Equal("public.relation2"))
 			Expect(relations[2].FQN()).To(Equal("public.relation3"))
 		})
 		It("sorts the slice correctly if there is an object dependent on one other object", func() {
-			relation1.DependsUpon = []string{"public.relation3"}
+			depMap[relation1ID] = map[backup.UniqueID]bool{relation3ID: true}
 			relations := []backup.Sortable{relation1, relation2, relation3}
 
-			relations = backup.TopologicalSort(relations)
+			relations = backup.TopologicalSort(relations, depMap)
 
 			Expect(relations[0].FQN()).To(Equal("public.relation2"))
 			Expect(relations[1].FQN()).To(Equal("public.relation3"))
 			Expect(relations[2].FQN()).To(Equal("public.relation1"))
 		})
 		It("sorts the slice correctly if there are two objects dependent on one other object", func() {
-			view1.DependsUpon = []string{"public.view2"}
-			view3.DependsUpon = []string{"public.view2"}
+			depMap[view1.GetUniqueID()] = map[backup.UniqueID]bool{view2.GetUniqueID(): true}
+			depMap[view3.GetUniqueID()] = map[backup.UniqueID]bool{view2.GetUniqueID(): true}
 			views := []backup.Sortable{view1, view2, view3}
 
-			views = backup.TopologicalSort(views)
+			views = backup.TopologicalSort(views, depMap)
 
 			Expect(views[0].FQN()).To(Equal("public.view2"))
 			Expect(views[1].FQN()).To(Equal("public.view1"))
 			Expect(views[2].FQN()).To(Equal("public.view3"))
 		})
 		It("sorts the slice correctly if there is one object dependent on two other objects", func() {
-			type2.DependsUpon = []string{"public.type1", "public.type3"}
+			depMap[type2ID] = map[backup.UniqueID]bool{type1ID: true, type3ID: true}
 			types := []backup.Sortable{type1, type2, type3}
 
-			types = backup.TopologicalSort(types)
+			types = backup.TopologicalSort(types, depMap)
 
 			Expect(types[0].FQN()).To(Equal("public.type1"))
 			Expect(types[1].FQN()).To(Equal("public.type3"))
 			Expect(types[2].FQN()).To(Equal("public.type2"))
 		})
 		It("sorts the slice correctly if there are complex dependencies", func() {
-			type2.DependsUpon = []string{"public.type1", "public.function2(integer, integer)"}
-			function3.DependsUpon = []string{"public.type1"}
-			sortable := []backup.Sortable{type1, type2, function3}
+			depMap[type2ID] = map[backup.UniqueID]bool{type1ID: true, function2ID: true}
+			depMap[function2ID] = map[backup.UniqueID]bool{type1ID: true}
+			sortable := []backup.Sortable{type1, type2, function2}
 
-			sortable = backup.TopologicalSort(sortable)
+			sortable = backup.TopologicalSort(sortable, depMap)
 
 			Expect(sortable[0].FQN()).To(Equal("public.type1"))
 			Expect(sortable[1].FQN()).To(Equal("public.function2(integer, integer)"))
 			Expect(sortable[2].FQN()).To(Equal("public.type2"))
 		})
+		It("does not confuse objects of different types with the same oid", func() {
+			depMap[relation1ID] = map[backup.UniqueID]bool{aggregate1.GetUniqueID(): true}
+			depMap[aggregate1.GetUniqueID()] = map[backup.UniqueID]bool{function1.GetUniqueID(): true, operator1.GetUniqueID(): true}
+			sortable := []backup.Sortable{relation1, aggregate1, type1, operator1, function1}
+
+			sortable = backup.TopologicalSort(sortable, depMap)
+
+			Expect(sortable[0].FQN()).To(Equal("public.type1"))
+			Expect(sortable[1].FQN()).To(Equal("public.##(integer, integer)"))
+			Expect(sortable[2].FQN()).To(Equal("public.function1(integer, integer)"))
+			Expect(sortable[3].FQN()).To(Equal("public.agg1(integer)"))
+			Expect(sortable[4].FQN()).To(Equal("public.relation1"))
+		})
+		It("sorts objects of every type in a single dependency order", func() {
+			depMap[view1.GetUniqueID()] = map[backup.UniqueID]bool{operator1.GetUniqueID(): true}
+			depMap[operator1.GetUniqueID()] = map[backup.UniqueID]bool{function2ID: true}
+			depMap[function2ID] = map[backup.UniqueID]bool{relation2ID: true}
+			sortable := []backup.Sortable{view1, operator1, function2, relation2}
+
+			sortable = backup.TopologicalSort(sortable, depMap)
+
+			Expect(sortable).To(Equal([]backup.Sortable{relation2, function2, operator1, view1}))
+		})
 		It("aborts if dependency loop (this shouldn't be possible)", func() {
-			type1.DependsUpon = []string{"public.type3"}
-			type2.DependsUpon = []string{"public.type1"}
-			type3.DependsUpon = []string{"public.type2"}
+			depMap[type1ID] = map[backup.UniqueID]bool{type3ID: true}
+			depMap[type2ID] = map[backup.UniqueID]bool{type1ID: true}
+			depMap[type3ID] = map[backup.UniqueID]bool{type2ID: true}
 			sortable := []backup.Sortable{type1, type2, type3}
 
 			defer testhelper.ShouldPanicWithMessage("Dependency resolution failed; see log file gbytes.Buffer for details. This is a bug, please report.")
-			sortable = backup.TopologicalSort(sortable)
+			sortable = backup.TopologicalSort(sortable, depMap)
 		})
 		It("aborts if dependencies are not met", func() {
-			type1.DependsUpon = []string{"missing_thing", "public.type2"}
+			depMap[type1ID] = map[backup.UniqueID]bool{{ClassID: backup.PG_TYPE_OID, Oid: 99}: true, type2ID: true}
 			sortable := []backup.Sortable{type1, type2}
 
 			defer testhelper.ShouldPanicWithMessage("Dependency resolution failed; see log file gbytes.Buffer for details. This is a bug, please report.")
-			sortable = backup.TopologicalSort(sortable)
+			sortable = backup.TopologicalSort(sortable, depMap)
 		})
 	})
-	Describe("SortFunctionsAndTypesAndTablesInDependencyOrder", func() {
-		It("returns a slice of unsorted functions followed by unsorted types followed by unsorted tables if there are no dependencies among objects", func() {
-			functions := []backup.Function{function1, function2, function3}
-			types := []backup.Type{type1, type2, type3}
-			relations := []backup.Relation{relation1, relation2, relation3}
-			results := backup.SortFunctionsAndTypesAndTablesInDependencyOrder(functions, types, relations)
-			expected := []backup.Sortable{function1, function2, function3, type1, type2, type3, relation1, relation2, relation3}
-			Expect(results).To(Equal(expected))
-		})
-		It("returns a slice of sorted functions, types, and relations if there are dependencies among objects of the same type", func() {
-			function2.DependsUpon = []string{"public.function2(integer, integer)"}
-			type2.DependsUpon = []string{"public.type3"}
-			relation2.DependsUpon = []string{"public.relation3"}
-			functions := []backup.Function{function1, function2, function3}
-			types := []backup.Type{type1, type2, type3}
-			relations := []backup.Relation{relation1, relation2, relation3}
-			results := backup.SortFunctionsAndTypesAndTablesInDependencyOrder(functions, types, relations)
-			expected := []backup.Sortable{function1, function3, type1, type3, relation1, relation3, function2, type2, relation2}
-			Expect(results).To(Equal(expected))
-		})
-		It("returns a slice of sorted functions, types, and relations if there are dependencies among objects of different types", func() {
-			function2.DependsUpon = []string{"public.type3"}
-			type2.DependsUpon = []string{"public.relation3"}
-			relation2.DependsUpon = []string{"public.type1"}
-			functions := []backup.Function{function1, function2, function3}
-			types := []backup.Type{type1, type2, type3}
-			relations := []backup.Relation{relation1, relation2, relation3}
-			results := backup.SortFunctionsAndTypesAndTablesInDependencyOrder(functions, types, relations)
-			expected := []backup.Sortable{function1, function3, type1, type3, relation1, relation3, relation2, function2, type2}
-			Expect(results).To(Equal(expected))
+	Describe("GetDependencies", func() {
+		header := []string{"classid", "objid", "refclassid", "refobjid"}
+		var backupSet map[backup.UniqueID]bool
+		BeforeEach(func() {
+			backupSet = backup.CreateBackupSet([]backup.Sortable{relation1, relation2, type1, function2, operator1})
 		})
-	})
-	Describe("ConstructFunctionDependencies", func() {
-		It("queries function dependencies in GPDB 5", func() {
-			testutils.SetDBVersion(connection, "5.0.0")
-			header := []string{"oid", "referencedobject"}
-			functionRows := sqlmock.NewRows(header).AddRow([]driver.Value{"1", "public.type"}...)
-
-			function1.Oid = 1
-			functions := []backup.Function{function1}
-
-			mock.ExpectQuery(`SELECT (.*)`).WillReturnRows(functionRows)
-			functions = backup.ConstructFunctionDependencies(connection, functions)
-
-			Expect(functions[0].DependsUpon).To(Equal([]string{"public.type"}))
+		It("constructs a dependency map between objects in the backup set", func() {
+			rows := sqlmock.NewRows(header).
+				AddRow([]driver.Value{backup.PG_CLASS_OID, 1, backup.PG_TYPE_OID, 1}...).
+				AddRow([]driver.Value{backup.PG_CLASS_OID, 1, backup.PG_PROC_OID, 2}...).
+				AddRow([]driver.Value{backup.PG_OPERATOR_OID, 1, backup.PG_PROC_OID, 2}...)
+			mock.ExpectQuery(`SELECT (.*)`).WillReturnRows(rows)
+
+			dependencies := backup.GetDependencies(connection, backupSet)
+
+			Expect(dependencies).To(Equal(backup.DependencyMap{
+				relation1ID:             {type1ID: true, function2ID: true},
+				operator1.GetUniqueID(): {function2ID: true},
+			}))
 		})
-		It("queries function dependencies in GPDB 4.3", func() {
-			testutils.SetDBVersion(connection, "4.3.0")
-			header := []string{"oid", "referencedobject"}
-			functionRows := sqlmock.NewRows(header).AddRow([]driver.Value{"1", "public.type"}...)
+		It("ignores dependencies on objects outside the backup set and dependencies of an object on itself", func() {
+			rows := sqlmock.NewRows(header).
+				AddRow([]driver.Value{backup.PG_CLASS_OID, 1, backup.PG_TYPE_OID, 99}...).
+				AddRow([]driver.Value{backup.PG_CLASS_OID, 99, backup.PG_TYPE_OID, 1}...).
+				AddRow([]driver.Value{backup.PG_CLASS_OID, 2, backup.PG_CLASS_OID, 2}...).
+				AddRow([]driver.Value{backup.PG_TYPE_OID, 2, backup.PG_CLASS_OID, 1}...)
+			mock.ExpectQuery(`SELECT (.*)`).WillReturnRows(rows)
 
-			function1.Oid = 1
-			functions := []backup.Function{function1}
+			dependencies := backup.GetDependencies(connection, backupSet)
 
-			mock.ExpectQuery(`SELECT (.*)`).WillReturnRows(functionRows)
-			functions = backup.ConstructFunctionDependencies(connection, functions)
-
-			Expect(functions[0].DependsUpon).To(Equal([]string{"public.type"}))
+			Expect(dependencies).To(BeEmpty())
 		})
-	})
-	Describe("ConstructBaseTypeDependencies", func() {
-		It("queries base type dependencies in GPDB 5", func() {
-			testutils.SetDBVersion(connection, "5.0.0")
-			header := []string{"oid", "referencedobject"}
-			baseTypeRows := sqlmock.NewRows(header).AddRow([]driver.Value{"2", "public.func(integer, integer)"}...)
+		It("records a dependency only once if it appears multiple times", func() {
+			rows := sqlmock.NewRows(header).
+				AddRow([]driver.Value{backup.PG_CLASS_OID, 2, backup.PG_CLASS_OID, 1}...).
+				AddRow([]driver.Value{backup.PG_CLASS_OID, 2, backup.PG_CLASS_OID, 1}...)
+			mock.ExpectQuery(`SELECT (.*)`).WillReturnRows(rows)
 
-			type1.Oid = 2
-			type1.Type = "b"
-			types := []backup.Type{type1}
+			dependencies := backup.GetDependencies(connection, backupSet)
 
-			mock.ExpectQuery(`SELECT (.*)`).WillReturnRows(baseTypeRows)
-			types = backup.ConstructBaseTypeDependencies5(connection, types)
-
-			Expect(types[0].DependsUpon).To(Equal([]string{"public.func(integer, integer)"}))
+			Expect(dependencies).To(Equal(backup.DependencyMap{relation2ID: {relation1ID: true}}))
 		})
-		It("queries base type dependencies in GPDB 4.3", func() {
+		It("excludes type modifier functions from the base type support function check in GPDB 4.3", func() {
 			testutils.SetDBVersion(connection, "4.3.0")
-			funcInfoMap := map[uint32]backup.FunctionInfo{
-				5: {QualifiedName: "public.func", Arguments: "integer, integer"},
-			}
-			header := []string{"oid", "referencedoid"}
-			baseTypeRows := sqlmock.NewRows(header).AddRow([]driver.Value{"2", "5"}...)
-
-			type1.Oid = 2
-			type1.Type = "b"
-			types := []backup.Type{type1}
+			mock.ExpectQuery(`t.typsend\)`).WillReturnRows(sqlmock.NewRows(header))
 
-			mock.ExpectQuery(`SELECT (.*)`).WillReturnRows(baseTypeRows)
-			types = backup.ConstructBaseTypeDependencies4(connection, types, funcInfoMap)
-
-			Expect(types[0].DependsUpon).To(Equal([]string{"public.func(integer, integer)"}))
+			backup.GetDependencies(connection, backupSet)
 		})
-	})
-	Describe("ConstructCompositeTypeDependencies", func() {
-		It("queries composite type dependencies in GPDB 5", func() {
+		It("includes type modifier functions in the base type support function check in GPDB 5", func() {
 			testutils.SetDBVersion(connection, "5.0.0")
-			header := []string{"oid", "referencedobject"}
-			compTypeRows := sqlmock.NewRows(header).AddRow([]driver.Value{"3", "public.othertype"}...)
-
-			type2.Oid = 3
-			type2.Type = "c"
-			types := []backup.Type{type2}
+			mock.ExpectQuery(`t.typsend, t.typmodin, t.typmodout\)`).WillReturnRows(sqlmock.NewRows(header))
 
-			mock.ExpectQuery(`SELECT (.*)`).WillReturnRows(compTypeRows)
-			types = backup.ConstructCompositeTypeDependencies(connection, types)
-
-			Expect(types[0].DependsUpon).To(Equal([]string{"public.othertype"}))
-		})
-		It("queries composite type dependencies in GPDB 4.3", func() {
-			testutils.SetDBVersion(connection, "4.3.0")
-			header := []string{"oid", "referencedobject"}
-			compTypeRows := sqlmock.NewRows(header).AddRow([]driver.Value{"3", "public.othertype"}...)
-
-			type2.Oid = 3
-			type2.Type = "c"
-			types := []backup.Type{type2}
-
-			mock.ExpectQuery(`SELECT (.*)`).WillReturnRows(compTypeRows)
-
-			types = backup.ConstructCompositeTypeDependencies(connection, types)
-
-			Expect(types[0].DependsUpon).To(Equal([]string{"public.othertype"}))
+			backup.GetDependencies(connection, backupSet)
 		})
 	})
-	Describe("ConstructDomainDependencies", func() {
-		It("queries domain dependencies in GPDB 5", func() {
-			testutils.SetDBVersion(connection, "5.0.0")
-			header := []string{"oid", "referencedobject"}
-			domainRows := sqlmock.NewRows(header).AddRow([]driver.Value{"4", "public.builtin"}...)
-
-			type3.Oid = 4
-			type3.Type = "d"
-
-			types := []backup.Type{type3}
+	Describe("SetEntryDependencies", func() {
+		var protocol backup.ExternalProtocol
+		BeforeEach(func() {
+			toc, backupfile = testutils.InitializeTestTOC(buffer, "predata")
+			protocol = backup.ExternalProtocol{Oid: 7, Name: "s3"}
+			for i := 0; i < 6; i++ {
+				toc.AddPredataEntry("public", "object", "TYPE", "", 0, backupfile)
+			}
+		})
+		It("makes entries depend on the last entry of each object they depend on", func() {
+			sorted := []backup.Sortable{type1, relation1, function2}
+			objectEntries := map[backup.UniqueID][]int{type1ID: {2}, relation1ID: {3, 4}, function2ID: {5}}
+			depMap[relation1ID] = map[backup.UniqueID]bool{type1ID: true}
+			depMap[function2ID] = map[backup.UniqueID]bool{relation1ID: true, type1ID: true}
+
+			backup.SetEntryDependencies(toc, sorted, objectEntries, depMap, 2)
+
+			Expect(toc.PredataEntries[0].Dependencies).To(BeNil())
+			Expect(toc.PredataEntries[1].Dependencies).To(BeNil())
+			Expect(toc.PredataEntries[2].Dependencies).To(Equal([]int{1}))
+			Expect(toc.PredataEntries[3].Dependencies).To(Equal([]int{2}))
+			Expect(toc.PredataEntries[4].Dependencies).To(Equal([]int{3}))
+			Expect(toc.PredataEntries[5].Dependencies).To(Equal([]int{2, 4}))
+		})
+		It("passes dependencies through objects that have no entries", func() {
+			sorted := []backup.Sortable{type1, protocol, function2}
+			protocolID := protocol.GetUniqueID()
+			objectEntries := map[backup.UniqueID][]int{type1ID: {2}, function2ID: {3}}
+			depMap[protocolID] = map[backup.UniqueID]bool{type1ID: true}
+			depMap[function2ID] = map[backup.UniqueID]bool{protocolID: true}
 
-			mock.ExpectQuery(`SELECT (.*)`).WillReturnRows(domainRows)
-			types = backup.ConstructDomainDependencies(connection, types)
+			backup.SetEntryDependencies(toc, sorted, objectEntries, depMap, 2)
 
-			Expect(types[0].DependsUpon).To(Equal([]string{"public.builtin"}))
+			Expect(toc.PredataEntries[3].Dependencies).To(Equal([]int{2}))
 		})
-		It("queries domain dependencies in GPDB 4.3", func() {
-			testutils.SetDBVersion(connection, "4.3.0")
-			header := []string{"oid", "referencedobject"}
-			domainRows := sqlmock.NewRows(header).AddRow([]driver.Value{"4", "public.builtin"}...)
-
-			type3.Oid = 4
-			type3.Type = "d"
-			types := []backup.Type{type3}
+		It("records empty dependencies for independent objects at the start of the TOC", func() {
+			sorted := []backup.Sortable{type1, type2}
+			objectEntries := map[backup.UniqueID][]int{type1ID: {0}, type2ID: {1}}
 
-			mock.ExpectQuery(`SELECT (.*)`).WillReturnRows(domainRows)
-			types = backup.ConstructDomainDependencies(connection, types)
+			backup.SetEntryDependencies(toc, sorted, objectEntries, depMap, 0)
 
-			Expect(types[0].DependsUpon).To(Equal([]string{"public.builtin"}))
+			Expect(toc.PredataEntries[0].Dependencies).To(Equal([]int{}))
+			Expect(toc.PredataEntries[1].Dependencies).To(Equal([]int{}))
 		})
 	})
-	Describe("ConstructFunctionAndTypeAndTableMetadataMap", func() {
-		It("composes metadata maps for functions, types, and tables into one map", func() {
-			funcMap := backup.MetadataMap{1: backup.ObjectMetadata{Comment: "function"}}
-			typeMap := backup.MetadataMap{2: backup.ObjectMetadata{Comment: "type"}}
-			tableMap := backup.MetadataMap{3: backup.ObjectMetadata{Comment: "relation"}}
-			result := backup.ConstructFunctionAndTypeAndTableMetadataMap(funcMap, typeMap, tableMap)
-			expected := backup.MetadataMap{
-				1: backup.ObjectMetadata{Comment: "function"},
-				2: backup.ObjectMetadata{Comment: "type"},
-				3: backup.ObjectMetadata{Comment: "relation"},
+	Describe("UniqueMetadataMap", func() {
+		It("keeps metadata for objects with the same oid in different catalog tables separate", func() {
+			metadataMap := make(backup.UniqueMetadataMap)
+			metadataMap.Add(backup.PG_PROC_OID, backup.MetadataMap{1: backup.ObjectMetadata{Comment: "function"}})
+			metadataMap.Add(backup.PG_TYPE_OID, backup.MetadataMap{1: backup.ObjectMetadata{Comment: "type"}})
+			metadataMap.Add(backup.PG_CLASS_OID, backup.MetadataMap{2: backup.ObjectMetadata{Comment: "relation"}})
+			expected := backup.UniqueMetadataMap{
+				{ClassID: backup.PG_PROC_OID, Oid: 1}:  backup.ObjectMetadata{Comment: "function"},
+				{ClassID: backup.PG_TYPE_OID, Oid: 1}:  backup.ObjectMetadata{Comment: "type"},
+				{ClassID: backup.PG_CLASS_OID, Oid: 2}: backup.ObjectMetadata{Comment: "relation"},
 			}
-			Expect(result).To(Equal(expected))
-		})
-	})
-	Describe("SortViews", func() {
-		It("sorts the slice correctly if there are two objects dependent on one other object", func() {
-			view1.DependsUpon = []string{"public.view2"}
-			view3.DependsUpon = []string{"public.view2"}
-			views := []backup.View{view1, view2, view3}
-
-			views = backup.SortViews(views)
-
-			Expect(views[0].FQN()).To(Equal("public.view2"))
-			Expect(views[1].FQN()).To(Equal("public.view1"))
-			Expect(views[2].FQN()).To(Equal("public.view3"))
+			Expect(metadataMap).To(Equal(expected))
 		})
 	})
 })
@@ -53,10 +53,12 @@ var (
 	leafPartitionData *bool
 	metadataOnly      *bool
 	noCompression     *bool
+	objectDefinitions *string
 	pluginConfigFile  *string
 	printVersion      *bool
 	quiet             *bool
 	singleDataFile    *bool
+	streamMetadata    *bool
 	verbose           *bool
 	withStats         *bool
 )
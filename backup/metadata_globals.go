@@ -89,6 +89,7 @@ func PrintCreateResourceQueueStatements(metadataFile *utils.FileWithByteCount, t
 		metadataFile.MustPrintf("\n\n%s RESOURCE QUEUE %s WITH (%s);", action, resQueue.Name, strings.Join(attributes, ", "))
 		PrintObjectMetadata(metadataFile, resQueueMetadata[resQueue.Oid], resQueue.Name, "RESOURCE QUEUE")
 		toc.AddGlobalEntry("", resQueue.Name, "RESOURCE QUEUE", start, metadataFile)
+		toc.AddGlobalDefinition(resQueue)
 	}
 }
 
@@ -114,6 +115,7 @@ func PrintCreateResourceGroupStatements(metadataFile *utils.FileWithByteCount, t
 				metadataFile.MustPrintf("\n\nALTER RESOURCE GROUP %s SET %s %d;", resGroup.Name, property.setting, property.value)
 				PrintObjectMetadata(metadataFile, resGroupMetadata[resGroup.Oid], resGroup.Name, "RESOURCE GROUP")
 				toc.AddGlobalEntry("", resGroup.Name, "RESOURCE GROUP", start, metadataFile)
+				toc.AddGlobalDefinition(resGroup)
 			}
 		} else {
 			start = metadataFile.ByteCount
@@ -126,6 +128,7 @@ func PrintCreateResourceGroupStatements(metadataFile *utils.FileWithByteCount, t
 			metadataFile.MustPrintf("\n\nCREATE RESOURCE GROUP %s WITH (%s);", resGroup.Name, strings.Join(attributes, ", "))
 			PrintObjectMetadata(metadataFile, resGroupMetadata[resGroup.Oid], resGroup.Name, "RESOURCE GROUP")
 			toc.AddGlobalEntry("", resGroup.Name, "RESOURCE GROUP", start, metadataFile)
+			toc.AddGlobalDefinition(resGroup)
 		}
 	}
 }
@@ -220,6 +223,7 @@ ALTER ROLE %s %s;`, role.Name, roleGUC)
 		}
 		PrintObjectMetadata(metadataFile, roleMetadata[role.Oid], role.Name, "ROLE")
 		toc.AddGlobalEntry("", role.Name, "ROLE", start, metadataFile)
+		toc.AddGlobalDefinition(role)
 	}
 }
 
@@ -233,6 +237,7 @@ func PrintRoleMembershipStatements(metadataFile *utils.FileWithByteCount, toc *u
 		}
 		metadataFile.MustPrintf(" GRANTED BY %s;", roleMember.Grantor)
 		toc.AddGlobalEntry("", roleMember.Member, "ROLE GRANT", start, metadataFile)
+		toc.AddGlobalDefinition(roleMember)
 	}
 }
 
@@ -247,6 +252,7 @@ func PrintCreateTablespaceStatements(metadataFile *utils.FileWithByteCount, toc
 		}
 		metadataFile.MustPrintf("\n\nCREATE TABLESPACE %s %s %s;", tablespace.Tablespace, fileLocStr, tablespace.FileLocation)
 		toc.AddGlobalEntry("", tablespace.Tablespace, "TABLESPACE", start, metadataFile)
+		toc.AddGlobalDefinition(tablespace)
 		start = metadataFile.ByteCount
 		PrintObjectMetadata(metadataFile, tablespaceMetadata[tablespace.Oid], tablespace.Tablespace, "TABLESPACE")
 		if metadataFile.ByteCount > start {
@@ -254,3 +260,20 @@ func PrintCreateTablespaceStatements(metadataFile *utils.FileWithByteCount, toc
 		}
 	}
 }
+
+/*
+ * The label on the database is restored with the rest of the database's
+ * metadata, so that it is applied to the database being restored to when
+ * --redirect-db is used.
+ */
+func PrintSharedSecurityLabelStatements(metadataFile *utils.FileWithByteCount, toc *utils.TOC, labels []SecurityLabel) {
+	for _, label := range labels {
+		start := metadataFile.ByteCount
+		printSecurityLabelStatement(metadataFile, label)
+		if label.ObjectType == "DATABASE" {
+			toc.AddGlobalEntry("", label.ObjectName, "DATABASE METADATA", start, metadataFile)
+		} else {
+			toc.AddGlobalEntry("", fmt.Sprintf("%s %s", label.ObjectType, label.ObjectName), "SECURITY LABEL", start, metadataFile)
+		}
+	}
+}
@@ -304,4 +304,21 @@ REVOKE ALL ON TABLESPACE test_tablespace FROM testrole;
 GRANT ALL ON TABLESPACE test_tablespace TO testrole;`)
 		})
 	})
+	Describe("PrintSharedSecurityLabelStatements", func() {
+		It("prints security labels on the database, roles, and tablespaces", func() {
+			labels := []backup.SecurityLabel{
+				{ObjectType: "DATABASE", ObjectName: "testdb", Provider: "selinux", Label: "system_u:object_r:sepgsql_db_t:s0"},
+				{ObjectType: "ROLE", ObjectName: "testrole", Provider: "selinux", Label: "user_u:object_r:sepgsql_role_t:s0"},
+				{ObjectType: "TABLESPACE", ObjectName: "test_tablespace", Provider: "selinux", Label: "it's classified"},
+			}
+			backup.PrintSharedSecurityLabelStatements(backupfile, toc, labels)
+			testutils.ExpectEntry(toc.GlobalEntries, 0, "", "", "testdb", "DATABASE METADATA")
+			testutils.ExpectEntry(toc.GlobalEntries, 1, "", "", "ROLE testrole", "SECURITY LABEL")
+			testutils.ExpectEntry(toc.GlobalEntries, 2, "", "", "TABLESPACE test_tablespace", "SECURITY LABEL")
+			testutils.AssertBufferContents(toc.GlobalEntries, buffer,
+				`SECURITY LABEL FOR selinux ON DATABASE testdb IS 'system_u:object_r:sepgsql_db_t:s0';`,
+				`SECURITY LABEL FOR selinux ON ROLE testrole IS 'user_u:object_r:sepgsql_role_t:s0';`,
+				`SECURITY LABEL FOR selinux ON TABLESPACE test_tablespace IS 'it''s classified';`)
+		})
+	})
 })
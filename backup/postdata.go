@@ -7,6 +7,9 @@ package backup
  */
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/greenplum-db/gpbackup/utils"
 )
 
@@ -23,6 +26,7 @@ func PrintCreateIndexStatements(metadataFile *utils.FileWithByteCount, toc *util
 		}
 		PrintObjectMetadata(metadataFile, indexMetadata[index.Oid], index.Name, "INDEX")
 		toc.AddPostdataEntry(index.OwningSchema, index.Name, "INDEX", tableFQN, start, metadataFile)
+		toc.AddPostdataDefinition(index)
 	}
 }
 
@@ -33,6 +37,7 @@ func PrintCreateRuleStatements(metadataFile *utils.FileWithByteCount, toc *utils
 		tableFQN := utils.MakeFQN(rule.OwningSchema, rule.OwningTable)
 		PrintObjectMetadata(metadataFile, ruleMetadata[rule.Oid], rule.Name, "RULE", tableFQN)
 		toc.AddPostdataEntry(rule.OwningSchema, rule.Name, "RULE", tableFQN, start, metadataFile)
+		toc.AddPostdataDefinition(rule)
 	}
 }
 
@@ -43,5 +48,82 @@ func PrintCreateTriggerStatements(metadataFile *utils.FileWithByteCount, toc *ut
 		tableFQN := utils.MakeFQN(trigger.OwningSchema, trigger.OwningTable)
 		PrintObjectMetadata(metadataFile, triggerMetadata[trigger.Oid], trigger.Name, "TRIGGER", tableFQN)
 		toc.AddPostdataEntry(trigger.OwningSchema, trigger.Name, "TRIGGER", tableFQN, start, metadataFile)
+		toc.AddPostdataDefinition(trigger)
+	}
+}
+
+/*
+ * Event triggers fire on DDL commands, so they are restored after all other
+ * metadata to keep them from firing during the restore.
+ */
+func PrintCreateEventTriggerStatements(metadataFile *utils.FileWithByteCount, toc *utils.TOC, eventTriggers []EventTrigger, eventTriggerMetadata MetadataMap) {
+	for _, eventTrigger := range eventTriggers {
+		start := metadataFile.ByteCount
+		metadataFile.MustPrintf("\n\nCREATE EVENT TRIGGER %s\nON %s", eventTrigger.Name, eventTrigger.Event)
+		if eventTrigger.EventTags != "" {
+			metadataFile.MustPrintf("\nWHEN TAG IN (%s)", eventTrigger.EventTags)
+		}
+		metadataFile.MustPrintf("\nEXECUTE PROCEDURE %s();", eventTrigger.FunctionName)
+		switch eventTrigger.Enabled {
+		case "D":
+			metadataFile.MustPrintf("\nALTER EVENT TRIGGER %s DISABLE;", eventTrigger.Name)
+		case "R":
+			metadataFile.MustPrintf("\nALTER EVENT TRIGGER %s ENABLE REPLICA;", eventTrigger.Name)
+		case "A":
+			metadataFile.MustPrintf("\nALTER EVENT TRIGGER %s ENABLE ALWAYS;", eventTrigger.Name)
+		}
+		PrintObjectMetadata(metadataFile, eventTriggerMetadata[eventTrigger.Oid], eventTrigger.Name, "EVENT TRIGGER")
+		toc.AddPostdataEntry("", eventTrigger.Name, "EVENT TRIGGER", "", start, metadataFile)
+		toc.AddPostdataDefinition(eventTrigger)
+	}
+}
+
+func printSecurityLabelStatement(metadataFile *utils.FileWithByteCount, label SecurityLabel) {
+	escapedLabel := strings.Replace(label.Label, "'", "''", -1)
+	metadataFile.MustPrintf("\n\nSECURITY LABEL FOR %s ON %s %s IS '%s';", label.Provider, label.ObjectType, label.ObjectName, escapedLabel)
+}
+
+func PrintSecurityLabelStatements(metadataFile *utils.FileWithByteCount, toc *utils.TOC, labels []SecurityLabel) {
+	for _, label := range labels {
+		start := metadataFile.ByteCount
+		printSecurityLabelStatement(metadataFile, label)
+		// Security labels don't have a unique name, so we construct an arbitrary identifier
+		labelStr := fmt.Sprintf("%s %s", label.ObjectType, label.ObjectName)
+		toc.AddPostdataEntry(label.Schema, labelStr, "SECURITY LABEL", "", start, metadataFile)
+		toc.AddPostdataDefinition(label)
+	}
+}
+
+/*
+ * Default privileges only apply to objects created after they are set, so they
+ * are restored last to keep them from being applied to restored objects.
+ */
+func PrintDefaultPrivilegesStatements(metadataFile *utils.FileWithByteCount, toc *utils.TOC, privileges []DefaultPrivileges) {
+	objectTypes := map[string]string{"f": "FUNCTION", "r": "TABLE", "S": "SEQUENCE", "T": "TYPE"}
+	for _, priv := range privileges {
+		start := metadataFile.ByteCount
+		prefix := fmt.Sprintf("ALTER DEFAULT PRIVILEGES FOR ROLE %s", priv.Owner)
+		if priv.Schema != "" {
+			prefix = fmt.Sprintf("%s IN SCHEMA %s", prefix, priv.Schema)
+		}
+		objectType := objectTypes[priv.ObjectType]
+		metadataFile.MustPrintf("\n\n%s REVOKE ALL ON %sS FROM PUBLIC;", prefix, objectType)
+		metadataFile.MustPrintf("\n%s REVOKE ALL ON %sS FROM %s;", prefix, objectType, priv.Owner)
+		for _, acl := range priv.Privileges {
+			grantee := acl.Grantee
+			if grantee == "" {
+				grantee = "PUBLIC"
+			}
+			privStr, privWithGrantStr := acl.getPrivilegeStrings(objectType)
+			if privStr != "" {
+				metadataFile.MustPrintf("\n%s GRANT %s ON %sS TO %s;", prefix, privStr, objectType, grantee)
+			}
+			if privWithGrantStr != "" {
+				metadataFile.MustPrintf("\n%s GRANT %s ON %sS TO %s WITH GRANT OPTION;", prefix, privWithGrantStr, objectType, grantee)
+			}
+		}
+		// A role has separate default privileges for each class of object in each schema
+		toc.AddPostdataEntry(priv.Schema, fmt.Sprintf("%s ON %sS", priv.Owner, objectType), "DEFAULT PRIVILEGES", "", start, metadataFile)
+		toc.AddPostdataDefinition(priv)
 	}
 }
@@ -3,8 +3,10 @@ package backup_test
 import (
 	"github.com/greenplum-db/gpbackup/backup"
 	"github.com/greenplum-db/gpbackup/testutils"
+	"github.com/greenplum-db/gpbackup/utils"
 
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
 )
 
 var _ = Describe("backup/postdata tests", func() {
@@ -19,6 +21,14 @@ var _ = Describe("backup/postdata tests", func() {
 			testutils.ExpectEntry(toc.PostdataEntries, 0, "public", "public.testtable", "testindex", "INDEX")
 			testutils.AssertBufferContents(toc.PostdataEntries, buffer, `CREATE INDEX testindex ON public.testtable USING btree(i);`)
 		})
+		It("records the index definition when object definitions are enabled", func() {
+			toc.InitializeObjectDefinitions()
+			indexes := []backup.IndexDefinition{{Oid: 1, Name: "testindex", OwningSchema: "public", OwningTable: "testtable", Def: "CREATE INDEX testindex ON public.testtable USING btree(i)"}}
+			backup.PrintCreateIndexStatements(backupfile, toc, indexes, backup.MetadataMap{})
+			Expect(toc.GetObjectDefinitions().PostdataDefinitions).To(Equal([]utils.ObjectDefinition{
+				{TOCIndex: 0, Schema: "public", Name: "testindex", ObjectType: "INDEX", Definition: indexes[0]},
+			}))
+		})
 		It("can print an index used for clustering", func() {
 			indexes := []backup.IndexDefinition{{Oid: 1, Name: "testindex", OwningSchema: "public", OwningTable: "testtable", Def: "CREATE INDEX testindex ON public.testtable USING btree(i)", IsClustered: true}}
 			emptyMetadataMap := backup.MetadataMap{}
@@ -77,4 +87,111 @@ COMMENT ON RULE testrule ON public.testtable IS 'This is a rule comment.';`)
 COMMENT ON TRIGGER testtrigger ON public.testtable IS 'This is a trigger comment.';`)
 		})
 	})
+	Context("PrintDefaultPrivilegesStatements", func() {
+		It("prints default privileges for a role in all schemas", func() {
+			privileges := []backup.DefaultPrivileges{{Owner: "testrole", Schema: "", Privileges: []backup.ACL{{Grantee: "anothertestrole", Select: true}, testutils.DefaultACLForType("testrole", "TABLE")}, ObjectType: "r"}}
+			backup.PrintDefaultPrivilegesStatements(backupfile, toc, privileges)
+			testutils.ExpectEntry(toc.PostdataEntries, 0, "", "", "testrole ON TABLES", "DEFAULT PRIVILEGES")
+			testutils.AssertBufferContents(toc.PostdataEntries, buffer, `ALTER DEFAULT PRIVILEGES FOR ROLE testrole REVOKE ALL ON TABLES FROM PUBLIC;
+ALTER DEFAULT PRIVILEGES FOR ROLE testrole REVOKE ALL ON TABLES FROM testrole;
+ALTER DEFAULT PRIVILEGES FOR ROLE testrole GRANT SELECT ON TABLES TO anothertestrole;
+ALTER DEFAULT PRIVILEGES FOR ROLE testrole GRANT ALL ON TABLES TO testrole;`)
+		})
+		It("prints default privileges for a role in a schema", func() {
+			privileges := []backup.DefaultPrivileges{{Owner: "testrole", Schema: "schema1", Privileges: []backup.ACL{testutils.DefaultACLForTypeWithGrant("", "FUNCTION")}, ObjectType: "f"}}
+			backup.PrintDefaultPrivilegesStatements(backupfile, toc, privileges)
+			testutils.ExpectEntry(toc.PostdataEntries, 0, "schema1", "", "testrole ON FUNCTIONS", "DEFAULT PRIVILEGES")
+			testutils.AssertBufferContents(toc.PostdataEntries, buffer, `ALTER DEFAULT PRIVILEGES FOR ROLE testrole IN SCHEMA schema1 REVOKE ALL ON FUNCTIONS FROM PUBLIC;
+ALTER DEFAULT PRIVILEGES FOR ROLE testrole IN SCHEMA schema1 REVOKE ALL ON FUNCTIONS FROM testrole;
+ALTER DEFAULT PRIVILEGES FOR ROLE testrole IN SCHEMA schema1 GRANT ALL ON FUNCTIONS TO PUBLIC WITH GRANT OPTION;`)
+		})
+		It("prints default privileges for sequences and types", func() {
+			privileges := []backup.DefaultPrivileges{
+				{Owner: "testrole", Schema: "", Privileges: []backup.ACL{{Grantee: "anothertestrole", Select: true, Usage: true}}, ObjectType: "S"},
+				{Owner: "testrole", Schema: "", Privileges: []backup.ACL{{Grantee: "anothertestrole", Usage: true}}, ObjectType: "T"},
+			}
+			backup.PrintDefaultPrivilegesStatements(backupfile, toc, privileges)
+			testutils.ExpectEntry(toc.PostdataEntries, 0, "", "", "testrole ON SEQUENCES", "DEFAULT PRIVILEGES")
+			testutils.ExpectEntry(toc.PostdataEntries, 1, "", "", "testrole ON TYPES", "DEFAULT PRIVILEGES")
+			testutils.AssertBufferContents(toc.PostdataEntries, buffer, `ALTER DEFAULT PRIVILEGES FOR ROLE testrole REVOKE ALL ON SEQUENCES FROM PUBLIC;
+ALTER DEFAULT PRIVILEGES FOR ROLE testrole REVOKE ALL ON SEQUENCES FROM testrole;
+ALTER DEFAULT PRIVILEGES FOR ROLE testrole GRANT SELECT,USAGE ON SEQUENCES TO anothertestrole;`, `ALTER DEFAULT PRIVILEGES FOR ROLE testrole REVOKE ALL ON TYPES FROM PUBLIC;
+ALTER DEFAULT PRIVILEGES FOR ROLE testrole REVOKE ALL ON TYPES FROM testrole;
+ALTER DEFAULT PRIVILEGES FOR ROLE testrole GRANT ALL ON TYPES TO anothertestrole;`)
+		})
+		It("prints only revoke statements if all default privileges have been revoked", func() {
+			privileges := []backup.DefaultPrivileges{{Owner: "testrole", Schema: "", Privileges: []backup.ACL{}, ObjectType: "r"}}
+			backup.PrintDefaultPrivilegesStatements(backupfile, toc, privileges)
+			testutils.AssertBufferContents(toc.PostdataEntries, buffer, `ALTER DEFAULT PRIVILEGES FOR ROLE testrole REVOKE ALL ON TABLES FROM PUBLIC;
+ALTER DEFAULT PRIVILEGES FOR ROLE testrole REVOKE ALL ON TABLES FROM testrole;`)
+		})
+	})
+	Context("PrintCreateEventTriggerStatements", func() {
+		It("prints a basic event trigger", func() {
+			eventTriggers := []backup.EventTrigger{{Oid: 1, Name: "event_trigger1", Event: "ddl_command_start", FunctionName: "public.abort_any_command", Enabled: "O"}}
+			backup.PrintCreateEventTriggerStatements(backupfile, toc, eventTriggers, backup.MetadataMap{})
+			testutils.ExpectEntry(toc.PostdataEntries, 0, "", "", "event_trigger1", "EVENT TRIGGER")
+			testutils.AssertBufferContents(toc.PostdataEntries, buffer, `CREATE EVENT TRIGGER event_trigger1
+ON ddl_command_start
+EXECUTE PROCEDURE public.abort_any_command();`)
+		})
+		It("prints an event trigger with event tags", func() {
+			eventTriggers := []backup.EventTrigger{{Oid: 1, Name: "event_trigger1", Event: "ddl_command_start", EventTags: "'CREATE TABLE', 'DROP TABLE'", FunctionName: "public.abort_any_command", Enabled: "O"}}
+			backup.PrintCreateEventTriggerStatements(backupfile, toc, eventTriggers, backup.MetadataMap{})
+			testutils.AssertBufferContents(toc.PostdataEntries, buffer, `CREATE EVENT TRIGGER event_trigger1
+ON ddl_command_start
+WHEN TAG IN ('CREATE TABLE', 'DROP TABLE')
+EXECUTE PROCEDURE public.abort_any_command();`)
+		})
+		It("prints event triggers that are not enabled by default", func() {
+			eventTriggers := []backup.EventTrigger{
+				{Oid: 1, Name: "event_trigger1", Event: "ddl_command_start", FunctionName: "public.abort_any_command", Enabled: "D"},
+				{Oid: 2, Name: "event_trigger2", Event: "ddl_command_end", FunctionName: "public.abort_any_command", Enabled: "R"},
+				{Oid: 3, Name: "event_trigger3", Event: "sql_drop", FunctionName: "public.abort_any_command", Enabled: "A"},
+			}
+			backup.PrintCreateEventTriggerStatements(backupfile, toc, eventTriggers, backup.MetadataMap{})
+			testutils.AssertBufferContents(toc.PostdataEntries, buffer, `CREATE EVENT TRIGGER event_trigger1
+ON ddl_command_start
+EXECUTE PROCEDURE public.abort_any_command();
+ALTER EVENT TRIGGER event_trigger1 DISABLE;`, `CREATE EVENT TRIGGER event_trigger2
+ON ddl_command_end
+EXECUTE PROCEDURE public.abort_any_command();
+ALTER EVENT TRIGGER event_trigger2 ENABLE REPLICA;`, `CREATE EVENT TRIGGER event_trigger3
+ON sql_drop
+EXECUTE PROCEDURE public.abort_any_command();
+ALTER EVENT TRIGGER event_trigger3 ENABLE ALWAYS;`)
+		})
+		It("prints an event trigger with an owner and a comment", func() {
+			eventTriggers := []backup.EventTrigger{{Oid: 1, Name: "event_trigger1", Event: "ddl_command_start", FunctionName: "public.abort_any_command", Enabled: "O"}}
+			eventTriggerMetadataMap := testutils.DefaultMetadataMap("EVENT TRIGGER", false, true, true)
+			backup.PrintCreateEventTriggerStatements(backupfile, toc, eventTriggers, eventTriggerMetadataMap)
+			testutils.AssertBufferContents(toc.PostdataEntries, buffer, `CREATE EVENT TRIGGER event_trigger1
+ON ddl_command_start
+EXECUTE PROCEDURE public.abort_any_command();
+
+COMMENT ON EVENT TRIGGER event_trigger1 IS 'This is an event trigger comment.';
+
+
+ALTER EVENT TRIGGER event_trigger1 OWNER TO testrole;`)
+		})
+	})
+	Context("PrintSecurityLabelStatements", func() {
+		It("prints security labels", func() {
+			labels := []backup.SecurityLabel{
+				{Schema: "public", ObjectType: "TABLE", ObjectName: "public.tablename", Provider: "selinux", Label: "system_u:object_r:sepgsql_table_t:s0"},
+				{Schema: "", ObjectType: "LANGUAGE", ObjectName: "plperl", Provider: "selinux", Label: "system_u:object_r:sepgsql_lang_t:s0"},
+			}
+			backup.PrintSecurityLabelStatements(backupfile, toc, labels)
+			testutils.ExpectEntry(toc.PostdataEntries, 0, "public", "", "TABLE public.tablename", "SECURITY LABEL")
+			testutils.ExpectEntry(toc.PostdataEntries, 1, "", "", "LANGUAGE plperl", "SECURITY LABEL")
+			testutils.AssertBufferContents(toc.PostdataEntries, buffer,
+				`SECURITY LABEL FOR selinux ON TABLE public.tablename IS 'system_u:object_r:sepgsql_table_t:s0';`,
+				`SECURITY LABEL FOR selinux ON LANGUAGE plperl IS 'system_u:object_r:sepgsql_lang_t:s0';`)
+		})
+		It("escapes single quotes in labels", func() {
+			labels := []backup.SecurityLabel{{Schema: "public", ObjectType: "FUNCTION", ObjectName: "public.func(integer)", Provider: "dummy", Label: "it's classified"}}
+			backup.PrintSecurityLabelStatements(backupfile, toc, labels)
+			testutils.AssertBufferContents(toc.PostdataEntries, buffer, `SECURITY LABEL FOR dummy ON FUNCTION public.func(integer) IS 'it''s classified';`)
+		})
+	})
 })
@@ -244,6 +244,7 @@ func PrintCreateExternalProtocolStatements(metadataFile *utils.FileWithByteCount
 		metadataFile.MustPrintf("PROTOCOL %s (%s);\n", protocol.Name, strings.Join(protocolFunctions, ", "))
 		PrintObjectMetadata(metadataFile, protoMetadata[protocol.Oid], protocol.Name, "PROTOCOL")
 		toc.AddPredataEntry("", protocol.Name, "PROTOCOL", "", start, metadataFile)
+		toc.AddPredataDefinition(protocol)
 	}
 }
 
@@ -279,5 +280,6 @@ func PrintExchangeExternalPartitionStatements(metadataFile *utils.FileWithByteCo
 		metadataFile.MustPrintf("WITH TABLE %s WITHOUT VALIDATION;", extPartRelationName)
 		metadataFile.MustPrintf("\n\nDROP TABLE %s;", extPartRelationName)
 		toc.AddPredataEntry(externalPartition.ParentSchema, externalPartition.ParentRelationName, "EXCHANGE PARTITION", "", start, metadataFile)
+		toc.AddPredataDefinition(externalPartition)
 	}
 }
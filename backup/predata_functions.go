@@ -26,6 +26,7 @@ func PrintCreateFunctionStatement(metadataFile *utils.FileWithByteCount, toc *ut
 	nameWithArgs := fmt.Sprintf("%s(%s)", funcDef.Name, funcDef.IdentArgs)
 	PrintObjectMetadata(metadataFile, funcMetadata, nameStr, "FUNCTION")
 	toc.AddPredataEntry(funcDef.Schema, nameWithArgs, "FUNCTION", "", start, metadataFile)
+	toc.AddPredataDefinition(funcDef)
 }
 
 /*
@@ -137,6 +138,7 @@ func PrintCreateAggregateStatements(metadataFile *utils.FileWithByteCount, toc *
 		aggWithArgs := fmt.Sprintf("%s(%s)", aggDef.Name, identArgumentsStr)
 		PrintObjectMetadata(metadataFile, aggMetadata[aggDef.Oid], aggFQN, "AGGREGATE")
 		toc.AddPredataEntry(aggDef.Schema, aggWithArgs, "AGGREGATE", "", start, metadataFile)
+		toc.AddPredataDefinition(aggDef)
 	}
 }
 
@@ -168,6 +170,7 @@ func PrintCreateCastStatements(metadataFile *utils.FileWithByteCount, toc *utils
 			filterSchema = castDef.FunctionSchema // Use the function's schema to allow restore filtering
 		}
 		toc.AddPredataEntry(filterSchema, castStr, "CAST", "", start, metadataFile)
+		toc.AddPredataDefinition(castDef)
 	}
 }
 
@@ -177,6 +180,7 @@ func PrintCreateExtensionStatements(metadataFile *utils.FileWithByteCount, toc *
 		metadataFile.MustPrintf("\n\nSET search_path=%s,pg_catalog;\nCREATE EXTENSION IF NOT EXISTS %s WITH SCHEMA %s;\nSET search_path=pg_catalog;", extensionDef.Schema, extensionDef.Name, extensionDef.Schema)
 		PrintObjectMetadata(metadataFile, extensionMetadata[extensionDef.Oid], extensionDef.Name, "EXTENSION")
 		toc.AddPredataEntry("", extensionDef.Name, "EXTENSION", "", start, metadataFile)
+		toc.AddPredataDefinition(extensionDef)
 	}
 }
 
@@ -239,6 +243,7 @@ func PrintCreateLanguageStatements(metadataFile *utils.FileWithByteCount, toc *u
 		PrintObjectMetadata(metadataFile, procLangMetadata[procLang.Oid], procLang.Name, "LANGUAGE")
 		metadataFile.MustPrintln()
 		toc.AddPredataEntry("", procLang.Name, "PROCEDURAL LANGUAGE", "", start, metadataFile)
+		toc.AddPredataDefinition(procLang)
 	}
 }
 
@@ -255,6 +260,7 @@ func PrintCreateConversionStatements(metadataFile *utils.FileWithByteCount, toc
 		PrintObjectMetadata(metadataFile, conversionMetadata[conversion.Oid], convFQN, "CONVERSION")
 		metadataFile.MustPrintln()
 		toc.AddPredataEntry(conversion.Schema, conversion.Name, "CONVERSION", "", start, metadataFile)
+		toc.AddPredataDefinition(conversion)
 	}
 }
 
@@ -272,6 +278,7 @@ func PrintCreateForeignDataWrapperStatements(metadataFile *utils.FileWithByteCou
 		metadataFile.MustPrintf(";")
 		PrintObjectMetadata(metadataFile, fdwMetadata[fdw.Oid], fdw.Name, "FOREIGN DATA WRAPPER")
 		toc.AddPredataEntry("", fdw.Name, "FOREIGN DATA WRAPPER", "", start, metadataFile)
+		toc.AddPredataDefinition(fdw)
 	}
 }
 
@@ -294,6 +301,7 @@ func PrintCreateServerStatements(metadataFile *utils.FileWithByteCount, toc *uti
 		//NOTE: We must specify SERVER when creating and dropping, but FOREIGN SERVER when granting and revoking
 		PrintObjectMetadata(metadataFile, serverMetadata[server.Oid], server.Name, "FOREIGN SERVER")
 		toc.AddPredataEntry("", server.Name, "FOREIGN SERVER", "", start, metadataFile)
+		toc.AddPredataDefinition(server)
 	}
 }
 
@@ -308,5 +316,6 @@ func PrintCreateUserMappingStatements(metadataFile *utils.FileWithByteCount, toc
 		// User mappings don't have a unique name, so we construct an arbitrary identifier
 		mappingStr := fmt.Sprintf("%s ON %s", mapping.User, mapping.Server)
 		toc.AddPredataEntry("", mappingStr, "USER MAPPING", "", start, metadataFile)
+		toc.AddPredataDefinition(mapping)
 	}
 }
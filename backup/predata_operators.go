@@ -57,6 +57,7 @@ CREATE OPERATOR %s (
 		operatorStr := fmt.Sprintf("%s (%s, %s)", operatorFQN, leftArg, rightArg)
 		PrintObjectMetadata(metadataFile, operatorMetadata[operator.Oid], operatorStr, "OPERATOR")
 		toc.AddPredataEntry(operator.Schema, operator.Name, "OPERATOR", "", start, metadataFile)
+		toc.AddPredataDefinition(operator)
 	}
 }
 
@@ -72,6 +73,7 @@ func PrintCreateOperatorFamilyStatements(metadataFile *utils.FileWithByteCount,
 		metadataFile.MustPrintf("\n\nCREATE OPERATOR FAMILY %s;", operatorFamilyStr)
 		PrintObjectMetadata(metadataFile, operatorFamilyMetadata[operatorFamily.Oid], operatorFamilyStr, "OPERATOR FAMILY")
 		toc.AddPredataEntry(operatorFamily.Schema, operatorFamily.Name, "OPERATOR FAMILY", "", start, metadataFile)
+		toc.AddPredataDefinition(operatorFamily)
 	}
 }
 
@@ -117,5 +119,6 @@ func PrintCreateOperatorClassStatements(metadataFile *utils.FileWithByteCount, t
 		operatorClassStr := fmt.Sprintf("%s USING %s", operatorClassFQN, operatorClass.IndexMethod)
 		PrintObjectMetadata(metadataFile, operatorClassMetadata[operatorClass.Oid], operatorClassStr, "OPERATOR CLASS")
 		toc.AddPredataEntry(operatorClass.Schema, operatorClass.Name, "OPERATOR CLASS", "", start, metadataFile)
+		toc.AddPredataDefinition(operatorClass)
 	}
 }
@@ -15,12 +15,11 @@ import (
 )
 
 type Relation struct {
-	SchemaOid   uint32
-	Oid         uint32
-	Schema      string
-	Name        string
-	DependsUpon []string // Used for dependency sorting
-	Inherits    []string // Only used for printing INHERITS statement
+	SchemaOid uint32
+	Oid       uint32
+	Schema    string
+	Name      string
+	Inherits  []string // Only used for printing INHERITS statement
 }
 
 /*
@@ -239,6 +238,13 @@ func PrintCreateTableStatement(metadataFile *utils.FileWithByteCount, toc *utils
 	}
 	PrintPostCreateTableStatements(metadataFile, table, tableDef, tableMetadata)
 	toc.AddPredataEntry(table.Schema, table.Name, "TABLE", "", start, metadataFile)
+	toc.AddPredataDefinition(TableWithDefinition{Relation: table, Definition: tableDef})
+}
+
+// The information needed to create a table is split across two structs, so both are recorded together.
+type TableWithDefinition struct {
+	Relation   Relation
+	Definition TableDefinition
 }
 
 func PrintRegularTableCreateStatement(metadataFile *utils.FileWithByteCount, toc *utils.TOC, table Relation, tableDef TableDefinition) {
@@ -385,6 +391,7 @@ func PrintCreateSequenceStatements(metadataFile *utils.FileWithByteCount, toc *u
 
 		PrintObjectMetadata(metadataFile, sequenceMetadata[sequence.Oid], seqFQN, "SEQUENCE")
 		toc.AddPredataEntry(sequence.Relation.Schema, sequence.Relation.Name, "SEQUENCE", "", start, metadataFile)
+		toc.AddPredataDefinition(sequence)
 	}
 }
 
@@ -407,5 +414,6 @@ func PrintCreateViewStatements(metadataFile *utils.FileWithByteCount, toc *utils
 		metadataFile.MustPrintf("\n\nCREATE VIEW %s AS %s\n", viewFQN, view.Definition)
 		PrintObjectMetadata(metadataFile, viewMetadata[view.Oid], viewFQN, "VIEW")
 		toc.AddPredataEntry(view.Schema, view.Name, "VIEW", "", start, metadataFile)
+		toc.AddPredataDefinition(view)
 	}
 }
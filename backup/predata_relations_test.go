@@ -8,6 +8,7 @@ import (
 	"github.com/greenplum-db/gp-common-go-libs/testhelper"
 	"github.com/greenplum-db/gpbackup/backup"
 	"github.com/greenplum-db/gpbackup/testutils"
+	"github.com/greenplum-db/gpbackup/utils"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -71,6 +72,14 @@ SET SUBPARTITION TEMPLATE
 
 ALTER TABLE public.tablename OWNER TO testrole;`)
 		})
+		It("records the table and its definition together when object definitions are enabled", func() {
+			toc.InitializeObjectDefinitions()
+			tableDef.IsExternal = false
+			backup.PrintCreateTableStatement(backupfile, toc, testTable, tableDef, noMetadata)
+			Expect(toc.GetObjectDefinitions().PredataDefinitions).To(Equal([]utils.ObjectDefinition{
+				{TOCIndex: 0, Schema: "public", Name: "tablename", ObjectType: "TABLE", Definition: backup.TableWithDefinition{Relation: testTable, Definition: tableDef}},
+			}))
+		})
 		It("calls PrintExternalTableCreateStatement for an external table", func() {
 			tableDef.IsExternal = true
 			backup.PrintCreateTableStatement(backupfile, toc, testTable, tableDef, noMetadata)
@@ -415,13 +424,11 @@ SET SUBPARTITION TEMPLATE
 				tableDef = backup.TableDefinition{DistPolicy: distRandom, PartDef: partDefEmpty, PartTemplateDef: partTemplateDefEmpty, StorageOpts: heapOpts, ExtTableDef: extTableEmpty}
 			})
 			AfterEach(func() {
-				testTable.DependsUpon = []string{}
 				testTable.Inherits = []string{}
 			})
 			It("prints a CREATE TABLE block with a single-inheritance INHERITS clause", func() {
 				col := []backup.ColumnDefinition{rowOne}
 				tableDef.ColumnDefs = col
-				testTable.DependsUpon = []string{"public.parent"}
 				testTable.Inherits = []string{"public.parent"}
 				backup.PrintRegularTableCreateStatement(backupfile, toc, testTable, tableDef)
 				testutils.AssertBufferContents(toc.PredataEntries, buffer, `CREATE TABLE public.tablename (
@@ -431,7 +438,6 @@ SET SUBPARTITION TEMPLATE
 			It("prints a CREATE TABLE block with a multiple-inheritance INHERITS clause", func() {
 				col := []backup.ColumnDefinition{rowOne, rowTwo}
 				tableDef.ColumnDefs = col
-				testTable.DependsUpon = []string{"public.parent_one", "public.parent_two"}
 				testTable.Inherits = []string{"public.parent_one", "public.parent_two"}
 				backup.PrintRegularTableCreateStatement(backupfile, toc, testTable, tableDef)
 				testutils.AssertBufferContents(toc.PredataEntries, buffer, `CREATE TABLE public.tablename (
@@ -538,7 +544,7 @@ GRANT ALL (j) ON TABLE public.tablename TO testrole2;`)
 		})
 	})
 	Describe("PrintCreateSequenceStatements", func() {
-		baseSequence := backup.Relation{SchemaOid: 0, Oid: 1, Schema: "public", Name: "seq_name", DependsUpon: nil, Inherits: nil}
+		baseSequence := backup.Relation{SchemaOid: 0, Oid: 1, Schema: "public", Name: "seq_name", Inherits: nil}
 		seqDefault := backup.Sequence{Relation: baseSequence, SequenceDefinition: backup.SequenceDefinition{Name: "seq_name", LastVal: 7, Increment: 1, MaxVal: 9223372036854775807, MinVal: 1, CacheVal: 5, LogCnt: 42, IsCycled: false, IsCalled: true}}
 		seqNegIncr := backup.Sequence{Relation: baseSequence, SequenceDefinition: backup.SequenceDefinition{Name: "seq_name", LastVal: 7, Increment: -1, MaxVal: -1, MinVal: -9223372036854775807, CacheVal: 5, LogCnt: 42, IsCycled: false, IsCalled: true}}
 		seqMaxPos := backup.Sequence{Relation: baseSequence, SequenceDefinition: backup.SequenceDefinition{Name: "seq_name", LastVal: 7, Increment: 1, MaxVal: 100, MinVal: 1, CacheVal: 5, LogCnt: 42, IsCycled: false, IsCalled: true}}
@@ -689,8 +695,8 @@ GRANT SELECT,USAGE ON SEQUENCE public.seq_name TO testrole WITH GRANT OPTION;`)
 	})
 	Describe("PrintCreateViewStatements", func() {
 		It("can print a basic view", func() {
-			viewOne := backup.View{Oid: 0, Schema: "public", Name: `"WowZa"`, Definition: "SELECT rolname FROM pg_role;", DependsUpon: []string{}}
-			viewTwo := backup.View{Oid: 1, Schema: "shamwow", Name: "shazam", Definition: "SELECT count(*) FROM pg_tables;", DependsUpon: []string{}}
+			viewOne := backup.View{Oid: 0, Schema: "public", Name: `"WowZa"`, Definition: "SELECT rolname FROM pg_role;"}
+			viewTwo := backup.View{Oid: 1, Schema: "shamwow", Name: "shazam", Definition: "SELECT count(*) FROM pg_tables;"}
 			viewMetadataMap := backup.MetadataMap{}
 			backup.PrintCreateViewStatements(backupfile, toc, []backup.View{viewOne, viewTwo}, viewMetadataMap)
 			testutils.ExpectEntry(toc.PredataEntries, 0, "public", "", `"WowZa"`, "VIEW")
@@ -699,8 +705,8 @@ GRANT SELECT,USAGE ON SEQUENCE public.seq_name TO testrole WITH GRANT OPTION;`)
 				`CREATE VIEW shamwow.shazam AS SELECT count(*) FROM pg_tables;`)
 		})
 		It("can print a view with privileges, an owner, and a comment", func() {
-			viewOne := backup.View{Oid: 0, Schema: "public", Name: `"WowZa"`, Definition: "SELECT rolname FROM pg_role;", DependsUpon: []string{}}
-			viewTwo := backup.View{Oid: 1, Schema: "shamwow", Name: "shazam", Definition: "SELECT count(*) FROM pg_tables;", DependsUpon: []string{}}
+			viewOne := backup.View{Oid: 0, Schema: "public", Name: `"WowZa"`, Definition: "SELECT rolname FROM pg_role;"}
+			viewTwo := backup.View{Oid: 1, Schema: "shamwow", Name: "shazam", Definition: "SELECT count(*) FROM pg_tables;"}
 			viewMetadataMap := testutils.DefaultMetadataMap("VIEW", true, true, true)
 			backup.PrintCreateViewStatements(backupfile, toc, []backup.View{viewOne, viewTwo}, viewMetadataMap)
 			testutils.AssertBufferContents(toc.PredataEntries, buffer,
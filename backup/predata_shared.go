@@ -71,6 +71,7 @@ func PrintConstraintStatements(metadataFile *utils.FileWithByteCount, toc *utils
 		metadataFile.MustPrintf(alterStr, objStr, constraint.OwningObject, constraint.Name, constraint.ConDef)
 		PrintObjectMetadata(metadataFile, conMetadata[constraint.Oid], constraint.Name, "CONSTRAINT", constraint.OwningObject)
 		toc.AddPredataEntry(constraint.Schema, constraint.Name, "CONSTRAINT", constraint.OwningObject, start, metadataFile)
+		toc.AddPredataDefinition(constraint)
 	}
 }
 
@@ -83,6 +84,7 @@ func PrintCreateSchemaStatements(backupfile *utils.FileWithByteCount, toc *utils
 		}
 		PrintObjectMetadata(backupfile, schemaMetadata[schema.Oid], schema.Name, "SCHEMA")
 		toc.AddPredataEntry(schema.Name, schema.Name, "SCHEMA", "", start, backupfile)
+		toc.AddPredataDefinition(schema)
 	}
 }
 
@@ -282,144 +284,13 @@ func (obj ObjectMetadata) GetPrivilegesStatements(objectName string, objectType
 			statements = append(statements, fmt.Sprintf("REVOKE ALL %sON %s%s FROM %s;", columnStr, typeStr, objectName, obj.Owner))
 		}
 		for _, acl := range obj.Privileges {
-			/*
-			 * Determine whether to print "GRANT ALL" instead of granting individual
-			 * privileges.  Information on which privileges exist for a given object
-			 * comes from src/include/utils/acl.h in GPDB.
-			 */
-			hasAllPrivileges := false
-			hasAllPrivilegesWithGrant := false
-			privStr := ""
-			privWithGrantStr := ""
 			grantee := ""
 			if acl.Grantee == "" {
 				grantee = "PUBLIC"
 			} else {
 				grantee = acl.Grantee
 			}
-			switch objectType {
-			case "COLUMN":
-				hasAllPrivileges = acl.Select && acl.Insert && acl.Update && acl.References
-				hasAllPrivilegesWithGrant = acl.SelectWithGrant && acl.InsertWithGrant && acl.UpdateWithGrant && acl.ReferencesWithGrant
-			case "DATABASE":
-				hasAllPrivileges = acl.Create && acl.Temporary && acl.Connect
-				hasAllPrivilegesWithGrant = acl.CreateWithGrant && acl.TemporaryWithGrant && acl.ConnectWithGrant
-			case "FOREIGN DATA WRAPPER":
-				hasAllPrivileges = acl.Usage
-				hasAllPrivilegesWithGrant = acl.UsageWithGrant
-			case "FOREIGN SERVER":
-				hasAllPrivileges = acl.Usage
-				hasAllPrivilegesWithGrant = acl.UsageWithGrant
-			case "FUNCTION":
-				hasAllPrivileges = acl.Execute
-				hasAllPrivilegesWithGrant = acl.ExecuteWithGrant
-			case "LANGUAGE":
-				hasAllPrivileges = acl.Usage
-				hasAllPrivilegesWithGrant = acl.UsageWithGrant
-			case "PROTOCOL":
-				hasAllPrivileges = acl.Select && acl.Insert
-				hasAllPrivilegesWithGrant = acl.SelectWithGrant && acl.InsertWithGrant
-			case "SCHEMA":
-				hasAllPrivileges = acl.Usage && acl.Create
-				hasAllPrivilegesWithGrant = acl.UsageWithGrant && acl.CreateWithGrant
-			case "SEQUENCE":
-				hasAllPrivileges = acl.Select && acl.Update && acl.Usage
-				hasAllPrivilegesWithGrant = acl.SelectWithGrant && acl.UpdateWithGrant && acl.UsageWithGrant
-			case "TABLE":
-				hasAllPrivileges = acl.Select && acl.Insert && acl.Update && acl.Delete && acl.Truncate && acl.References && acl.Trigger
-				hasAllPrivilegesWithGrant = acl.SelectWithGrant && acl.InsertWithGrant && acl.UpdateWithGrant && acl.DeleteWithGrant &&
-					acl.TruncateWithGrant && acl.ReferencesWithGrant && acl.TriggerWithGrant
-			case "TABLESPACE":
-				hasAllPrivileges = acl.Create
-				hasAllPrivilegesWithGrant = acl.CreateWithGrant
-			case "VIEW":
-				hasAllPrivileges = acl.Select && acl.Insert && acl.Update && acl.Delete && acl.Truncate && acl.References && acl.Trigger
-				hasAllPrivilegesWithGrant = acl.SelectWithGrant && acl.InsertWithGrant && acl.UpdateWithGrant && acl.DeleteWithGrant &&
-					acl.TruncateWithGrant && acl.ReferencesWithGrant && acl.TriggerWithGrant
-			}
-			if hasAllPrivileges {
-				privStr = "ALL"
-			} else {
-				privList := make([]string, 0)
-				if acl.Select {
-					privList = append(privList, "SELECT")
-				}
-				if acl.Insert {
-					privList = append(privList, "INSERT")
-				}
-				if acl.Update {
-					privList = append(privList, "UPDATE")
-				}
-				if acl.Delete {
-					privList = append(privList, "DELETE")
-				}
-				if acl.Truncate {
-					privList = append(privList, "TRUNCATE")
-				}
-				if acl.References {
-					privList = append(privList, "REFERENCES")
-				}
-				if acl.Trigger {
-					privList = append(privList, "TRIGGER")
-				}
-				/*
-				 * We skip checking whether acl.Execute is set here because only Functions have Execute,
-				 * and functions only have Execute, so Execute == hasAllPrivileges for Functions.
-				 */
-				if acl.Usage {
-					privList = append(privList, "USAGE")
-				}
-				if acl.Create {
-					privList = append(privList, "CREATE")
-				}
-				if acl.Temporary {
-					privList = append(privList, "TEMPORARY")
-				}
-				if acl.Connect {
-					privList = append(privList, "CONNECT")
-				}
-				privStr = strings.Join(privList, ",")
-			}
-			if hasAllPrivilegesWithGrant {
-				privWithGrantStr = "ALL"
-			} else {
-				privWithGrantList := make([]string, 0)
-				if acl.SelectWithGrant {
-					privWithGrantList = append(privWithGrantList, "SELECT")
-				}
-				if acl.InsertWithGrant {
-					privWithGrantList = append(privWithGrantList, "INSERT")
-				}
-				if acl.UpdateWithGrant {
-					privWithGrantList = append(privWithGrantList, "UPDATE")
-				}
-				if acl.DeleteWithGrant {
-					privWithGrantList = append(privWithGrantList, "DELETE")
-				}
-				if acl.TruncateWithGrant {
-					privWithGrantList = append(privWithGrantList, "TRUNCATE")
-				}
-				if acl.ReferencesWithGrant {
-					privWithGrantList = append(privWithGrantList, "REFERENCES")
-				}
-				if acl.TriggerWithGrant {
-					privWithGrantList = append(privWithGrantList, "TRIGGER")
-				}
-				// The comment above regarding Execute applies to ExecuteWithGrant as well.
-				if acl.UsageWithGrant {
-					privWithGrantList = append(privWithGrantList, "USAGE")
-				}
-				if acl.CreateWithGrant {
-					privWithGrantList = append(privWithGrantList, "CREATE")
-				}
-				if acl.TemporaryWithGrant {
-					privWithGrantList = append(privWithGrantList, "TEMPORARY")
-				}
-				if acl.ConnectWithGrant {
-					privWithGrantList = append(privWithGrantList, "CONNECT")
-				}
-				privWithGrantStr = strings.Join(privWithGrantList, ",")
-			}
+			privStr, privWithGrantStr := acl.getPrivilegeStrings(objectType)
 			if privStr != "" {
 				statements = append(statements, fmt.Sprintf("GRANT %s %sON %s%s TO %s;", privStr, columnStr, typeStr, objectName, grantee))
 			}
@@ -434,6 +305,145 @@ func (obj ObjectMetadata) GetPrivilegesStatements(objectName string, objectType
 	return ""
 }
 
+func (acl ACL) getPrivilegeStrings(objectType string) (string, string) {
+	/*
+	 * Determine whether to print "GRANT ALL" instead of granting individual
+	 * privileges.  Information on which privileges exist for a given object
+	 * comes from src/include/utils/acl.h in GPDB.
+	 */
+	hasAllPrivileges := false
+	hasAllPrivilegesWithGrant := false
+	privStr := ""
+	privWithGrantStr := ""
+	switch objectType {
+	case "COLUMN":
+		hasAllPrivileges = acl.Select && acl.Insert && acl.Update && acl.References
+		hasAllPrivilegesWithGrant = acl.SelectWithGrant && acl.InsertWithGrant && acl.UpdateWithGrant && acl.ReferencesWithGrant
+	case "DATABASE":
+		hasAllPrivileges = acl.Create && acl.Temporary && acl.Connect
+		hasAllPrivilegesWithGrant = acl.CreateWithGrant && acl.TemporaryWithGrant && acl.ConnectWithGrant
+	case "FOREIGN DATA WRAPPER":
+		hasAllPrivileges = acl.Usage
+		hasAllPrivilegesWithGrant = acl.UsageWithGrant
+	case "FOREIGN SERVER":
+		hasAllPrivileges = acl.Usage
+		hasAllPrivilegesWithGrant = acl.UsageWithGrant
+	case "FUNCTION":
+		hasAllPrivileges = acl.Execute
+		hasAllPrivilegesWithGrant = acl.ExecuteWithGrant
+	case "LANGUAGE":
+		hasAllPrivileges = acl.Usage
+		hasAllPrivilegesWithGrant = acl.UsageWithGrant
+	case "PROTOCOL":
+		hasAllPrivileges = acl.Select && acl.Insert
+		hasAllPrivilegesWithGrant = acl.SelectWithGrant && acl.InsertWithGrant
+	case "SCHEMA":
+		hasAllPrivileges = acl.Usage && acl.Create
+		hasAllPrivilegesWithGrant = acl.UsageWithGrant && acl.CreateWithGrant
+	case "SEQUENCE":
+		hasAllPrivileges = acl.Select && acl.Update && acl.Usage
+		hasAllPrivilegesWithGrant = acl.SelectWithGrant && acl.UpdateWithGrant && acl.UsageWithGrant
+	case "TABLE":
+		hasAllPrivileges = acl.Select && acl.Insert && acl.Update && acl.Delete && acl.Truncate && acl.References && acl.Trigger
+		hasAllPrivilegesWithGrant = acl.SelectWithGrant && acl.InsertWithGrant && acl.UpdateWithGrant && acl.DeleteWithGrant &&
+			acl.TruncateWithGrant && acl.ReferencesWithGrant && acl.TriggerWithGrant
+	case "TABLESPACE":
+		hasAllPrivileges = acl.Create
+		hasAllPrivilegesWithGrant = acl.CreateWithGrant
+	case "TYPE":
+		hasAllPrivileges = acl.Usage
+		hasAllPrivilegesWithGrant = acl.UsageWithGrant
+	case "VIEW":
+		hasAllPrivileges = acl.Select && acl.Insert && acl.Update && acl.Delete && acl.Truncate && acl.References && acl.Trigger
+		hasAllPrivilegesWithGrant = acl.SelectWithGrant && acl.InsertWithGrant && acl.UpdateWithGrant && acl.DeleteWithGrant &&
+			acl.TruncateWithGrant && acl.ReferencesWithGrant && acl.TriggerWithGrant
+	}
+	if hasAllPrivileges {
+		privStr = "ALL"
+	} else {
+		privList := make([]string, 0)
+		if acl.Select {
+			privList = append(privList, "SELECT")
+		}
+		if acl.Insert {
+			privList = append(privList, "INSERT")
+		}
+		if acl.Update {
+			privList = append(privList, "UPDATE")
+		}
+		if acl.Delete {
+			privList = append(privList, "DELETE")
+		}
+		if acl.Truncate {
+			privList = append(privList, "TRUNCATE")
+		}
+		if acl.References {
+			privList = append(privList, "REFERENCES")
+		}
+		if acl.Trigger {
+			privList = append(privList, "TRIGGER")
+		}
+		/*
+		 * We skip checking whether acl.Execute is set here because only Functions have Execute,
+		 * and functions only have Execute, so Execute == hasAllPrivileges for Functions.
+		 */
+		if acl.Usage {
+			privList = append(privList, "USAGE")
+		}
+		if acl.Create {
+			privList = append(privList, "CREATE")
+		}
+		if acl.Temporary {
+			privList = append(privList, "TEMPORARY")
+		}
+		if acl.Connect {
+			privList = append(privList, "CONNECT")
+		}
+		privStr = strings.Join(privList, ",")
+	}
+	if hasAllPrivilegesWithGrant {
+		privWithGrantStr = "ALL"
+	} else {
+		privWithGrantList := make([]string, 0)
+		if acl.SelectWithGrant {
+			privWithGrantList = append(privWithGrantList, "SELECT")
+		}
+		if acl.InsertWithGrant {
+			privWithGrantList = append(privWithGrantList, "INSERT")
+		}
+		if acl.UpdateWithGrant {
+			privWithGrantList = append(privWithGrantList, "UPDATE")
+		}
+		if acl.DeleteWithGrant {
+			privWithGrantList = append(privWithGrantList, "DELETE")
+		}
+		if acl.TruncateWithGrant {
+			privWithGrantList = append(privWithGrantList, "TRUNCATE")
+		}
+		if acl.ReferencesWithGrant {
+			privWithGrantList = append(privWithGrantList, "REFERENCES")
+		}
+		if acl.TriggerWithGrant {
+			privWithGrantList = append(privWithGrantList, "TRIGGER")
+		}
+		// The comment above regarding Execute applies to ExecuteWithGrant as well.
+		if acl.UsageWithGrant {
+			privWithGrantList = append(privWithGrantList, "USAGE")
+		}
+		if acl.CreateWithGrant {
+			privWithGrantList = append(privWithGrantList, "CREATE")
+		}
+		if acl.TemporaryWithGrant {
+			privWithGrantList = append(privWithGrantList, "TEMPORARY")
+		}
+		if acl.ConnectWithGrant {
+			privWithGrantList = append(privWithGrantList, "CONNECT")
+		}
+		privWithGrantStr = strings.Join(privWithGrantList, ",")
+	}
+	return privStr, privWithGrantStr
+}
+
 func (obj ObjectMetadata) GetOwnerStatement(objectName string, objectType string) string {
 	if objectType == "VIEW" {
 		return ""
@@ -464,27 +474,61 @@ func (obj ObjectMetadata) GetCommentStatement(objectName string, objectType stri
 	return commentStr
 }
 
-func PrintCreateDependentTypeAndFunctionAndTablesStatements(metadataFile *utils.FileWithByteCount, toc *utils.TOC, objects []Sortable, metadataMap MetadataMap, tableDefsMap map[uint32]TableDefinition, constraints []Constraint) {
+func PrintDependentObjectStatements(metadataFile *utils.FileWithByteCount, toc *utils.TOC, objects []Sortable, metadataMap UniqueMetadataMap, tableDefsMap map[uint32]TableDefinition, constraints []Constraint, funcInfoMap map[uint32]FunctionInfo) map[UniqueID][]int {
 	conMap := make(map[string][]Constraint)
 	for _, constraint := range constraints {
 		conMap[constraint.OwningObject] = append(conMap[constraint.OwningObject], constraint)
 	}
+	objectEntries := make(map[UniqueID][]int, 0)
 	for _, object := range objects {
+		entryStart := len(toc.PredataEntries)
+		uniqueID := object.GetUniqueID()
+		objMetadata := metadataMap[uniqueID]
+		// Functions that print several objects at once take their metadata by oid
+		objMetadataMap := MetadataMap{uniqueID.Oid: objMetadata}
 		switch obj := object.(type) {
 		case Type:
 			switch obj.Type {
 			case "b":
-				PrintCreateBaseTypeStatement(metadataFile, toc, obj, metadataMap[obj.Oid])
+				PrintCreateBaseTypeStatement(metadataFile, toc, obj, objMetadata)
 			case "c":
-				PrintCreateCompositeTypeStatement(metadataFile, toc, obj, metadataMap[obj.Oid])
+				PrintCreateCompositeTypeStatement(metadataFile, toc, obj, objMetadata)
 			case "d":
 				domainName := utils.MakeFQN(obj.Schema, obj.Name)
-				PrintCreateDomainStatement(metadataFile, toc, obj, metadataMap[obj.Oid], conMap[domainName])
+				PrintCreateDomainStatement(metadataFile, toc, obj, objMetadata, conMap[domainName])
 			}
 		case Function:
-			PrintCreateFunctionStatement(metadataFile, toc, obj, metadataMap[obj.Oid])
+			PrintCreateFunctionStatement(metadataFile, toc, obj, objMetadata)
 		case Relation:
-			PrintCreateTableStatement(metadataFile, toc, obj, tableDefsMap[obj.Oid], metadataMap[obj.Oid])
+			PrintCreateTableStatement(metadataFile, toc, obj, tableDefsMap[obj.Oid], objMetadata)
+		case View:
+			PrintCreateViewStatements(metadataFile, toc, []View{obj}, objMetadataMap)
+		case ExternalProtocol:
+			PrintCreateExternalProtocolStatements(metadataFile, toc, []ExternalProtocol{obj}, funcInfoMap, objMetadataMap)
+		case TextSearchParser:
+			PrintCreateTextSearchParserStatements(metadataFile, toc, []TextSearchParser{obj}, objMetadataMap)
+		case TextSearchTemplate:
+			PrintCreateTextSearchTemplateStatements(metadataFile, toc, []TextSearchTemplate{obj}, objMetadataMap)
+		case TextSearchDictionary:
+			PrintCreateTextSearchDictionaryStatements(metadataFile, toc, []TextSearchDictionary{obj}, objMetadataMap)
+		case TextSearchConfiguration:
+			PrintCreateTextSearchConfigurationStatements(metadataFile, toc, []TextSearchConfiguration{obj}, objMetadataMap)
+		case Operator:
+			PrintCreateOperatorStatements(metadataFile, toc, []Operator{obj}, objMetadataMap)
+		case OperatorFamily:
+			PrintCreateOperatorFamilyStatements(metadataFile, toc, []OperatorFamily{obj}, objMetadataMap)
+		case OperatorClass:
+			PrintCreateOperatorClassStatements(metadataFile, toc, []OperatorClass{obj}, objMetadataMap)
+		case Conversion:
+			PrintCreateConversionStatements(metadataFile, toc, []Conversion{obj}, objMetadataMap)
+		case Aggregate:
+			PrintCreateAggregateStatements(metadataFile, toc, []Aggregate{obj}, funcInfoMap, objMetadataMap)
+		case Cast:
+			PrintCreateCastStatements(metadataFile, toc, []Cast{obj}, objMetadataMap)
+		}
+		for i := entryStart; i < len(toc.PredataEntries); i++ {
+			objectEntries[uniqueID] = append(objectEntries[uniqueID], i)
 		}
 	}
+	return objectEntries
 }
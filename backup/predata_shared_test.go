@@ -194,11 +194,11 @@ GRANT ALL ON SCHEMA schemaname TO testrole;`)
 		})
 	})
 	Describe("GetUniqueSchemas", func() {
-		alphabeticalAFoo := backup.Relation{SchemaOid: 1, Oid: 0, Schema: "otherschema", Name: "foo", DependsUpon: nil, Inherits: nil}
-		alphabeticalABar := backup.Relation{SchemaOid: 1, Oid: 0, Schema: "otherschema", Name: "bar", DependsUpon: nil, Inherits: nil}
+		alphabeticalAFoo := backup.Relation{SchemaOid: 1, Oid: 0, Schema: "otherschema", Name: "foo", Inherits: nil}
+		alphabeticalABar := backup.Relation{SchemaOid: 1, Oid: 0, Schema: "otherschema", Name: "bar", Inherits: nil}
 		schemaOther := backup.Schema{Oid: 2, Name: "otherschema"}
-		alphabeticalBFoo := backup.Relation{SchemaOid: 2, Oid: 0, Schema: "public", Name: "foo", DependsUpon: nil, Inherits: nil}
-		alphabeticalBBar := backup.Relation{SchemaOid: 2, Oid: 0, Schema: "public", Name: "bar", DependsUpon: nil, Inherits: nil}
+		alphabeticalBFoo := backup.Relation{SchemaOid: 2, Oid: 0, Schema: "public", Name: "foo", Inherits: nil}
+		alphabeticalBBar := backup.Relation{SchemaOid: 2, Oid: 0, Schema: "public", Name: "bar", Inherits: nil}
 		schemaPublic := backup.Schema{Oid: 1, Name: "public"}
 		schemas := []backup.Schema{schemaOther, schemaPublic}
 
@@ -450,10 +450,10 @@ GRANT ALL ON FOREIGN SERVER foreignserver TO testrole;`)
 			structmatcher.ExpectStructsToMatch(&expected, result)
 		})
 	})
-	Describe("PrintCreateDependentTypeAndFunctionAndTablesStatements", func() {
+	Describe("PrintDependentObjectStatements", func() {
 		var (
 			objects      []backup.Sortable
-			metadataMap  backup.MetadataMap
+			metadataMap  backup.UniqueMetadataMap
 			tableDefsMap map[uint32]backup.TableDefinition
 		)
 		BeforeEach(func() {
@@ -465,12 +465,12 @@ GRANT ALL ON FOREIGN SERVER foreignserver TO testrole;`)
 				backup.Type{Oid: 4, Schema: "public", Name: "domain", Type: "d", BaseType: "numeric", Category: "U"},
 				backup.Relation{Oid: 5, Schema: "public", Name: "relation"},
 			}
-			metadataMap = backup.MetadataMap{
-				1: backup.ObjectMetadata{Comment: "function"},
-				2: backup.ObjectMetadata{Comment: "base type"},
-				3: backup.ObjectMetadata{Comment: "composite type"},
-				4: backup.ObjectMetadata{Comment: "domain"},
-				5: backup.ObjectMetadata{Comment: "relation"},
+			metadataMap = backup.UniqueMetadataMap{
+				{ClassID: backup.PG_PROC_OID, Oid: 1}:  backup.ObjectMetadata{Comment: "function"},
+				{ClassID: backup.PG_TYPE_OID, Oid: 2}:  backup.ObjectMetadata{Comment: "base type"},
+				{ClassID: backup.PG_TYPE_OID, Oid: 3}:  backup.ObjectMetadata{Comment: "composite type"},
+				{ClassID: backup.PG_TYPE_OID, Oid: 4}:  backup.ObjectMetadata{Comment: "domain"},
+				{ClassID: backup.PG_CLASS_OID, Oid: 5}: backup.ObjectMetadata{Comment: "relation"},
 			}
 			tableDefsMap = map[uint32]backup.TableDefinition{
 				5: {DistPolicy: "DISTRIBUTED RANDOMLY", ColumnDefs: []backup.ColumnDefinition{}},
@@ -480,7 +480,7 @@ GRANT ALL ON FOREIGN SERVER foreignserver TO testrole;`)
 			constraints := []backup.Constraint{
 				{Name: "check_constraint", ConDef: "CHECK (VALUE > 2)", OwningObject: "public.domain"},
 			}
-			backup.PrintCreateDependentTypeAndFunctionAndTablesStatements(backupfile, toc, objects, metadataMap, tableDefsMap, constraints)
+			backup.PrintDependentObjectStatements(backupfile, toc, objects, metadataMap, tableDefsMap, constraints, map[uint32]backup.FunctionInfo{})
 			testhelper.ExpectRegexp(buffer, `
 CREATE FUNCTION public.function(integer, integer) RETURNS integer AS
 $_$SELECT $1 + $2$_$
@@ -521,7 +521,7 @@ COMMENT ON TABLE public.relation IS 'relation';
 		})
 		It("prints create statements for dependent types, functions, and tables (no domain constraint)", func() {
 			constraints := []backup.Constraint{}
-			backup.PrintCreateDependentTypeAndFunctionAndTablesStatements(backupfile, toc, objects, metadataMap, tableDefsMap, constraints)
+			backup.PrintDependentObjectStatements(backupfile, toc, objects, metadataMap, tableDefsMap, constraints, map[uint32]backup.FunctionInfo{})
 			testhelper.ExpectRegexp(buffer, `
 CREATE FUNCTION public.function(integer, integer) RETURNS integer AS
 $_$SELECT $1 + $2$_$
@@ -557,6 +557,80 @@ CREATE TABLE public.relation (
 
 
 COMMENT ON TABLE public.relation IS 'relation';
+`)
+		})
+		It("prints the metadata of each object when objects in different catalog tables have the same oid", func() {
+			objects = []backup.Sortable{
+				backup.Type{Oid: 1, Schema: "public", Name: "composite", Type: "c", Attributes: pq.StringArray{"\tfoo integer"}, Category: "U"},
+				backup.Relation{Oid: 1, Schema: "public", Name: "relation"},
+			}
+			metadataMap = backup.UniqueMetadataMap{
+				{ClassID: backup.PG_TYPE_OID, Oid: 1}:  backup.ObjectMetadata{Comment: "composite type"},
+				{ClassID: backup.PG_CLASS_OID, Oid: 1}: backup.ObjectMetadata{Comment: "relation"},
+			}
+			tableDefsMap = map[uint32]backup.TableDefinition{
+				1: {DistPolicy: "DISTRIBUTED RANDOMLY", ColumnDefs: []backup.ColumnDefinition{}},
+			}
+			backup.PrintDependentObjectStatements(backupfile, toc, objects, metadataMap, tableDefsMap, []backup.Constraint{}, map[uint32]backup.FunctionInfo{})
+			testhelper.ExpectRegexp(buffer, `
+CREATE TYPE public.composite AS (
+	foo integer
+);
+
+COMMENT ON TYPE public.composite IS 'composite type';
+
+
+CREATE TABLE public.relation (
+) DISTRIBUTED RANDOMLY;
+
+
+COMMENT ON TABLE public.relation IS 'relation';
+`)
+		})
+		It("prints create statements for other dependent objects in the order given", func() {
+			objects = []backup.Sortable{
+				backup.Relation{Oid: 5, Schema: "public", Name: "relation"},
+				backup.Operator{Oid: 6, Schema: "public", Name: "##", Procedure: "public.path_inter", LeftArgType: "public.path", RightArgType: "public.path", CommutatorOp: "0", NegatorOp: "0", RestrictFunction: "-", JoinFunction: "-"},
+				backup.Aggregate{Oid: 7, Schema: "public", Name: "agg", Arguments: "integer", IdentArgs: "integer", TransitionFunction: 1, TransitionDataType: "integer", InitValIsNull: true},
+				backup.Cast{Oid: 8, SourceTypeFQN: "public.base", TargetTypeFQN: "text", CastMethod: "i", CastContext: "e"},
+				backup.View{Oid: 9, Schema: "public", Name: "view", Definition: "SELECT count(*) FROM public.relation;"},
+			}
+			metadataMap[backup.UniqueID{ClassID: backup.PG_PROC_OID, Oid: 7}] = backup.ObjectMetadata{Comment: "aggregate"}
+			metadataMap[backup.UniqueID{ClassID: backup.PG_CLASS_OID, Oid: 9}] = backup.ObjectMetadata{Comment: "view"}
+			funcInfoMap := map[uint32]backup.FunctionInfo{1: {QualifiedName: "public.function", Arguments: "integer, integer"}}
+			objectEntries := backup.PrintDependentObjectStatements(backupfile, toc, objects, metadataMap, tableDefsMap, []backup.Constraint{}, funcInfoMap)
+			Expect(toc.PredataEntries).To(HaveLen(5))
+			for i, object := range objects {
+				Expect(objectEntries[object.GetUniqueID()]).To(Equal([]int{i}))
+			}
+			testutils.ExpectEntry(toc.PredataEntries, 0, "public", "", "relation", "TABLE")
+			testutils.ExpectEntry(toc.PredataEntries, 1, "public", "", "##", "OPERATOR")
+			testutils.ExpectEntry(toc.PredataEntries, 2, "public", "", "agg(integer)", "AGGREGATE")
+			testutils.ExpectEntry(toc.PredataEntries, 3, "pg_catalog", "", "(public.base AS text)", "CAST")
+			testutils.ExpectEntry(toc.PredataEntries, 4, "public", "", "view", "VIEW")
+			testhelper.ExpectRegexp(buffer, `
+CREATE OPERATOR public.## (
+	PROCEDURE = public.path_inter,
+	LEFTARG = public.path,
+	RIGHTARG = public.path
+);
+
+CREATE AGGREGATE public.agg(integer) (
+	SFUNC = public.function,
+	STYPE = integer
+);
+
+
+COMMENT ON AGGREGATE public.agg(integer) IS 'aggregate';
+
+
+CREATE CAST (public.base AS text)
+	WITH INOUT;
+
+CREATE VIEW public.view AS SELECT count(*) FROM public.relation;
+
+
+COMMENT ON VIEW public.view IS 'view';
 `)
 		})
 	})
@@ -31,6 +31,7 @@ func PrintCreateTextSearchParserStatements(metadataFile *utils.FileWithByteCount
 		metadataFile.MustPrintf("\n);")
 		PrintObjectMetadata(metadataFile, parserMetadata[parser.Oid], parserFQN, "TEXT SEARCH PARSER")
 		toc.AddPredataEntry(parser.Schema, parser.Name, "TEXT SEARCH PARSER", "", start, metadataFile)
+		toc.AddPredataDefinition(parser)
 	}
 }
 
@@ -46,6 +47,7 @@ func PrintCreateTextSearchTemplateStatements(metadataFile *utils.FileWithByteCou
 		metadataFile.MustPrintf("\n);")
 		PrintObjectMetadata(metadataFile, templateMetadata[template.Oid], templateFQN, "TEXT SEARCH TEMPLATE")
 		toc.AddPredataEntry(template.Schema, template.Name, "TEXT SEARCH TEMPLATE", "", start, metadataFile)
+		toc.AddPredataDefinition(template)
 	}
 }
 
@@ -61,6 +63,7 @@ func PrintCreateTextSearchDictionaryStatements(metadataFile *utils.FileWithByteC
 		metadataFile.MustPrintf("\n);")
 		PrintObjectMetadata(metadataFile, dictionaryMetadata[dictionary.Oid], dictionaryFQN, "TEXT SEARCH DICTIONARY")
 		toc.AddPredataEntry(dictionary.Schema, dictionary.Name, "TEXT SEARCH DICTIONARY", "", start, metadataFile)
+		toc.AddPredataDefinition(dictionary)
 	}
 }
 
@@ -83,5 +86,6 @@ func PrintCreateTextSearchConfigurationStatements(metadataFile *utils.FileWithBy
 		}
 		PrintObjectMetadata(metadataFile, configurationMetadata[configuration.Oid], configurationFQN, "TEXT SEARCH CONFIGURATION")
 		toc.AddPredataEntry(configuration.Schema, configuration.Name, "TEXT SEARCH CONFIGURATION", "", start, metadataFile)
+		toc.AddPredataDefinition(configuration)
 	}
 }
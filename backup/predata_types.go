@@ -48,6 +48,7 @@ func PrintCreateDomainStatement(metadataFile *utils.FileWithByteCount, toc *util
 	metadataFile.MustPrintln(";")
 	PrintObjectMetadata(metadataFile, typeMetadata, typeFQN, "DOMAIN")
 	toc.AddPredataEntry(domain.Schema, domain.Name, "DOMAIN", "", start, metadataFile)
+	toc.AddPredataDefinition(domain)
 }
 
 func PrintCreateBaseTypeStatement(metadataFile *utils.FileWithByteCount, toc *utils.TOC, base Type, typeMetadata ObjectMetadata) {
@@ -120,6 +121,7 @@ func PrintCreateBaseTypeStatement(metadataFile *utils.FileWithByteCount, toc *ut
 	}
 	PrintObjectMetadata(metadataFile, typeMetadata, typeFQN, "TYPE")
 	toc.AddPredataEntry(base.Schema, base.Name, "TYPE", "", start, metadataFile)
+	toc.AddPredataDefinition(base)
 }
 
 func PrintCreateCompositeTypeStatement(metadataFile *utils.FileWithByteCount, toc *utils.TOC, composite Type, typeMetadata ObjectMetadata) {
@@ -130,6 +132,7 @@ func PrintCreateCompositeTypeStatement(metadataFile *utils.FileWithByteCount, to
 	metadataFile.MustPrintf(");")
 	PrintObjectMetadata(metadataFile, typeMetadata, typeFQN, "TYPE")
 	toc.AddPredataEntry(composite.Schema, composite.Name, "TYPE", "", start, metadataFile)
+	toc.AddPredataDefinition(composite)
 }
 
 func PrintCreateEnumTypeStatements(metadataFile *utils.FileWithByteCount, toc *utils.TOC, enums []Type, typeMetadata MetadataMap) {
@@ -139,5 +142,17 @@ func PrintCreateEnumTypeStatements(metadataFile *utils.FileWithByteCount, toc *u
 		metadataFile.MustPrintf("\n\nCREATE TYPE %s AS ENUM (\n\t%s\n);\n", typeFQN, enum.EnumLabels)
 		PrintObjectMetadata(metadataFile, typeMetadata[enum.Oid], typeFQN, "TYPE")
 		toc.AddPredataEntry(enum.Schema, enum.Name, "TYPE", "", start, metadataFile)
+		toc.AddPredataDefinition(enum)
+	}
+}
+
+func PrintCreateCollationStatements(metadataFile *utils.FileWithByteCount, toc *utils.TOC, collations []Collation, collationMetadata MetadataMap) {
+	for _, collation := range collations {
+		start := metadataFile.ByteCount
+		collationFQN := utils.MakeFQN(collation.Schema, collation.Name)
+		metadataFile.MustPrintf("\n\nCREATE COLLATION %s (LC_COLLATE = '%s', LC_CTYPE = '%s');", collationFQN, collation.Collate, collation.Ctype)
+		PrintObjectMetadata(metadataFile, collationMetadata[collation.Oid], collationFQN, "COLLATION")
+		toc.AddPredataEntry(collation.Schema, collation.Name, "COLLATION", "", start, metadataFile)
+		toc.AddPredataDefinition(collation)
 	}
 }
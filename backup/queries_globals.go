@@ -348,6 +348,34 @@ AND spcname != 'pg_global';`
 	return results
 }
 
+/*
+ * Labels on shared objects apply to the whole cluster, so of the databases in
+ * the cluster only the label on the database being backed up is backed up,
+ * and labels on roles are only backed up along with the roles themselves.
+ */
+func GetSharedSecurityLabels(connection *dbconn.DBConn) []SecurityLabel {
+	sharedClasses := "'pg_tablespace'::regclass"
+	if len(includeSchemas) == 0 {
+		sharedClasses += ", 'pg_authid'::regclass"
+	}
+	query := fmt.Sprintf(`
+SELECT
+	'' AS schema,
+	upper(l.objtype) AS objecttype,
+	l.objname AS objectname,
+	quote_ident(l.provider) AS provider,
+	l.label
+FROM pg_seclabels l
+WHERE l.classoid IN (%s)
+OR (l.classoid = 'pg_database'::regclass AND l.objoid = (SELECT oid FROM pg_database WHERE datname = current_database()))
+ORDER BY l.classoid, l.objoid, l.provider;`, sharedClasses)
+
+	results := make([]SecurityLabel, 0)
+	err := connection.Select(&results, query)
+	gplog.FatalOnError(err)
+	return results
+}
+
 func GetDBSize(connection *dbconn.DBConn) string {
 	size := struct{ DBSize string }{}
 	sizeQuery := fmt.Sprintf("SELECT pg_size_pretty(pg_database_size(E'%s')) as dbsize", dbconn.EscapeConnectionParam(connection.DBName))
@@ -6,6 +6,7 @@ package backup
  */
 
 import (
+	"database/sql"
 	"fmt"
 
 	"github.com/greenplum-db/gp-common-go-libs/dbconn"
@@ -198,3 +199,144 @@ ORDER BY tgname;`, tableAndSchemaFilterClause(), constraintClause)
 	gplog.FatalOnError(err)
 	return results
 }
+
+type DefaultPrivileges struct {
+	Owner      string
+	Schema     string
+	Privileges []ACL
+	ObjectType string
+}
+
+/*
+ * Default privileges set for a role in all schemas are only backed up if no
+ * schemas are included, like other objects that do not belong to a schema.
+ */
+func GetDefaultPrivileges(connection *dbconn.DBConn) []DefaultPrivileges {
+	filterClause := fmt.Sprintf("a.defaclnamespace != 0 AND %s", SchemaFilterClause("n"))
+	if len(includeSchemas) == 0 {
+		filterClause = fmt.Sprintf("a.defaclnamespace = 0 OR (%s)", filterClause)
+	}
+	query := fmt.Sprintf(`
+SELECT
+	a.oid,
+	quote_ident(r.rolname) AS owner,
+	coalesce(quote_ident(n.nspname), '') AS schema,
+	a.defaclobjtype AS objecttype,
+	CASE
+		WHEN coalesce(array_upper(a.defaclacl, 1), 0) = 0 THEN a.defaclacl[0]
+		ELSE unnest(a.defaclacl)
+	END AS privileges,
+	CASE
+		WHEN coalesce(array_upper(a.defaclacl, 1), 0) = 0 THEN 'Empty'
+		ELSE ''
+	END AS kind
+FROM pg_default_acl a
+JOIN pg_roles r
+	ON (a.defaclrole = r.oid)
+LEFT JOIN pg_namespace n
+	ON (a.defaclnamespace = n.oid)
+WHERE %s
+ORDER BY a.oid;`, filterClause)
+
+	results := make([]struct {
+		Oid        uint32
+		Owner      string
+		Schema     string
+		ObjectType string
+		Privileges sql.NullString
+		Kind       string
+	}, 0)
+	err := connection.Select(&results, query)
+	gplog.FatalOnError(err)
+
+	defaultPrivileges := make([]DefaultPrivileges, 0)
+	currentOid := uint32(0)
+	for _, result := range results {
+		if result.Oid != currentOid {
+			currentOid = result.Oid
+			defaultPrivileges = append(defaultPrivileges, DefaultPrivileges{Owner: result.Owner, Schema: result.Schema, Privileges: []ACL{}, ObjectType: result.ObjectType})
+		}
+		if result.Kind == "Empty" || !result.Privileges.Valid {
+			continue
+		}
+		if privileges := ParseACL(result.Privileges.String); privileges != nil {
+			current := &defaultPrivileges[len(defaultPrivileges)-1]
+			current.Privileges = append(current.Privileges, *privileges)
+		}
+	}
+	for i := range defaultPrivileges {
+		defaultPrivileges[i].Privileges = sortACLs(defaultPrivileges[i].Privileges)
+	}
+	return defaultPrivileges
+}
+
+type EventTrigger struct {
+	Oid          uint32
+	Name         string
+	Event        string
+	EventTags    string
+	FunctionName string
+	Enabled      string
+}
+
+func GetEventTriggers(connection *dbconn.DBConn) []EventTrigger {
+	query := `
+SELECT
+	e.oid,
+	quote_ident(e.evtname) AS name,
+	e.evtevent AS event,
+	coalesce(array_to_string(ARRAY(SELECT quote_literal(t) FROM unnest(e.evttags) AS t), ', '), '') AS eventtags,
+	quote_ident(n.nspname) || '.' || quote_ident(p.proname) AS functionname,
+	e.evtenabled AS enabled
+FROM pg_event_trigger e
+JOIN pg_proc p ON e.evtfoid = p.oid
+JOIN pg_namespace n ON p.pronamespace = n.oid
+WHERE e.oid NOT IN (select objid from pg_depend where deptype = 'e')
+ORDER BY e.evtname;`
+
+	results := make([]EventTrigger, 0)
+	err := connection.Select(&results, query)
+	gplog.FatalOnError(err)
+	return results
+}
+
+type SecurityLabel struct {
+	Schema     string
+	ObjectType string
+	ObjectName string
+	Provider   string
+	Label      string
+}
+
+/*
+ * The pg_seclabels view already formats each labeled object's type and name
+ * as they appear in a SECURITY LABEL statement.  Labels on shared objects are
+ * backed up with the global metadata, and labels on objects that belong to an
+ * extension are restored with the extension.  Labels on objects that do not
+ * belong to a schema, such as procedural languages, are only backed up if no
+ * schemas are included.
+ */
+func GetSecurityLabels(connection *dbconn.DBConn) []SecurityLabel {
+	filterClause := fmt.Sprintf("l.objnamespace IS NOT NULL AND %s", SchemaFilterClause("n"))
+	if len(includeSchemas) == 0 {
+		filterClause = fmt.Sprintf("l.objnamespace IS NULL OR (%s)", filterClause)
+	}
+	query := fmt.Sprintf(`
+SELECT
+	coalesce(quote_ident(n.nspname), '') AS schema,
+	upper(l.objtype) AS objecttype,
+	l.objname AS objectname,
+	quote_ident(l.provider) AS provider,
+	l.label
+FROM pg_seclabels l
+LEFT JOIN pg_namespace n ON l.objnamespace = n.oid
+WHERE l.classoid NOT IN ('pg_database'::regclass, 'pg_authid'::regclass, 'pg_tablespace'::regclass)
+AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.classid = l.classoid AND d.objid = l.objoid AND d.deptype = 'e')
+AND (%s)
+ORDER BY l.classoid, l.objoid, l.objsubid, l.provider;`, filterClause)
+
+	results := make([]SecurityLabel, 0)
+	err := connection.Select(&results, query)
+	gplog.FatalOnError(err)
+	return results
+}
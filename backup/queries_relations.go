@@ -322,31 +322,63 @@ func SelectAsOidToStringMap(connection *dbconn.DBConn, query string) map[uint32]
 
 func GetDistributionPolicies(connection *dbconn.DBConn, tables []Relation) map[uint32]string {
 	// This query is adapted from the addDistributedBy() function in pg_dump.c.
-	query := `
+	query := fmt.Sprintf(`
 SELECT
-	a.attrelid AS oid,
-	'(' || array_to_string(array_agg(quote_ident(a.attname)), ', ') || ')' AS value
-FROM pg_attribute a
-JOIN (
-	SELECT
-		unnest(attrnums) AS attnum,
-		localoid
-	FROM gp_distribution_policy
-) p
-ON (p.localoid,p.attnum) = (a.attrelid,a.attnum)
-GROUP BY a.attrelid ORDER BY a.attrelid;`
+	p.localoid AS oid,
+	%s AS value
+FROM gp_distribution_policy p
+ORDER BY p.localoid;`, utils.DistributionPolicyExpression(connection))
 
 	resultMap := SelectAsOidToStringMap(connection, query)
 	for _, table := range tables {
-		if resultMap[table.Oid] != "" {
-			resultMap[table.Oid] = fmt.Sprintf("DISTRIBUTED BY %s", resultMap[table.Oid])
-		} else {
+		if resultMap[table.Oid] == "" {
 			resultMap[table.Oid] = "DISTRIBUTED RANDOMLY"
 		}
 	}
 	return resultMap
 }
 
+/*
+ * The size of a partitioned table is the size of all of its partitions, as its
+ * data is backed up with the parent table.  External partitions are skipped,
+ * as their data is not backed up.
+ */
+func GetTableSizes(connection *dbconn.DBConn, tables []Relation) map[uint32]int64 {
+	resultMap := make(map[uint32]int64, len(tables))
+	if len(tables) == 0 {
+		return resultMap
+	}
+	oids := make([]string, 0, len(tables))
+	for _, table := range tables {
+		oids = append(oids, fmt.Sprintf("%d", table.Oid))
+	}
+	query := fmt.Sprintf(`
+SELECT
+	c.oid,
+	(pg_relation_size(c.oid) + coalesce((
+		SELECT sum(pg_relation_size(r.parchildrelid))
+		FROM pg_partition_rule r
+		JOIN pg_partition p ON r.paroid = p.oid
+		JOIN pg_class pc ON r.parchildrelid = pc.oid
+		WHERE p.parrelid = c.oid
+		AND pc.relstorage != 'x'
+	), 0))::bigint AS size
+FROM pg_class c
+WHERE c.oid IN (%s)
+AND c.relstorage != 'x';`, strings.Join(oids, ", "))
+
+	results := make([]struct {
+		Oid  uint32
+		Size int64
+	}, 0)
+	err := connection.Select(&results, query)
+	gplog.FatalOnError(err)
+	for _, result := range results {
+		resultMap[result.Oid] = result.Size
+	}
+	return resultMap
+}
+
 func GetTableType(connection *dbconn.DBConn) map[uint32]string {
 	if connection.Version.Before("6") {
 		return map[uint32]string{}
@@ -375,74 +407,35 @@ func GetTablespaceNames(connection *dbconn.DBConn) map[uint32]string {
 	return SelectAsOidToStringMap(connection, query)
 }
 
-type Dependency struct {
-	Oid              uint32
-	ReferencedObject string
-}
-
-func ConstructTableDependencies(connection *dbconn.DBConn, tables []Relation, tableDefs map[uint32]TableDefinition, isTableFiltered bool) []Relation {
-	var tableNameSet *utils.FilterSet
-	var tableOidList []string
-	if isTableFiltered {
-		tableNameSet = utils.NewEmptyIncludeSet()
-		tableOidList = make([]string, len(tables))
-		for i, table := range tables {
-			tableNameSet.Add(table.ToString())
-			tableOidList[i] = fmt.Sprintf("%d", table.Oid)
-		}
-	}
-	nonTableQuery := fmt.Sprintf(`
-SELECT
-	objid AS oid,
-	quote_ident(n.nspname) || '.' || quote_ident(p.typname) AS referencedobject,
-	'f' AS istable
-FROM pg_depend d
-JOIN pg_type p ON d.refobjid = p.oid
-JOIN pg_namespace n ON p.typnamespace = n.oid
-JOIN pg_class c ON d.objid = c.oid AND c.relkind = 'r'
-WHERE %s
-AND p.oid NOT IN (select objid from pg_depend where deptype = 'e')`, SchemaFilterClause("n"))
-	tableQuery := `
+/*
+ * Inheritance is recorded for all tables, even if the parent table is not in
+ * the backup set, as the INHERITS clause is part of the table definition.
+ */
+func GetTableInheritance(connection *dbconn.DBConn, tables []Relation, tableDefs map[uint32]TableDefinition) []Relation {
+	query := `
 SELECT
-	objid AS oid,
-	quote_ident(n.nspname) || '.' || quote_ident(p.relname) AS referencedobject,
-	't' AS istable
-FROM pg_depend d
-JOIN pg_class p ON d.refobjid = p.oid AND p.relkind = 'r'
+	i.inhrelid AS oid,
+	quote_ident(n.nspname) || '.' || quote_ident(p.relname) AS referencedobject
+FROM pg_inherits i
+JOIN pg_class p ON i.inhparent = p.oid
 JOIN pg_namespace n ON p.relnamespace = n.oid
-JOIN pg_class c ON d.objid = c.oid AND c.relkind = 'r'
-AND p.oid NOT IN (select objid from pg_depend where deptype = 'e')`
+WHERE p.oid NOT IN (select objid from pg_depend where deptype = 'e')
+ORDER BY i.inhrelid, i.inhseqno;`
 
-	query := ""
-	// If we are filtering on tables, we only want to record dependencies on other tables in the list
-	if isTableFiltered {
-		query = fmt.Sprintf("%s\nWHERE objid IN (%s);", tableQuery, strings.Join(tableOidList, ","))
-	} else {
-		query = fmt.Sprintf("%s\nUNION\n%s;", nonTableQuery, tableQuery)
-	}
 	results := make([]struct {
 		Oid              uint32
 		ReferencedObject string
-		IsTable          bool
 	}, 0)
-	dependencyMap := make(map[uint32][]string, 0)
 	inheritanceMap := make(map[uint32][]string, 0)
 	err := connection.Select(&results, query)
 	gplog.FatalOnError(err)
-	for _, dependency := range results {
-		if tableDefs[dependency.Oid].IsExternal && tableDefs[dependency.Oid].PartitionType == "l" {
+	for _, inheritance := range results {
+		if tableDefs[inheritance.Oid].IsExternal && tableDefs[inheritance.Oid].PartitionType == "l" {
 			continue
 		}
-		if dependency.IsTable {
-			inheritanceMap[dependency.Oid] = append(inheritanceMap[dependency.Oid], dependency.ReferencedObject)
-		}
-		if isTableFiltered && !tableNameSet.MatchesFilter(dependency.ReferencedObject) {
-			continue
-		}
-		dependencyMap[dependency.Oid] = append(dependencyMap[dependency.Oid], dependency.ReferencedObject)
+		inheritanceMap[inheritance.Oid] = append(inheritanceMap[inheritance.Oid], inheritance.ReferencedObject)
 	}
 	for i := 0; i < len(tables); i++ {
-		tables[i].DependsUpon = dependencyMap[tables[i].Oid]
 		tables[i].Inherits = inheritanceMap[tables[i].Oid]
 	}
 	return tables
@@ -524,11 +517,10 @@ WHERE s.relkind = 'S';`
 }
 
 type View struct {
-	Oid         uint32
-	Schema      string
-	Name        string
-	Definition  string
-	DependsUpon []string
+	Oid        uint32
+	Schema     string
+	Name       string
+	Definition string
 }
 
 func (v View) ToString() string {
@@ -553,36 +545,6 @@ AND c.oid NOT IN (select objid from pg_depend where deptype = 'e');`, SchemaFilt
 	return results
 }
 
-func ConstructViewDependencies(connection *dbconn.DBConn, views []View) []View {
-	query := fmt.Sprintf(`
-SELECT DISTINCT
-	v2.oid,
-	quote_ident(n.nspname) || '.' || quote_ident(v1.relname) AS referencedobject
-FROM pg_class v1
-JOIN pg_depend d ON d.refobjid = v1.oid
-JOIN pg_rewrite rw ON rw.oid = d.objid
-JOIN pg_class v2 ON rw.ev_class = v2.oid
-JOIN pg_namespace n ON v1.relnamespace = n.oid
-WHERE d.classid = 'pg_rewrite'::regclass::oid
-	AND v1.oid != v2.oid
-	AND v1.relkind = 'v'
-	AND %s
-	AND v1.oid NOT IN (select objid from pg_depend where deptype = 'e')
-ORDER BY v2.oid, referencedobject;`, SchemaFilterClause("n"))
-
-	results := make([]Dependency, 0)
-	dependencyMap := make(map[uint32][]string, 0)
-	err := connection.Select(&results, query)
-	gplog.FatalOnError(err)
-	for _, dependency := range results {
-		dependencyMap[dependency.Oid] = append(dependencyMap[dependency.Oid], dependency.ReferencedObject)
-	}
-	for i := 0; i < len(views); i++ {
-		views[i].DependsUpon = dependencyMap[views[i].Oid]
-	}
-	return views
-}
-
 func LockTables(connection *dbconn.DBConn, tables []Relation) {
 	gplog.Info("Acquiring ACCESS SHARE locks on tables")
 	progressBar := utils.NewProgressBar(len(tables), "Locks acquired: ", utils.PB_VERBOSE)
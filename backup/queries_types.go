@@ -111,7 +111,6 @@ type Type struct {
 	BaseType        string
 	NotNull         bool `db:"typnotnull"`
 	Attributes      pq.StringArray
-	DependsUpon     []string
 	StorageOptions  string
 }
 
@@ -304,136 +303,30 @@ ORDER BY n.nspname, t.typname;`, SchemaFilterClause("n"))
 	return results
 }
 
-/*
- * We already have the functions on which a base type depends in the base type's
- * TypeDefinition, but we need to query pg_proc to determine whether one of those
- * functions is a built-in function (and therefore should not be considered a
- * dependency for dependency sorting purposes).
- */
-func ConstructBaseTypeDependencies4(connection *dbconn.DBConn, types []Type, funcInfoMap map[uint32]FunctionInfo) []Type {
-	query := fmt.Sprintf(`
-SELECT DISTINCT
-    t.oid,
-    p.oid AS referencedoid
-FROM pg_depend d
-JOIN pg_proc p ON (d.refobjid = p.oid AND p.pronamespace != (SELECT oid FROM pg_namespace WHERE nspname = 'pg_catalog'))
-JOIN pg_type t ON (d.objid = t.oid AND t.typtype = 'b')
-JOIN pg_namespace n ON n.oid = t.typnamespace
-WHERE %s
-AND d.refclassid = 'pg_proc'::regclass
-AND d.deptype = 'n';`, SchemaFilterClause("n"))
-
-	results := make([]struct {
-		Oid           uint32
-		ReferencedOid uint32
-	}, 0)
-	dependencyMap := make(map[uint32][]string, 0)
-	err := connection.Select(&results, query)
-	gplog.FatalOnError(err)
-	for _, dependency := range results {
-		referencedFunc := funcInfoMap[dependency.ReferencedOid]
-		dependencyStr := fmt.Sprintf("%s(%s)", referencedFunc.QualifiedName, referencedFunc.Arguments)
-		dependencyMap[dependency.Oid] = append(dependencyMap[dependency.Oid], dependencyStr)
-	}
-	for i := 0; i < len(types); i++ {
-		if types[i].Type == "b" {
-			types[i].DependsUpon = dependencyMap[types[i].Oid]
-		}
-	}
-	return types
-}
-
-func ConstructBaseTypeDependencies5(connection *dbconn.DBConn, types []Type) []Type {
-	query := fmt.Sprintf(`
-SELECT DISTINCT
-    t.oid,
-    quote_ident(n.nspname) || '.' || quote_ident(p.proname) || '(' || pg_get_function_arguments(p.oid) || ')' AS referencedobject
-FROM pg_depend d
-JOIN pg_proc p ON (d.refobjid = p.oid AND p.pronamespace != (SELECT oid FROM pg_namespace WHERE nspname = 'pg_catalog'))
-JOIN pg_type t ON (d.objid = t.oid AND t.typtype = 'b')
-JOIN pg_namespace n ON n.oid = p.pronamespace
-WHERE %s
-AND d.refclassid = 'pg_proc'::regclass
-AND d.deptype = 'n';`, SchemaFilterClause("n"))
-
-	results := make([]Dependency, 0)
-	dependencyMap := make(map[uint32][]string, 0)
-	err := connection.Select(&results, query)
-	gplog.FatalOnError(err)
-	for _, dependency := range results {
-		dependencyMap[dependency.Oid] = append(dependencyMap[dependency.Oid], dependency.ReferencedObject)
-	}
-	for i := 0; i < len(types); i++ {
-		if types[i].Type == "b" {
-			types[i].DependsUpon = dependencyMap[types[i].Oid]
-		}
-	}
-	return types
+type Collation struct {
+	Oid     uint32
+	Schema  string
+	Name    string
+	Collate string `db:"collcollate"`
+	Ctype   string `db:"collctype"`
 }
 
-/*
- * We already have the base type of a domain in the domain's TypeDefinition, but
- * we need to query pg_type to determine whether the base type is built in (and
- * therefore should not be considered a dependency for dependency sorting purposes).
- */
-func ConstructDomainDependencies(connection *dbconn.DBConn, types []Type) []Type {
+func GetCollations(connection *dbconn.DBConn) []Collation {
 	query := fmt.Sprintf(`
 SELECT
-	t.oid,
-	quote_ident(n.nspname) || '.' || quote_ident(bt.typname) AS referencedobject
-FROM pg_type t
-JOIN pg_type bt ON t.typbasetype = bt.oid
-JOIN pg_namespace n ON bt.typnamespace = n.oid
-WHERE %s
-AND bt.typnamespace != (
-	SELECT
-		bn.oid
-	FROM pg_namespace bn
-	WHERE bn.nspname = 'pg_catalog'
-);`, SchemaFilterClause("n"))
-
-	results := make([]Dependency, 0)
-	dependencyMap := make(map[uint32][]string, 0)
-	err := connection.Select(&results, query)
-	gplog.FatalOnError(err)
-	for _, dependency := range results {
-		dependencyMap[dependency.Oid] = append(dependencyMap[dependency.Oid], dependency.ReferencedObject)
-	}
-	for i := 0; i < len(types); i++ {
-		if types[i].Type == "d" {
-			types[i].DependsUpon = dependencyMap[types[i].Oid]
-		}
-	}
-	return types
-}
-
-func ConstructCompositeTypeDependencies(connection *dbconn.DBConn, types []Type) []Type {
-	query := fmt.Sprintf(`
-SELECT DISTINCT
-	tc.oid,
-	coalesce((SELECT quote_ident(n.nspname) || '.' || quote_ident(typname) FROM pg_type WHERE t.typelem = oid), quote_ident(n.nspname) || '.' || quote_ident(t.typname)) AS referencedobject
-FROM pg_depend d
-JOIN pg_type t
-	ON (d.refobjid = t.oid AND t.typtype != 'p' AND t.typtype != 'e' AND t.typnamespace != (SELECT oid FROM pg_namespace WHERE nspname = 'pg_catalog'))
-JOIN pg_class c ON (d.objid = c.oid AND c.relkind = 'c')
-JOIN pg_type tc ON (tc.typrelid = c.oid AND tc.typtype = 'c')
-JOIN pg_namespace n ON n.oid = t.typnamespace
+	c.oid,
+	quote_ident(n.nspname) AS schema,
+	quote_ident(c.collname) AS name,
+	c.collcollate,
+	c.collctype
+FROM pg_collation c
+JOIN pg_namespace n ON c.collnamespace = n.oid
 WHERE %s
-AND d.refclassid = 'pg_type'::regclass
-AND c.reltype != t.oid
-AND d.deptype = 'n';`, SchemaFilterClause("n"))
+AND c.oid NOT IN (select objid from pg_depend where deptype = 'e')
+ORDER BY n.nspname, c.collname;`, SchemaFilterClause("n"))
 
-	results := make([]Dependency, 0)
-	dependencyMap := make(map[uint32][]string, 0)
+	results := make([]Collation, 0)
 	err := connection.Select(&results, query)
 	gplog.FatalOnError(err)
-	for _, dependency := range results {
-		dependencyMap[dependency.Oid] = append(dependencyMap[dependency.Oid], dependency.ReferencedObject)
-	}
-	for i := 0; i < len(types); i++ {
-		if types[i].Type == "c" {
-			types[i].DependsUpon = dependencyMap[types[i].Oid]
-		}
-	}
-	return types
+	return results
 }
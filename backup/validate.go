@@ -83,9 +83,13 @@ func ValidateFlagCombinations() {
 	utils.CheckExclusiveFlags("metadata-only", "leaf-partition-data")
 	utils.CheckExclusiveFlags("metadata-only", "single-data-file")
 	utils.CheckExclusiveFlags("no-compression", "compression-level")
+	utils.CheckExclusiveFlags("data-only", "object-definitions")
 	if *pluginConfigFile != "" && !(*singleDataFile || *metadataOnly) {
 		gplog.Fatal(errors.Errorf("--plugin-config must be specified with either --single-data-file or --metadata-only"), "")
 	}
+	if *streamMetadata && *pluginConfigFile == "" {
+		gplog.Fatal(errors.Errorf("--stream-metadata must be specified with --plugin-config"), "")
+	}
 }
 
 func ValidateCompressionLevel(compressionLevel int) {
@@ -99,4 +103,5 @@ func ValidateFlagValues() {
 	utils.ValidateFullPath(*backupDir)
 	utils.ValidateFullPath(*pluginConfigFile)
 	ValidateCompressionLevel(*compressionLevel)
+	utils.ValidateObjectDefinitionFormat(*objectDefinitions)
 }
@@ -75,6 +75,7 @@ func InitializeBackupReport() {
 	isExcludeSchemaFiltered := len(excludeSchemas) > 0
 	isExcludeTableFiltered := len(excludeTables) > 0
 	backupReport.SetBackupParamsFromFlags(*dataOnly, *metadataOnly, "", isIncludeSchemaFiltered, isIncludeTableFiltered, isExcludeSchemaFiltered, isExcludeTableFiltered, *singleDataFile, *withStats)
+	backupReport.ObjectDefinitions = *objectDefinitions
 }
 
 func InitializeFilterLists() {
@@ -119,7 +120,6 @@ func RetrieveFunctions(procLangs []ProceduralLanguage) ([]Function, []Function,
 	functions := GetFunctionsAllVersions(connection)
 	objectCounts["Functions"] = len(functions)
 	functionMetadata := GetMetadataForObjectType(connection, TYPE_FUNCTION)
-	functions = ConstructFunctionDependencies(connection, functions)
 	langFuncs, otherFuncs := ExtractLanguageFunctions(functions, procLangs)
 	return langFuncs, otherFuncs, functionMetadata
 }
@@ -129,17 +129,10 @@ func RetrieveTypes() ([]Type, MetadataMap, map[uint32]FunctionInfo) {
 	shells := GetShellTypes(connection)
 	bases := GetBaseTypes(connection)
 	funcInfoMap := GetFunctionOidToInfoMap(connection)
-	if connection.Version.Before("5") {
-		bases = ConstructBaseTypeDependencies4(connection, bases, funcInfoMap)
-	} else {
-		bases = ConstructBaseTypeDependencies5(connection, bases)
-	}
 	types := append(shells, bases...)
 	composites := GetCompositeTypes(connection)
-	composites = ConstructCompositeTypeDependencies(connection, composites)
 	types = append(types, composites...)
 	domains := GetDomainTypes(connection)
-	domains = ConstructDomainDependencies(connection, domains)
 	types = append(types, domains...)
 	objectCounts["Types"] = len(types)
 	typeMetadata := GetMetadataForObjectType(connection, TYPE_TYPE)
@@ -152,6 +145,131 @@ func RetrieveConstraints(tables ...Relation) ([]Constraint, MetadataMap) {
 	return constraints, conMetadata
 }
 
+/*
+ * The following functions retrieve objects that are sorted in dependency order
+ * along with functions, types, and tables, appending them to the list of
+ * objects to be sorted and their metadata to the shared metadata map.
+ */
+
+func RetrieveProtocols(objects *[]Sortable, metadataMap UniqueMetadataMap) {
+	gplog.Verbose("Retrieving protocol information")
+	protocols := GetExternalProtocols(connection)
+	objectCounts["Protocols"] = len(protocols)
+	for _, protocol := range protocols {
+		*objects = append(*objects, protocol)
+	}
+	metadataMap.Add(PG_EXTPROTOCOL_OID, GetMetadataForObjectType(connection, TYPE_PROTOCOL))
+}
+
+func RetrieveTSParsers(objects *[]Sortable, metadataMap UniqueMetadataMap) {
+	gplog.Verbose("Retrieving text search parser information")
+	parsers := GetTextSearchParsers(connection)
+	objectCounts["Text Search Parsers"] = len(parsers)
+	for _, parser := range parsers {
+		*objects = append(*objects, parser)
+	}
+	metadataMap.Add(PG_TS_PARSER_OID, GetCommentsForObjectType(connection, TYPE_TSPARSER))
+}
+
+func RetrieveTSTemplates(objects *[]Sortable, metadataMap UniqueMetadataMap) {
+	gplog.Verbose("Retrieving text search template information")
+	templates := GetTextSearchTemplates(connection)
+	objectCounts["Text Search Templates"] = len(templates)
+	for _, template := range templates {
+		*objects = append(*objects, template)
+	}
+	metadataMap.Add(PG_TS_TEMPLATE_OID, GetCommentsForObjectType(connection, TYPE_TSTEMPLATE))
+}
+
+func RetrieveTSDictionaries(objects *[]Sortable, metadataMap UniqueMetadataMap) {
+	gplog.Verbose("Retrieving text search dictionary information")
+	dictionaries := GetTextSearchDictionaries(connection)
+	objectCounts["Text Search Dictionaries"] = len(dictionaries)
+	for _, dictionary := range dictionaries {
+		*objects = append(*objects, dictionary)
+	}
+	metadataMap.Add(PG_TS_DICT_OID, GetMetadataForObjectType(connection, TYPE_TSDICTIONARY))
+}
+
+func RetrieveTSConfigurations(objects *[]Sortable, metadataMap UniqueMetadataMap) {
+	gplog.Verbose("Retrieving text search configuration information")
+	configurations := GetTextSearchConfigurations(connection)
+	objectCounts["Text Search Configurations"] = len(configurations)
+	for _, configuration := range configurations {
+		*objects = append(*objects, configuration)
+	}
+	metadataMap.Add(PG_TS_CONFIG_OID, GetMetadataForObjectType(connection, TYPE_TSCONFIGURATION))
+}
+
+func RetrieveOperators(objects *[]Sortable, metadataMap UniqueMetadataMap) {
+	gplog.Verbose("Retrieving operator information")
+	operators := GetOperators(connection)
+	objectCounts["Operators"] = len(operators)
+	for _, operator := range operators {
+		*objects = append(*objects, operator)
+	}
+	metadataMap.Add(PG_OPERATOR_OID, GetMetadataForObjectType(connection, TYPE_OPERATOR))
+}
+
+func RetrieveOperatorFamilies(objects *[]Sortable, metadataMap UniqueMetadataMap) {
+	gplog.Verbose("Retrieving operator family information")
+	operatorFamilies := GetOperatorFamilies(connection)
+	objectCounts["Operator Families"] = len(operatorFamilies)
+	for _, operatorFamily := range operatorFamilies {
+		*objects = append(*objects, operatorFamily)
+	}
+	metadataMap.Add(PG_OPFAMILY_OID, GetMetadataForObjectType(connection, TYPE_OPERATORFAMILY))
+}
+
+func RetrieveOperatorClasses(objects *[]Sortable, metadataMap UniqueMetadataMap) {
+	gplog.Verbose("Retrieving operator class information")
+	operatorClasses := GetOperatorClasses(connection)
+	objectCounts["Operator Classes"] = len(operatorClasses)
+	for _, operatorClass := range operatorClasses {
+		*objects = append(*objects, operatorClass)
+	}
+	metadataMap.Add(PG_OPCLASS_OID, GetMetadataForObjectType(connection, TYPE_OPERATORCLASS))
+}
+
+func RetrieveConversions(objects *[]Sortable, metadataMap UniqueMetadataMap) {
+	gplog.Verbose("Retrieving conversion information")
+	conversions := GetConversions(connection)
+	objectCounts["Conversions"] = len(conversions)
+	for _, conversion := range conversions {
+		*objects = append(*objects, conversion)
+	}
+	metadataMap.Add(PG_CONVERSION_OID, GetMetadataForObjectType(connection, TYPE_CONVERSION))
+}
+
+func RetrieveAggregates(objects *[]Sortable, metadataMap UniqueMetadataMap) {
+	gplog.Verbose("Retrieving aggregate information")
+	aggregates := GetAggregates(connection)
+	objectCounts["Aggregates"] = len(aggregates)
+	for _, aggregate := range aggregates {
+		*objects = append(*objects, aggregate)
+	}
+	metadataMap.Add(PG_PROC_OID, GetMetadataForObjectType(connection, TYPE_AGGREGATE))
+}
+
+func RetrieveCasts(objects *[]Sortable, metadataMap UniqueMetadataMap) {
+	gplog.Verbose("Retrieving cast information")
+	casts := GetCasts(connection)
+	objectCounts["Casts"] = len(casts)
+	for _, cast := range casts {
+		*objects = append(*objects, cast)
+	}
+	metadataMap.Add(PG_CAST_OID, GetCommentsForObjectType(connection, TYPE_CAST))
+}
+
+func RetrieveViews(objects *[]Sortable) {
+	gplog.Verbose("Retrieving view information")
+	views := GetViews(connection)
+	objectCounts["Views"] = len(views)
+	for _, view := range views {
+		*objects = append(*objects, view)
+	}
+}
+
 /*
  * Generic metadata wrapper functions
  */
@@ -290,15 +408,22 @@ func BackupCreateSequences(metadataFile *utils.FileWithByteCount, sequences []Se
 	PrintCreateSequenceStatements(metadataFile, globalTOC, sequences, relationMetadata)
 }
 
-// This function is fairly unwieldy, but there's not really a good way to break it down
-func BackupFunctionsAndTypesAndTables(metadataFile *utils.FileWithByteCount, otherFuncs []Function, types []Type, tables []Relation, functionMetadata MetadataMap, typeMetadata MetadataMap, relationMetadata MetadataMap, tableDefs map[uint32]TableDefinition, constraints []Constraint) {
-	gplog.Verbose("Writing CREATE FUNCTION statements to metadata file")
-	gplog.Verbose("Writing CREATE TYPE statements for base, composite, and domain types to metadata file")
-	gplog.Verbose("Writing CREATE TABLE statements to metadata file")
-	tables = ConstructTableDependencies(connection, tables, tableDefs, false)
-	sortedSlice := SortFunctionsAndTypesAndTablesInDependencyOrder(otherFuncs, types, tables)
-	filteredMetadata := ConstructFunctionAndTypeAndTableMetadataMap(functionMetadata, typeMetadata, relationMetadata)
-	PrintCreateDependentTypeAndFunctionAndTablesStatements(metadataFile, globalTOC, sortedSlice, filteredMetadata, tableDefs, constraints)
+/*
+ * Functions, types, tables, and every other object that can depend on them are
+ * sorted together so that each object is created after everything it depends on.
+ */
+func BackupDependentObjects(metadataFile *utils.FileWithByteCount, tables []Relation, tableDefs map[uint32]TableDefinition, objects []Sortable, metadataMap UniqueMetadataMap, constraints []Constraint, funcInfoMap map[uint32]FunctionInfo) {
+	gplog.Verbose("Writing CREATE statements for dependent objects to metadata file")
+	tables = GetTableInheritance(connection, tables, tableDefs)
+	for _, table := range tables {
+		objects = append(objects, table)
+	}
+	backupSet := CreateBackupSet(objects)
+	dependencies := GetDependencies(connection, backupSet)
+	sortedSlice := TopologicalSort(objects, dependencies)
+	blockStart := len(globalTOC.PredataEntries)
+	objectEntries := PrintDependentObjectStatements(metadataFile, globalTOC, sortedSlice, metadataMap, tableDefs, constraints, funcInfoMap)
+	SetEntryDependencies(globalTOC, sortedSlice, objectEntries, dependencies, blockStart)
 	extPartInfo, partInfoMap := GetExternalPartitionInfo(connection)
 	if len(extPartInfo) > 0 {
 		gplog.Verbose("Writing EXCHANGE PARTITION statements to metadata file")
@@ -309,18 +434,9 @@ func BackupFunctionsAndTypesAndTables(metadataFile *utils.FileWithByteCount, oth
 // This function should be used only with a table-only backup.  For an unfiltered backup, the above function is used.
 func BackupTables(metadataFile *utils.FileWithByteCount, tables []Relation, relationMetadata MetadataMap, tableDefs map[uint32]TableDefinition, constraints []Constraint) {
 	gplog.Verbose("Writing CREATE TABLE statements to metadata file")
-	tables = ConstructTableDependencies(connection, tables, tableDefs, true)
-	sortable := make([]Sortable, 0)
-	for _, table := range tables {
-		sortable = append(sortable, table)
-	}
-	sortedSlice := TopologicalSort(sortable)
-	PrintCreateDependentTypeAndFunctionAndTablesStatements(metadataFile, globalTOC, sortedSlice, relationMetadata, tableDefs, constraints)
-	extPartInfo, partInfoMap := GetExternalPartitionInfo(connection)
-	if len(extPartInfo) > 0 {
-		gplog.Verbose("Writing EXCHANGE PARTITION statements to metadata file")
-		PrintExchangeExternalPartitionStatements(metadataFile, globalTOC, extPartInfo, partInfoMap, tables)
-	}
+	metadataMap := make(UniqueMetadataMap)
+	metadataMap.Add(PG_CLASS_OID, relationMetadata)
+	BackupDependentObjects(metadataFile, tables, tableDefs, []Sortable{}, metadataMap, constraints, map[uint32]FunctionInfo{})
 }
 
 func BackupAlterSequences(metadataFile *utils.FileWithByteCount, sequences []Sequence) {
@@ -329,92 +445,12 @@ func BackupAlterSequences(metadataFile *utils.FileWithByteCount, sequences []Seq
 	PrintAlterSequenceStatements(metadataFile, globalTOC, sequences, sequenceColumnOwners)
 }
 
-func BackupProtocols(metadataFile *utils.FileWithByteCount, funcInfoMap map[uint32]FunctionInfo) {
-	gplog.Verbose("Writing CREATE PROTOCOL statements to metadata file")
-	protocols := GetExternalProtocols(connection)
-	objectCounts["Protocols"] = len(protocols)
-	protoMetadata := GetMetadataForObjectType(connection, TYPE_PROTOCOL)
-	PrintCreateExternalProtocolStatements(metadataFile, globalTOC, protocols, funcInfoMap, protoMetadata)
-}
-
-func BackupTSParsers(metadataFile *utils.FileWithByteCount) {
-	gplog.Verbose("Writing CREATE TEXT SEARCH PARSER statements to metadata file")
-	parsers := GetTextSearchParsers(connection)
-	objectCounts["Text Search Parsers"] = len(parsers)
-	parserMetadata := GetCommentsForObjectType(connection, TYPE_TSPARSER)
-	PrintCreateTextSearchParserStatements(metadataFile, globalTOC, parsers, parserMetadata)
-}
-
-func BackupTSTemplates(metadataFile *utils.FileWithByteCount) {
-	gplog.Verbose("Writing CREATE TEXT SEARCH TEMPLATE statements to metadata file")
-	templates := GetTextSearchTemplates(connection)
-	objectCounts["Text Search Templates"] = len(templates)
-	templateMetadata := GetCommentsForObjectType(connection, TYPE_TSTEMPLATE)
-	PrintCreateTextSearchTemplateStatements(metadataFile, globalTOC, templates, templateMetadata)
-}
-
-func BackupTSDictionaries(metadataFile *utils.FileWithByteCount) {
-	gplog.Verbose("Writing CREATE TEXT SEARCH DICTIONARY statements to metadata file")
-	dictionaries := GetTextSearchDictionaries(connection)
-	objectCounts["Text Search Dictionaries"] = len(dictionaries)
-	dictionaryMetadata := GetMetadataForObjectType(connection, TYPE_TSDICTIONARY)
-	PrintCreateTextSearchDictionaryStatements(metadataFile, globalTOC, dictionaries, dictionaryMetadata)
-}
-
-func BackupTSConfigurations(metadataFile *utils.FileWithByteCount) {
-	gplog.Verbose("Writing CREATE TEXT SEARCH CONFIGURATION statements to metadata file")
-	configurations := GetTextSearchConfigurations(connection)
-	objectCounts["Text Search Configurations"] = len(configurations)
-	configurationMetadata := GetMetadataForObjectType(connection, TYPE_TSCONFIGURATION)
-	PrintCreateTextSearchConfigurationStatements(metadataFile, globalTOC, configurations, configurationMetadata)
-}
-
-func BackupConversions(metadataFile *utils.FileWithByteCount) {
-	gplog.Verbose("Writing CREATE CONVERSION statements to metadata file")
-	conversions := GetConversions(connection)
-	objectCounts["Conversions"] = len(conversions)
-	convMetadata := GetMetadataForObjectType(connection, TYPE_CONVERSION)
-	PrintCreateConversionStatements(metadataFile, globalTOC, conversions, convMetadata)
-}
-
-func BackupOperators(metadataFile *utils.FileWithByteCount) {
-	gplog.Verbose("Writing CREATE OPERATOR statements to metadata file")
-	operators := GetOperators(connection)
-	objectCounts["Operators"] = len(operators)
-	operatorMetadata := GetMetadataForObjectType(connection, TYPE_OPERATOR)
-	PrintCreateOperatorStatements(metadataFile, globalTOC, operators, operatorMetadata)
-}
-
-func BackupOperatorFamilies(metadataFile *utils.FileWithByteCount) {
-	gplog.Verbose("Writing CREATE OPERATOR FAMILY statements to metadata file")
-	operatorFamilies := GetOperatorFamilies(connection)
-	objectCounts["Operator Families"] = len(operatorFamilies)
-	operatorFamilyMetadata := GetMetadataForObjectType(connection, TYPE_OPERATORFAMILY)
-	PrintCreateOperatorFamilyStatements(metadataFile, globalTOC, operatorFamilies, operatorFamilyMetadata)
-}
-
-func BackupOperatorClasses(metadataFile *utils.FileWithByteCount) {
-	gplog.Verbose("Writing CREATE OPERATOR CLASS statements to metadata file")
-	operatorClasses := GetOperatorClasses(connection)
-	objectCounts["Operator Classes"] = len(operatorClasses)
-	operatorClassMetadata := GetMetadataForObjectType(connection, TYPE_OPERATORCLASS)
-	PrintCreateOperatorClassStatements(metadataFile, globalTOC, operatorClasses, operatorClassMetadata)
-}
-
-func BackupAggregates(metadataFile *utils.FileWithByteCount, funcInfoMap map[uint32]FunctionInfo) {
-	gplog.Verbose("Writing CREATE AGGREGATE statements to metadata file")
-	aggregates := GetAggregates(connection)
-	objectCounts["Aggregates"] = len(aggregates)
-	aggMetadata := GetMetadataForObjectType(connection, TYPE_AGGREGATE)
-	PrintCreateAggregateStatements(metadataFile, globalTOC, aggregates, funcInfoMap, aggMetadata)
-}
-
-func BackupCasts(metadataFile *utils.FileWithByteCount) {
-	gplog.Verbose("Writing CREATE CAST statements to metadata file")
-	casts := GetCasts(connection)
-	objectCounts["Casts"] = len(casts)
-	castMetadata := GetCommentsForObjectType(connection, TYPE_CAST)
-	PrintCreateCastStatements(metadataFile, globalTOC, casts, castMetadata)
+func BackupCollations(metadataFile *utils.FileWithByteCount) {
+	gplog.Verbose("Writing CREATE COLLATION statements to metadata file")
+	collations := GetCollations(connection)
+	objectCounts["Collations"] = len(collations)
+	collationMetadata := GetMetadataForObjectType(connection, TYPE_COLLATION)
+	PrintCreateCollationStatements(metadataFile, globalTOC, collations, collationMetadata)
 }
 
 func BackupExtensions(metadataFile *utils.FileWithByteCount) {
@@ -425,15 +461,6 @@ func BackupExtensions(metadataFile *utils.FileWithByteCount) {
 	PrintCreateExtensionStatements(metadataFile, globalTOC, extensions, extensionMetadata)
 }
 
-func BackupViews(metadataFile *utils.FileWithByteCount, relationMetadata MetadataMap) {
-	gplog.Verbose("Writing CREATE VIEW statements to metadata file")
-	views := GetViews(connection)
-	objectCounts["Views"] = len(views)
-	views = ConstructViewDependencies(connection, views)
-	views = SortViews(views)
-	PrintCreateViewStatements(metadataFile, globalTOC, views, relationMetadata)
-}
-
 func BackupConstraints(metadataFile *utils.FileWithByteCount, constraints []Constraint, conMetadata MetadataMap) {
 	gplog.Verbose("Writing ADD CONSTRAINT statements to metadata file")
 	PrintConstraintStatements(metadataFile, globalTOC, constraints, conMetadata)
@@ -467,6 +494,35 @@ func BackupTriggers(metadataFile *utils.FileWithByteCount) {
 	PrintCreateTriggerStatements(metadataFile, globalTOC, triggers, triggerMetadata)
 }
 
+func BackupEventTriggers(metadataFile *utils.FileWithByteCount) {
+	gplog.Verbose("Writing CREATE EVENT TRIGGER statements to metadata file")
+	eventTriggers := GetEventTriggers(connection)
+	objectCounts["Event Triggers"] = len(eventTriggers)
+	eventTriggerMetadata := GetMetadataForObjectType(connection, TYPE_EVENTTRIGGER)
+	PrintCreateEventTriggerStatements(metadataFile, globalTOC, eventTriggers, eventTriggerMetadata)
+}
+
+func BackupSecurityLabels(metadataFile *utils.FileWithByteCount) {
+	gplog.Verbose("Writing SECURITY LABEL statements to metadata file")
+	labels := GetSecurityLabels(connection)
+	objectCounts["Security Labels"] = len(labels)
+	PrintSecurityLabelStatements(metadataFile, globalTOC, labels)
+}
+
+func BackupSharedSecurityLabels(metadataFile *utils.FileWithByteCount) {
+	gplog.Verbose("Writing SECURITY LABEL statements for shared objects to metadata file")
+	labels := GetSharedSecurityLabels(connection)
+	objectCounts["Shared Security Labels"] = len(labels)
+	PrintSharedSecurityLabelStatements(metadataFile, globalTOC, labels)
+}
+
+func BackupDefaultPrivileges(metadataFile *utils.FileWithByteCount) {
+	gplog.Verbose("Writing ALTER DEFAULT PRIVILEGES statements to metadata file")
+	defaultPrivileges := GetDefaultPrivileges(connection)
+	objectCounts["Default Privileges"] = len(defaultPrivileges)
+	PrintDefaultPrivilegesStatements(metadataFile, globalTOC, defaultPrivileges)
+}
+
 /*
  * Data wrapper functions
  */
@@ -0,0 +1,272 @@
+package diff
+
+/*
+ * This file contains structs and functions related to comparing the metadata
+ * of two backups, or of a backup and a live database, and reporting the
+ * differences.
+ */
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/greenplum-db/gpbackup/utils"
+)
+
+var metadataSections = []string{"global", "predata", "postdata"}
+
+/*
+ * A MetadataObject holds all of the statements printed for a single object.
+ * Some objects have more than one TOC entry with the same identifying fields
+ * (e.g. resource groups, database GUCs), so their statements are concatenated.
+ */
+type MetadataObject struct {
+	Section         string
+	ObjectType      string
+	Schema          string
+	Name            string
+	ReferenceObject string
+	Statement       string
+}
+
+func (obj MetadataObject) Key() string {
+	return strings.Join([]string{obj.Section, obj.ObjectType, obj.Schema, obj.Name, obj.ReferenceObject}, "\x00")
+}
+
+func (obj MetadataObject) DisplayName() string {
+	name := obj.Name
+	if obj.Schema != "" && obj.ObjectType != "SCHEMA" {
+		name = utils.MakeFQN(obj.Schema, obj.Name)
+	}
+	if obj.ReferenceObject != "" {
+		name = fmt.Sprintf("%s ON %s", name, obj.ReferenceObject)
+	}
+	return name
+}
+
+type ChangedObject struct {
+	Name string
+	Diff string
+}
+
+type ObjectTypeDiff struct {
+	ObjectType string
+	Added      []string
+	Removed    []string
+	Changed    []ChangedObject
+}
+
+func GetMetadataObjects(toc *utils.TOC, metadataFile io.ReaderAt, schemaSet *utils.FilterSet) []MetadataObject {
+	objects := make([]MetadataObject, 0)
+	objectIndexes := make(map[string]int, 0)
+	for _, section := range metadataSections {
+		for _, statement := range toc.GetAllSQLStatements(section, metadataFile) {
+			if schemaSet != nil && !schemaSet.MatchesFilter(statement.Schema) {
+				continue
+			}
+			object := MetadataObject{Section: section, ObjectType: statement.ObjectType, Schema: statement.Schema, Name: statement.Name, ReferenceObject: statement.ReferenceObject, Statement: strings.TrimSpace(statement.Statement)}
+			if index, ok := objectIndexes[object.Key()]; ok {
+				objects[index].Statement += "\n" + object.Statement
+				continue
+			}
+			objectIndexes[object.Key()] = len(objects)
+			objects = append(objects, object)
+		}
+	}
+	return objects
+}
+
+/*
+ * Objects are matched on their section, type, schema, name, and reference
+ * object, and a changed object's diff shows how its statements would need to
+ * change to go from the source metadata to the target metadata.
+ */
+func CompareMetadata(sourceObjects []MetadataObject, targetObjects []MetadataObject, sourceLabel string, targetLabel string) []ObjectTypeDiff {
+	diffMap := make(map[string]*ObjectTypeDiff, 0)
+	getDiff := func(objectType string) *ObjectTypeDiff {
+		if _, ok := diffMap[objectType]; !ok {
+			diffMap[objectType] = &ObjectTypeDiff{ObjectType: objectType, Added: []string{}, Removed: []string{}, Changed: []ChangedObject{}}
+		}
+		return diffMap[objectType]
+	}
+
+	targetMap := make(map[string]MetadataObject, len(targetObjects))
+	for _, object := range targetObjects {
+		targetMap[object.Key()] = object
+	}
+	sourceMap := make(map[string]MetadataObject, len(sourceObjects))
+	for _, source := range sourceObjects {
+		sourceMap[source.Key()] = source
+		target, ok := targetMap[source.Key()]
+		if !ok {
+			objectDiff := getDiff(source.ObjectType)
+			objectDiff.Removed = append(objectDiff.Removed, source.DisplayName())
+		} else if source.Statement != target.Statement {
+			objectDiff := getDiff(source.ObjectType)
+			fromLabel := fmt.Sprintf("%s: %s %s", sourceLabel, source.ObjectType, source.DisplayName())
+			toLabel := fmt.Sprintf("%s: %s %s", targetLabel, target.ObjectType, target.DisplayName())
+			objectDiff.Changed = append(objectDiff.Changed, ChangedObject{Name: source.DisplayName(), Diff: UnifiedDiff(source.Statement, target.Statement, fromLabel, toLabel)})
+		}
+	}
+	for _, target := range targetObjects {
+		if _, ok := sourceMap[target.Key()]; !ok {
+			objectDiff := getDiff(target.ObjectType)
+			objectDiff.Added = append(objectDiff.Added, target.DisplayName())
+		}
+	}
+
+	diffs := make([]ObjectTypeDiff, 0)
+	for _, objectDiff := range diffMap {
+		diffs = append(diffs, *objectDiff)
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].ObjectType < diffs[j].ObjectType
+	})
+	return diffs
+}
+
+func PrintDiffReport(writer io.Writer, diffs []ObjectTypeDiff, sourceLabel string, targetLabel string) {
+	utils.MustPrintf(writer, "Comparing %s with %s\n", sourceLabel, targetLabel)
+	if len(diffs) == 0 {
+		utils.MustPrintf(writer, "\nNo differences found\n")
+		return
+	}
+	for _, objectDiff := range diffs {
+		utils.MustPrintf(writer, "\n%s: %d added, %d removed, %d changed\n", objectDiff.ObjectType, len(objectDiff.Added), len(objectDiff.Removed), len(objectDiff.Changed))
+		for _, name := range objectDiff.Added {
+			utils.MustPrintf(writer, "  Added:   %s\n", name)
+		}
+		for _, name := range objectDiff.Removed {
+			utils.MustPrintf(writer, "  Removed: %s\n", name)
+		}
+		for _, changed := range objectDiff.Changed {
+			utils.MustPrintf(writer, "  Changed: %s\n", changed.Name)
+		}
+	}
+	for _, objectDiff := range diffs {
+		for _, changed := range objectDiff.Changed {
+			utils.MustPrintf(writer, "\n%s", changed.Diff)
+		}
+	}
+}
+
+/*
+ * Unified diff functions
+ */
+
+const diffContextLines = 3
+
+type diffLine struct {
+	op   byte
+	text string
+}
+
+/*
+ * This function computes a line-based diff using the longest common subsequence
+ * of the two inputs.  Metadata statements are short enough that the quadratic
+ * cost of doing so is not a concern.
+ */
+func diffLines(from []string, to []string) []diffLine {
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	lines := make([]diffLine, 0)
+	i, j := 0, 0
+	for i < len(from) && j < len(to) {
+		if from[i] == to[j] {
+			lines = append(lines, diffLine{' ', from[i]})
+			i++
+			j++
+		} else if lcs[i+1][j] >= lcs[i][j+1] {
+			lines = append(lines, diffLine{'-', from[i]})
+			i++
+		} else {
+			lines = append(lines, diffLine{'+', to[j]})
+			j++
+		}
+	}
+	for ; i < len(from); i++ {
+		lines = append(lines, diffLine{'-', from[i]})
+	}
+	for ; j < len(to); j++ {
+		lines = append(lines, diffLine{'+', to[j]})
+	}
+	return lines
+}
+
+func UnifiedDiff(from string, to string, fromLabel string, toLabel string) string {
+	lines := diffLines(strings.Split(from, "\n"), strings.Split(to, "\n"))
+	changes := make([]int, 0)
+	for i, line := range lines {
+		if line.op != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", fromLabel, toLabel))
+	for first := 0; first < len(changes); {
+		last := first
+		for last+1 < len(changes) && changes[last+1]-changes[last]-1 <= 2*diffContextLines {
+			last++
+		}
+		start := changes[first] - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+		end := changes[last] + diffContextLines + 1
+		if end > len(lines) {
+			end = len(lines)
+		}
+		fromStart, toStart := 0, 0
+		for _, line := range lines[:start] {
+			if line.op != '+' {
+				fromStart++
+			}
+			if line.op != '-' {
+				toStart++
+			}
+		}
+		fromCount, toCount := 0, 0
+		for _, line := range lines[start:end] {
+			if line.op != '+' {
+				fromCount++
+			}
+			if line.op != '-' {
+				toCount++
+			}
+		}
+		buffer.WriteString(fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(fromStart, fromCount), hunkRange(toStart, toCount)))
+		for _, line := range lines[start:end] {
+			buffer.WriteString(fmt.Sprintf("%c%s\n", line.op, line.text))
+		}
+		first = last + 1
+	}
+	return buffer.String()
+}
+
+// An empty range refers to the line before the change, as in GNU diff.
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
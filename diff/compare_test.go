@@ -0,0 +1,215 @@
+package diff_test
+
+import (
+	"bytes"
+
+	"github.com/greenplum-db/gpbackup/diff"
+	"github.com/greenplum-db/gpbackup/testutils"
+	"github.com/greenplum-db/gpbackup/utils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("diff/compare tests", func() {
+	Describe("GetMetadataObjects", func() {
+		var (
+			toc        *utils.TOC
+			backupfile *utils.FileWithByteCount
+			buffer     *bytes.Buffer
+		)
+		addEntry := func(section string, schema string, name string, objectType string, referenceObject string, statement string) {
+			start := backupfile.ByteCount
+			backupfile.MustPrintf("\n\n%s\n", statement)
+			toc.AddMetadataEntry(schema, name, objectType, referenceObject, start, backupfile, section)
+		}
+		BeforeEach(func() {
+			buffer = &bytes.Buffer{}
+			toc, backupfile = testutils.InitializeTestTOC(buffer, "metadata")
+			addEntry("global", "", "role1", "ROLE", "", "CREATE ROLE role1;")
+			addEntry("predata", "public", "public", "SCHEMA", "", "CREATE SCHEMA public;")
+			addEntry("predata", "public", "table1", "TABLE", "", "CREATE TABLE public.table1 (i int);")
+			addEntry("predata", "schema2", "table2", "TABLE", "", "CREATE TABLE schema2.table2 (i int);")
+			addEntry("postdata", "public", "index1", "INDEX", "public.table1", "CREATE INDEX index1 ON public.table1 (i);")
+			addEntry("statistics", "public", "table1", "STATISTICS", "", "UPDATE pg_class SET reltuples = 0;")
+		})
+		It("returns the trimmed statement for each object in the global, predata, and postdata sections", func() {
+			objects := diff.GetMetadataObjects(toc, bytes.NewReader(buffer.Bytes()), nil)
+
+			Expect(objects).To(Equal([]diff.MetadataObject{
+				{Section: "global", ObjectType: "ROLE", Name: "role1", Statement: "CREATE ROLE role1;"},
+				{Section: "predata", ObjectType: "SCHEMA", Schema: "public", Name: "public", Statement: "CREATE SCHEMA public;"},
+				{Section: "predata", ObjectType: "TABLE", Schema: "public", Name: "table1", Statement: "CREATE TABLE public.table1 (i int);"},
+				{Section: "predata", ObjectType: "TABLE", Schema: "schema2", Name: "table2", Statement: "CREATE TABLE schema2.table2 (i int);"},
+				{Section: "postdata", ObjectType: "INDEX", Schema: "public", Name: "index1", ReferenceObject: "public.table1", Statement: "CREATE INDEX index1 ON public.table1 (i);"},
+			}))
+		})
+		It("concatenates the statements of entries for the same object", func() {
+			addEntry("predata", "public", "table1", "TABLE", "", "ALTER TABLE public.table1 OWNER TO role1;")
+
+			objects := diff.GetMetadataObjects(toc, bytes.NewReader(buffer.Bytes()), nil)
+
+			Expect(objects).To(HaveLen(5))
+			Expect(objects[2].Statement).To(Equal("CREATE TABLE public.table1 (i int);\nALTER TABLE public.table1 OWNER TO role1;"))
+		})
+		It("returns only objects in included schemas", func() {
+			objects := diff.GetMetadataObjects(toc, bytes.NewReader(buffer.Bytes()), utils.NewIncludeSet([]string{"schema2"}))
+
+			Expect(objects).To(Equal([]diff.MetadataObject{
+				{Section: "predata", ObjectType: "TABLE", Schema: "schema2", Name: "table2", Statement: "CREATE TABLE schema2.table2 (i int);"},
+			}))
+		})
+		It("does not return objects in excluded schemas", func() {
+			objects := diff.GetMetadataObjects(toc, bytes.NewReader(buffer.Bytes()), utils.NewExcludeSet([]string{"public"}))
+
+			Expect(objects).To(HaveLen(2))
+			Expect(objects[0].Name).To(Equal("role1"))
+			Expect(objects[1].Name).To(Equal("table2"))
+		})
+	})
+	Describe("CompareMetadata", func() {
+		table1 := diff.MetadataObject{Section: "predata", ObjectType: "TABLE", Schema: "public", Name: "table1", Statement: "CREATE TABLE public.table1 (\n\ti int\n);"}
+		table2 := diff.MetadataObject{Section: "predata", ObjectType: "TABLE", Schema: "public", Name: "table2", Statement: "CREATE TABLE public.table2 (\n\ti int\n);"}
+		index1 := diff.MetadataObject{Section: "postdata", ObjectType: "INDEX", Schema: "public", Name: "index1", ReferenceObject: "public.table1", Statement: "CREATE INDEX index1 ON public.table1 (i);"}
+		role1 := diff.MetadataObject{Section: "global", ObjectType: "ROLE", Name: "role1", Statement: "CREATE ROLE role1;"}
+
+		It("returns no differences for identical metadata", func() {
+			diffs := diff.CompareMetadata([]diff.MetadataObject{table1, index1}, []diff.MetadataObject{table1, index1}, "source", "target")
+
+			Expect(diffs).To(BeEmpty())
+		})
+		It("reports added, removed, and changed objects grouped by object type", func() {
+			changedTable1 := table1
+			changedTable1.Statement = "CREATE TABLE public.table1 (\n\ti int,\n\tj text\n);"
+
+			diffs := diff.CompareMetadata([]diff.MetadataObject{role1, table1, index1}, []diff.MetadataObject{changedTable1, table2, index1}, "source", "target")
+
+			Expect(diffs).To(Equal([]diff.ObjectTypeDiff{
+				{ObjectType: "ROLE", Added: []string{}, Removed: []string{"role1"}, Changed: []diff.ChangedObject{}},
+				{ObjectType: "TABLE", Added: []string{"public.table2"}, Removed: []string{}, Changed: []diff.ChangedObject{{
+					Name: "public.table1",
+					Diff: `--- source: TABLE public.table1
++++ target: TABLE public.table1
+@@ -1,3 +1,4 @@
+ CREATE TABLE public.table1 (
+-	i int
++	i int,
++	j text
+ );
+`,
+				}}},
+			}))
+		})
+		It("treats objects with the same name but a different reference object as distinct", func() {
+			otherIndex1 := index1
+			otherIndex1.ReferenceObject = "public.table2"
+
+			diffs := diff.CompareMetadata([]diff.MetadataObject{index1}, []diff.MetadataObject{otherIndex1}, "source", "target")
+
+			Expect(diffs).To(Equal([]diff.ObjectTypeDiff{
+				{ObjectType: "INDEX", Added: []string{"public.index1 ON public.table2"}, Removed: []string{"public.index1 ON public.table1"}, Changed: []diff.ChangedObject{}},
+			}))
+		})
+	})
+	Describe("UnifiedDiff", func() {
+		It("returns an empty string if the inputs are identical", func() {
+			Expect(diff.UnifiedDiff("a\nb", "a\nb", "from", "to")).To(Equal(""))
+		})
+		It("limits context to three lines around each change", func() {
+			from := "1\n2\n3\n4\n5\n6\n7\n8\n9"
+			to := "1\n2\n3\n4\nfive\n6\n7\n8\n9"
+
+			Expect(diff.UnifiedDiff(from, to, "from", "to")).To(Equal(`--- from
++++ to
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+`))
+		})
+		It("prints separate hunks for changes that are far apart", func() {
+			from := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10"
+			to := "one\n2\n3\n4\n5\n6\n7\n8\n9\nten"
+
+			Expect(diff.UnifiedDiff(from, to, "from", "to")).To(Equal(`--- from
++++ to
+@@ -1,4 +1,4 @@
+-1
++one
+ 2
+ 3
+ 4
+@@ -7,4 +7,4 @@
+ 7
+ 8
+ 9
+-10
++ten
+`))
+		})
+		It("merges hunks whose context would overlap", func() {
+			from := "1\n2\n3\n4\n5\n6\n7\n8"
+			to := "one\n2\n3\n4\n5\n6\n7\neight"
+
+			Expect(diff.UnifiedDiff(from, to, "from", "to")).To(Equal(`--- from
++++ to
+@@ -1,8 +1,8 @@
+-1
++one
+ 2
+ 3
+ 4
+ 5
+ 6
+ 7
+-8
++eight
+`))
+		})
+		It("counts only context lines on the removed side of a hunk that adds lines", func() {
+			Expect(diff.UnifiedDiff("a\nb", "a\nnew\nb", "from", "to")).To(Equal(`--- from
++++ to
+@@ -1,2 +1,3 @@
+ a
++new
+ b
+`))
+		})
+	})
+	Describe("PrintDiffReport", func() {
+		It("prints a message if there are no differences", func() {
+			buffer := gbytes.NewBuffer()
+
+			diff.PrintDiffReport(buffer, []diff.ObjectTypeDiff{}, "backup 20170101010101", "database testdb")
+
+			Expect(string(buffer.Contents())).To(Equal("Comparing backup 20170101010101 with database testdb\n\nNo differences found\n"))
+		})
+		It("prints a summary for each object type followed by the diffs of changed objects", func() {
+			buffer := gbytes.NewBuffer()
+			diffs := []diff.ObjectTypeDiff{
+				{ObjectType: "INDEX", Added: []string{"public.index2 ON public.table1"}, Removed: []string{}, Changed: []diff.ChangedObject{}},
+				{ObjectType: "TABLE", Added: []string{}, Removed: []string{"public.table2"}, Changed: []diff.ChangedObject{{Name: "public.table1", Diff: "--- a\n+++ b\n"}}},
+			}
+
+			diff.PrintDiffReport(buffer, diffs, "backup 20170101010101", "backup 20170102010101")
+
+			Expect(string(buffer.Contents())).To(Equal(`Comparing backup 20170101010101 with backup 20170102010101
+
+INDEX: 1 added, 0 removed, 0 changed
+  Added:   public.index2 ON public.table1
+
+TABLE: 0 added, 1 removed, 1 changed
+  Removed: public.table2
+  Changed: public.table1
+
+--- a
++++ b
+`))
+		})
+	})
+})
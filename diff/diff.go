@@ -0,0 +1,188 @@
+package diff
+
+/*
+ * This file contains the entry points for gpbackup_diff, which compares the
+ * metadata of a backup against that of another backup or of a live database.
+ */
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/greenplum-db/gp-common-go-libs/cluster"
+	"github.com/greenplum-db/gp-common-go-libs/dbconn"
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"github.com/greenplum-db/gpbackup/backup"
+	"github.com/greenplum-db/gpbackup/utils"
+	"github.com/pkg/errors"
+)
+
+/*
+ * We define and initialize flags separately to avoid import conflicts in tests.
+ * The flag variables, and setter functions for them, are in global_variables.go.
+ */
+func initializeFlags() {
+	backupDir = flag.String("backup-dir", "", "The absolute path of the directory in which the backup files to be compared are located")
+	compareTimestamp = flag.String("compare-timestamp", "", "The timestamp of a second backup to compare against, in the format YYYYMMDDHHMMSS")
+	dbname = flag.String("dbname", "", "A database whose current metadata should be compared against the backup")
+	debug = flag.Bool("debug", false, "Print verbose and debug log messages")
+	flag.Var(&excludeSchemas, "exclude-schema", "Compare all objects except those in the specified schema(s). --exclude-schema can be specified multiple times.")
+	flag.Var(&includeSchemas, "include-schema", "Compare only objects in the specified schema(s). --include-schema can be specified multiple times.")
+	printVersion = flag.Bool("version", false, "Print version number and exit")
+	quiet = flag.Bool("quiet", false, "Suppress non-warning, non-error log messages")
+	timestamp = flag.String("timestamp", "", "The timestamp of the backup to be compared, in the format YYYYMMDDHHMMSS")
+	verbose = flag.Bool("verbose", false, "Print verbose log messages")
+}
+
+// This function handles setup that can be done before parsing flags.
+func DoInit() {
+	CleanupGroup = &sync.WaitGroup{}
+	CleanupGroup.Add(1)
+	gplog.InitializeLogging("gpbackup_diff", "")
+	initializeFlags()
+	utils.InitializeSignalHandler(DoCleanup, "diff process", &wasTerminated)
+}
+
+/*
+* This function handles argument parsing and validation, e.g. checking that a passed filename exists.
+* It should only validate; initialization with any sort of side effects should go in DoInit or DoSetup.
+ */
+func DoValidation() {
+	if len(os.Args) == 1 {
+		flag.PrintDefaults()
+		os.Exit(0)
+	}
+	flag.Parse()
+	if *printVersion {
+		fmt.Printf("gpbackup_diff %s\n", version)
+		os.Exit(0)
+	}
+	ValidateFlagCombinations()
+	utils.ValidateFullPath(*backupDir)
+	for _, ts := range []string{*timestamp, *compareTimestamp} {
+		if ts != "" && !utils.IsValidTimestamp(ts) {
+			gplog.Fatal(errors.Errorf("Timestamp %s is invalid.  Timestamps must be in the format YYYYMMDDHHMMSS.", ts), "")
+		}
+	}
+}
+
+func ValidateFlagCombinations() {
+	utils.CheckMandatoryFlags("timestamp")
+	utils.CheckExclusiveFlags("debug", "quiet", "verbose")
+	utils.CheckExclusiveFlags("compare-timestamp", "dbname")
+	utils.CheckExclusiveFlags("include-schema", "exclude-schema")
+	if *compareTimestamp == "" && *dbname == "" {
+		gplog.Fatal(errors.Errorf("Either --compare-timestamp or --dbname must be specified"), "")
+	}
+}
+
+// This function handles setup that must be done after parsing flags.
+func DoSetup() {
+	SetLoggerVerbosity()
+	connection = dbconn.NewDBConn("postgres")
+	connection.MustConnect(1)
+	utils.SetDatabaseVersion(connection)
+	segConfig := cluster.GetSegmentConfiguration(connection)
+	globalCluster = cluster.NewCluster(segConfig)
+}
+
+func SetLoggerVerbosity() {
+	if *quiet {
+		gplog.SetVerbosity(gplog.LOGERROR)
+	} else if *debug {
+		gplog.SetVerbosity(gplog.LOGDEBUG)
+	} else if *verbose {
+		gplog.SetVerbosity(gplog.LOGVERBOSE)
+	}
+}
+
+func DoDiff() {
+	sourceLabel := fmt.Sprintf("backup %s", *timestamp)
+	sourceObjects := GetBackupMetadataObjects(*timestamp)
+	var targetLabel string
+	var targetObjects []MetadataObject
+	if *compareTimestamp != "" {
+		targetLabel = fmt.Sprintf("backup %s", *compareTimestamp)
+		targetObjects = GetBackupMetadataObjects(*compareTimestamp)
+	} else {
+		targetLabel = fmt.Sprintf("database %s", *dbname)
+		targetObjects = GetDatabaseMetadataObjects(*dbname)
+	}
+	diffs := CompareMetadata(sourceObjects, targetObjects, sourceLabel, targetLabel)
+	PrintDiffReport(os.Stdout, diffs, sourceLabel, targetLabel)
+}
+
+func getSchemaFilterSet() *utils.FilterSet {
+	if len(includeSchemas) > 0 {
+		return utils.NewIncludeSet(includeSchemas)
+	}
+	return utils.NewExcludeSet(excludeSchemas)
+}
+
+func GetBackupMetadataObjects(backupTimestamp string) []MetadataObject {
+	segPrefix := utils.ParseSegPrefix(*backupDir)
+	fpInfo := utils.NewFilePathInfo(globalCluster.SegDirMap, *backupDir, backupTimestamp, segPrefix)
+	config := utils.ReadConfigFile(fpInfo.GetConfigFilePath())
+	if config.DataOnly {
+		gplog.Fatal(errors.Errorf("Backup %s is a data-only backup and contains no metadata to compare", backupTimestamp), "")
+	}
+	metadataFilename := fpInfo.GetMetadataFilePath()
+	if !utils.FileExistsAndIsReadable(metadataFilename) {
+		gplog.Fatal(errors.Errorf("Metadata file %s for backup %s does not exist or is not readable", metadataFilename, backupTimestamp), "")
+	}
+	gplog.Verbose("Reading metadata for backup %s from %s", backupTimestamp, metadataFilename)
+	toc := utils.NewTOC(fpInfo.GetTOCFilePath())
+	toc.InitializeEntryMap()
+	metadataFile := utils.MustOpenFileForReading(metadataFilename)
+	defer metadataFile.Close()
+	return GetMetadataObjects(toc, metadataFile, getSchemaFilterSet())
+}
+
+func GetDatabaseMetadataObjects(databaseName string) []MetadataObject {
+	gplog.Verbose("Retrieving current metadata for database %s", databaseName)
+	buffer := &bytes.Buffer{}
+	toc := backup.BackupMetadataForComparison(databaseName, utils.NewFileWithByteCount(buffer))
+	return GetMetadataObjects(toc, bytes.NewReader(buffer.Bytes()), getSchemaFilterSet())
+}
+
+func DoTeardown() {
+	errStr := ""
+	if err := recover(); err != nil {
+		errStr = fmt.Sprintf("%v", err)
+	}
+	if wasTerminated {
+		/*
+		 * Don't print an error if the diff was canceled, as the signal handler
+		 * will take care of cleanup and return codes.  Just wait until the signal
+		 * handler's DoCleanup completes so the main goroutine doesn't exit while
+		 * cleanup is still in progress.
+		 */
+		CleanupGroup.Wait()
+		return
+	}
+	if errStr != "" {
+		fmt.Println(errStr)
+	}
+	errorCode := gplog.GetErrorCode()
+
+	DoCleanup()
+
+	os.Exit(errorCode)
+}
+
+func DoCleanup() {
+	defer func() {
+		if err := recover(); err != nil {
+			gplog.Warn("Encountered error during cleanup: %v", err)
+		}
+		gplog.Verbose("Cleanup complete")
+		CleanupGroup.Done()
+	}()
+	gplog.Verbose("Beginning cleanup")
+	if connection != nil {
+		connection.Close()
+	}
+}
@@ -0,0 +1,65 @@
+package diff
+
+import (
+	"sync"
+
+	"github.com/greenplum-db/gp-common-go-libs/cluster"
+	"github.com/greenplum-db/gp-common-go-libs/dbconn"
+	"github.com/greenplum-db/gpbackup/utils"
+)
+
+/*
+ * This file contains global variables and setter functions for those variables
+ * used in testing.
+ */
+
+/*
+ * Non-flag variables
+ */
+
+var (
+	connection    *dbconn.DBConn
+	globalCluster cluster.Cluster
+	version       string
+	wasTerminated bool
+
+	/*
+	 * Used for synchronizing DoCleanup.  In DoInit() we increment the group
+	 * and then wait for at least one DoCleanup to finish, either in DoTeardown
+	 * or the signal handler.
+	 */
+	CleanupGroup *sync.WaitGroup
+)
+
+/*
+ * Command-line flags
+ */
+
+var (
+	backupDir        *string
+	compareTimestamp *string
+	dbname           *string
+	debug            *bool
+	excludeSchemas   utils.ArrayFlags
+	includeSchemas   utils.ArrayFlags
+	printVersion     *bool
+	quiet            *bool
+	timestamp        *string
+	verbose          *bool
+)
+
+/*
+ * Setter functions
+ */
+
+func SetCluster(cluster cluster.Cluster) {
+	globalCluster = cluster
+}
+
+func SetExcludeSchemas(schemas []string) {
+	excludeSchemas = schemas
+}
+
+func SetIncludeSchemas(schemas []string) {
+	includeSchemas = schemas
+}
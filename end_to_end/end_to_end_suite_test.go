@@ -1,6 +1,7 @@
 package end_to_end_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"math/rand"
@@ -225,6 +226,102 @@ var _ = Describe("backup end to end integration tests", func() {
 
 			os.Remove("/tmp/include-tables.txt")
 		})
+		It("runs gpbackup and gprestore with include-object-type restore flag", func() {
+			timestamp := gpbackup(gpbackupPath)
+			gprestore(gprestorePath, timestamp, "-redirect-db", "restoredb", "-include-object-type", "SCHEMA")
+
+			assertTablesCreated(restoreConn, 0)
+			schemaCount := dbconn.MustSelectString(restoreConn, "SELECT count(*) AS string FROM pg_namespace WHERE nspname = 'schema2'")
+			Expect(schemaCount).To(Equal("1"))
+		})
+		It("runs gpbackup and gprestore with exclude-object-type restore flag", func() {
+			timestamp := gpbackup(gpbackupPath)
+			gprestore(gprestorePath, timestamp, "-redirect-db", "restoredb", "-exclude-object-type", "index")
+
+			assertTablesCreated(restoreConn, 30)
+			assertDataRestored(restoreConn, publicSchemaTupleCounts)
+			assertDataRestored(restoreConn, schema2TupleCounts)
+		})
+		It("runs gpbackup and gprestore with no-owner and no-privileges restore flags", func() {
+			timestamp := gpbackup(gpbackupPath)
+			gprestore(gprestorePath, timestamp, "-redirect-db", "restoredb", "-no-owner", "-no-privileges")
+
+			assertTablesCreated(restoreConn, 30)
+			assertDataRestored(restoreConn, publicSchemaTupleCounts)
+			assertDataRestored(restoreConn, schema2TupleCounts)
+		})
+		It("runs gprestore with clean and if-exists flags into a database that already contains the backed-up objects", func() {
+			timestamp := gpbackup(gpbackupPath)
+			gprestore(gprestorePath, timestamp, "-redirect-db", "restoredb")
+			gprestore(gprestorePath, timestamp, "-redirect-db", "restoredb", "-clean", "-if-exists")
+
+			assertTablesCreated(restoreConn, 30)
+			assertDataRestored(restoreConn, publicSchemaTupleCounts)
+			assertDataRestored(restoreConn, schema2TupleCounts)
+		})
+		It("runs gprestore with data-only and disable-triggers flags into a database that already contains the backed-up tables", func() {
+			timestamp := gpbackup(gpbackupPath, "-metadata-only")
+			timestamp2 := gpbackup(gpbackupPath)
+			gprestore(gprestorePath, timestamp, "-redirect-db", "restoredb")
+			gprestore(gprestorePath, timestamp2, "-redirect-db", "restoredb", "-data-only", "-disable-triggers", "-jobs", "4")
+
+			assertTablesCreated(restoreConn, 30)
+			assertDataRestored(restoreConn, publicSchemaTupleCounts)
+			assertDataRestored(restoreConn, schema2TupleCounts)
+			foreignKeyQuery := "SELECT count(*) AS string FROM pg_constraint c JOIN pg_namespace n ON c.connamespace = n.oid WHERE c.contype = 'f' AND n.nspname IN ('public', 'schema2')"
+			Expect(dbconn.MustSelectString(restoreConn, foreignKeyQuery)).To(Equal(dbconn.MustSelectString(backupConn, foreignKeyQuery)))
+		})
+		It("runs gprestore with data-only and sync-sequences flags", func() {
+			testhelper.AssertQueryRuns(backupConn, "CREATE TABLE public.sequence_table (id serial, i int)")
+			defer testhelper.AssertQueryRuns(backupConn, "DROP TABLE public.sequence_table")
+			timestamp := gpbackup(gpbackupPath, "-metadata-only")
+			testhelper.AssertQueryRuns(backupConn, "INSERT INTO public.sequence_table (i) SELECT generate_series(1, 10)")
+			timestamp2 := gpbackup(gpbackupPath, "-data-only")
+			gprestore(gprestorePath, timestamp, "-redirect-db", "restoredb")
+			gprestore(gprestorePath, timestamp2, "-redirect-db", "restoredb", "-data-only", "-sync-sequences", "max", "-include-table", "public.sequence_table")
+
+			assertDataRestored(restoreConn, map[string]int{"public.sequence_table": 10})
+			nextValue := dbconn.MustSelectString(restoreConn, "SELECT nextval('public.sequence_table_id_seq') AS string")
+			Expect(nextValue).To(Equal("11"))
+		})
+		It("runs gprestore with defer-constraints and jobs flags", func() {
+			timestamp := gpbackup(gpbackupPath)
+			gprestore(gprestorePath, timestamp, "-redirect-db", "restoredb", "-defer-constraints", "-jobs", "4")
+
+			assertTablesCreated(restoreConn, 30)
+			assertDataRestored(restoreConn, publicSchemaTupleCounts)
+			assertDataRestored(restoreConn, schema2TupleCounts)
+			constraintQuery := "SELECT count(*) AS string FROM pg_constraint c JOIN pg_namespace n ON c.connamespace = n.oid WHERE n.nspname IN ('public', 'schema2')"
+			Expect(dbconn.MustSelectString(restoreConn, constraintQuery)).To(Equal(dbconn.MustSelectString(backupConn, constraintQuery)))
+		})
+		It("runs gprestore with priority-table-file and jobs flags", func() {
+			priorityFile := utils.MustOpenFileForWriting("/tmp/priority-tables.txt")
+			utils.MustPrintln(priorityFile, "schema2.foo3\npublic.sales")
+			timestamp := gpbackup(gpbackupPath)
+			gprestore(gprestorePath, timestamp, "-redirect-db", "restoredb", "-priority-table-file", "/tmp/priority-tables.txt", "-jobs", "4")
+
+			assertTablesCreated(restoreConn, 30)
+			assertDataRestored(restoreConn, publicSchemaTupleCounts)
+			assertDataRestored(restoreConn, schema2TupleCounts)
+
+			os.Remove("/tmp/priority-tables.txt")
+		})
+		It("runs gprestore with output-file flag and restores from the resulting script", func() {
+			timestamp := gpbackup(gpbackupPath)
+			gprestore(gprestorePath, timestamp, "-redirect-db", "restoredb", "-output-file", "/tmp/restore_script.sql")
+
+			assertTablesCreated(restoreConn, 0)
+			output, err := exec.Command("psql", "-d", "restoredb", "-v", "ON_ERROR_STOP=1", "-f", "/tmp/restore_script.sql").CombinedOutput()
+			if err != nil {
+				fmt.Printf("%s", output)
+				Fail(fmt.Sprintf("%v", err))
+			}
+			assertTablesCreated(restoreConn, 30)
+			assertDataRestored(restoreConn, publicSchemaTupleCounts)
+			assertDataRestored(restoreConn, schema2TupleCounts)
+
+			os.Remove("/tmp/restore_script.sql")
+		})
 		It("runs gpbackup and gprestore with exclude-table-file flag", func() {
 			excludeFile := utils.MustOpenFileForWriting("/tmp/exclude-tables.txt")
 			utils.MustPrintln(excludeFile, "schema2.foo2\nschema2.returns\npublic.sales")
@@ -272,6 +369,26 @@ var _ = Describe("backup end to end integration tests", func() {
 
 			os.RemoveAll(backupdir)
 		})
+		It("runs gpbackup with object-definitions flag", func() {
+			backupdir := "/tmp/object_definitions"
+			timestamp := gpbackup(gpbackupPath, "-metadata-only", "-object-definitions", "json", "-backup-dir", backupdir)
+			definitionsFile, _ := filepath.Glob(filepath.Join(backupdir, "*-1/backups/*", timestamp, "*definitions.json"))
+			Expect(definitionsFile).To(HaveLen(1))
+			contents, _ := ioutil.ReadFile(definitionsFile[0])
+			definitions := utils.ObjectDefinitions{}
+			Expect(json.Unmarshal(contents, &definitions)).To(Succeed())
+
+			tableNames := make([]string, 0)
+			for _, definition := range definitions.PredataDefinitions {
+				if definition.ObjectType == "TABLE" {
+					tableNames = append(tableNames, utils.MakeFQN(definition.Schema, definition.Name))
+				}
+			}
+			Expect(tableNames).To(ContainElement("public.foo"))
+			Expect(tableNames).To(ContainElement("schema2.returns"))
+
+			os.RemoveAll(backupdir)
+		})
 		It("runs gpbackup and gprestore with with-stats flag", func() {
 			backupdir := "/tmp/with_stats"
 			timestamp := gpbackup(gpbackupPath, "-with-stats", "-backup-dir", backupdir)
@@ -342,6 +459,22 @@ var _ = Describe("backup end to end integration tests", func() {
 			os.RemoveAll(pluginDir)
 		})
 
+		It("runs gpbackup and gprestore with plugin, single-data-file, and streamed metadata", func() {
+			pluginDir := "/tmp/plugin_dest"
+			pluginExecutablePath := fmt.Sprintf("%s/go/src/github.com/greenplum-db/gpbackup/plugins/example_plugin.sh", os.Getenv("HOME"))
+			copyPluginToAllHosts(backupConn, pluginExecutablePath)
+			pluginConfigPath := fmt.Sprintf("%s/go/src/github.com/greenplum-db/gpbackup/plugins/example_plugin_config.yaml", os.Getenv("HOME"))
+
+			timestamp := gpbackup(gpbackupPath, "-single-data-file", "-stream-metadata", "-plugin-config", pluginConfigPath)
+			gprestore(gprestorePath, timestamp, "-redirect-db", "restoredb", "-plugin-config", pluginConfigPath)
+
+			assertTablesCreated(restoreConn, 30)
+			assertDataRestored(restoreConn, publicSchemaTupleCounts)
+			assertDataRestored(restoreConn, schema2TupleCounts)
+
+			os.RemoveAll(pluginDir)
+		})
+
 		It("runs gpbackup and gprestore with include-table-file restore flag", func() {
 			includeFile := utils.MustOpenFileForWriting("/tmp/include-tables.txt")
 			utils.MustPrintln(includeFile, "public.sales\npublic.foo")
@@ -390,6 +523,31 @@ var _ = Describe("backup end to end integration tests", func() {
 
 			os.RemoveAll(backupdir)
 		})
+		It("runs gpbackup and gprestore with jobs flag with a single data file", func() {
+			backupdir := "/tmp/parallel"
+			timestamp := gpbackup(gpbackupPath, "-backup-dir", backupdir, "-single-data-file", "-no-compression")
+			gprestore(gprestorePath, timestamp, "-redirect-db", "restoredb", "-backup-dir", backupdir, "-jobs", "4")
+
+			assertTablesCreated(restoreConn, 30)
+			assertDataRestored(restoreConn, schema2TupleCounts)
+			assertDataRestored(restoreConn, publicSchemaTupleCounts)
+
+			os.RemoveAll(backupdir)
+		})
+		It("runs gpbackup and gprestore with plugin, jobs flag, and include-schema restore flag with a single data file", func() {
+			pluginDir := "/tmp/plugin_dest"
+			pluginExecutablePath := fmt.Sprintf("%s/go/src/github.com/greenplum-db/gpbackup/plugins/example_plugin.sh", os.Getenv("HOME"))
+			copyPluginToAllHosts(backupConn, pluginExecutablePath)
+			pluginConfigPath := fmt.Sprintf("%s/go/src/github.com/greenplum-db/gpbackup/plugins/example_plugin_config.yaml", os.Getenv("HOME"))
+
+			timestamp := gpbackup(gpbackupPath, "-single-data-file", "-no-compression", "-plugin-config", pluginConfigPath)
+			gprestore(gprestorePath, timestamp, "-redirect-db", "restoredb", "-plugin-config", pluginConfigPath, "-include-schema", "schema2", "-jobs", "4")
+
+			assertTablesCreated(restoreConn, 15)
+			assertDataRestored(restoreConn, schema2TupleCounts)
+
+			os.RemoveAll(pluginDir)
+		})
 		It("runs gpbackup and gprestore with include-schema restore flag with a single data file", func() {
 			backupdir := "/tmp/include_schema"
 			timestamp := gpbackup(gpbackupPath, "-backup-dir", backupdir, "-single-data-file")
@@ -414,6 +572,21 @@ var _ = Describe("backup end to end integration tests", func() {
 			timestamp := gpbackup(gpbackupPath, "-leaf-partition-data")
 			gprestore(gprestorePath, timestamp, "-redirect-db", "restoredb")
 
+		})
+		It("runs gpbackup and gprestore with jobs flag on database with all objects", func() {
+			testhelper.AssertQueryRuns(backupConn, "DROP SCHEMA IF EXISTS schema2 CASCADE; DROP SCHEMA public CASCADE; CREATE SCHEMA public; DROP PROCEDURAL LANGUAGE IF EXISTS plpythonu;")
+			defer testutils.ExecuteSQLFile(backupConn, "test_tables.sql")
+			defer testhelper.AssertQueryRuns(backupConn, "DROP SCHEMA IF EXISTS schema2 CASCADE; DROP SCHEMA public CASCADE; CREATE SCHEMA public; DROP PROCEDURAL LANGUAGE IF EXISTS plpythonu;")
+			testutils.ExecuteSQLFile(backupConn, "gpdb4_objects.sql")
+			if backupConn.Version.AtLeast("5") {
+				testutils.ExecuteSQLFile(backupConn, "gpdb5_objects.sql")
+			}
+			if backupConn.Version.AtLeast("6") {
+				testutils.ExecuteSQLFile(backupConn, "gpdb6_objects.sql")
+			}
+			timestamp := gpbackup(gpbackupPath, "-leaf-partition-data")
+			gprestore(gprestorePath, timestamp, "-redirect-db", "restoredb", "-jobs", "4")
+
 		})
 		It("runs gpbackup and sends a SIGINT to ensure cleanup functions successfully", func() {
 			backupdir := "/tmp/signals"
@@ -28,3 +28,19 @@ $_$;
 
 
 CREATE FOREIGN DATA WRAPPER fdw;
+
+
+CREATE TABLE replicated_table (
+	a integer,
+	b text
+) DISTRIBUTED REPLICATED;
+
+INSERT INTO replicated_table SELECT i, 'replicated' FROM generate_series(1, 10) i;
+
+
+CREATE TABLE legacy_hash_table (
+	a integer,
+	b text
+) DISTRIBUTED BY (a cdbhash_int4_ops, b);
+
+INSERT INTO legacy_hash_table SELECT i, 'legacy' FROM generate_series(1, 10) i;
@@ -0,0 +1,15 @@
+// +build gpbackup_diff
+
+package main
+
+import (
+	. "github.com/greenplum-db/gpbackup/diff"
+)
+
+func main() {
+	defer DoTeardown()
+	DoInit()
+	DoValidation()
+	DoSetup()
+	DoDiff()
+}
@@ -0,0 +1,15 @@
+// +build gpbackup_replicate
+
+package main
+
+import (
+	. "github.com/greenplum-db/gpbackup/replicate"
+)
+
+func main() {
+	defer DoTeardown()
+	DoInit()
+	DoValidation()
+	DoSetup()
+	DoReplicate()
+}
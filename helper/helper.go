@@ -7,6 +7,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"sort"
@@ -23,6 +24,7 @@ import (
 var ( // Shared globals
 	content          *int
 	dataFile         *string
+	numJobs          *int
 	oid              *uint
 	oidFile          *string
 	pipeFile         *string
@@ -31,6 +33,8 @@ var ( // Shared globals
 	tocFile          *string
 	version          string
 	pluginConfigFile *string
+
+	resolvePluginConfig *bool
 )
 
 var ( // Globals for restore only
@@ -38,6 +42,7 @@ var ( // Globals for restore only
 	currentPipe   string
 	lastPipe      string
 	nextPipe      string
+	oidList       []int
 	wasTerminated bool
 	writer        *bufio.Writer
 	writeHandle   *os.File
@@ -52,7 +57,9 @@ func DoHelper() {
 	defer DoTeardown()
 	InitializeGlobals()
 	utils.InitializeSignalHandler(DoCleanup, fmt.Sprintf("restore agent on segment %d", *content), &wasTerminated)
-	if *restoreAgent {
+	if *resolvePluginConfig {
+		doResolvePluginConfig()
+	} else if *restoreAgent {
 		doRestoreAgent()
 	} else {
 		doBackupHelper()
@@ -65,11 +72,13 @@ func InitializeGlobals() {
 	content = flag.Int("content", -2, "Content ID of the corresponding segment")
 	dataFile = flag.String("data-file", "", "Absolute path to the data file")
 	gplog.InitializeLogging("gpbackup_helper", "")
+	numJobs = flag.Int("jobs", 1, "Number of tables to restore in parallel, if table data can be read by offset")
 	oid = flag.Uint("oid", 0, "Oid of the table being processed")
 	oidFile = flag.String("oid-file", "", "Absolute path to the file containing a list of oids to restore")
 	pipeFile = flag.String("pipe-file", "", "Absolute path to the pipe file")
 	pluginConfigFile = flag.String("plugin-config", "", "The configuration file to use for a plugin")
 	printVersion = flag.Bool("version", false, "Print version number and exit")
+	resolvePluginConfig = flag.Bool("resolve-plugin-config", false, "Resolve secret references in the plugin config file in place")
 	restoreAgent = flag.Bool("restore-agent", false, "Use gpbackup_helper as an agent for restore")
 	tocFile = flag.String("toc-file", "", "Absolute path to the table of contents file")
 	flag.Parse()
@@ -84,6 +93,10 @@ func SetContent(id int) {
 	content = &id
 }
 
+func SetDataFile(name string) {
+	dataFile = &name
+}
+
 func SetFilename(name string) {
 	tocFile = &name
 }
@@ -133,24 +146,49 @@ func getOidListFromFile() []int {
 	oidStr, err := operating.System.ReadFile(*oidFile)
 	gplog.FatalOnError(err)
 	oidStrList := strings.Split(strings.TrimSpace(fmt.Sprintf("%s", oidStr)), "\n")
-	oidList := make([]int, len(oidStrList))
+	oids := make([]int, len(oidStrList))
 	for i, oid := range oidStrList {
 		num, _ := strconv.ParseInt(oid, 10, 32)
-		oidList[i] = int(num)
+		oids[i] = int(num)
 	}
-	sort.Ints(oidList)
-	return oidList
+	return oids
 }
 
 func doRestoreAgent() {
 	tocEntries := utils.NewSegmentTOC(*tocFile).DataEntries
+	oidList = getOidListFromFile()
+
+	var pluginConfig *utils.PluginConfig
+	if *pluginConfigFile != "" {
+		pluginConfig = utils.ReadPluginConfig(*pluginConfigFile)
+	}
+	if CanReadTableRanges(pluginConfig) {
+		doRangedRestore(tocEntries, pluginConfig)
+	} else {
+		doSequentialRestore(tocEntries)
+	}
+}
+
+/*
+ * Table data can only be read by offset if the offsets in the TOC refer to the
+ * data file itself, which is not the case for compressed data files, and if
+ * the data file is local or the plugin supports reading ranges of a file.
+ */
+func CanReadTableRanges(pluginConfig *utils.PluginConfig) bool {
+	if strings.HasSuffix(*dataFile, ".gz") {
+		return false
+	}
+	return pluginConfig == nil || pluginConfig.HasCapability("restore_data_range")
+}
+
+func doSequentialRestore(tocEntries map[uint]utils.SegmentDataEntry) {
 	lastByte := uint64(0)
-	oidList := getOidListFromFile()
+	sort.Ints(oidList)
 
 	lastPipe = ""
 	currentPipe = fmt.Sprintf("%s_%d", *pipeFile, oidList[0])
 	nextPipe = ""
-	log(fmt.Sprintf("Opening pipe for oid %d", oid))
+	log(fmt.Sprintf("Opening pipe for oid %d", oidList[0]))
 	writer, writeHandle = getPipeWriter(currentPipe)
 	reader := getPipeReader()
 	for i, oid := range oidList {
@@ -177,13 +215,112 @@ func doRestoreAgent() {
 		currentPipe = nextPipe
 		removeFileIfExists(lastPipe)
 		if currentPipe != "" {
-			log(fmt.Sprintf("Opening pipe for oid %d", oid))
+			log(fmt.Sprintf("Opening pipe for oid %d", oidList[i+1]))
 			writer, writeHandle = getPipeWriter(currentPipe)
 		}
 	}
 }
 
+/*
+ * Each table's data is read independently, so tables are restored in the
+ * order gprestore lists them, up to --jobs at a time, and the data of tables
+ * that are not being restored is never read.
+ */
+func doRangedRestore(tocEntries map[uint]utils.SegmentDataEntry, pluginConfig *utils.PluginConfig) {
+	var dataHandle *os.File
+	if pluginConfig == nil {
+		var err error
+		dataHandle, err = os.Open(*dataFile)
+		gplog.FatalOnError(err)
+		defer dataHandle.Close()
+	}
+	openRange := func(start uint64, end uint64) (io.ReadCloser, error) {
+		if pluginConfig != nil {
+			return pluginConfig.RestoreDataRange(*dataFile, start, end-start)
+		}
+		return ioutil.NopCloser(io.NewSectionReader(dataHandle, int64(start), int64(end-start))), nil
+	}
+
+	tasks := make(chan int, len(oidList))
+	for i := range oidList {
+		tasks <- i
+	}
+	close(tasks)
+	errs := make(chan error, *numJobs)
+	var workerPool sync.WaitGroup
+	for i := 0; i < *numJobs; i++ {
+		workerPool.Add(1)
+		go func() {
+			defer workerPool.Done()
+			for i := range tasks {
+				err := restoreTableRange(i, tocEntries[uint(oidList[i])], openRange)
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		workerPool.Wait()
+		close(errs)
+	}()
+	for err := range errs {
+		gplog.FatalOnError(err)
+	}
+}
+
+func restoreTableRange(index int, entry utils.SegmentDataEntry, openRange func(uint64, uint64) (io.ReadCloser, error)) error {
+	oid := oidList[index]
+	pipe := fmt.Sprintf("%s_%d", *pipeFile, oid)
+	log(fmt.Sprintf("Restoring table with oid %d", oid))
+	if *numJobs == 1 {
+		/*
+		 * With a single job, pipes are created one table ahead as they are
+		 * when reading sequentially; otherwise, gprestore creates them all.
+		 */
+		if index < len(oidList)-1 {
+			err := syscall.Mkfifo(fmt.Sprintf("%s_%d", *pipeFile, oidList[index+1]), 0777)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	log(fmt.Sprintf("Start Byte: %d; End Byte: %d", entry.StartByte, entry.EndByte))
+	reader, err := openRange(entry.StartByte, entry.EndByte)
+	if err != nil {
+		return err
+	}
+	log(fmt.Sprintf("Opening pipe for oid %d", oid))
+	pipeHandle, err := os.OpenFile(pipe, os.O_WRONLY, os.ModeNamedPipe)
+	if err != nil {
+		reader.Close()
+		return err
+	}
+	pipeWriter := bufio.NewWriter(pipeHandle)
+	bytesRead, err := io.CopyN(pipeWriter, reader, int64(entry.EndByte-entry.StartByte))
+	log(fmt.Sprintf("Read %d bytes", bytesRead))
+	if err == nil {
+		err = pipeWriter.Flush()
+	}
+	if closeErr := reader.Close(); err == nil {
+		err = closeErr
+	}
+	log(fmt.Sprintf("Closing pipe for oid %d", oid))
+	if closeErr := pipeHandle.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Remove(pipe)
+}
+
 func createNextPipe() {
+	// With more than one job, gprestore creates the pipes for all tables up front
+	if *numJobs > 1 {
+		return
+	}
 	err := syscall.Mkfifo(nextPipe, 0777)
 	gplog.FatalOnError(err)
 }
@@ -256,6 +393,16 @@ func removeFileIfExists(filename string) {
 	}
 }
 
+/*
+ * Plugin config functions
+ */
+
+func doResolvePluginConfig() {
+	pluginConfig := utils.ReadPluginConfig(*pluginConfigFile)
+	pluginConfig.ResolveSecrets()
+	pluginConfig.WriteToFile(*pluginConfigFile)
+}
+
 /*
  * Shared helper functions
  */
@@ -292,6 +439,9 @@ func DoCleanup() {
 		removeFileIfExists(lastPipe)
 		removeFileIfExists(currentPipe)
 		removeFileIfExists(nextPipe)
+		for _, oid := range oidList {
+			removeFileIfExists(fmt.Sprintf("%s_%d", *pipeFile, oid))
+		}
 	}
 }
 
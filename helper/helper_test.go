@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	"github.com/greenplum-db/gp-common-go-libs/operating"
 	"github.com/greenplum-db/gpbackup/helper"
@@ -84,4 +85,31 @@ dataentries:
 			})
 		})
 	})
+	Describe("CanReadTableRanges", func() {
+		var tempDir string
+		var pluginConfig *utils.PluginConfig
+		BeforeEach(func() {
+			tempDir, _ = ioutil.TempDir("", "helper_test")
+			pluginConfig = &utils.PluginConfig{ExecutablePath: filepath.Join(tempDir, "test_plugin.sh")}
+			_ = ioutil.WriteFile(pluginConfig.ExecutablePath, []byte("#!/bin/bash\nplugin_capabilities() {\n  [ -f $(dirname $0)/capabilities ] && cat $(dirname $0)/capabilities\n}\n\"$@\"\n"), 0755)
+			helper.SetDataFile("/backups/gpbackup_0_20170101010101")
+		})
+		AfterEach(func() {
+			os.RemoveAll(tempDir)
+		})
+		It("returns true for an uncompressed local data file", func() {
+			Expect(helper.CanReadTableRanges(nil)).To(BeTrue())
+		})
+		It("returns false for a compressed data file", func() {
+			helper.SetDataFile("/backups/gpbackup_0_20170101010101.gz")
+			Expect(helper.CanReadTableRanges(nil)).To(BeFalse())
+		})
+		It("returns true if the plugin supports ranged reads", func() {
+			_ = ioutil.WriteFile(filepath.Join(tempDir, "capabilities"), []byte("restore_data_range\n"), 0644)
+			Expect(helper.CanReadTableRanges(pluginConfig)).To(BeTrue())
+		})
+		It("returns false if the plugin does not support ranged reads", func() {
+			Expect(helper.CanReadTableRanges(pluginConfig)).To(BeFalse())
+		})
+	})
 })
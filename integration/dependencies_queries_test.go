@@ -0,0 +1,188 @@
+package integration
+
+import (
+	"github.com/greenplum-db/gp-common-go-libs/testhelper"
+	"github.com/greenplum-db/gpbackup/backup"
+	"github.com/greenplum-db/gpbackup/testutils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("backup integration tests", func() {
+	Describe("GetDependencies", func() {
+		var (
+			backupSet map[backup.UniqueID]bool
+		)
+		BeforeEach(func() {
+			backupSet = make(map[backup.UniqueID]bool, 0)
+		})
+		typeID := func(name string) backup.UniqueID {
+			oid := testutils.OidFromObjectName(connection, "public", name, backup.TYPE_TYPE)
+			backupSet[backup.UniqueID{ClassID: backup.PG_TYPE_OID, Oid: oid}] = true
+			return backup.UniqueID{ClassID: backup.PG_TYPE_OID, Oid: oid}
+		}
+		functionID := func(name string) backup.UniqueID {
+			oid := testutils.OidFromObjectName(connection, "public", name, backup.TYPE_FUNCTION)
+			backupSet[backup.UniqueID{ClassID: backup.PG_PROC_OID, Oid: oid}] = true
+			return backup.UniqueID{ClassID: backup.PG_PROC_OID, Oid: oid}
+		}
+		relationID := func(name string) backup.UniqueID {
+			oid := testutils.OidFromObjectName(connection, "public", name, backup.TYPE_RELATION)
+			backupSet[backup.UniqueID{ClassID: backup.PG_CLASS_OID, Oid: oid}] = true
+			return backup.UniqueID{ClassID: backup.PG_CLASS_OID, Oid: oid}
+		}
+		operatorID := func(name string) backup.UniqueID {
+			oid := testutils.OidFromObjectName(connection, "public", name, backup.TYPE_OPERATOR)
+			backupSet[backup.UniqueID{ClassID: backup.PG_OPERATOR_OID, Oid: oid}] = true
+			return backup.UniqueID{ClassID: backup.PG_OPERATOR_OID, Oid: oid}
+		}
+		Context("types and functions", func() {
+			BeforeEach(func() {
+				testhelper.AssertQueryRuns(connection, "CREATE TYPE base_type")
+				testhelper.AssertQueryRuns(connection, "CREATE FUNCTION base_fn_in(cstring) RETURNS base_type AS 'boolin' LANGUAGE internal")
+				testhelper.AssertQueryRuns(connection, "CREATE FUNCTION base_fn_out(base_type) RETURNS cstring AS 'boolout' LANGUAGE internal")
+				testhelper.AssertQueryRuns(connection, "CREATE TYPE base_type(INPUT=base_fn_in, OUTPUT=base_fn_out)")
+			})
+			AfterEach(func() {
+				testhelper.AssertQueryRuns(connection, "DROP TYPE base_type CASCADE")
+			})
+			It("records dependencies of a base type on its support functions but not the reverse", func() {
+				baseType := typeID("base_type")
+				inFunc := functionID("base_fn_in")
+				outFunc := functionID("base_fn_out")
+
+				dependencies := backup.GetDependencies(connection, backupSet)
+
+				Expect(dependencies).To(HaveLen(1))
+				Expect(dependencies[baseType]).To(Equal(map[backup.UniqueID]bool{inFunc: true, outFunc: true}))
+			})
+			It("records dependencies of a composite type on the user-defined types of its attributes", func() {
+				testhelper.AssertQueryRuns(connection, "CREATE TYPE comp_type AS (base base_type, base2 base_type, builtin integer)")
+				defer testhelper.AssertQueryRuns(connection, "DROP TYPE comp_type")
+				baseType := typeID("base_type")
+				compType := typeID("comp_type")
+
+				dependencies := backup.GetDependencies(connection, backupSet)
+
+				Expect(dependencies).To(Equal(backup.DependencyMap{compType: {baseType: true}}))
+			})
+			It("records dependencies of a domain on a parent domain, but not on a built-in type", func() {
+				testhelper.AssertQueryRuns(connection, "CREATE DOMAIN parent_domain AS integer")
+				defer testhelper.AssertQueryRuns(connection, "DROP DOMAIN parent_domain")
+				testhelper.AssertQueryRuns(connection, "CREATE DOMAIN domain_type AS parent_domain")
+				defer testhelper.AssertQueryRuns(connection, "DROP DOMAIN domain_type")
+				parentDomain := typeID("parent_domain")
+				domain := typeID("domain_type")
+
+				dependencies := backup.GetDependencies(connection, backupSet)
+
+				Expect(dependencies).To(Equal(backup.DependencyMap{domain: {parentDomain: true}}))
+			})
+			It("records dependencies of a domain on a function used in one of its constraints", func() {
+				testhelper.AssertQueryRuns(connection, "CREATE FUNCTION is_positive(integer) RETURNS boolean AS 'SELECT $1 > 0' LANGUAGE SQL IMMUTABLE")
+				defer testhelper.AssertQueryRuns(connection, "DROP FUNCTION is_positive(integer)")
+				testhelper.AssertQueryRuns(connection, "CREATE DOMAIN positive_int AS integer CHECK (is_positive(VALUE))")
+				defer testhelper.AssertQueryRuns(connection, "DROP DOMAIN positive_int")
+				function := functionID("is_positive")
+				domain := typeID("positive_int")
+
+				dependencies := backup.GetDependencies(connection, backupSet)
+
+				Expect(dependencies).To(Equal(backup.DependencyMap{domain: {function: true}}))
+			})
+			It("records dependencies of a function on user-defined types in its arguments, return type, and implicit array types", func() {
+				testhelper.AssertQueryRuns(connection, "CREATE TYPE composite_ints AS (one integer, two integer)")
+				defer testhelper.AssertQueryRuns(connection, "DROP TYPE composite_ints CASCADE")
+				testhelper.AssertQueryRuns(connection, "CREATE FUNCTION compose(base_type[], composite_ints) RETURNS composite_ints STRICT IMMUTABLE LANGUAGE PLPGSQL AS 'DECLARE comp composite_ints; BEGIN SELECT $1[0].one+$2.one, $1[0].two+$2.two INTO comp; RETURN comp; END;';")
+				defer testhelper.AssertQueryRuns(connection, "DROP FUNCTION compose(base_type[], composite_ints)")
+				baseType := typeID("base_type")
+				compType := typeID("composite_ints")
+				function := functionID("compose")
+
+				dependencies := backup.GetDependencies(connection, backupSet)
+
+				Expect(dependencies[function]).To(Equal(map[backup.UniqueID]bool{baseType: true, compType: true}))
+			})
+		})
+		Context("relations", func() {
+			It("records dependencies of a table on a table it inherits from", func() {
+				testhelper.AssertQueryRuns(connection, "CREATE TABLE parent(i int)")
+				defer testhelper.AssertQueryRuns(connection, "DROP TABLE parent")
+				testhelper.AssertQueryRuns(connection, "CREATE TABLE child() INHERITS (parent)")
+				defer testhelper.AssertQueryRuns(connection, "DROP TABLE child")
+				parent := relationID("parent")
+				child := relationID("child")
+
+				dependencies := backup.GetDependencies(connection, backupSet)
+
+				Expect(dependencies).To(Equal(backup.DependencyMap{child: {parent: true}}))
+			})
+			It("does not record dependencies on tables that are not in the backup set", func() {
+				testhelper.AssertQueryRuns(connection, "CREATE TABLE parent(i int)")
+				defer testhelper.AssertQueryRuns(connection, "DROP TABLE parent")
+				testhelper.AssertQueryRuns(connection, "CREATE TABLE child() INHERITS (parent)")
+				defer testhelper.AssertQueryRuns(connection, "DROP TABLE child")
+				relationID("child")
+
+				dependencies := backup.GetDependencies(connection, backupSet)
+
+				Expect(dependencies).To(BeEmpty())
+			})
+			It("records dependencies of a table on a function used in a column default", func() {
+				testhelper.AssertQueryRuns(connection, "CREATE FUNCTION default_val() RETURNS integer AS 'SELECT 42' LANGUAGE SQL IMMUTABLE")
+				defer testhelper.AssertQueryRuns(connection, "DROP FUNCTION default_val()")
+				testhelper.AssertQueryRuns(connection, "CREATE TABLE default_table(i int DEFAULT default_val())")
+				defer testhelper.AssertQueryRuns(connection, "DROP TABLE default_table")
+				function := functionID("default_val")
+				table := relationID("default_table")
+
+				dependencies := backup.GetDependencies(connection, backupSet)
+
+				Expect(dependencies).To(Equal(backup.DependencyMap{table: {function: true}}))
+			})
+			It("records dependencies of a view on an aggregate", func() {
+				testhelper.AssertQueryRuns(connection, "CREATE FUNCTION mysfunc(integer, integer) RETURNS integer AS 'SELECT $1 + $2' LANGUAGE SQL IMMUTABLE")
+				defer testhelper.AssertQueryRuns(connection, "DROP FUNCTION mysfunc(integer, integer)")
+				testhelper.AssertQueryRuns(connection, "CREATE AGGREGATE agg_sum(integer) (SFUNC = mysfunc, STYPE = integer)")
+				defer testhelper.AssertQueryRuns(connection, "DROP AGGREGATE agg_sum(integer)")
+				testhelper.AssertQueryRuns(connection, "CREATE VIEW agg_view AS SELECT agg_sum(relnatts) FROM pg_class")
+				defer testhelper.AssertQueryRuns(connection, "DROP VIEW agg_view")
+				sfunc := functionID("mysfunc")
+				aggregate := functionID("agg_sum")
+				view := relationID("agg_view")
+
+				dependencies := backup.GetDependencies(connection, backupSet)
+
+				Expect(dependencies).To(Equal(backup.DependencyMap{
+					aggregate: {sfunc: true},
+					view:      {aggregate: true},
+				}))
+			})
+			It("records dependencies of a view on other views and on operators", func() {
+				testhelper.AssertQueryRuns(connection, "CREATE FUNCTION int_add(integer, integer) RETURNS integer AS 'SELECT $1 + $2' LANGUAGE SQL IMMUTABLE")
+				defer testhelper.AssertQueryRuns(connection, "DROP FUNCTION int_add(integer, integer) CASCADE")
+				testhelper.AssertQueryRuns(connection, "CREATE OPERATOR ### (LEFTARG = integer, RIGHTARG = integer, PROCEDURE = int_add)")
+				testhelper.AssertQueryRuns(connection, "CREATE VIEW parent1 AS SELECT 1 ### 2 AS a")
+				defer testhelper.AssertQueryRuns(connection, "DROP VIEW parent1")
+				testhelper.AssertQueryRuns(connection, "CREATE VIEW parent2 AS SELECT relnatts AS a FROM pg_class")
+				defer testhelper.AssertQueryRuns(connection, "DROP VIEW parent2")
+				testhelper.AssertQueryRuns(connection, "CREATE VIEW child AS (SELECT a ### 1 FROM parent1 UNION SELECT a FROM parent2)")
+				defer testhelper.AssertQueryRuns(connection, "DROP VIEW child")
+				function := functionID("int_add")
+				operator := operatorID("###")
+				parent1 := relationID("parent1")
+				parent2 := relationID("parent2")
+				child := relationID("child")
+
+				dependencies := backup.GetDependencies(connection, backupSet)
+
+				Expect(dependencies).To(Equal(backup.DependencyMap{
+					operator: {function: true},
+					parent1:  {operator: true},
+					child:    {operator: true, parent1: true, parent2: true},
+				}))
+			})
+		})
+	})
+})
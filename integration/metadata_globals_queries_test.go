@@ -305,4 +305,43 @@ CREATEEXTTABLE (protocol='gphdfs', type='writable')`)
 			Fail("Tablespace 'test_tablespace' was not created")
 		})
 	})
+	Describe("GetSharedSecurityLabels", func() {
+		/*
+		 * No security label provider is loaded in the test cluster, so labels
+		 * are inserted into the catalog directly.
+		 */
+		BeforeEach(func() {
+			testutils.SkipIfBefore6(connection)
+			testhelper.AssertQueryRuns(connection, "SET allow_system_table_mods = true")
+		})
+		AfterEach(func() {
+			if connection.Version.AtLeast("6") {
+				testhelper.AssertQueryRuns(connection, "DELETE FROM pg_shseclabel WHERE provider = 'dummy'")
+				testhelper.AssertQueryRuns(connection, "RESET allow_system_table_mods")
+				backup.SetIncludeSchemas([]string{})
+			}
+		})
+		It("returns security labels on roles and on the current database only", func() {
+			testhelper.AssertQueryRuns(connection, "INSERT INTO pg_shseclabel VALUES ((SELECT oid FROM pg_roles WHERE rolname = 'testrole'), 'pg_authid'::regclass, 'dummy', 'unclassified')")
+			testhelper.AssertQueryRuns(connection, "INSERT INTO pg_shseclabel VALUES ((SELECT oid FROM pg_database WHERE datname = current_database()), 'pg_database'::regclass, 'dummy', 'classified')")
+			testhelper.AssertQueryRuns(connection, "INSERT INTO pg_shseclabel VALUES ((SELECT oid FROM pg_database WHERE datname = 'template1'), 'pg_database'::regclass, 'dummy', 'classified')")
+
+			databaseLabel := backup.SecurityLabel{Schema: "", ObjectType: "DATABASE", ObjectName: "testdb", Provider: "dummy", Label: "classified"}
+			roleLabel := backup.SecurityLabel{Schema: "", ObjectType: "ROLE", ObjectName: "testrole", Provider: "dummy", Label: "unclassified"}
+
+			results := backup.GetSharedSecurityLabels(connection)
+
+			Expect(len(results)).To(Equal(2))
+			structmatcher.ExpectStructsToMatch(&roleLabel, &results[0])
+			structmatcher.ExpectStructsToMatch(&databaseLabel, &results[1])
+		})
+		It("does not return security labels on roles if schemas are included", func() {
+			testhelper.AssertQueryRuns(connection, "INSERT INTO pg_shseclabel VALUES ((SELECT oid FROM pg_roles WHERE rolname = 'testrole'), 'pg_authid'::regclass, 'dummy', 'unclassified')")
+			backup.SetIncludeSchemas([]string{"public"})
+
+			results := backup.GetSharedSecurityLabels(connection)
+
+			Expect(results).To(BeEmpty())
+		})
+	})
 })
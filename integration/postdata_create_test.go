@@ -170,4 +170,44 @@ var _ = Describe("backup integration create statement tests", func() {
 			structmatcher.ExpectStructsToMatch(&resultMetadata, &triggerMetadata)
 		})
 	})
+	Describe("PrintDefaultPrivilegesStatements", func() {
+		It("creates default privileges for a role in a schema", func() {
+			testutils.SkipIfBefore6(connection)
+			testhelper.AssertQueryRuns(connection, "CREATE SCHEMA testschema")
+			defer testhelper.AssertQueryRuns(connection, "DROP SCHEMA testschema")
+			privileges := []backup.DefaultPrivileges{{Owner: "testrole", Schema: "testschema", Privileges: []backup.ACL{{Grantee: "anothertestrole", Select: true, Update: true}}, ObjectType: "r"}}
+			backup.PrintDefaultPrivilegesStatements(backupfile, toc, privileges)
+
+			testhelper.AssertQueryRuns(connection, buffer.String())
+			defer testhelper.AssertQueryRuns(connection, "ALTER DEFAULT PRIVILEGES FOR ROLE testrole IN SCHEMA testschema REVOKE ALL ON TABLES FROM anothertestrole")
+
+			resultPrivileges := backup.GetDefaultPrivileges(connection)
+			Expect(len(resultPrivileges)).To(Equal(1))
+			structmatcher.ExpectStructsToMatch(&privileges[0], &resultPrivileges[0])
+		})
+	})
+	Describe("PrintCreateEventTriggerStatements", func() {
+		It("creates an event trigger with a comment", func() {
+			testutils.SkipIfBefore6(connection)
+			testhelper.AssertQueryRuns(connection, "CREATE FUNCTION abort_any_command() RETURNS event_trigger LANGUAGE plpgsql AS $$ BEGIN RAISE EXCEPTION 'exception'; END; $$")
+			defer testhelper.AssertQueryRuns(connection, "DROP FUNCTION abort_any_command()")
+			eventTriggers := []backup.EventTrigger{{Oid: 1, Name: "event_trigger1", Event: "ddl_command_start", EventTags: "'CREATE TABLE'", FunctionName: "public.abort_any_command", Enabled: "D"}}
+			eventTriggerMetadataMap := testutils.DefaultMetadataMap("EVENT TRIGGER", false, false, true)
+			eventTriggerMetadata := eventTriggerMetadataMap[1]
+			backup.PrintCreateEventTriggerStatements(backupfile, toc, eventTriggers, eventTriggerMetadataMap)
+
+			testhelper.AssertQueryRuns(connection, buffer.String())
+			defer testhelper.AssertQueryRuns(connection, "DROP EVENT TRIGGER event_trigger1")
+
+			resultEventTriggers := backup.GetEventTriggers(connection)
+			resultMetadataMap := backup.GetMetadataForObjectType(connection, backup.TYPE_EVENTTRIGGER)
+			eventTriggers[0].Oid = testutils.OidFromObjectName(connection, "", "event_trigger1", backup.TYPE_EVENTTRIGGER)
+			resultMetadata := resultMetadataMap[eventTriggers[0].Oid]
+			eventTriggerMetadata.Owner = resultMetadata.Owner
+
+			Expect(len(resultEventTriggers)).To(Equal(1))
+			structmatcher.ExpectStructsToMatch(&eventTriggers[0], &resultEventTriggers[0])
+			structmatcher.ExpectStructsToMatch(&eventTriggerMetadata, &resultMetadata)
+		})
+	})
 })
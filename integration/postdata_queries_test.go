@@ -321,4 +321,141 @@ PARTITION BY RANGE (date)
 			structmatcher.ExpectStructsToMatchExcluding(&trigger1, &results[0], "Oid")
 		})
 	})
+	Describe("GetDefaultPrivileges", func() {
+		BeforeEach(func() {
+			testutils.SkipIfBefore6(connection)
+		})
+		It("returns a slice of default privileges for all schemas", func() {
+			testhelper.AssertQueryRuns(connection, "ALTER DEFAULT PRIVILEGES FOR ROLE testrole GRANT SELECT ON TABLES TO anothertestrole")
+			defer testhelper.AssertQueryRuns(connection, "ALTER DEFAULT PRIVILEGES FOR ROLE testrole REVOKE SELECT ON TABLES FROM anothertestrole")
+
+			expected := backup.DefaultPrivileges{Owner: "testrole", Schema: "", Privileges: []backup.ACL{{Grantee: "anothertestrole", Select: true}, testutils.DefaultACLForType("testrole", "TABLE")}, ObjectType: "r"}
+
+			results := backup.GetDefaultPrivileges(connection)
+
+			Expect(len(results)).To(Equal(1))
+			structmatcher.ExpectStructsToMatch(&expected, &results[0])
+		})
+		It("returns a slice of default privileges in a specific schema", func() {
+			testhelper.AssertQueryRuns(connection, "CREATE SCHEMA testschema")
+			defer testhelper.AssertQueryRuns(connection, "DROP SCHEMA testschema")
+			testhelper.AssertQueryRuns(connection, "ALTER DEFAULT PRIVILEGES FOR ROLE testrole IN SCHEMA testschema GRANT EXECUTE ON FUNCTIONS TO anothertestrole")
+			defer testhelper.AssertQueryRuns(connection, "ALTER DEFAULT PRIVILEGES FOR ROLE testrole IN SCHEMA testschema REVOKE EXECUTE ON FUNCTIONS FROM anothertestrole")
+
+			expected := backup.DefaultPrivileges{Owner: "testrole", Schema: "testschema", Privileges: []backup.ACL{{Grantee: "anothertestrole", Execute: true}}, ObjectType: "f"}
+
+			results := backup.GetDefaultPrivileges(connection)
+
+			Expect(len(results)).To(Equal(1))
+			structmatcher.ExpectStructsToMatch(&expected, &results[0])
+		})
+		It("returns a slice of default privileges belonging to filtered schemas", func() {
+			testhelper.AssertQueryRuns(connection, "CREATE SCHEMA testschema")
+			defer testhelper.AssertQueryRuns(connection, "DROP SCHEMA testschema")
+			testhelper.AssertQueryRuns(connection, "ALTER DEFAULT PRIVILEGES FOR ROLE testrole GRANT SELECT ON TABLES TO anothertestrole")
+			defer testhelper.AssertQueryRuns(connection, "ALTER DEFAULT PRIVILEGES FOR ROLE testrole REVOKE SELECT ON TABLES FROM anothertestrole")
+			testhelper.AssertQueryRuns(connection, "ALTER DEFAULT PRIVILEGES FOR ROLE testrole IN SCHEMA testschema GRANT SELECT ON SEQUENCES TO anothertestrole")
+			defer testhelper.AssertQueryRuns(connection, "ALTER DEFAULT PRIVILEGES FOR ROLE testrole IN SCHEMA testschema REVOKE SELECT ON SEQUENCES FROM anothertestrole")
+			backup.SetIncludeSchemas([]string{"testschema"})
+
+			expected := backup.DefaultPrivileges{Owner: "testrole", Schema: "testschema", Privileges: []backup.ACL{{Grantee: "anothertestrole", Select: true}}, ObjectType: "S"}
+
+			results := backup.GetDefaultPrivileges(connection)
+
+			Expect(len(results)).To(Equal(1))
+			structmatcher.ExpectStructsToMatch(&expected, &results[0])
+		})
+	})
+	Describe("GetEventTriggers", func() {
+		BeforeEach(func() {
+			testutils.SkipIfBefore6(connection)
+			testhelper.AssertQueryRuns(connection, "CREATE FUNCTION abort_any_command() RETURNS event_trigger LANGUAGE plpgsql AS $$ BEGIN RAISE EXCEPTION 'exception'; END; $$")
+		})
+		AfterEach(func() {
+			if connection.Version.AtLeast("6") {
+				testhelper.AssertQueryRuns(connection, "DROP FUNCTION abort_any_command()")
+			}
+		})
+		It("returns a slice of event triggers", func() {
+			testhelper.AssertQueryRuns(connection, "CREATE EVENT TRIGGER event_trigger1 ON ddl_command_start WHEN TAG IN ('CREATE TABLE', 'DROP TABLE') EXECUTE PROCEDURE abort_any_command()")
+			defer testhelper.AssertQueryRuns(connection, "DROP EVENT TRIGGER event_trigger1")
+			testhelper.AssertQueryRuns(connection, "ALTER EVENT TRIGGER event_trigger1 DISABLE")
+			testhelper.AssertQueryRuns(connection, "CREATE EVENT TRIGGER event_trigger2 ON sql_drop EXECUTE PROCEDURE abort_any_command()")
+			defer testhelper.AssertQueryRuns(connection, "DROP EVENT TRIGGER event_trigger2")
+			testhelper.AssertQueryRuns(connection, "ALTER EVENT TRIGGER event_trigger2 DISABLE")
+
+			eventTrigger1 := backup.EventTrigger{Oid: 0, Name: "event_trigger1", Event: "ddl_command_start", EventTags: "'CREATE TABLE', 'DROP TABLE'", FunctionName: "public.abort_any_command", Enabled: "D"}
+			eventTrigger2 := backup.EventTrigger{Oid: 0, Name: "event_trigger2", Event: "sql_drop", EventTags: "", FunctionName: "public.abort_any_command", Enabled: "D"}
+
+			results := backup.GetEventTriggers(connection)
+
+			Expect(len(results)).To(Equal(2))
+			structmatcher.ExpectStructsToMatchExcluding(&eventTrigger1, &results[0], "Oid")
+			structmatcher.ExpectStructsToMatchExcluding(&eventTrigger2, &results[1], "Oid")
+		})
+	})
+	Describe("GetSecurityLabels", func() {
+		/*
+		 * No security label provider is loaded in the test cluster, so labels
+		 * are inserted into the catalog directly.
+		 */
+		BeforeEach(func() {
+			testutils.SkipIfBefore6(connection)
+			testhelper.AssertQueryRuns(connection, "SET allow_system_table_mods = true")
+		})
+		AfterEach(func() {
+			if connection.Version.AtLeast("6") {
+				testhelper.AssertQueryRuns(connection, "DELETE FROM pg_seclabel WHERE provider = 'dummy'")
+				testhelper.AssertQueryRuns(connection, "DELETE FROM pg_shseclabel WHERE provider = 'dummy'")
+				testhelper.AssertQueryRuns(connection, "RESET allow_system_table_mods")
+			}
+		})
+		It("returns a slice of security labels", func() {
+			testhelper.AssertQueryRuns(connection, "CREATE TABLE seclabel_table(i int)")
+			defer testhelper.AssertQueryRuns(connection, "DROP TABLE seclabel_table")
+			testhelper.AssertQueryRuns(connection, "INSERT INTO pg_seclabel VALUES ('public.seclabel_table'::regclass, 'pg_class'::regclass, 0, 'dummy', 'classified')")
+
+			tableLabel := backup.SecurityLabel{Schema: "public", ObjectType: "TABLE", ObjectName: "public.seclabel_table", Provider: "dummy", Label: "classified"}
+
+			results := backup.GetSecurityLabels(connection)
+
+			Expect(len(results)).To(Equal(1))
+			structmatcher.ExpectStructsToMatch(&tableLabel, &results[0])
+		})
+		It("does not return security labels on shared objects", func() {
+			testhelper.AssertQueryRuns(connection, "INSERT INTO pg_shseclabel VALUES ((SELECT oid FROM pg_roles WHERE rolname = 'testrole'), 'pg_authid'::regclass, 'dummy', 'unclassified')")
+			testhelper.AssertQueryRuns(connection, "INSERT INTO pg_shseclabel VALUES ((SELECT oid FROM pg_database WHERE datname = current_database()), 'pg_database'::regclass, 'dummy', 'unclassified')")
+			testhelper.AssertQueryRuns(connection, "INSERT INTO pg_shseclabel VALUES ((SELECT oid FROM pg_database WHERE datname = 'template1'), 'pg_database'::regclass, 'dummy', 'unclassified')")
+
+			results := backup.GetSecurityLabels(connection)
+
+			Expect(results).To(BeEmpty())
+		})
+		It("does not return security labels on objects that belong to an extension", func() {
+			testhelper.AssertQueryRuns(connection, "INSERT INTO pg_seclabel VALUES ((SELECT oid FROM pg_language WHERE lanname = 'plpgsql'), 'pg_language'::regclass, 0, 'dummy', 'classified')")
+
+			results := backup.GetSecurityLabels(connection)
+
+			Expect(results).To(BeEmpty())
+		})
+		It("returns a slice of security labels belonging to filtered schemas", func() {
+			testhelper.AssertQueryRuns(connection, "CREATE TABLE seclabel_table(i int)")
+			defer testhelper.AssertQueryRuns(connection, "DROP TABLE seclabel_table")
+			testhelper.AssertQueryRuns(connection, "CREATE SCHEMA testschema")
+			defer testhelper.AssertQueryRuns(connection, "DROP SCHEMA testschema")
+			testhelper.AssertQueryRuns(connection, "CREATE TABLE testschema.seclabel_table(i int)")
+			defer testhelper.AssertQueryRuns(connection, "DROP TABLE testschema.seclabel_table")
+			testhelper.AssertQueryRuns(connection, "INSERT INTO pg_seclabel VALUES ('public.seclabel_table'::regclass, 'pg_class'::regclass, 0, 'dummy', 'classified')")
+			testhelper.AssertQueryRuns(connection, "INSERT INTO pg_seclabel VALUES ('testschema.seclabel_table'::regclass, 'pg_class'::regclass, 0, 'dummy', 'classified')")
+			testhelper.AssertQueryRuns(connection, "INSERT INTO pg_shseclabel VALUES ((SELECT oid FROM pg_roles WHERE rolname = 'testrole'), 'pg_authid'::regclass, 'dummy', 'unclassified')")
+			backup.SetIncludeSchemas([]string{"testschema"})
+
+			tableLabel := backup.SecurityLabel{Schema: "testschema", ObjectType: "TABLE", ObjectName: "testschema.seclabel_table", Provider: "dummy", Label: "classified"}
+
+			results := backup.GetSecurityLabels(connection)
+
+			Expect(len(results)).To(Equal(1))
+			structmatcher.ExpectStructsToMatch(&tableLabel, &results[0])
+		})
+	})
 })
@@ -480,81 +480,6 @@ LANGUAGE SQL`)
 			structmatcher.ExpectStructsToMatchExcluding(&expectedConversion, &resultConversions[0], "Oid")
 		})
 	})
-	Describe("ConstructFunctionDependencies", func() {
-		BeforeEach(func() {
-			testhelper.AssertQueryRuns(connection, "CREATE TYPE composite_ints AS (one integer, two integer)")
-		})
-		AfterEach(func() {
-			testhelper.AssertQueryRuns(connection, "DROP TYPE composite_ints CASCADE")
-		})
-		It("constructs dependencies correctly for a function dependent on a user-defined type in the arguments", func() {
-			testhelper.AssertQueryRuns(connection, "CREATE FUNCTION add(composite_ints) RETURNS integer STRICT IMMUTABLE LANGUAGE SQL AS 'SELECT ($1.one + $1.two);'")
-			defer testhelper.AssertQueryRuns(connection, "DROP FUNCTION add(composite_ints)")
-
-			allFunctions := backup.GetFunctionsAllVersions(connection)
-			function := backup.Function{}
-			for _, funct := range allFunctions {
-				if funct.Name == "add" {
-					function = funct
-					break
-				}
-			}
-			functions := []backup.Function{function}
-
-			functions = backup.ConstructFunctionDependencies(connection, functions)
-
-			Expect(len(functions)).To(Equal(1))
-			Expect(len(functions[0].DependsUpon)).To(Equal(1))
-			Expect(functions[0].DependsUpon[0]).To(Equal("public.composite_ints"))
-		})
-		It("constructs dependencies correctly for a function dependent on a user-defined type in the return type", func() {
-			testhelper.AssertQueryRuns(connection, "CREATE FUNCTION compose(integer, integer) RETURNS composite_ints STRICT IMMUTABLE LANGUAGE PLPGSQL AS 'DECLARE comp composite_ints; BEGIN SELECT $1, $2 INTO comp; RETURN comp; END;';")
-			defer testhelper.AssertQueryRuns(connection, "DROP FUNCTION compose(integer, integer)")
-
-			allFunctions := backup.GetFunctionsAllVersions(connection)
-			function := backup.Function{}
-			for _, funct := range allFunctions {
-				if funct.Name == "compose" {
-					function = funct
-					break
-				}
-			}
-			functions := []backup.Function{function}
-
-			functions = backup.ConstructFunctionDependencies(connection, functions)
-
-			Expect(len(functions)).To(Equal(1))
-			Expect(len(functions[0].DependsUpon)).To(Equal(1))
-			Expect(functions[0].DependsUpon[0]).To(Equal("public.composite_ints"))
-		})
-		It("constructs dependencies correctly for a function dependent on an implicit array type", func() {
-			testhelper.AssertQueryRuns(connection, "CREATE TYPE base_type")
-			defer testhelper.AssertQueryRuns(connection, "DROP TYPE base_type CASCADE")
-			testhelper.AssertQueryRuns(connection, "CREATE FUNCTION base_fn_in(cstring) RETURNS base_type AS 'boolin' LANGUAGE internal")
-			testhelper.AssertQueryRuns(connection, "CREATE FUNCTION base_fn_out(base_type) RETURNS cstring AS 'boolout' LANGUAGE internal")
-			testhelper.AssertQueryRuns(connection, "CREATE TYPE base_type(INPUT=base_fn_in, OUTPUT=base_fn_out)")
-			testhelper.AssertQueryRuns(connection, "CREATE FUNCTION compose(base_type[], composite_ints) RETURNS composite_ints STRICT IMMUTABLE LANGUAGE PLPGSQL AS 'DECLARE comp composite_ints; BEGIN SELECT $1[0].one+$2.one, $1[0].two+$2.two INTO comp; RETURN comp; END;';")
-			defer testhelper.AssertQueryRuns(connection, "DROP FUNCTION compose(base_type[], composite_ints)")
-
-			allFunctions := backup.GetFunctionsAllVersions(connection)
-			function := backup.Function{}
-			for _, funct := range allFunctions {
-				if funct.Name == "compose" {
-					function = funct
-					break
-				}
-			}
-			functions := []backup.Function{function}
-
-			functions = backup.ConstructFunctionDependencies(connection, functions)
-
-			Expect(len(functions)).To(Equal(1))
-			Expect(len(functions[0].DependsUpon)).To(Equal(3))
-			Expect(functions[0].DependsUpon[0]).To(Equal("public.composite_ints"))
-			Expect(functions[0].DependsUpon[1]).To(Equal("public.base_type"))
-			Expect(functions[0].DependsUpon[2]).To(Equal("public.composite_ints"))
-		})
-	})
 	Describe("GetForeignDataWrappers", func() {
 		It("returns a slice of foreign data wrappers", func() {
 			testutils.SkipIfBefore6(connection)
@@ -75,7 +75,6 @@ SET SUBPARTITION TEMPLATE  ` + `
 			tableDefs = map[uint32]backup.TableDefinition{}
 		})
 		AfterEach(func() {
-			testTable.DependsUpon = []string{}
 			testTable.Inherits = []string{}
 			testhelper.AssertQueryRuns(connection, "DROP TABLE IF EXISTS public.testtable")
 		})
@@ -196,7 +195,6 @@ SET SUBPARTITION TEMPLATE  ` + `
 			testhelper.AssertQueryRuns(connection, "CREATE TABLE public.parent (i int)")
 			defer testhelper.AssertQueryRuns(connection, "DROP TABLE public.parent")
 			tableDef.ColumnDefs = []backup.ColumnDefinition{}
-			testTable.DependsUpon = []string{"public.parent"}
 			testTable.Inherits = []string{"public.parent"}
 
 			backup.PrintRegularTableCreateStatement(backupfile, toc, testTable, tableDef)
@@ -205,12 +203,10 @@ SET SUBPARTITION TEMPLATE  ` + `
 			defer testhelper.AssertQueryRuns(connection, "DROP TABLE public.testtable")
 			testTable.Oid = testutils.OidFromObjectName(connection, "public", "testtable", backup.TYPE_RELATION)
 			tables := []backup.Relation{testTable}
-			tables = backup.ConstructTableDependencies(connection, tables, tableDefs, false)
+			tables = backup.GetTableInheritance(connection, tables, tableDefs)
 
 			Expect(len(tables)).To(Equal(1))
-			Expect(len(tables[0].DependsUpon)).To(Equal(1))
 			Expect(len(tables[0].Inherits)).To(Equal(1))
-			Expect(tables[0].DependsUpon[0]).To(Equal("public.parent"))
 			Expect(tables[0].Inherits[0]).To(Equal("public.parent"))
 		})
 		It("creates a table that inherits from two tables", func() {
@@ -219,7 +215,6 @@ SET SUBPARTITION TEMPLATE  ` + `
 			testhelper.AssertQueryRuns(connection, "CREATE TABLE public.parent_two (j character varying(20))")
 			defer testhelper.AssertQueryRuns(connection, "DROP TABLE public.parent_two")
 			tableDef.ColumnDefs = []backup.ColumnDefinition{}
-			testTable.DependsUpon = []string{"public.parent_one", "public.parent_two"}
 			testTable.Inherits = []string{"public.parent_one", "public.parent_two"}
 
 			backup.PrintRegularTableCreateStatement(backupfile, toc, testTable, tableDef)
@@ -228,14 +223,10 @@ SET SUBPARTITION TEMPLATE  ` + `
 			defer testhelper.AssertQueryRuns(connection, "DROP TABLE public.testtable")
 			testTable.Oid = testutils.OidFromObjectName(connection, "public", "testtable", backup.TYPE_RELATION)
 			tables := []backup.Relation{testTable}
-			tables = backup.ConstructTableDependencies(connection, tables, tableDefs, false)
+			tables = backup.GetTableInheritance(connection, tables, tableDefs)
 
-			sort.Strings(tables[0].DependsUpon)
 			sort.Strings(tables[0].Inherits)
 			Expect(len(tables)).To(Equal(1))
-			Expect(len(tables[0].DependsUpon)).To(Equal(2))
-			Expect(tables[0].DependsUpon[0]).To(Equal("public.parent_one"))
-			Expect(tables[0].DependsUpon[1]).To(Equal("public.parent_two"))
 			Expect(len(tables[0].Inherits)).To(Equal(2))
 			Expect(tables[0].Inherits[0]).To(Equal("public.parent_one"))
 			Expect(tables[0].Inherits[1]).To(Equal("public.parent_two"))
@@ -299,7 +290,7 @@ SET SUBPARTITION TEMPLATE  ` + `
 	})
 	Describe("PrintCreateViewStatements", func() {
 		It("creates a view with privileges and a comment (can't specify owner in GPDB5)", func() {
-			viewDef := backup.View{Oid: 1, Schema: "public", Name: "simpleview", Definition: "SELECT pg_roles.rolname FROM pg_roles;", DependsUpon: nil}
+			viewDef := backup.View{Oid: 1, Schema: "public", Name: "simpleview", Definition: "SELECT pg_roles.rolname FROM pg_roles;"}
 			viewMetadataMap := testutils.DefaultMetadataMap("VIEW", true, true, true)
 			viewMetadata := viewMetadataMap[1]
 
@@ -325,7 +316,7 @@ SET SUBPARTITION TEMPLATE  ` + `
 			sequenceMetadataMap backup.MetadataMap
 		)
 		BeforeEach(func() {
-			sequence = backup.Relation{SchemaOid: 0, Oid: 1, Schema: "public", Name: "my_sequence", DependsUpon: nil, Inherits: nil}
+			sequence = backup.Relation{SchemaOid: 0, Oid: 1, Schema: "public", Name: "my_sequence", Inherits: nil}
 			sequenceDef = backup.Sequence{Relation: sequence}
 			sequenceMetadataMap = backup.MetadataMap{}
 		})
@@ -399,7 +390,7 @@ SET SUBPARTITION TEMPLATE  ` + `
 			if connection.Version.AtLeast("6") {
 				startValue = 1
 			}
-			sequenceDef := backup.Sequence{Relation: backup.Relation{SchemaOid: 0, Oid: 1, Schema: "public", Name: "my_sequence", DependsUpon: nil, Inherits: nil}}
+			sequenceDef := backup.Sequence{Relation: backup.Relation{SchemaOid: 0, Oid: 1, Schema: "public", Name: "my_sequence", Inherits: nil}}
 			columnOwnerMap := map[string]string{"public.my_sequence": "public.sequence_table.a"}
 
 			sequenceDef.SequenceDefinition = backup.SequenceDefinition{Name: "my_sequence",
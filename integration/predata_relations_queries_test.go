@@ -1,8 +1,10 @@
 package integration
 
 import (
+	"fmt"
 	"sort"
 
+	"github.com/greenplum-db/gp-common-go-libs/dbconn"
 	"github.com/greenplum-db/gp-common-go-libs/structmatcher"
 	"github.com/greenplum-db/gp-common-go-libs/testhelper"
 	"github.com/greenplum-db/gpbackup/backup"
@@ -458,6 +460,68 @@ PARTITION BY RANGE (year)
 
 			Expect(distPolicies).To(Equal(`DISTRIBUTED BY ("group")`))
 		})
+		It("returns distribution policy info for a table DISTRIBUTED BY columns in a different order than the table", func() {
+			testhelper.AssertQueryRuns(connection, "CREATE TABLE dist_two(a int, b text) DISTRIBUTED BY (b, a)")
+			defer testhelper.AssertQueryRuns(connection, "DROP TABLE dist_two")
+			oid := testutils.OidFromObjectName(connection, "public", "dist_two", backup.TYPE_RELATION)
+
+			tables := []backup.Relation{{Oid: oid}}
+			distPolicies := backup.GetDistributionPolicies(connection, tables)[oid]
+
+			Expect(distPolicies).To(Equal("DISTRIBUTED BY (b, a)"))
+		})
+		It("returns distribution policy info for a table DISTRIBUTED REPLICATED", func() {
+			testutils.SkipIfBefore6(connection)
+			testhelper.AssertQueryRuns(connection, "CREATE TABLE dist_replicated(a int, b text) DISTRIBUTED REPLICATED")
+			defer testhelper.AssertQueryRuns(connection, "DROP TABLE dist_replicated")
+			oid := testutils.OidFromObjectName(connection, "public", "dist_replicated", backup.TYPE_RELATION)
+
+			tables := []backup.Relation{{Oid: oid}}
+			distPolicies := backup.GetDistributionPolicies(connection, tables)[oid]
+
+			Expect(distPolicies).To(Equal("DISTRIBUTED REPLICATED"))
+		})
+		It("returns distribution policy info for a table DISTRIBUTED BY a column with a non-default operator class", func() {
+			testutils.SkipIfBefore6(connection)
+			testhelper.AssertQueryRuns(connection, "CREATE TABLE dist_opclass(a int, b text) DISTRIBUTED BY (a cdbhash_int4_ops, b)")
+			defer testhelper.AssertQueryRuns(connection, "DROP TABLE dist_opclass")
+			oid := testutils.OidFromObjectName(connection, "public", "dist_opclass", backup.TYPE_RELATION)
+
+			tables := []backup.Relation{{Oid: oid}}
+			distPolicies := backup.GetDistributionPolicies(connection, tables)[oid]
+
+			Expect(distPolicies).To(Equal("DISTRIBUTED BY (a pg_catalog.cdbhash_int4_ops, b)"))
+		})
+	})
+	Describe("GetTableSizes", func() {
+		It("returns the size of a table", func() {
+			testhelper.AssertQueryRuns(connection, "CREATE TABLE size_table(i int) DISTRIBUTED BY (i)")
+			defer testhelper.AssertQueryRuns(connection, "DROP TABLE size_table")
+			testhelper.AssertQueryRuns(connection, "INSERT INTO size_table SELECT generate_series(1, 10000)")
+			oid := testutils.OidFromObjectName(connection, "public", "size_table", backup.TYPE_RELATION)
+			expectedSize := dbconn.MustSelectString(connection, "SELECT pg_relation_size('public.size_table'::regclass)::text AS string")
+
+			tables := []backup.Relation{{Oid: oid}}
+			tableSizes := backup.GetTableSizes(connection, tables)
+
+			Expect(fmt.Sprintf("%d", tableSizes[oid])).To(Equal(expectedSize))
+			Expect(tableSizes[oid]).To(BeNumerically(">", 0))
+		})
+		It("returns the size of all partitions for a partitioned table", func() {
+			testhelper.AssertQueryRuns(connection, `CREATE TABLE size_part(i int) DISTRIBUTED BY (i)
+PARTITION BY RANGE (i) (START (1) END (10001) EVERY (5000))`)
+			defer testhelper.AssertQueryRuns(connection, "DROP TABLE size_part")
+			testhelper.AssertQueryRuns(connection, "INSERT INTO size_part SELECT generate_series(1, 10000)")
+			oid := testutils.OidFromObjectName(connection, "public", "size_part", backup.TYPE_RELATION)
+			expectedSize := dbconn.MustSelectString(connection, `SELECT sum(pg_relation_size(r.parchildrelid))::text AS string
+FROM pg_partition_rule r JOIN pg_partition p ON r.paroid = p.oid WHERE p.parrelid = 'public.size_part'::regclass`)
+
+			tables := []backup.Relation{{Oid: oid}}
+			tableSizes := backup.GetTableSizes(connection, tables)
+
+			Expect(fmt.Sprintf("%d", tableSizes[oid])).To(Equal(expectedSize))
+			Expect(tableSizes[oid]).To(BeNumerically(">", 0))
+		})
 	})
 	Describe("GetPartitionDefinitions", func() {
 		It("returns empty string when no partition exists", func() {
@@ -709,7 +773,7 @@ SET SUBPARTITION TEMPLATE
 
 			results := backup.GetViews(connection)
 
-			viewDef := backup.View{Oid: 1, Schema: "public", Name: "simpleview", Definition: "SELECT pg_roles.rolname FROM pg_roles;", DependsUpon: nil}
+			viewDef := backup.View{Oid: 1, Schema: "public", Name: "simpleview", Definition: "SELECT pg_roles.rolname FROM pg_roles;"}
 
 			Expect(len(results)).To(Equal(1))
 			structmatcher.ExpectStructsToMatchExcluding(&viewDef, &results[0], "Oid")
@@ -725,18 +789,18 @@ SET SUBPARTITION TEMPLATE
 
 			results := backup.GetViews(connection)
 
-			viewDef := backup.View{Oid: 1, Schema: "testschema", Name: "simpleview", Definition: "SELECT pg_roles.rolname FROM pg_roles;", DependsUpon: nil}
+			viewDef := backup.View{Oid: 1, Schema: "testschema", Name: "simpleview", Definition: "SELECT pg_roles.rolname FROM pg_roles;"}
 
 			Expect(len(results)).To(Equal(1))
 			structmatcher.ExpectStructsToMatchExcluding(&viewDef, &results[0], "Oid")
 		})
 	})
-	Describe("ConstructTableDependencies", func() {
+	Describe("GetTableInheritance", func() {
 		child := backup.BasicRelation("public", "child")
 		childOne := backup.BasicRelation("public", "child_one")
 		childTwo := backup.BasicRelation("public", "child_two")
 		tableDefs := map[uint32]backup.TableDefinition{}
-		It("constructs dependencies correctly if there is one table dependent on one table", func() {
+		It("records inheritance correctly if there is one table inheriting from one table", func() {
 			testhelper.AssertQueryRuns(connection, "CREATE TABLE parent(i int)")
 			defer testhelper.AssertQueryRuns(connection, "DROP TABLE parent")
 			testhelper.AssertQueryRuns(connection, "CREATE TABLE child() INHERITS (parent)")
@@ -745,15 +809,13 @@ SET SUBPARTITION TEMPLATE
 			child.Oid = testutils.OidFromObjectName(connection, "public", "child", backup.TYPE_RELATION)
 			tables := []backup.Relation{child}
 
-			tables = backup.ConstructTableDependencies(connection, tables, tableDefs, false)
+			tables = backup.GetTableInheritance(connection, tables, tableDefs)
 
 			Expect(len(tables)).To(Equal(1))
-			Expect(len(tables[0].DependsUpon)).To(Equal(1))
-			Expect(tables[0].DependsUpon[0]).To(Equal("public.parent"))
 			Expect(len(tables[0].Inherits)).To(Equal(1))
 			Expect(tables[0].Inherits[0]).To(Equal("public.parent"))
 		})
-		It("constructs dependencies correctly if there are two tables dependent on one table", func() {
+		It("records inheritance correctly if there are two tables inheriting from one table", func() {
 			testhelper.AssertQueryRuns(connection, "CREATE TABLE parent(i int)")
 			defer testhelper.AssertQueryRuns(connection, "DROP TABLE parent")
 			testhelper.AssertQueryRuns(connection, "CREATE TABLE child_one() INHERITS (parent)")
@@ -765,19 +827,15 @@ SET SUBPARTITION TEMPLATE
 			childTwo.Oid = testutils.OidFromObjectName(connection, "public", "child_two", backup.TYPE_RELATION)
 			tables := []backup.Relation{childOne, childTwo}
 
-			tables = backup.ConstructTableDependencies(connection, tables, tableDefs, false)
+			tables = backup.GetTableInheritance(connection, tables, tableDefs)
 
 			Expect(len(tables)).To(Equal(2))
-			Expect(len(tables[0].DependsUpon)).To(Equal(1))
-			Expect(tables[0].DependsUpon[0]).To(Equal("public.parent"))
 			Expect(len(tables[0].Inherits)).To(Equal(1))
 			Expect(tables[0].Inherits[0]).To(Equal("public.parent"))
-			Expect(len(tables[1].DependsUpon)).To(Equal(1))
-			Expect(tables[1].DependsUpon[0]).To(Equal("public.parent"))
 			Expect(len(tables[1].Inherits)).To(Equal(1))
 			Expect(tables[1].Inherits[0]).To(Equal("public.parent"))
 		})
-		It("constructs dependencies correctly if there is one table dependent on two tables", func() {
+		It("records inheritance correctly if there is one table inheriting from two tables", func() {
 			testhelper.AssertQueryRuns(connection, "CREATE TABLE parent_one(i int)")
 			defer testhelper.AssertQueryRuns(connection, "DROP TABLE parent_one")
 			testhelper.AssertQueryRuns(connection, "CREATE TABLE parent_two(j int)")
@@ -788,24 +846,20 @@ SET SUBPARTITION TEMPLATE
 			child.Oid = testutils.OidFromObjectName(connection, "public", "child", backup.TYPE_RELATION)
 			tables := []backup.Relation{child}
 
-			tables = backup.ConstructTableDependencies(connection, tables, tableDefs, false)
+			tables = backup.GetTableInheritance(connection, tables, tableDefs)
 
-			sort.Strings(tables[0].DependsUpon)
 			sort.Strings(tables[0].Inherits)
 			Expect(len(tables)).To(Equal(1))
-			Expect(len(tables[0].DependsUpon)).To(Equal(2))
-			Expect(tables[0].DependsUpon[0]).To(Equal("public.parent_one"))
-			Expect(tables[0].DependsUpon[1]).To(Equal("public.parent_two"))
 			Expect(len(tables[0].Inherits)).To(Equal(2))
 			Expect(tables[0].Inherits[0]).To(Equal("public.parent_one"))
 			Expect(tables[0].Inherits[1]).To(Equal("public.parent_two"))
 		})
-		It("constructs dependencies correctly if there are no table dependencies", func() {
+		It("records inheritance correctly if there are no tables", func() {
 			tables := []backup.Relation{}
-			tables = backup.ConstructTableDependencies(connection, tables, tableDefs, false)
+			tables = backup.GetTableInheritance(connection, tables, tableDefs)
 			Expect(len(tables)).To(Equal(0))
 		})
-		It("constructs dependencies correctly if there are two dependent tables but one is not in the backup set", func() {
+		It("records inheritance on a parent table that is not in the backup set", func() {
 			testhelper.AssertQueryRuns(connection, "CREATE TABLE parent(i int)")
 			defer testhelper.AssertQueryRuns(connection, "DROP TABLE parent")
 			testhelper.AssertQueryRuns(connection, "CREATE TABLE child_one() INHERITS (parent)")
@@ -816,14 +870,13 @@ SET SUBPARTITION TEMPLATE
 			childOne.Oid = testutils.OidFromObjectName(connection, "public", "child_one", backup.TYPE_RELATION)
 			tables := []backup.Relation{childOne}
 
-			tables = backup.ConstructTableDependencies(connection, tables, tableDefs, true)
+			tables = backup.GetTableInheritance(connection, tables, tableDefs)
 
 			Expect(len(tables)).To(Equal(1))
-			Expect(len(tables[0].DependsUpon)).To(Equal(0))
 			Expect(len(tables[0].Inherits)).To(Equal(1))
 			Expect(tables[0].Inherits[0]).To(Equal("public.parent"))
 		})
-		It("does not record a dependency of an external leaf partition on a parent table", func() {
+		It("does not record inheritance of an external leaf partition on a parent table", func() {
 			testhelper.AssertQueryRuns(connection, `CREATE TABLE partition_table (id int, gender char(1))
 DISTRIBUTED BY (id)
 PARTITION BY LIST (gender)
@@ -842,32 +895,10 @@ FORMAT 'csv';`)
 			tables := []backup.Relation{partition}
 			partTableDefs := map[uint32]backup.TableDefinition{partition.Oid: {IsExternal: true, PartitionType: "l"}}
 
-			tables = backup.ConstructTableDependencies(connection, tables, partTableDefs, false)
+			tables = backup.GetTableInheritance(connection, tables, partTableDefs)
 
 			Expect(len(tables)).To(Equal(1))
-			Expect(len(tables[0].DependsUpon)).To(Equal(0))
 			Expect(len(tables[0].Inherits)).To(Equal(0))
 		})
 	})
-	Describe("ConstructViewDependencies", func() {
-		It("constructs dependencies correctly for a view that depends on two other views", func() {
-			testhelper.AssertQueryRuns(connection, "CREATE VIEW parent1 AS SELECT relname FROM pg_class")
-			defer testhelper.AssertQueryRuns(connection, "DROP VIEW parent1")
-			testhelper.AssertQueryRuns(connection, "CREATE VIEW parent2 AS SELECT relname FROM pg_class")
-			defer testhelper.AssertQueryRuns(connection, "DROP VIEW parent2")
-			testhelper.AssertQueryRuns(connection, "CREATE VIEW child AS (SELECT * FROM parent1 UNION SELECT * FROM parent2)")
-			defer testhelper.AssertQueryRuns(connection, "DROP VIEW child")
-
-			childView := backup.View{}
-			childView.Oid = testutils.OidFromObjectName(connection, "public", "child", backup.TYPE_RELATION)
-			views := []backup.View{childView}
-
-			views = backup.ConstructViewDependencies(connection, views)
-
-			Expect(len(views)).To(Equal(1))
-			Expect(len(views[0].DependsUpon)).To(Equal(2))
-			Expect(views[0].DependsUpon[0]).To(Equal("public.parent1"))
-			Expect(views[0].DependsUpon[1]).To(Equal("public.parent2"))
-		})
-	})
 })
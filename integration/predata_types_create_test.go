@@ -122,4 +122,25 @@ var _ = Describe("backup integration create statement tests", func() {
 			structmatcher.ExpectStructsToMatchIncluding(&domainType, &resultTypes[0], "Schema", "Name", "Type", "DefaultVal", "BaseType", "NotNull")
 		})
 	})
+	Describe("PrintCreateCollationStatements", func() {
+		It("creates a collation with an owner and a comment", func() {
+			testutils.SkipIfBefore6(connection)
+			collations := []backup.Collation{{Oid: 1, Schema: "public", Name: "testcollation", Collate: "POSIX", Ctype: "POSIX"}}
+			collationMetadataMap := testutils.DefaultMetadataMap("COLLATION", false, true, true)
+			collationMetadata := collationMetadataMap[1]
+			backup.PrintCreateCollationStatements(backupfile, toc, collations, collationMetadataMap)
+
+			testhelper.AssertQueryRuns(connection, buffer.String())
+			defer testhelper.AssertQueryRuns(connection, "DROP COLLATION public.testcollation")
+
+			resultCollations := backup.GetCollations(connection)
+			resultMetadataMap := backup.GetMetadataForObjectType(connection, backup.TYPE_COLLATION)
+			collations[0].Oid = testutils.OidFromObjectName(connection, "public", "testcollation", backup.TYPE_COLLATION)
+			resultMetadata := resultMetadataMap[collations[0].Oid]
+
+			Expect(len(resultCollations)).To(Equal(1))
+			structmatcher.ExpectStructsToMatch(&collations[0], &resultCollations[0])
+			structmatcher.ExpectStructsToMatch(&collationMetadata, &resultMetadata)
+		})
+	})
 })
@@ -1,8 +1,6 @@
 package integration
 
 import (
-	"sort"
-
 	"github.com/greenplum-db/gp-common-go-libs/structmatcher"
 	"github.com/greenplum-db/gp-common-go-libs/testhelper"
 	"github.com/greenplum-db/gpbackup/backup"
@@ -160,150 +158,36 @@ var _ = Describe("backup integration tests", func() {
 			structmatcher.ExpectStructsToMatchIncluding(&shellTypeOtherSchema, &results[0], "Schema", "Name", "Type")
 		})
 	})
-	Describe("ConstructCompositeTypeDependencies", func() {
-		BeforeEach(func() {
-			testhelper.AssertQueryRuns(connection, "CREATE FUNCTION base_fn_in(cstring) RETURNS base_type AS 'boolin' LANGUAGE internal")
-			testhelper.AssertQueryRuns(connection, "CREATE FUNCTION base_fn_out(base_type) RETURNS cstring AS 'boolout' LANGUAGE internal")
-			testhelper.AssertQueryRuns(connection, "CREATE FUNCTION base_fn_in2(cstring) RETURNS base_type2 AS 'boolin' LANGUAGE internal")
-			testhelper.AssertQueryRuns(connection, "CREATE FUNCTION base_fn_out2(base_type2) RETURNS cstring AS 'boolout' LANGUAGE internal")
-			testhelper.AssertQueryRuns(connection, "CREATE TYPE base_type(INPUT=base_fn_in, OUTPUT=base_fn_out)")
-			testhelper.AssertQueryRuns(connection, "CREATE TYPE base_type2(INPUT=base_fn_in2, OUTPUT=base_fn_out2)")
-		})
-		AfterEach(func() {
-			testhelper.AssertQueryRuns(connection, "DROP TYPE base_type CASCADE")
-			testhelper.AssertQueryRuns(connection, "DROP TYPE base_type2 CASCADE")
-		})
-		It("constructs dependencies correctly for a composite type dependent on one user-defined type", func() {
-			testhelper.AssertQueryRuns(connection, "CREATE TYPE comp_type AS (base base_type, builtin integer)")
-			defer testhelper.AssertQueryRuns(connection, "DROP TYPE comp_type")
-
-			composites := backup.GetCompositeTypes(connection)
-			compTypes := backup.ConstructCompositeTypeDependencies(connection, composites)
-
-			Expect(len(compTypes)).To(Equal(1))
-			Expect(len(compTypes[0].DependsUpon)).To(Equal(1))
-			Expect(compTypes[0].DependsUpon[0]).To(Equal("public.base_type"))
-		})
-		It("constructs dependencies correctly for a composite type dependent on multiple user-defined types", func() {
-			testhelper.AssertQueryRuns(connection, "CREATE TYPE comp_type AS (base base_type, base2 base_type2)")
-			defer testhelper.AssertQueryRuns(connection, "DROP TYPE comp_type")
-
-			composites := backup.GetCompositeTypes(connection)
-			compTypes := backup.ConstructCompositeTypeDependencies(connection, composites)
-
-			Expect(len(compTypes)).To(Equal(1))
-			Expect(len(compTypes[0].DependsUpon)).To(Equal(2))
-			sort.Strings(compTypes[0].DependsUpon)
-			Expect(compTypes[0].DependsUpon).To(Equal([]string{"public.base_type", "public.base_type2"}))
-		})
-		It("constructs dependencies correctly for a composite type dependent on the same user-defined type multiple times", func() {
-			testhelper.AssertQueryRuns(connection, "CREATE TYPE comp_type AS (base base_type, base2 base_type)")
-			defer testhelper.AssertQueryRuns(connection, "DROP TYPE comp_type")
-
-			composites := backup.GetCompositeTypes(connection)
-			compTypes := backup.ConstructCompositeTypeDependencies(connection, composites)
-
-			Expect(len(compTypes)).To(Equal(1))
-			Expect(len(compTypes[0].DependsUpon)).To(Equal(1))
-			Expect(compTypes[0].DependsUpon[0]).To(Equal("public.base_type"))
-		})
-	})
-	Describe("ConstructBaseTypeDependencies4", func() {
-		funcInfoMap := map[uint32]backup.FunctionInfo{}
-		BeforeEach(func() {
-			testutils.SkipIfNot4(connection)
-			testhelper.AssertQueryRuns(connection, "CREATE FUNCTION base_fn_in(cstring) RETURNS base_type AS 'boolin' LANGUAGE internal")
-			testhelper.AssertQueryRuns(connection, "CREATE FUNCTION base_fn_out(base_type) RETURNS cstring AS 'boolout' LANGUAGE internal")
-			inOid := testutils.OidFromObjectName(connection, "public", "base_fn_in", backup.TYPE_FUNCTION)
-			outOid := testutils.OidFromObjectName(connection, "public", "base_fn_out", backup.TYPE_FUNCTION)
-			funcInfoMap[inOid] = backup.FunctionInfo{QualifiedName: "public.base_fn_in", Arguments: "cstring"}
-			funcInfoMap[outOid] = backup.FunctionInfo{QualifiedName: "public.base_fn_out", Arguments: "base_type"}
-		})
-		AfterEach(func() {
-			testhelper.AssertQueryRuns(connection, "DROP TYPE base_type CASCADE")
-		})
-		It("constructs dependencies on user-defined functions", func() {
-			testhelper.AssertQueryRuns(connection, "CREATE TYPE base_type(INPUT=base_fn_in, OUTPUT=base_fn_out)")
-
-			bases := backup.GetBaseTypes(connection)
-			baseTypes := backup.ConstructBaseTypeDependencies4(connection, bases, funcInfoMap)
-
-			Expect(len(baseTypes)).To(Equal(1))
-			Expect(len(baseTypes[0].DependsUpon)).To(Equal(2))
-			sort.Strings(baseTypes[0].DependsUpon)
-			Expect(baseTypes[0].DependsUpon[0]).To(Equal("public.base_fn_in(cstring)"))
-			Expect(baseTypes[0].DependsUpon[1]).To(Equal("public.base_fn_out(base_type)"))
-		})
-		It("doesn't construct dependencies on built-in functions", func() {
-			testhelper.AssertQueryRuns(connection, "CREATE TYPE base_type(INPUT=base_fn_in, OUTPUT=base_fn_out, TYPMOD_IN=numerictypmodin, TYPMOD_OUT=numerictypmodout)")
-
-			bases := backup.GetBaseTypes(connection)
-			baseTypes := backup.ConstructBaseTypeDependencies4(connection, bases, funcInfoMap)
-
-			Expect(len(baseTypes)).To(Equal(1))
-			Expect(len(baseTypes[0].DependsUpon)).To(Equal(2))
-			sort.Strings(baseTypes[0].DependsUpon)
-			Expect(baseTypes[0].DependsUpon[0]).To(Equal("public.base_fn_in(cstring)"))
-			Expect(baseTypes[0].DependsUpon[1]).To(Equal("public.base_fn_out(base_type)"))
-		})
-	})
-	Describe("ConstructBaseTypeDependencies5", func() {
+	Describe("GetCollations", func() {
 		BeforeEach(func() {
-			testutils.SkipIfBefore5(connection)
-			testhelper.AssertQueryRuns(connection, "CREATE FUNCTION base_fn_in(cstring) RETURNS base_type AS 'boolin' LANGUAGE internal")
-			testhelper.AssertQueryRuns(connection, "CREATE FUNCTION base_fn_out(base_type) RETURNS cstring AS 'boolout' LANGUAGE internal")
+			testutils.SkipIfBefore6(connection)
 		})
-		AfterEach(func() {
-			testhelper.AssertQueryRuns(connection, "DROP TYPE base_type CASCADE")
-		})
-		It("constructs dependencies on user-defined functions", func() {
-			testhelper.AssertQueryRuns(connection, "CREATE TYPE base_type(INPUT=base_fn_in, OUTPUT=base_fn_out)")
-
-			bases := backup.GetBaseTypes(connection)
-			baseTypes := backup.ConstructBaseTypeDependencies5(connection, bases)
+		It("returns a slice of collations", func() {
+			testhelper.AssertQueryRuns(connection, `CREATE COLLATION some_coll (lc_collate = 'POSIX', lc_ctype = 'POSIX')`)
+			defer testhelper.AssertQueryRuns(connection, "DROP COLLATION some_coll")
 
-			Expect(len(baseTypes)).To(Equal(1))
-			Expect(len(baseTypes[0].DependsUpon)).To(Equal(2))
-			sort.Strings(baseTypes[0].DependsUpon)
-			Expect(baseTypes[0].DependsUpon[0]).To(Equal("public.base_fn_in(cstring)"))
-			Expect(baseTypes[0].DependsUpon[1]).To(Equal("public.base_fn_out(base_type)"))
-		})
-		It("doesn't construct dependencies on built-in functions", func() {
-			testhelper.AssertQueryRuns(connection, "CREATE TYPE base_type(INPUT=base_fn_in, OUTPUT=base_fn_out, TYPMOD_IN=numerictypmodin, TYPMOD_OUT=numerictypmodout)")
+			expectedCollation := backup.Collation{Oid: 0, Schema: "public", Name: "some_coll", Collate: "POSIX", Ctype: "POSIX"}
 
-			bases := backup.GetBaseTypes(connection)
-			baseTypes := backup.ConstructBaseTypeDependencies5(connection, bases)
+			resultCollations := backup.GetCollations(connection)
 
-			Expect(len(baseTypes)).To(Equal(1))
-			Expect(len(baseTypes[0].DependsUpon)).To(Equal(2))
-			sort.Strings(baseTypes[0].DependsUpon)
-			Expect(baseTypes[0].DependsUpon[0]).To(Equal("public.base_fn_in(cstring)"))
-			Expect(baseTypes[0].DependsUpon[1]).To(Equal("public.base_fn_out(base_type)"))
+			Expect(len(resultCollations)).To(Equal(1))
+			structmatcher.ExpectStructsToMatchExcluding(&expectedCollation, &resultCollations[0], "Oid")
 		})
-	})
-	Describe("ConstructDomainDependencies", func() {
-		It("constructs dependencies on user-defined types", func() {
-			testhelper.AssertQueryRuns(connection, "CREATE DOMAIN parent_domain AS integer")
-			defer testhelper.AssertQueryRuns(connection, "DROP DOMAIN parent_domain")
-			testhelper.AssertQueryRuns(connection, "CREATE DOMAIN domain_type AS parent_domain")
-			defer testhelper.AssertQueryRuns(connection, "DROP DOMAIN domain_type")
-
-			domains := backup.GetDomainTypes(connection)
-			domains = backup.ConstructDomainDependencies(connection, domains)
+		It("returns a slice of collations in a specific schema", func() {
+			testhelper.AssertQueryRuns(connection, `CREATE COLLATION some_coll (lc_collate = 'POSIX', lc_ctype = 'POSIX')`)
+			defer testhelper.AssertQueryRuns(connection, "DROP COLLATION some_coll")
+			testhelper.AssertQueryRuns(connection, "CREATE SCHEMA testschema")
+			defer testhelper.AssertQueryRuns(connection, "DROP SCHEMA testschema")
+			testhelper.AssertQueryRuns(connection, `CREATE COLLATION testschema.some_coll (lc_collate = 'POSIX', lc_ctype = 'POSIX')`)
+			defer testhelper.AssertQueryRuns(connection, "DROP COLLATION testschema.some_coll")
+			backup.SetIncludeSchemas([]string{"testschema"})
 
-			Expect(len(domains)).To(Equal(2))
-			Expect(len(domains[0].DependsUpon)).To(Equal(1))
-			Expect(domains[0].DependsUpon[0]).To(Equal("public.parent_domain"))
-		})
-		It("doesn't construct dependencies on built-in types", func() {
-			testhelper.AssertQueryRuns(connection, "CREATE DOMAIN parent_domain AS integer")
-			defer testhelper.AssertQueryRuns(connection, "DROP DOMAIN parent_domain")
+			expectedCollation := backup.Collation{Oid: 0, Schema: "testschema", Name: "some_coll", Collate: "POSIX", Ctype: "POSIX"}
 
-			domains := backup.GetDomainTypes(connection)
-			domains = backup.ConstructDomainDependencies(connection, domains)
+			resultCollations := backup.GetCollations(connection)
 
-			Expect(len(domains)).To(Equal(1))
-			Expect(domains[0].DependsUpon).To(BeNil())
+			Expect(len(resultCollations)).To(Equal(1))
+			structmatcher.ExpectStructsToMatchExcluding(&expectedCollation, &resultCollations[0], "Oid")
 		})
 	})
 })
@@ -0,0 +1,71 @@
+#!/bin/bash
+set -e
+
+# This plugin stores backup files in an object store over HTTP, such as the
+# in-process object store used by the tests in plugins/plugintest.  The
+# endpoint and bucket options must be set in the plugin config file.
+
+get_option(){
+  sed -n "s/^ *$2: *//p" $1 | tr -d "\"'"
+}
+
+object_url(){
+  echo "$(get_option $1 endpoint)/$(get_option $1 bucket)$2"
+}
+
+setup_plugin_for_backup(){
+  curl -sSf -o /dev/null "$(object_url $1)?prefix="
+}
+
+setup_plugin_for_restore(){
+  curl -sSf -o /dev/null "$(object_url $1)?prefix="
+}
+
+cleanup_plugin_for_backup(){
+  :
+}
+
+cleanup_plugin_for_restore(){
+  :
+}
+
+restore_file() {
+  mkdir -p `dirname "$2"`
+  curl -sSf -o "$2" "$(object_url $1 $2)"
+}
+
+backup_file() {
+  curl -sSf -T "$2" "$(object_url $1 $2)"
+}
+
+backup_data() {
+  curl -sSf -T - "$(object_url $1 $2)"
+}
+
+restore_data() {
+  curl -sSf "$(object_url $1 $2)"
+}
+
+restore_data_range() {
+  if [ $4 -gt 0 ]; then
+    curl -sSf -r "$3-$(($3+$4-1))" "$(object_url $1 $2)"
+  fi
+}
+
+plugin_api_version(){
+  echo "0.1.0"
+}
+
+plugin_config_schema(){
+  echo "options:"
+  echo "  endpoint:"
+  echo "    required: true"
+  echo "  bucket:"
+  echo "    required: true"
+}
+
+plugin_capabilities(){
+  echo "restore_data_range"
+}
+
+"$@"
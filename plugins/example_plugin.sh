@@ -38,8 +38,21 @@ restore_data() {
 	cat /tmp/plugin_dest/$filename
 }
 
+restore_data_range() {
+  filename=`basename "$2"`
+	tail -c +$(($3+1)) /tmp/plugin_dest/$filename | head -c $4
+}
+
 plugin_api_version(){
   echo "0.1.0"
 }
 
+plugin_config_schema(){
+  echo "options: {}"
+}
+
+plugin_capabilities(){
+  echo "restore_data_range"
+}
+
 "$@"
@@ -78,6 +78,20 @@ fi
 echo "[PASSED] backup_data"
 echo "[PASSED] restore_data"
 
+# ----------------------------------------------
+# Optional ranged restore function
+# ----------------------------------------------
+
+if $plugin plugin_capabilities 2>/dev/null | grep -q "^restore_data_range$"; then
+  echo "[RUNNING] restore_data_range"
+  output=`$plugin restore_data_range $plugin_config $testdata 10 20`
+  if [ "$output" != "${data:10:20}" ]; then
+    echo "Failed to restore range of data using plugin"
+    exit 1
+  fi
+  echo "[PASSED] restore_data_range"
+fi
+
 # ----------------------------------------------
 # Cleanup functions
 # ----------------------------------------------
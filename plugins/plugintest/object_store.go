@@ -0,0 +1,110 @@
+package plugintest
+
+/*
+ * This file contains an in-memory object store served over HTTP, so that
+ * plugins which back up to object storage can be tested without access to
+ * real storage.
+ */
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+ * Plugin config files used for testing can reference the object store's URL
+ * as ${GPBACKUP_PLUGIN_TEST_ENDPOINT}.
+ */
+const EndpointVariable = "GPBACKUP_PLUGIN_TEST_ENDPOINT"
+
+/*
+ * Objects are addressed path-style, as /bucket/key, and support PUT, GET,
+ * HEAD, and DELETE.  GET requests may include a Range header, and a GET on a
+ * bucket with a prefix query parameter lists matching keys one per line.
+ * Authorization headers are ignored.
+ */
+type ObjectStore struct {
+	URL     string
+	server  *httptest.Server
+	mutex   sync.Mutex
+	objects map[string][]byte
+}
+
+func NewObjectStore() *ObjectStore {
+	store := &ObjectStore{objects: make(map[string][]byte, 0)}
+	store.server = httptest.NewServer(store)
+	store.URL = store.server.URL
+	return store
+}
+
+func (store *ObjectStore) Close() {
+	store.server.Close()
+}
+
+func (store *ObjectStore) GetObject(path string) ([]byte, bool) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	contents, ok := store.objects[path]
+	return contents, ok
+}
+
+func (store *ObjectStore) PutObject(path string, contents []byte) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.objects[path] = contents
+}
+
+func (store *ObjectStore) ListObjects(prefix string) []string {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	paths := make([]string, 0)
+	for path := range store.objects {
+		if strings.HasPrefix(path, prefix) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (store *ObjectStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	switch r.Method {
+	case http.MethodPut:
+		contents, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		store.PutObject(path, contents)
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		if prefix, ok := r.URL.Query()["prefix"]; ok {
+			bucket := strings.TrimSuffix(path, "/") + "/"
+			for _, objectPath := range store.ListObjects(bucket + prefix[0]) {
+				fmt.Fprintln(w, strings.TrimPrefix(objectPath, bucket))
+			}
+			return
+		}
+		contents, ok := store.GetObject(path)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, path, time.Time{}, bytes.NewReader(contents))
+	case http.MethodDelete:
+		store.mutex.Lock()
+		delete(store.objects, path)
+		store.mutex.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, fmt.Sprintf("Method %s is not supported", r.Method), http.StatusMethodNotAllowed)
+	}
+}
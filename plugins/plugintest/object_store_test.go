@@ -0,0 +1,66 @@
+package plugintest_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/greenplum-db/gpbackup/plugins/plugintest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("plugintest/object_store tests", func() {
+	var objectStore *plugintest.ObjectStore
+	BeforeEach(func() {
+		objectStore = plugintest.NewObjectStore()
+		objectStore.PutObject("/bucket/backups/file1", []byte("0123456789"))
+		objectStore.PutObject("/bucket/backups/file2", []byte("abc"))
+		objectStore.PutObject("/bucket/other/file3", []byte("def"))
+	})
+	AfterEach(func() {
+		objectStore.Close()
+	})
+	request := func(method string, path string, body []byte, header ...string) (int, string) {
+		req, _ := http.NewRequest(method, objectStore.URL+path, bytes.NewReader(body))
+		if len(header) == 2 {
+			req.Header.Set(header[0], header[1])
+		}
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close()
+		contents, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(contents)
+	}
+	It("stores an object with PUT", func() {
+		status, _ := request("PUT", "/bucket/backups/file4", []byte("new data"))
+		Expect(status).To(Equal(http.StatusOK))
+		contents, ok := objectStore.GetObject("/bucket/backups/file4")
+		Expect(ok).To(BeTrue())
+		Expect(string(contents)).To(Equal("new data"))
+	})
+	It("returns an object with GET", func() {
+		status, contents := request("GET", "/bucket/backups/file1", nil)
+		Expect(status).To(Equal(http.StatusOK))
+		Expect(contents).To(Equal("0123456789"))
+	})
+	It("returns part of an object with a ranged GET", func() {
+		status, contents := request("GET", "/bucket/backups/file1", nil, "Range", "bytes=2-5")
+		Expect(status).To(Equal(http.StatusPartialContent))
+		Expect(contents).To(Equal("2345"))
+	})
+	It("returns not found for a nonexistent object", func() {
+		status, _ := request("GET", "/bucket/backups/nonexistent", nil)
+		Expect(status).To(Equal(http.StatusNotFound))
+	})
+	It("lists objects in a bucket with a prefix", func() {
+		status, contents := request("GET", "/bucket?prefix=backups/", nil)
+		Expect(status).To(Equal(http.StatusOK))
+		Expect(contents).To(Equal("backups/file1\nbackups/file2\n"))
+	})
+	It("removes an object with DELETE", func() {
+		status, _ := request("DELETE", "/bucket/backups/file2", nil)
+		Expect(status).To(Equal(http.StatusNoContent))
+		Expect(objectStore.ListObjects("/bucket/")).To(Equal([]string{"/bucket/backups/file1", "/bucket/other/file3"}))
+	})
+})
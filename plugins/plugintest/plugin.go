@@ -0,0 +1,140 @@
+package plugintest
+
+/*
+ * This file contains functions to invoke each plugin API command in the same
+ * way that gpbackup, gprestore, and gpbackup_helper invoke it.
+ */
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/greenplum-db/gpbackup/utils"
+	"github.com/pkg/errors"
+)
+
+const SupportedAPIVersion = "0.1.0"
+
+type Plugin struct {
+	ExecutablePath string
+	ConfigPath     string
+}
+
+/*
+ * Reads the plugin config file and writes a copy with all secret references
+ * resolved to configDir, as gpbackup does on each host before invoking the
+ * plugin.
+ */
+func NewPlugin(configFile string, configDir string) *Plugin {
+	pluginConfig := utils.ReadPluginConfig(configFile)
+	pluginConfig.ResolveSecrets()
+	resolvedConfigFile := filepath.Join(configDir, filepath.Base(configFile))
+	pluginConfig.WriteToFile(resolvedConfigFile)
+	return &Plugin{ExecutablePath: pluginConfig.ExecutablePath, ConfigPath: resolvedConfigFile}
+}
+
+func (plugin *Plugin) run(stdin io.Reader, stdout io.Writer, command string, args ...string) error {
+	cmd := exec.Command(plugin.ExecutablePath, append([]string{command}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		return errors.Errorf("Plugin command %s %s failed: %v %s", command, strings.Join(args, " "), err, stderr.String())
+	}
+	return nil
+}
+
+/*
+ * Runs any plugin command and returns its output.  Plugins must exit with a
+ * non-zero status for commands they do not implement, as gpbackup relies on
+ * this to detect whether optional commands are supported.
+ */
+func (plugin *Plugin) Run(command string, args ...string) (string, error) {
+	var stdout bytes.Buffer
+	err := plugin.run(nil, &stdout, command, args...)
+	return stdout.String(), err
+}
+
+func (plugin *Plugin) APIVersion() (string, error) {
+	output, err := plugin.Run("plugin_api_version")
+	return strings.TrimSpace(output), err
+}
+
+func (plugin *Plugin) Capabilities() ([]string, error) {
+	output, err := plugin.Run("plugin_capabilities")
+	if err != nil {
+		return nil, err
+	}
+	capabilities := make([]string, 0)
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			capabilities = append(capabilities, line)
+		}
+	}
+	return capabilities, nil
+}
+
+func (plugin *Plugin) HasCapability(capability string) bool {
+	capabilities, err := plugin.Capabilities()
+	if err != nil {
+		return false
+	}
+	for _, pluginCapability := range capabilities {
+		if pluginCapability == capability {
+			return true
+		}
+	}
+	return false
+}
+
+func (plugin *Plugin) SetupForBackup(backupDir string) error {
+	return plugin.run(nil, nil, "setup_plugin_for_backup", plugin.ConfigPath, backupDir)
+}
+
+func (plugin *Plugin) SetupForRestore(backupDir string) error {
+	return plugin.run(nil, nil, "setup_plugin_for_restore", plugin.ConfigPath, backupDir)
+}
+
+func (plugin *Plugin) CleanupForBackup() error {
+	return plugin.run(nil, nil, "cleanup_plugin_for_backup")
+}
+
+func (plugin *Plugin) CleanupForRestore() error {
+	return plugin.run(nil, nil, "cleanup_plugin_for_restore")
+}
+
+func (plugin *Plugin) BackupFile(filename string) error {
+	return plugin.run(nil, nil, "backup_file", plugin.ConfigPath, filename)
+}
+
+func (plugin *Plugin) RestoreFile(filename string) error {
+	return plugin.run(nil, nil, "restore_file", plugin.ConfigPath, filename)
+}
+
+func (plugin *Plugin) BackupData(filename string, data io.Reader) error {
+	return plugin.run(data, nil, "backup_data", plugin.ConfigPath, filename)
+}
+
+func (plugin *Plugin) RestoreData(filename string, data io.Writer) error {
+	return plugin.run(nil, data, "restore_data", plugin.ConfigPath, filename)
+}
+
+func (plugin *Plugin) RestoreDataRange(filename string, offset int64, length int64, data io.Writer) error {
+	return plugin.run(nil, data, "restore_data_range", plugin.ConfigPath, filename, fmt.Sprintf("%d", offset), fmt.Sprintf("%d", length))
+}
+
+/*
+ * Returns a reader of size bytes of pseudorandom data, so that large streams
+ * can be backed up without holding them in memory.  Readers with the same
+ * seed return the same data.
+ */
+func NewDataReader(size int64, seed int64) io.Reader {
+	return io.LimitReader(rand.New(rand.NewSource(seed)), size)
+}
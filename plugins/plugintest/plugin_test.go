@@ -0,0 +1,137 @@
+package plugintest_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/greenplum-db/gpbackup/plugins/plugintest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("plugin conformance tests", func() {
+	Describe("plugin_api_version", func() {
+		It("reports the supported API version", func() {
+			version, err := plugin.APIVersion()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(version).To(Equal(plugintest.SupportedAPIVersion))
+		})
+	})
+	Describe("setup and cleanup", func() {
+		It("sets up and cleans up the plugin for backup", func() {
+			Expect(plugin.SetupForBackup(backupDir)).To(Succeed())
+			Expect(plugin.CleanupForBackup()).To(Succeed())
+		})
+		It("sets up and cleans up the plugin for restore", func() {
+			Expect(plugin.SetupForRestore(backupDir)).To(Succeed())
+			Expect(plugin.CleanupForRestore()).To(Succeed())
+		})
+	})
+	Describe("unknown commands", func() {
+		It("fails for a command the plugin does not implement", func() {
+			_, err := plugin.Run("nonexistent_command")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Describe("file and data commands", func() {
+		BeforeEach(func() {
+			Expect(plugin.SetupForBackup(backupDir)).To(Succeed())
+			Expect(plugin.SetupForRestore(backupDir)).To(Succeed())
+		})
+		AfterEach(func() {
+			Expect(plugin.CleanupForBackup()).To(Succeed())
+			Expect(plugin.CleanupForRestore()).To(Succeed())
+		})
+		Describe("backup_file and restore_file", func() {
+			It("restores a file that was backed up", func() {
+				filename := filepath.Join(backupDir, "gpbackup_20180101010101_report")
+				Expect(ioutil.WriteFile(filename, []byte("Backup Report\n"), 0644)).To(Succeed())
+				Expect(plugin.BackupFile(filename)).To(Succeed())
+				os.Remove(filename)
+				Expect(plugin.RestoreFile(filename)).To(Succeed())
+				contents, err := ioutil.ReadFile(filename)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(contents)).To(Equal("Backup Report\n"))
+			})
+			It("fails to restore a file that was not backed up", func() {
+				filename := filepath.Join(backupDir, "gpbackup_20180101010101_nonexistent")
+				Expect(plugin.RestoreFile(filename)).ToNot(Succeed())
+			})
+		})
+		Describe("backup_data and restore_data", func() {
+			It("restores data that was backed up", func() {
+				filename := filepath.Join(backupDir, "gpbackup_0_20180101010101_text")
+				Expect(plugin.BackupData(filename, bytes.NewBufferString("1,abc\n2,def\n"))).To(Succeed())
+				var restored bytes.Buffer
+				Expect(plugin.RestoreData(filename, &restored)).To(Succeed())
+				Expect(restored.String()).To(Equal("1,abc\n2,def\n"))
+			})
+			It("restores binary data that was backed up", func() {
+				filename := filepath.Join(backupDir, "gpbackup_0_20180101010101_binary")
+				data := make([]byte, 4096)
+				for i := range data {
+					data[i] = byte(i)
+				}
+				Expect(plugin.BackupData(filename, bytes.NewReader(data))).To(Succeed())
+				var restored bytes.Buffer
+				Expect(plugin.RestoreData(filename, &restored)).To(Succeed())
+				Expect(restored.Bytes()).To(Equal(data))
+			})
+			It("restores an empty stream that was backed up", func() {
+				filename := filepath.Join(backupDir, "gpbackup_0_20180101010101_empty")
+				Expect(plugin.BackupData(filename, bytes.NewReader([]byte{}))).To(Succeed())
+				var restored bytes.Buffer
+				Expect(plugin.RestoreData(filename, &restored)).To(Succeed())
+				Expect(restored.Len()).To(Equal(0))
+			})
+			It("restores a large stream that was backed up", func() {
+				filename := filepath.Join(backupDir, "gpbackup_0_20180101010101_large")
+				Expect(plugin.BackupData(filename, plugintest.NewDataReader(*dataSize, 1))).To(Succeed())
+				expectedHash := sha256.New()
+				numBytes, _ := io.Copy(expectedHash, plugintest.NewDataReader(*dataSize, 1))
+				Expect(numBytes).To(Equal(*dataSize))
+				restoredHash := sha256.New()
+				Expect(plugin.RestoreData(filename, restoredHash)).To(Succeed())
+				Expect(restoredHash.Sum(nil)).To(Equal(expectedHash.Sum(nil)))
+			})
+			It("fails to restore data that was not backed up", func() {
+				filename := filepath.Join(backupDir, "gpbackup_0_20180101010101_nonexistent")
+				Expect(plugin.RestoreData(filename, ioutil.Discard)).ToNot(Succeed())
+			})
+		})
+		Describe("restore_data_range", func() {
+			var filename string
+			BeforeEach(func() {
+				if !plugin.HasCapability("restore_data_range") {
+					Skip("Plugin does not support restore_data_range")
+				}
+				filename = filepath.Join(backupDir, "gpbackup_0_20180101010101_range")
+				Expect(plugin.BackupData(filename, bytes.NewBufferString("0123456789abcdefghij"))).To(Succeed())
+			})
+			It("restores a range at the start of the data", func() {
+				var restored bytes.Buffer
+				Expect(plugin.RestoreDataRange(filename, 0, 5, &restored)).To(Succeed())
+				Expect(restored.String()).To(Equal("01234"))
+			})
+			It("restores a range in the middle of the data", func() {
+				var restored bytes.Buffer
+				Expect(plugin.RestoreDataRange(filename, 8, 6, &restored)).To(Succeed())
+				Expect(restored.String()).To(Equal("89abcd"))
+			})
+			It("restores a range at the end of the data", func() {
+				var restored bytes.Buffer
+				Expect(plugin.RestoreDataRange(filename, 15, 5, &restored)).To(Succeed())
+				Expect(restored.String()).To(Equal("fghij"))
+			})
+			It("restores no data for an empty range", func() {
+				var restored bytes.Buffer
+				Expect(plugin.RestoreDataRange(filename, 10, 0, &restored)).To(Succeed())
+				Expect(restored.Len()).To(Equal(0))
+			})
+		})
+	})
+})
@@ -0,0 +1,64 @@
+package plugintest_test
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/greenplum-db/gp-common-go-libs/testhelper"
+	"github.com/greenplum-db/gpbackup/plugins/plugintest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+/*
+ * To test a plugin other than the example HTTP plugin, run
+ *   ginkgo plugins/plugintest -- -plugin_config /path/to/plugin_config.yaml
+ * The config file may reference ${GPBACKUP_PLUGIN_TEST_ENDPOINT} to use the
+ * in-process object store.
+ */
+var (
+	pluginConfigFile = flag.String("plugin_config", "", "The configuration file for the plugin to test; the example HTTP plugin is tested if none is given")
+	dataSize         = flag.Int64("data_size", 64*1024*1024, "The size in bytes of the large data stream to back up and restore")
+)
+
+var (
+	plugin    *plugintest.Plugin
+	store     *plugintest.ObjectStore
+	tempDir   string
+	backupDir string
+)
+
+func TestPlugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "plugin tests")
+}
+
+var _ = BeforeSuite(func() {
+	testhelper.SetupTestLogger()
+	store = plugintest.NewObjectStore()
+	os.Setenv(plugintest.EndpointVariable, store.URL)
+	tempDir, _ = ioutil.TempDir("", "plugin_test")
+
+	configFile := *pluginConfigFile
+	if configFile == "" {
+		executablePath, _ := filepath.Abs("../example_http_plugin.sh")
+		configFile = filepath.Join(tempDir, "example_http_plugin_config.yaml")
+		configContents := fmt.Sprintf("executablepath: %s\noptions:\n  endpoint: ${%s}\n  bucket: gpbackup_plugin_test\n", executablePath, plugintest.EndpointVariable)
+		Expect(ioutil.WriteFile(configFile, []byte(configContents), 0644)).To(Succeed())
+	}
+	configDir := filepath.Join(tempDir, "config")
+	Expect(os.Mkdir(configDir, 0700)).To(Succeed())
+	plugin = plugintest.NewPlugin(configFile, configDir)
+
+	backupDir = filepath.Join(tempDir, "backups", "20180101", "20180101010101")
+	Expect(os.MkdirAll(backupDir, 0755)).To(Succeed())
+})
+
+var _ = AfterSuite(func() {
+	store.Close()
+	os.RemoveAll(tempDir)
+})
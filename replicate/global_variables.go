@@ -0,0 +1,75 @@
+package replicate
+
+import (
+	"sync"
+
+	"github.com/greenplum-db/gp-common-go-libs/cluster"
+	"github.com/greenplum-db/gp-common-go-libs/dbconn"
+	"github.com/greenplum-db/gpbackup/utils"
+)
+
+/*
+ * This file contains global variables and setter functions for those variables
+ * used in testing.
+ */
+
+/*
+ * Non-flag variables
+ */
+
+var (
+	backupConfig       *utils.BackupConfig
+	connection         *dbconn.DBConn
+	destPluginConfig   *utils.PluginConfig
+	globalCluster      cluster.Cluster
+	globalFPInfo       utils.FilePathInfo
+	sourcePluginConfig *utils.PluginConfig
+	version            string
+	wasTerminated      bool
+
+	/*
+	 * Used for synchronizing DoCleanup.  In DoInit() we increment the group
+	 * and then wait for at least one DoCleanup to finish, either in DoTeardown
+	 * or the signal handler.
+	 */
+	CleanupGroup *sync.WaitGroup
+)
+
+/*
+ * Command-line flags
+ */
+
+var (
+	backupDir              *string
+	debug                  *bool
+	pluginConfigFile       *string
+	printVersion           *bool
+	quiet                  *bool
+	sourcePluginConfigFile *string
+	timestamp              *string
+	verbose                *bool
+)
+
+/*
+ * Setter functions
+ */
+
+func SetBackupConfig(config *utils.BackupConfig) {
+	backupConfig = config
+}
+
+func SetCluster(cluster cluster.Cluster) {
+	globalCluster = cluster
+}
+
+func SetDestPluginConfig(config *utils.PluginConfig) {
+	destPluginConfig = config
+}
+
+func SetFPInfo(fpInfo utils.FilePathInfo) {
+	globalFPInfo = fpInfo
+}
+
+func SetSourcePluginConfig(config *utils.PluginConfig) {
+	sourcePluginConfig = config
+}
@@ -0,0 +1,110 @@
+package replicate
+
+/*
+ * This file contains functions that copy backup files between locations,
+ * either locally on the master or remotely on all hosts over SSH.
+ */
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/greenplum-db/gp-common-go-libs/cluster"
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"github.com/greenplum-db/gpbackup/utils"
+)
+
+/*
+ * The config file is not included here, as it is updated to record the new
+ * copy before it is copied in CopyConfigFile.
+ */
+func GetBackupFilesForContent(contentID int) []string {
+	if contentID == -1 {
+		backupFiles := []string{globalFPInfo.GetMetadataFilePath(), globalFPInfo.GetTOCFilePath(), globalFPInfo.GetBackupReportFilePath()}
+		if backupConfig.WithStatistics {
+			backupFiles = append(backupFiles, globalFPInfo.GetStatisticsFilePath())
+		}
+		if backupConfig.ObjectDefinitions != "" {
+			backupFiles = append(backupFiles, globalFPInfo.GetObjectDefinitionsFilePath(backupConfig.ObjectDefinitions))
+		}
+		return backupFiles
+	}
+	if backupConfig.MetadataOnly {
+		return []string{}
+	}
+	dataFile := globalFPInfo.GetTableBackupFilePath(contentID, 0, true)
+	tocFile := globalFPInfo.GetSegmentTOCFilePath(globalFPInfo.GetDirForContent(contentID), fmt.Sprintf("%d", contentID))
+	return []string{dataFile, tocFile}
+}
+
+func GetSourceReadCommand(filename string) string {
+	if sourcePluginConfig != nil {
+		return fmt.Sprintf("%s restore_data %s %s", sourcePluginConfig.ExecutablePath, sourcePluginConfig.ConfigPath, filename)
+	}
+	return fmt.Sprintf("cat %s", filename)
+}
+
+/*
+ * Files are streamed to the destination with backup_data rather than uploaded
+ * with backup_file, as plugins may remove the local file after backup_file
+ * succeeds.  The copy is then read back and its checksum and size, as given
+ * by cksum, are compared against those of the source.
+ */
+func GetCopyFileCommand(readCommand string, filename string) string {
+	writeCommand := fmt.Sprintf("%s backup_data %s %s", destPluginConfig.ExecutablePath, destPluginConfig.ConfigPath, filename)
+	verifyCommand := fmt.Sprintf("%s restore_data %s %s", destPluginConfig.ExecutablePath, destPluginConfig.ConfigPath, filename)
+	return fmt.Sprintf(`%s | %s && test "$(%s | cksum)" = "$(%s | cksum)" || { echo "Failed to copy %s"; exit 1; }`, readCommand, writeCommand, readCommand, verifyCommand, filename)
+}
+
+func GetCopyCommandForContent(contentID int) string {
+	commands := []string{"set -o pipefail"}
+	for _, filename := range GetBackupFilesForContent(contentID) {
+		commands = append(commands, GetCopyFileCommand(GetSourceReadCommand(filename), filename))
+	}
+	return strings.Join(commands, "; ")
+}
+
+func CopyBackupFilesOnAllHosts() {
+	remoteOutput := globalCluster.GenerateAndExecuteCommand("Copying backup files to plugin destination", func(contentID int) string {
+		return GetCopyCommandForContent(contentID)
+	}, cluster.ON_SEGMENTS_AND_MASTER)
+	globalCluster.CheckClusterError(remoteOutput, "Unable to copy backup files to plugin destination", func(contentID int) string {
+		return fmt.Sprintf("Unable to copy backup files for segment %d", contentID)
+	})
+}
+
+/*
+ * The copy is recorded in the config file both at the source and at the
+ * destination.  The destination's config file names the destination plugin,
+ * so that gprestore can restore from the copy with --plugin-config.
+ */
+func CopyConfigFile() {
+	configFilename := globalFPInfo.GetConfigFilePath()
+	backupConfig.Copies = append(backupConfig.Copies, utils.BackupCopy{
+		Plugin:       destPluginConfig.ExecutablePath,
+		PluginConfig: *pluginConfigFile,
+		Timestamp:    utils.CurrentTimestamp(),
+	})
+
+	destConfig := *backupConfig
+	destConfig.Plugin = destPluginConfig.ExecutablePath
+	utils.UpdateConfigFile(configFilename, &destConfig)
+	copyCommand := fmt.Sprintf("set -o pipefail; %s", GetCopyFileCommand(fmt.Sprintf("cat %s", configFilename), configFilename))
+	output, err := globalCluster.ExecuteLocalCommand(copyCommand)
+	if err != nil {
+		gplog.Fatal(err, "Unable to copy config file to plugin destination: %s", output)
+	}
+
+	utils.UpdateConfigFile(configFilename, backupConfig)
+	if sourcePluginConfig != nil {
+		output, err := globalCluster.ExecuteLocalCommand(fmt.Sprintf("%s backup_data %s %s < %s", sourcePluginConfig.ExecutablePath, sourcePluginConfig.ConfigPath, configFilename, configFilename))
+		if err != nil {
+			gplog.Fatal(err, "Unable to update config file at plugin source: %s", output)
+		}
+		err = os.Remove(configFilename)
+		if err != nil {
+			gplog.Warn("Failed to remove config file %s", configFilename)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package replicate_test
+
+import (
+	"github.com/greenplum-db/gp-common-go-libs/cluster"
+	"github.com/greenplum-db/gp-common-go-libs/testhelper"
+	"github.com/greenplum-db/gpbackup/replicate"
+	"github.com/greenplum-db/gpbackup/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("replicate/remote tests", func() {
+	masterSeg := cluster.SegConfig{ContentID: -1, Hostname: "localhost", DataDir: "/data/gpseg-1"}
+	localSegOne := cluster.SegConfig{ContentID: 0, Hostname: "localhost", DataDir: "/data/gpseg0"}
+	remoteSegOne := cluster.SegConfig{ContentID: 1, Hostname: "remotehost1", DataDir: "/data/gpseg1"}
+	var backupConfig *utils.BackupConfig
+	BeforeEach(func() {
+		testCluster := cluster.NewCluster([]cluster.SegConfig{masterSeg, localSegOne, remoteSegOne})
+		replicate.SetCluster(testCluster)
+		replicate.SetFPInfo(utils.NewFilePathInfo(testCluster.SegDirMap, "", "20170101010101", "gpseg"))
+		backupConfig = &utils.BackupConfig{SingleDataFile: true}
+		replicate.SetBackupConfig(backupConfig)
+		replicate.SetDestPluginConfig(&utils.PluginConfig{ExecutablePath: "/dest_plugin", ConfigPath: "/tmp/dest_config.yaml"})
+		replicate.SetSourcePluginConfig(nil)
+		utils.SetCompressionParameters(false, utils.Compression{})
+	})
+	Describe("GetBackupFilesForContent", func() {
+		It("returns the metadata files for the master", func() {
+			Expect(replicate.GetBackupFilesForContent(-1)).To(Equal([]string{
+				"/data/gpseg-1/backups/20170101/20170101010101/gpbackup_20170101010101_metadata.sql",
+				"/data/gpseg-1/backups/20170101/20170101010101/gpbackup_20170101010101_toc.yaml",
+				"/data/gpseg-1/backups/20170101/20170101010101/gpbackup_20170101010101_report",
+			}))
+		})
+		It("includes the statistics file for the master if the backup has statistics", func() {
+			backupConfig.WithStatistics = true
+			Expect(replicate.GetBackupFilesForContent(-1)).To(ContainElement(
+				"/data/gpseg-1/backups/20170101/20170101010101/gpbackup_20170101010101_statistics.sql"))
+		})
+		It("includes the object definitions file for the master if the backup has one", func() {
+			backupConfig.ObjectDefinitions = "yaml"
+			Expect(replicate.GetBackupFilesForContent(-1)).To(ContainElement(
+				"/data/gpseg-1/backups/20170101/20170101010101/gpbackup_20170101010101_definitions.yaml"))
+		})
+		It("returns the data file and segment TOC for a segment", func() {
+			Expect(replicate.GetBackupFilesForContent(1)).To(Equal([]string{
+				"/data/gpseg1/backups/20170101/20170101010101/gpbackup_1_20170101010101",
+				"/data/gpseg1/backups/20170101/20170101010101/gpbackup_1_20170101010101_toc.yaml",
+			}))
+		})
+		It("returns no files for a segment in a metadata-only backup", func() {
+			backupConfig.MetadataOnly = true
+			Expect(replicate.GetBackupFilesForContent(1)).To(BeEmpty())
+		})
+	})
+	Describe("GetCopyFileCommand", func() {
+		It("streams a file to the destination plugin and verifies its checksum", func() {
+			command := replicate.GetCopyFileCommand("cat /data/file", "/data/file")
+			Expect(command).To(Equal(`cat /data/file | /dest_plugin backup_data /tmp/dest_config.yaml /data/file && test "$(cat /data/file | cksum)" = "$(/dest_plugin restore_data /tmp/dest_config.yaml /data/file | cksum)" || { echo "Failed to copy /data/file"; exit 1; }`))
+		})
+	})
+	Describe("GetSourceReadCommand", func() {
+		It("reads a local file if there is no source plugin", func() {
+			Expect(replicate.GetSourceReadCommand("/data/file")).To(Equal("cat /data/file"))
+		})
+		It("reads a file from the source plugin if there is one", func() {
+			replicate.SetSourcePluginConfig(&utils.PluginConfig{ExecutablePath: "/source_plugin", ConfigPath: "/tmp/source_config.yaml"})
+			Expect(replicate.GetSourceReadCommand("/data/file")).To(Equal("/source_plugin restore_data /tmp/source_config.yaml /data/file"))
+		})
+	})
+	Describe("GetCopyCommandForContent", func() {
+		It("only sets pipefail for a segment in a metadata-only backup", func() {
+			backupConfig.MetadataOnly = true
+			Expect(replicate.GetCopyCommandForContent(0)).To(Equal("set -o pipefail"))
+		})
+		It("copies each file for a segment", func() {
+			command := replicate.GetCopyCommandForContent(0)
+			Expect(command).To(HavePrefix("set -o pipefail; cat /data/gpseg0/backups/20170101/20170101010101/gpbackup_0_20170101010101 | "))
+			Expect(command).To(ContainSubstring("; cat /data/gpseg0/backups/20170101/20170101010101/gpbackup_0_20170101010101_toc.yaml | "))
+		})
+	})
+	Describe("ValidateBackupConfig", func() {
+		It("panics if the backup has multiple data files per segment", func() {
+			backupConfig.SingleDataFile = false
+			defer testhelper.ShouldPanicWithMessage("Backup 20170101010101 must use --single-data-file or --metadata-only to be copied to a plugin")
+			replicate.ValidateBackupConfig()
+		})
+		It("panics if the backup was taken with a plugin and no source plugin is given", func() {
+			backupConfig.Plugin = "/source_plugin"
+			defer testhelper.ShouldPanicWithMessage("Backup 20170101010101 was taken with plugin /source_plugin. The --source-plugin-config flag must be used to copy it.")
+			replicate.ValidateBackupConfig()
+		})
+	})
+})
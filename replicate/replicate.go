@@ -0,0 +1,194 @@
+package replicate
+
+/*
+ * This file contains the entry points for gpbackup_replicate, which copies an
+ * existing backup set from local backup directories or from one plugin
+ * destination to another plugin destination.
+ */
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/greenplum-db/gp-common-go-libs/cluster"
+	"github.com/greenplum-db/gp-common-go-libs/dbconn"
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"github.com/greenplum-db/gpbackup/utils"
+	"github.com/pkg/errors"
+)
+
+/*
+ * We define and initialize flags separately to avoid import conflicts in tests.
+ * The flag variables, and setter functions for them, are in global_variables.go.
+ */
+func initializeFlags() {
+	backupDir = flag.String("backup-dir", "", "The absolute path of the directory in which the backup files to be copied are located")
+	debug = flag.Bool("debug", false, "Print verbose and debug log messages")
+	pluginConfigFile = flag.String("plugin-config", "", "The configuration file for the plugin to copy the backup to")
+	printVersion = flag.Bool("version", false, "Print version number and exit")
+	quiet = flag.Bool("quiet", false, "Suppress non-warning, non-error log messages")
+	sourcePluginConfigFile = flag.String("source-plugin-config", "", "The configuration file for the plugin to copy the backup from, if the backup is not in local backup directories")
+	timestamp = flag.String("timestamp", "", "The timestamp of the backup to be copied, in the format YYYYMMDDHHMMSS")
+	verbose = flag.Bool("verbose", false, "Print verbose log messages")
+}
+
+// This function handles setup that can be done before parsing flags.
+func DoInit() {
+	CleanupGroup = &sync.WaitGroup{}
+	CleanupGroup.Add(1)
+	gplog.InitializeLogging("gpbackup_replicate", "")
+	initializeFlags()
+	utils.InitializeSignalHandler(DoCleanup, "replicate process", &wasTerminated)
+}
+
+/*
+* This function handles argument parsing and validation, e.g. checking that a passed filename exists.
+* It should only validate; initialization with any sort of side effects should go in DoInit or DoSetup.
+ */
+func DoValidation() {
+	if len(os.Args) == 1 {
+		flag.PrintDefaults()
+		os.Exit(0)
+	}
+	flag.Parse()
+	if *printVersion {
+		fmt.Printf("gpbackup_replicate %s\n", version)
+		os.Exit(0)
+	}
+	ValidateFlagCombinations()
+	utils.ValidateFullPath(*backupDir)
+	utils.ValidateFullPath(*pluginConfigFile)
+	utils.ValidateFullPath(*sourcePluginConfigFile)
+	if !utils.IsValidTimestamp(*timestamp) {
+		gplog.Fatal(errors.Errorf("Timestamp %s is invalid.  Timestamps must be in the format YYYYMMDDHHMMSS.", *timestamp), "")
+	}
+}
+
+func ValidateFlagCombinations() {
+	utils.CheckMandatoryFlags("timestamp", "plugin-config")
+	utils.CheckExclusiveFlags("debug", "quiet", "verbose")
+	utils.CheckExclusiveFlags("backup-dir", "source-plugin-config")
+	/*
+	 * Both plugin configs are copied into the same private directory on each
+	 * host, so they must not share a file name.
+	 */
+	if *sourcePluginConfigFile != "" && filepath.Base(*sourcePluginConfigFile) == filepath.Base(*pluginConfigFile) {
+		gplog.Fatal(errors.Errorf("The --plugin-config and --source-plugin-config files must have different file names"), "")
+	}
+}
+
+// This function handles setup that must be done after parsing flags.
+func DoSetup() {
+	SetLoggerVerbosity()
+	gplog.Info("Backup Key = %s", *timestamp)
+
+	connection = dbconn.NewDBConn("postgres")
+	connection.MustConnect(1)
+	utils.SetDatabaseVersion(connection)
+	segConfig := cluster.GetSegmentConfiguration(connection)
+	globalCluster = cluster.NewCluster(segConfig)
+	segPrefix := utils.ParseSegPrefix(*backupDir)
+	globalFPInfo = utils.NewFilePathInfo(globalCluster.SegDirMap, *backupDir, *timestamp, segPrefix)
+
+	if *sourcePluginConfigFile != "" {
+		sourcePluginConfig = utils.ReadPluginConfig(*sourcePluginConfigFile)
+		sourcePluginConfig.CheckPluginExistsOnAllHosts(globalCluster)
+		sourcePluginConfig.ValidateOptions(sourcePluginConfig.GetPluginSchema())
+		sourcePluginConfig.CopyPluginConfigToAllHosts(globalCluster, *sourcePluginConfigFile)
+		sourcePluginConfig.SetupPluginForRestoreOnAllHosts(globalCluster, sourcePluginConfig.ConfigPath, globalFPInfo.GetDirForContent(-1))
+		sourcePluginConfig.RestoreFile(globalFPInfo.GetConfigFilePath())
+	}
+	InitializeBackupConfig()
+
+	destPluginConfig = utils.ReadPluginConfig(*pluginConfigFile)
+	destPluginConfig.CheckPluginExistsOnAllHosts(globalCluster)
+	destPluginConfig.ValidateOptions(destPluginConfig.GetPluginSchema())
+	destPluginConfig.CopyPluginConfigToAllHosts(globalCluster, *pluginConfigFile)
+	destPluginConfig.SetupPluginForBackupOnAllHosts(globalCluster, destPluginConfig.ConfigPath, globalFPInfo.GetDirForContent(-1))
+}
+
+func SetLoggerVerbosity() {
+	if *quiet {
+		gplog.SetVerbosity(gplog.LOGERROR)
+	} else if *debug {
+		gplog.SetVerbosity(gplog.LOGDEBUG)
+	} else if *verbose {
+		gplog.SetVerbosity(gplog.LOGVERBOSE)
+	}
+}
+
+func InitializeBackupConfig() {
+	backupConfig = utils.ReadConfigFile(globalFPInfo.GetConfigFilePath())
+	ValidateBackupConfig()
+	utils.InitializeCompressionParameters(backupConfig.Compressed, 0)
+}
+
+func ValidateBackupConfig() {
+	if !backupConfig.SingleDataFile && !backupConfig.MetadataOnly {
+		gplog.Fatal(errors.Errorf("Backup %s must use --single-data-file or --metadata-only to be copied to a plugin", globalFPInfo.Timestamp), "")
+	}
+	if sourcePluginConfig == nil && backupConfig.Plugin != "" {
+		gplog.Fatal(errors.Errorf("Backup %s was taken with plugin %s. The --source-plugin-config flag must be used to copy it.", globalFPInfo.Timestamp, backupConfig.Plugin), "")
+	}
+}
+
+func DoReplicate() {
+	gplog.Info("Copying backup %s to plugin %s", globalFPInfo.Timestamp, destPluginConfig.ExecutablePath)
+	CopyBackupFilesOnAllHosts()
+	CopyConfigFile()
+	gplog.Info("Backup copy completed successfully")
+}
+
+func DoTeardown() {
+	errStr := ""
+	if err := recover(); err != nil {
+		errStr = fmt.Sprintf("%v", err)
+	}
+	if wasTerminated {
+		/*
+		 * Don't print an error if the copy was canceled, as the signal handler
+		 * will take care of cleanup and return codes.  Just wait until the signal
+		 * handler's DoCleanup completes so the main goroutine doesn't exit while
+		 * cleanup is still in progress.
+		 */
+		CleanupGroup.Wait()
+		return
+	}
+	if errStr != "" {
+		fmt.Println(errStr)
+	}
+	errorCode := gplog.GetErrorCode()
+
+	DoCleanup()
+
+	os.Exit(errorCode)
+}
+
+func DoCleanup() {
+	defer func() {
+		if err := recover(); err != nil {
+			gplog.Warn("Encountered error during cleanup: %v", err)
+		}
+		gplog.Verbose("Cleanup complete")
+		CleanupGroup.Done()
+	}()
+	gplog.Verbose("Beginning cleanup")
+	if destPluginConfig != nil {
+		destPluginConfig.CleanupPluginForBackupOnAllHosts(globalCluster)
+	}
+	if sourcePluginConfig != nil {
+		sourcePluginConfig.CleanupPluginForRestoreOnAllHosts(globalCluster)
+	}
+	if destPluginConfig != nil {
+		destPluginConfig.DeletePluginConfigFromAllHosts(globalCluster)
+	}
+	if sourcePluginConfig != nil {
+		sourcePluginConfig.DeletePluginConfigFromAllHosts(globalCluster)
+	}
+	if connection != nil {
+		connection.Close()
+	}
+}
@@ -0,0 +1,116 @@
+package restore
+
+/*
+ * This file contains functions related to analyzing tables after their data
+ * has been restored, so that the query planner has statistics for them.
+ */
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/greenplum-db/gp-common-go-libs/dbconn"
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"github.com/greenplum-db/gpbackup/utils"
+	pb "gopkg.in/cheggaaa/pb.v1"
+)
+
+// Each partition of a partitioned table, at any level, is mapped to its root partition.
+func GetPartitionRoots(connection *dbconn.DBConn) map[string]string {
+	query := `
+SELECT
+	quote_ident(partitionschemaname) || '.' || quote_ident(partitiontablename) AS partition,
+	quote_ident(schemaname) || '.' || quote_ident(tablename) AS root
+FROM pg_partitions;`
+	results := make([]struct {
+		Partition string
+		Root      string
+	}, 0)
+	err := connection.Select(&results, query)
+	gplog.FatalOnError(err)
+	partitionRoots := make(map[string]string, len(results))
+	for _, result := range results {
+		partitionRoots[result.Partition] = result.Root
+	}
+	return partitionRoots
+}
+
+/*
+ * Analyzing the root partition of a partitioned table analyzes all of its
+ * partitions, so partitions whose data was restored are analyzed through their
+ * root partition instead of individually.  Tables whose statistics were
+ * restored from the backup are skipped, as analyzing them would only replace
+ * the restored statistics.  The number of skipped tables is also returned.
+ */
+func GetTablesToAnalyze(entries []utils.MasterDataEntry, partitionRoots map[string]string, restoredStatistics map[string]bool) ([]string, int) {
+	tables := make([]string, 0)
+	seenTables := make(map[string]bool, 0)
+	numSkipped := 0
+	for _, entry := range entries {
+		table := utils.MakeFQN(entry.Schema, entry.Name)
+		if root, ok := partitionRoots[table]; ok {
+			table = root
+		}
+		if seenTables[table] {
+			continue
+		}
+		seenTables[table] = true
+		if restoredStatistics[table] {
+			numSkipped++
+			continue
+		}
+		tables = append(tables, table)
+	}
+	return tables, numSkipped
+}
+
+func AnalyzeTables(tables []string) (uint32, uint32) {
+	var numAnalyzed, numFailed uint32
+	totalTables := len(tables)
+	progressBar := utils.NewProgressBar(totalTables, "Tables analyzed: ", utils.PB_INFO)
+	progressBar.Start()
+	tasks := make(chan string, totalTables)
+	var workerPool sync.WaitGroup
+	for i := 0; i < connection.NumConns; i++ {
+		workerPool.Add(1)
+		go func(whichConn int) {
+			for table := range tasks {
+				if wasTerminated {
+					break
+				}
+				if analyzeTable(table, whichConn) {
+					atomic.AddUint32(&numAnalyzed, 1)
+				} else {
+					atomic.AddUint32(&numFailed, 1)
+				}
+				progressBar.Increment()
+			}
+			workerPool.Done()
+		}(i)
+	}
+	for _, table := range tables {
+		tasks <- table
+	}
+	close(tasks)
+	workerPool.Wait()
+	if wasTerminated {
+		progressBar.(*pb.ProgressBar).NotPrint = true
+		return numAnalyzed, numFailed
+	}
+	progressBar.Finish()
+	return numAnalyzed, numFailed
+}
+
+func analyzeTable(table string, whichConn int) bool {
+	gplog.Verbose("Analyzing table %s", table)
+	_, err := connection.Exec(fmt.Sprintf("ANALYZE %s;", table), whichConn)
+	if err != nil {
+		if !*onErrorContinue {
+			gplog.Fatal(err, "Error analyzing table %s", table)
+		}
+		gplog.Error("Error analyzing table %s: %v", table, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package restore_test
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/greenplum-db/gpbackup/restore"
+	"github.com/greenplum-db/gpbackup/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+var _ = Describe("restore/analyze tests", func() {
+	Describe("GetPartitionRoots", func() {
+		It("maps each partition to its root partition", func() {
+			header := []string{"partition", "root"}
+			rows := sqlmock.NewRows(header).AddRow("public.sales_1_prt_jan", "public.sales").AddRow("public.sales_1_prt_jan_2_prt_east", "public.sales")
+			mock.ExpectQuery("SELECT (.*)").WillReturnRows(rows)
+
+			partitionRoots := restore.GetPartitionRoots(connection)
+
+			Expect(partitionRoots).To(Equal(map[string]string{"public.sales_1_prt_jan": "public.sales", "public.sales_1_prt_jan_2_prt_east": "public.sales"}))
+		})
+	})
+	Describe("GetTablesToAnalyze", func() {
+		entries := []utils.MasterDataEntry{
+			{Schema: "public", Name: "foo"},
+			{Schema: "public", Name: "sales_1_prt_jan_2_prt_east"},
+			{Schema: "public", Name: "sales_1_prt_feb_2_prt_east"},
+			{Schema: "public", Name: "bar"},
+			{Schema: "public", Name: "baz"},
+		}
+		partitionRoots := map[string]string{"public.sales_1_prt_jan_2_prt_east": "public.sales", "public.sales_1_prt_feb_2_prt_east": "public.sales"}
+		It("analyzes each partitioned table once, through its root partition", func() {
+			tables, numSkipped := restore.GetTablesToAnalyze(entries, partitionRoots, nil)
+			Expect(tables).To(Equal([]string{"public.foo", "public.sales", "public.bar", "public.baz"}))
+			Expect(numSkipped).To(Equal(0))
+		})
+		It("skips tables whose statistics were restored", func() {
+			restoredStatistics := map[string]bool{"public.bar": true, "public.sales": true}
+			tables, numSkipped := restore.GetTablesToAnalyze(entries, partitionRoots, restoredStatistics)
+			Expect(tables).To(Equal([]string{"public.foo", "public.baz"}))
+			Expect(numSkipped).To(Equal(2))
+		})
+	})
+	Describe("AnalyzeTables", func() {
+		BeforeEach(func() {
+			restore.SetConnection(connection)
+			restore.SetOnErrorContinue(false)
+		})
+		It("analyzes each table", func() {
+			mock.ExpectExec(regexp.QuoteMeta("ANALYZE public.foo;")).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta("ANALYZE public.bar;")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+			numAnalyzed, numFailed := restore.AnalyzeTables([]string{"public.foo", "public.bar"})
+
+			Expect(numAnalyzed).To(Equal(uint32(2)))
+			Expect(numFailed).To(Equal(uint32(0)))
+			Expect(mock.ExpectationsWereMet()).To(Succeed())
+		})
+		It("counts tables that could not be analyzed with --on-error-continue", func() {
+			restore.SetOnErrorContinue(true)
+			mock.ExpectExec(regexp.QuoteMeta("ANALYZE public.foo;")).WillReturnError(errors.New(`relation "public.foo" does not exist`))
+			mock.ExpectExec(regexp.QuoteMeta("ANALYZE public.bar;")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+			numAnalyzed, numFailed := restore.AnalyzeTables([]string{"public.foo", "public.bar"})
+
+			Expect(numAnalyzed).To(Equal(uint32(1)))
+			Expect(numFailed).To(Equal(uint32(1)))
+		})
+	})
+})
@@ -0,0 +1,352 @@
+package restore
+
+/*
+ * This file contains functions related to dropping existing objects in the
+ * restore database before the objects in the backup are restored over them.
+ */
+
+import (
+	"container/heap"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"github.com/greenplum-db/gpbackup/utils"
+	"github.com/pkg/errors"
+)
+
+var (
+	foreignTableRegex        = regexp.MustCompile(`^\s*CREATE\s+(?:READABLE\s+|WRITABLE\s+)?(EXTERNAL|FOREIGN)\s`)
+	materializedViewRegex    = regexp.MustCompile(`^\s*CREATE\s+MATERIALIZED\s+VIEW\s`)
+	operatorLeftArgRegex     = regexp.MustCompile(`(?m)^\tLEFTARG = (.+?),?$`)
+	operatorRightArgRegex    = regexp.MustCompile(`(?m)^\tRIGHTARG = (.+?),?$`)
+	indexMethodRegex         = regexp.MustCompile(`\sUSING ([^\s;]+)`)
+	sequenceOwnerRegex       = regexp.MustCompile(`OWNED BY (.+)\.(?:"(?:[^"]|"")+"|[^."]+);`)
+	foreignKeyReferenceRegex = regexp.MustCompile(`\sREFERENCES\s+((?:"[^"]*"|[^\s(."]+)\.(?:"[^"]*"|[^\s(."]+))`)
+	shellTypeRegex           = regexp.MustCompile(`^\s*CREATE TYPE [^\s(]+;\s*$`)
+	typeIOFunctionRegex      = regexp.MustCompile(`(?m)^\t(?:INPUT|OUTPUT|RECEIVE|SEND|TYPMOD_IN|TYPMOD_OUT) = (.+?),?$`)
+)
+
+/*
+ * Objects that are dropped along with a table, such as its indexes and
+ * constraints, and the sequences it owns, are not dropped separately when that
+ * table is dropped, since dropping them afterward would fail if --if-exists is
+ * not used.
+ */
+type droppedTableSet struct {
+	tables map[string]bool
+}
+
+func newDroppedTableSet(predataStatements []utils.StatementWithType) droppedTableSet {
+	tables := make(map[string]bool, 0)
+	for _, statement := range predataStatements {
+		if statement.ObjectType == "TABLE" {
+			tables[utils.MakeFQN(statement.Schema, statement.Name)] = true
+		}
+	}
+	return droppedTableSet{tables: tables}
+}
+
+// Partitions of a table are dropped along with their parent.
+func (set droppedTableSet) Contains(fqn string) bool {
+	if set.tables[fqn] {
+		return true
+	}
+	schema, table, ok := splitFQN(fqn)
+	if !ok {
+		return false
+	}
+	unquotedTable := utils.UnquoteIdent(table)
+	for droppedFQN := range set.tables {
+		droppedSchema, droppedTable, _ := splitFQN(droppedFQN)
+		if droppedSchema != schema {
+			continue
+		}
+		if suffix, ok := getNameSuffix(unquotedTable, utils.UnquoteIdent(droppedTable)); ok && partitionChildRegex.MatchString(suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ * Objects are dropped in the reverse of the order in which they will be
+ * restored, so that each object is dropped before anything it depends on.
+ * Schemas are not dropped, as restoring a schema that already exists is not
+ * an error, and objects like security labels and default privileges that
+ * cannot be dropped are left as they are.
+ *
+ * Foreign keys are dropped before anything else, as tables are not ordered by
+ * the foreign keys between them and a table cannot be dropped while another
+ * table still references it.  Only foreign keys that exist in the restore
+ * database are dropped, since dropping one is not an error with IF EXISTS
+ * before GPDB 6, and a foreign key on a table that is being dropped is left to
+ * be dropped along with that table unless the table it references is being
+ * dropped as well.
+ */
+func GetCleanStatements(predataStatements []utils.StatementWithType, postdataStatements []utils.StatementWithType, existingForeignKeys map[string]bool) []utils.StatementWithType {
+	droppedTables := newDroppedTableSet(predataStatements)
+	droppedSequences := make(map[string]bool, 0)
+	for _, statement := range predataStatements {
+		if statement.ObjectType != "SEQUENCE OWNER" {
+			continue
+		}
+		matches := sequenceOwnerRegex.FindStringSubmatchIndex(utils.MaskQuotedSQL(statement.Statement))
+		if matches != nil && droppedTables.Contains(statement.Statement[matches[2]:matches[3]]) {
+			droppedSequences[utils.MakeFQN(statement.Schema, statement.Name)] = true
+		}
+	}
+	baseTypes, droppedFunctions := getBaseTypesAndIOFunctions(predataStatements)
+
+	cleanStatements := make([]utils.StatementWithType, 0)
+	for _, statements := range [][]utils.StatementWithType{predataStatements, postdataStatements} {
+		for _, statement := range statements {
+			if !isForeignKeyConstraint(statement) || !existingForeignKeys[getForeignKeyKey(statement.ReferenceObject, statement.Name)] {
+				continue
+			}
+			if droppedTables.Contains(statement.ReferenceObject) && !droppedTables.Contains(getReferencedTable(statement)) {
+				continue
+			}
+			cleanStatements = append(cleanStatements, newDropStatement(statement))
+		}
+	}
+	for _, statements := range [][]utils.StatementWithType{postdataStatements, predataStatements} {
+		for _, i := range getReverseDependencyOrder(statements) {
+			statement := statements[i]
+			if isForeignKeyConstraint(statement) || droppedTables.Contains(statement.ReferenceObject) || droppedSequences[utils.MakeFQN(statement.Schema, statement.Name)] {
+				continue
+			}
+			if statement.ObjectType == "TYPE" && baseTypes[utils.MakeFQN(statement.Schema, statement.Name)] && shellTypeRegex.MatchString(statement.Statement) {
+				continue
+			}
+			if statement.ObjectType == "FUNCTION" && isDroppedIOFunction(statement, droppedFunctions) {
+				continue
+			}
+			dropStatement, ok := getDropStatement(statement)
+			if !ok {
+				continue
+			}
+			if statement.ObjectType == "TYPE" && baseTypes[utils.MakeFQN(statement.Schema, statement.Name)] {
+				dropStatement = strings.TrimSuffix(dropStatement, ";") + " CASCADE;"
+			}
+			cleanStatement := newDropStatement(statement)
+			cleanStatement.Statement = dropStatement
+			cleanStatements = append(cleanStatements, cleanStatement)
+		}
+	}
+	return cleanStatements
+}
+
+// Quoted identifiers are masked, so the table name is taken from the original statement.
+func getReferencedTable(statement utils.StatementWithType) string {
+	matches := foreignKeyReferenceRegex.FindStringSubmatchIndex(utils.MaskQuotedSQL(statement.Statement))
+	if matches == nil {
+		return ""
+	}
+	return statement.Statement[matches[2]:matches[3]]
+}
+
+func newDropStatement(statement utils.StatementWithType) utils.StatementWithType {
+	dropStatement, _ := getDropStatement(statement)
+	return utils.StatementWithType{Schema: statement.Schema, Name: statement.Name, ObjectType: statement.ObjectType, ReferenceObject: statement.ReferenceObject, Statement: dropStatement}
+}
+
+/*
+ * This function returns the indexes of the statements in the reverse of the
+ * order in which they are restored, as determined by their Dependencies, so
+ * that no statement is dropped until every statement that depends on it has
+ * been dropped.  Of the statements that are ready to be dropped, the latest
+ * one is always dropped first, so statements without recorded dependencies are
+ * dropped in the reverse of the order in which they appear.
+ */
+func getReverseDependencyOrder(statements []utils.StatementWithType) []int {
+	remaining := make([]int, len(statements))
+	for i, statement := range statements {
+		for _, dependency := range statement.Dependencies {
+			// Statements only ever depend on statements before them, as in ExecuteStatementsInDependencyOrder
+			if dependency >= 0 && dependency < i {
+				remaining[dependency]++
+			}
+		}
+	}
+	ready := &readyStatements{}
+	for i := range statements {
+		if remaining[i] == 0 {
+			heap.Push(ready, i)
+		}
+	}
+	order := make([]int, 0, len(statements))
+	for ready.Len() > 0 {
+		next := heap.Pop(ready).(int)
+		order = append(order, next)
+		for _, dependency := range statements[next].Dependencies {
+			if dependency >= 0 && dependency < next {
+				remaining[dependency]--
+				if remaining[dependency] == 0 {
+					heap.Push(ready, dependency)
+				}
+			}
+		}
+	}
+	return order
+}
+
+// A max-heap of statement indexes, so that the latest ready statement is dropped first
+type readyStatements []int
+
+func (ready readyStatements) Len() int            { return len(ready) }
+func (ready readyStatements) Less(i, j int) bool  { return ready[i] > ready[j] }
+func (ready readyStatements) Swap(i, j int)       { ready[i], ready[j] = ready[j], ready[i] }
+func (ready *readyStatements) Push(x interface{}) { *ready = append(*ready, x.(int)) }
+func (ready *readyStatements) Pop() interface{} {
+	old := *ready
+	index := old[len(old)-1]
+	*ready = old[:len(old)-1]
+	return index
+}
+
+/*
+ * A base type and its input and output functions depend on each other, so
+ * neither can be dropped on its own.  As pg_dump does, the base type is dropped
+ * with CASCADE, which drops its I/O functions as well; its shell type entry and
+ * the entries for those functions are then skipped, as dropping them again
+ * would fail.
+ */
+func getBaseTypesAndIOFunctions(statements []utils.StatementWithType) (map[string]bool, map[string]bool) {
+	baseTypes := make(map[string]bool, 0)
+	ioFunctions := make(map[string]bool, 0)
+	for _, statement := range statements {
+		if statement.ObjectType != "TYPE" {
+			continue
+		}
+		matches := typeIOFunctionRegex.FindAllStringSubmatch(statement.Statement, -1)
+		if matches == nil {
+			continue
+		}
+		baseTypes[utils.MakeFQN(statement.Schema, statement.Name)] = true
+		for _, match := range matches {
+			ioFunctions[match[1]] = true
+		}
+	}
+	return baseTypes, ioFunctions
+}
+
+// I/O functions are recorded with or without their schema, depending on the search path at the time of the backup.
+func isDroppedIOFunction(statement utils.StatementWithType, ioFunctions map[string]bool) bool {
+	name := statement.Name
+	if index := strings.Index(name, "("); index != -1 {
+		name = name[:index]
+	}
+	return ioFunctions[name] || ioFunctions[utils.MakeFQN(statement.Schema, name)]
+}
+
+func getDropStatement(statement utils.StatementWithType) (string, bool) {
+	ifExistsStr := ""
+	if *ifExists {
+		ifExistsStr = " IF EXISTS"
+	}
+	fqn := statement.Name
+	if statement.Schema != "" {
+		fqn = utils.MakeFQN(statement.Schema, statement.Name)
+	}
+	maskedStatement := utils.MaskQuotedSQL(statement.Statement)
+	objectType := statement.ObjectType
+	switch statement.ObjectType {
+	case "TABLE":
+		if matches := foreignTableRegex.FindStringSubmatch(maskedStatement); matches != nil {
+			objectType = fmt.Sprintf("%s TABLE", matches[1])
+		}
+	case "VIEW":
+		if materializedViewRegex.MatchString(maskedStatement) {
+			objectType = "MATERIALIZED VIEW"
+		}
+	case "AGGREGATE", "COLLATION", "CONVERSION", "DOMAIN", "FUNCTION", "INDEX", "SEQUENCE", "TEXT SEARCH CONFIGURATION",
+		"TEXT SEARCH DICTIONARY", "TEXT SEARCH PARSER", "TEXT SEARCH TEMPLATE", "TYPE":
+	case "CAST", "EVENT TRIGGER", "EXTENSION", "FOREIGN DATA WRAPPER", "PROTOCOL":
+		fqn = statement.Name
+	case "FOREIGN SERVER":
+		objectType = "SERVER"
+		fqn = statement.Name
+	case "PROCEDURAL LANGUAGE":
+		objectType = "LANGUAGE"
+		fqn = statement.Name
+	case "OPERATOR":
+		leftArg, rightArg := "NONE", "NONE"
+		if matches := operatorLeftArgRegex.FindStringSubmatch(statement.Statement); matches != nil {
+			leftArg = matches[1]
+		}
+		if matches := operatorRightArgRegex.FindStringSubmatch(statement.Statement); matches != nil {
+			rightArg = matches[1]
+		}
+		fqn = fmt.Sprintf("%s (%s, %s)", fqn, leftArg, rightArg)
+	case "OPERATOR CLASS", "OPERATOR FAMILY":
+		matches := indexMethodRegex.FindStringSubmatch(maskedStatement)
+		if matches == nil {
+			return "", false
+		}
+		fqn = fmt.Sprintf("%s USING %s", fqn, matches[1])
+	case "USER MAPPING":
+		mappingNames := strings.SplitN(statement.Name, " ON ", 2)
+		if len(mappingNames) != 2 {
+			return "", false
+		}
+		return fmt.Sprintf("\n\nDROP USER MAPPING%s FOR %s SERVER %s;", ifExistsStr, mappingNames[0], mappingNames[1]), true
+	case "RULE", "TRIGGER":
+		return fmt.Sprintf("\n\nDROP %s%s %s ON %s;", statement.ObjectType, ifExistsStr, statement.Name, statement.ReferenceObject), true
+	case "CONSTRAINT":
+		// ALTER TABLE ... DROP CONSTRAINT IF EXISTS is not supported before GPDB 6
+		if connection.Version.Before("6") {
+			ifExistsStr = ""
+		}
+		return fmt.Sprintf("\n\nALTER TABLE %s DROP CONSTRAINT%s %s;", statement.ReferenceObject, ifExistsStr, statement.Name), true
+	default:
+		return "", false
+	}
+	return fmt.Sprintf("\n\nDROP %s%s %s;", objectType, ifExistsStr, fqn), true
+}
+
+/*
+ * The drop statements are executed in a single transaction, so that either all
+ * existing objects are dropped or none of them are.  With --on-error-continue,
+ * the statements are executed individually instead, so that failing to drop
+ * one object does not prevent the others from being dropped.
+ */
+func ExecuteCleanStatements(statements []utils.StatementWithType) {
+	progressBar := utils.NewProgressBar(len(statements), "Existing objects dropped: ", utils.PB_VERBOSE)
+	progressBar.Start()
+	defer progressBar.Finish()
+	if *onErrorContinue {
+		numErrors := 0
+		for _, statement := range statements {
+			if wasTerminated {
+				return
+			}
+			_, err := connection.Exec(statement.Statement)
+			if err != nil {
+				gplog.Verbose("Error encountered when executing statement: %s Error was: %s", strings.TrimSpace(statement.Statement), err.Error())
+				numErrors++
+			}
+			progressBar.Increment()
+		}
+		if numErrors > 0 {
+			gplog.Error("Failed to drop %d existing objects; see log file %s for a list of failed statements.", numErrors, gplog.GetLogFilePath())
+		}
+		return
+	}
+	connection.MustBegin()
+	for _, statement := range statements {
+		if wasTerminated {
+			_ = connection.Rollback()
+			return
+		}
+		_, err := connection.Exec(statement.Statement)
+		if err != nil {
+			gplog.Verbose("Error encountered when executing statement: %s Error was: %s", strings.TrimSpace(statement.Statement), err.Error())
+			_ = connection.Rollback()
+			gplog.Fatal(errors.Errorf("%s; no existing objects were dropped", err.Error()), "Failed to drop existing objects")
+		}
+		progressBar.Increment()
+	}
+	connection.MustCommit()
+}
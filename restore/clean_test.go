@@ -0,0 +1,210 @@
+package restore_test
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/greenplum-db/gp-common-go-libs/testhelper"
+	"github.com/greenplum-db/gpbackup/restore"
+	"github.com/greenplum-db/gpbackup/testutils"
+	"github.com/greenplum-db/gpbackup/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+var _ = Describe("restore/clean tests", func() {
+	BeforeEach(func() {
+		restore.SetConnection(connection)
+		restore.SetIfExists(false)
+		restore.SetOnErrorContinue(false)
+	})
+	Describe("GetCleanStatements", func() {
+		function := utils.StatementWithType{Schema: "public", Name: "add_order(integer)", ObjectType: "FUNCTION", Statement: "\n\nCREATE FUNCTION public.add_order(integer) RETURNS void AS $$SELECT 1$$ LANGUAGE sql;"}
+		sequence := utils.StatementWithType{Schema: "public", Name: "orders_id_seq", ObjectType: "SEQUENCE", Statement: "\n\nCREATE SEQUENCE public.orders_id_seq;"}
+		table := utils.StatementWithType{Schema: "public", Name: "orders", ObjectType: "TABLE", Statement: "\n\nCREATE TABLE public.orders (id integer) DISTRIBUTED BY (id);"}
+		externalTable := utils.StatementWithType{Schema: "public", Name: "orders_ext", ObjectType: "TABLE", Statement: "\n\nCREATE READABLE EXTERNAL TABLE public.orders_ext (id integer) LOCATION ('file://tmp/orders.txt') FORMAT 'TEXT';"}
+		sequenceOwner := utils.StatementWithType{Schema: "public", Name: "orders_id_seq", ObjectType: "SEQUENCE OWNER", Statement: "\n\nALTER SEQUENCE public.orders_id_seq OWNED BY public.orders.id;"}
+		view := utils.StatementWithType{Schema: "public", Name: "order_view", ObjectType: "VIEW", Statement: "\n\nCREATE VIEW public.order_view AS SELECT * FROM public.orders;"}
+		constraint := utils.StatementWithType{Schema: "public", Name: "orders_pkey", ObjectType: "CONSTRAINT", ReferenceObject: "public.orders", Statement: "\n\nALTER TABLE ONLY public.orders ADD CONSTRAINT orders_pkey PRIMARY KEY (id);"}
+		index := utils.StatementWithType{Schema: "public", Name: "orders_idx", ObjectType: "INDEX", ReferenceObject: "public.orders", Statement: "\n\nCREATE INDEX orders_idx ON public.orders USING btree (id);"}
+		childIndex := utils.StatementWithType{Schema: "public", Name: "orders_1_prt_one_idx", ObjectType: "INDEX", ReferenceObject: "public.orders_1_prt_one", Statement: "\n\nCREATE INDEX orders_1_prt_one_idx ON public.orders_1_prt_one USING btree (id);"}
+		trigger := utils.StatementWithType{Schema: "public", Name: "sales_trigger", ObjectType: "TRIGGER", ReferenceObject: "public.sales", Statement: "\n\nCREATE TRIGGER sales_trigger AFTER INSERT ON public.sales FOR EACH ROW EXECUTE PROCEDURE public.add_order();"}
+		defaultPrivileges := utils.StatementWithType{Schema: "", Name: "testrole ON TABLES", ObjectType: "DEFAULT PRIVILEGES", Statement: "\n\nALTER DEFAULT PRIVILEGES FOR ROLE testrole REVOKE ALL ON TABLES FROM PUBLIC;"}
+
+		It("drops objects in reverse order, skipping objects dropped along with their tables", func() {
+			predata := []utils.StatementWithType{function, sequence, table, externalTable, sequenceOwner, view, constraint}
+			postdata := []utils.StatementWithType{index, childIndex, trigger, defaultPrivileges}
+
+			statements := restore.GetCleanStatements(predata, postdata, map[string]bool{})
+
+			dropStatements := make([]string, len(statements))
+			for i, statement := range statements {
+				dropStatements[i] = statement.Statement
+			}
+			Expect(dropStatements).To(Equal([]string{
+				"\n\nDROP TRIGGER sales_trigger ON public.sales;",
+				"\n\nDROP VIEW public.order_view;",
+				"\n\nDROP EXTERNAL TABLE public.orders_ext;",
+				"\n\nDROP TABLE public.orders;",
+				"\n\nDROP FUNCTION public.add_order(integer);",
+			}))
+		})
+		It("drops foreign keys before any tables, even on tables that are being dropped", func() {
+			customers := utils.StatementWithType{Schema: "public", Name: "customers", ObjectType: "TABLE", Statement: "\n\nCREATE TABLE public.customers (id integer) DISTRIBUTED BY (id);"}
+			customerKey := utils.StatementWithType{Schema: "public", Name: "customers_pkey", ObjectType: "CONSTRAINT", ReferenceObject: "public.customers", Statement: "\n\nALTER TABLE ONLY public.customers ADD CONSTRAINT customers_pkey PRIMARY KEY (id);"}
+			foreignKey := utils.StatementWithType{Schema: "public", Name: "orders_customer_fkey", ObjectType: "CONSTRAINT", ReferenceObject: "public.orders", Statement: "\n\nALTER TABLE ONLY public.orders ADD CONSTRAINT orders_customer_fkey FOREIGN KEY (id) REFERENCES public.customers(id);"}
+
+			existingForeignKeys := map[string]bool{"public.orders|orders_customer_fkey": true}
+
+			statements := restore.GetCleanStatements([]utils.StatementWithType{customers, table, customerKey, foreignKey}, []utils.StatementWithType{}, existingForeignKeys)
+
+			dropStatements := make([]string, len(statements))
+			for i, statement := range statements {
+				dropStatements[i] = statement.Statement
+			}
+			Expect(dropStatements).To(Equal([]string{
+				"\n\nALTER TABLE public.orders DROP CONSTRAINT orders_customer_fkey;",
+				"\n\nDROP TABLE public.orders;",
+				"\n\nDROP TABLE public.customers;",
+			}))
+		})
+		It("does not drop foreign keys that do not exist in the restore database", func() {
+			restore.SetIfExists(true)
+			foreignKey := utils.StatementWithType{Schema: "public", Name: "orders_customer_fkey", ObjectType: "CONSTRAINT", ReferenceObject: "public.orders", Statement: "\n\nALTER TABLE ONLY public.orders ADD CONSTRAINT orders_customer_fkey FOREIGN KEY (id) REFERENCES public.customers(id);"}
+
+			statements := restore.GetCleanStatements([]utils.StatementWithType{table, foreignKey}, []utils.StatementWithType{}, map[string]bool{})
+
+			Expect(statements).To(HaveLen(1))
+			Expect(statements[0].Statement).To(Equal("\n\nDROP TABLE IF EXISTS public.orders;"))
+		})
+		It("leaves foreign keys on tables being dropped to be dropped with them if the tables they reference are not being dropped", func() {
+			foreignKey := utils.StatementWithType{Schema: "public", Name: "orders_customer_fkey", ObjectType: "CONSTRAINT", ReferenceObject: "public.orders", Statement: "\n\nALTER TABLE ONLY public.orders ADD CONSTRAINT orders_customer_fkey FOREIGN KEY (id) REFERENCES \"public\".\"Customers\"(id);"}
+			existingForeignKeys := map[string]bool{"public.orders|orders_customer_fkey": true}
+
+			statements := restore.GetCleanStatements([]utils.StatementWithType{table, foreignKey}, []utils.StatementWithType{}, existingForeignKeys)
+
+			Expect(statements).To(HaveLen(1))
+			Expect(statements[0].Statement).To(Equal("\n\nDROP TABLE public.orders;"))
+		})
+		It("drops each base type once, along with its I/O functions", func() {
+			shellType := utils.StatementWithType{Schema: "public", Name: "base_type", ObjectType: "TYPE", Statement: "\n\nCREATE TYPE public.base_type;\n"}
+			inputFunction := utils.StatementWithType{Schema: "public", Name: "base_type_in(cstring)", ObjectType: "FUNCTION", Statement: "\n\nCREATE FUNCTION public.base_type_in(cstring) RETURNS public.base_type AS 'boolin' LANGUAGE internal;"}
+			outputFunction := utils.StatementWithType{Schema: "public", Name: "base_type_out(public.base_type)", ObjectType: "FUNCTION", Statement: "\n\nCREATE FUNCTION public.base_type_out(public.base_type) RETURNS cstring AS 'boolout' LANGUAGE internal;"}
+			baseType := utils.StatementWithType{Schema: "public", Name: "base_type", ObjectType: "TYPE", Statement: "\n\nCREATE TYPE public.base_type (\n\tINPUT = public.base_type_in,\n\tOUTPUT = base_type_out\n);\n"}
+
+			statements := restore.GetCleanStatements([]utils.StatementWithType{shellType, inputFunction, outputFunction, baseType, function}, []utils.StatementWithType{}, map[string]bool{})
+
+			dropStatements := make([]string, len(statements))
+			for i, statement := range statements {
+				dropStatements[i] = statement.Statement
+			}
+			Expect(dropStatements).To(Equal([]string{
+				"\n\nDROP FUNCTION public.add_order(integer);",
+				"\n\nDROP TYPE public.base_type CASCADE;",
+			}))
+		})
+		It("drops a shell type that has no base type", func() {
+			shellType := utils.StatementWithType{Schema: "public", Name: "shell_type", ObjectType: "TYPE", Statement: "\n\nCREATE TYPE public.shell_type;\n"}
+
+			statements := restore.GetCleanStatements([]utils.StatementWithType{shellType}, []utils.StatementWithType{}, map[string]bool{})
+
+			Expect(statements[0].Statement).To(Equal("\n\nDROP TYPE public.shell_type;"))
+		})
+		It("drops objects in the reverse of their recorded dependency order", func() {
+			dependentView := view
+			dependentView.Dependencies = []int{0}
+			independentFunction := function
+			independentFunction.Dependencies = []int{}
+			dependentFunction := utils.StatementWithType{Schema: "public", Name: "view_count()", ObjectType: "FUNCTION", Statement: "\n\nCREATE FUNCTION public.view_count() RETURNS bigint AS $$SELECT count(*) FROM public.order_view$$ LANGUAGE sql;", Dependencies: []int{2}}
+
+			statements := restore.GetCleanStatements([]utils.StatementWithType{table, independentFunction, dependentView, dependentFunction}, []utils.StatementWithType{}, map[string]bool{})
+
+			dropStatements := make([]string, len(statements))
+			for i, statement := range statements {
+				dropStatements[i] = statement.Statement
+			}
+			Expect(dropStatements).To(Equal([]string{
+				"\n\nDROP FUNCTION public.view_count();",
+				"\n\nDROP VIEW public.order_view;",
+				"\n\nDROP FUNCTION public.add_order(integer);",
+				"\n\nDROP TABLE public.orders;",
+			}))
+		})
+		It("drops constraints and indexes on tables that are not being dropped", func() {
+			restore.SetIfExists(true)
+			testutils.SetDBVersion(connection, "6.0.0")
+			defer testutils.SetDBVersion(connection, "5.1.0")
+
+			statements := restore.GetCleanStatements([]utils.StatementWithType{constraint}, []utils.StatementWithType{index}, map[string]bool{})
+
+			Expect(statements).To(Equal([]utils.StatementWithType{
+				{Schema: "public", Name: "orders_idx", ObjectType: "INDEX", ReferenceObject: "public.orders", Statement: "\n\nDROP INDEX IF EXISTS public.orders_idx;"},
+				{Schema: "public", Name: "orders_pkey", ObjectType: "CONSTRAINT", ReferenceObject: "public.orders", Statement: "\n\nALTER TABLE public.orders DROP CONSTRAINT IF EXISTS orders_pkey;"},
+			}))
+		})
+		It("does not use IF EXISTS when dropping constraints before GPDB 6", func() {
+			restore.SetIfExists(true)
+
+			statements := restore.GetCleanStatements([]utils.StatementWithType{constraint}, []utils.StatementWithType{}, map[string]bool{})
+
+			Expect(statements[0].Statement).To(Equal("\n\nALTER TABLE public.orders DROP CONSTRAINT orders_pkey;"))
+		})
+		It("drops objects whose drop statements need more than their names", func() {
+			restore.SetIfExists(true)
+			operator := utils.StatementWithType{Schema: "public", Name: "##", ObjectType: "OPERATOR", Statement: "\n\nCREATE OPERATOR public.## (\n\tPROCEDURE = public.path_inter,\n\tLEFTARG = path,\n\tRIGHTARG = path\n);"}
+			operatorClass := utils.StatementWithType{Schema: "public", Name: "testclass", ObjectType: "OPERATOR CLASS", Statement: "\n\nCREATE OPERATOR CLASS public.testclass\n\tFOR TYPE integer USING gist AS\n\tSTORAGE integer;"}
+			userMapping := utils.StatementWithType{Schema: "", Name: "testrole ON testserver", ObjectType: "USER MAPPING", Statement: "\n\nCREATE USER MAPPING FOR testrole\n\tSERVER testserver;"}
+			language := utils.StatementWithType{Schema: "", Name: "plpythonu", ObjectType: "PROCEDURAL LANGUAGE", Statement: "\n\nCREATE PROCEDURAL LANGUAGE plpythonu;"}
+
+			statements := restore.GetCleanStatements([]utils.StatementWithType{operator, operatorClass, userMapping, language}, []utils.StatementWithType{}, map[string]bool{})
+
+			dropStatements := make([]string, len(statements))
+			for i, statement := range statements {
+				dropStatements[i] = statement.Statement
+			}
+			Expect(dropStatements).To(Equal([]string{
+				"\n\nDROP LANGUAGE IF EXISTS plpythonu;",
+				"\n\nDROP USER MAPPING IF EXISTS FOR testrole SERVER testserver;",
+				"\n\nDROP OPERATOR CLASS IF EXISTS public.testclass USING gist;",
+				"\n\nDROP OPERATOR IF EXISTS public.## (path, path);",
+			}))
+		})
+	})
+	Describe("ExecuteCleanStatements", func() {
+		dropView := utils.StatementWithType{ObjectType: "VIEW", Statement: "\n\nDROP VIEW public.order_view;"}
+		dropTable := utils.StatementWithType{ObjectType: "TABLE", Statement: "\n\nDROP TABLE public.orders;"}
+		It("drops all objects in a single transaction", func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(dropView.Statement)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(dropTable.Statement)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectCommit()
+
+			restore.ExecuteCleanStatements([]utils.StatementWithType{dropView, dropTable})
+
+			Expect(mock.ExpectationsWereMet()).To(Succeed())
+		})
+		It("rolls back the transaction if any object cannot be dropped", func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(dropView.Statement)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(dropTable.Statement)).WillReturnError(errors.New(`table "orders" does not exist`))
+			mock.ExpectRollback()
+
+			defer func() {
+				Expect(mock.ExpectationsWereMet()).To(Succeed())
+			}()
+			defer testhelper.ShouldPanicWithMessage(`table "orders" does not exist; no existing objects were dropped`)
+			restore.ExecuteCleanStatements([]utils.StatementWithType{dropView, dropTable})
+		})
+		It("drops objects individually with --on-error-continue", func() {
+			restore.SetOnErrorContinue(true)
+			mock.ExpectExec(regexp.QuoteMeta(dropView.Statement)).WillReturnError(errors.New(`view "order_view" does not exist`))
+			mock.ExpectExec(regexp.QuoteMeta(dropTable.Statement)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+			restore.ExecuteCleanStatements([]utils.StatementWithType{dropView, dropTable})
+
+			Expect(mock.ExpectationsWereMet()).To(Succeed())
+		})
+	})
+})
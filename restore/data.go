@@ -17,8 +17,18 @@ var (
 	tableDelim = ","
 )
 
-func CopyTableIn(connection *dbconn.DBConn, tableName string, tableAttributes string, backupFile string, singleDataFile bool, whichConn int, oid uint32) int64 {
+func CopyTableIn(connection *dbconn.DBConn, tableName string, tableAttributes string, backupFile string, singleDataFile bool, whichConn int, oid uint32) (int64, error) {
 	whichConn = connection.ValidateConnNum(whichConn)
+	query := GetCopyTableInQuery(tableName, tableAttributes, backupFile, singleDataFile, oid)
+	result, err := connection.Exec(query, whichConn)
+	if err != nil {
+		return 0, err
+	}
+	numRows, _ := result.RowsAffected()
+	return numRows, nil
+}
+
+func GetCopyTableInQuery(tableName string, tableAttributes string, backupFile string, singleDataFile bool, oid uint32) string {
 	usingCompression, compressionProgram := utils.GetCompressionParameters()
 	copyCommand := ""
 	if singleDataFile {
@@ -28,16 +38,10 @@ func CopyTableIn(connection *dbconn.DBConn, tableName string, tableAttributes st
 	} else {
 		copyCommand = fmt.Sprintf("'%s'", backupFile)
 	}
-	query := fmt.Sprintf("COPY %s%s FROM %s WITH CSV DELIMITER '%s' ON SEGMENT;", tableName, tableAttributes, copyCommand, tableDelim)
-	result, err := connection.Exec(query, whichConn)
-	if err != nil {
-		gplog.Fatal(err, "Error loading data into table %s", tableName)
-	}
-	numRows, _ := result.RowsAffected()
-	return numRows
+	return fmt.Sprintf("COPY %s%s FROM %s WITH CSV DELIMITER '%s' ON SEGMENT;", tableName, tableAttributes, copyCommand, tableDelim)
 }
 
-func restoreSingleTableData(entry utils.MasterDataEntry, tableNum uint32, totalTables int, whichConn int) {
+func RestoreSingleTableData(entry utils.MasterDataEntry, tableNum uint32, totalTables int, whichConn int) {
 	name := utils.MakeFQN(entry.Schema, entry.Name)
 	if gplog.GetVerbosity() > gplog.LOGINFO {
 		// No progress bar at this log level, so we note table count here
@@ -51,7 +55,47 @@ func restoreSingleTableData(entry utils.MasterDataEntry, tableNum uint32, totalT
 	} else {
 		backupFile = globalFPInfo.GetTableBackupFilePathForCopyCommand(entry.Oid, backupConfig.SingleDataFile)
 	}
-	numRowsRestored := CopyTableIn(connection, name, entry.AttributeString, backupFile, backupConfig.SingleDataFile, whichConn, entry.Oid)
+	var numRowsRestored int64
+	copyData := func() error {
+		var err error
+		numRowsRestored, err = CopyTableIn(connection, name, entry.AttributeString, backupFile, backupConfig.SingleDataFile, whichConn, entry.Oid)
+		return err
+	}
+	if *disableTriggers && disableTableTriggers(name, whichConn) {
+		defer enableTableTriggers(name, whichConn)
+	}
+	var err error
+	if backupConfig.SingleDataFile {
+		// Data read from a segment pipe by a failed COPY cannot be read again, so it cannot be retried
+		err = copyData()
+	} else {
+		/*
+		 * Any partial data from a failed COPY is truncated before it is retried.
+		 * In a data-only restore the table was not created by gprestore and may
+		 * hold rows that are not from the backup, so it is never truncated.
+		 */
+		var truncateTable func() error
+		if !isDataOnlyRestore() {
+			truncateTable = func() error {
+				_, err := connection.Exec(fmt.Sprintf("TRUNCATE %s;", name), whichConn)
+				return err
+			}
+		}
+		err = ExecuteWithRetry(fmt.Sprintf("loading data into table %s", name), whichConn, copyData, truncateTable)
+	}
+	if err != nil {
+		/*
+		 * When a COPY from a segment pipe fails, the restore agent exits, so no
+		 * later table in a single data file can be restored and continuing would
+		 * leave the remaining COPY commands waiting on their pipes forever.
+		 */
+		if !*onErrorContinue || backupConfig.SingleDataFile {
+			gplog.Fatal(err, "Error loading data into table %s", name)
+		}
+		gplog.Error("Error loading data into table %s: %v", name, err)
+		recordFailedTable(name, err.Error())
+		return
+	}
 	numRowsBackedUp := entry.RowsCopied
 	CheckRowsRestored(numRowsRestored, numRowsBackedUp, name)
 }
@@ -61,6 +105,7 @@ func CheckRowsRestored(rowsRestored int64, rowsBackedUp int64, tableName string)
 		rowsErrMsg := fmt.Sprintf("Expected to restore %d rows to table %s, but restored %d instead", rowsBackedUp, tableName, rowsRestored)
 		if *onErrorContinue {
 			gplog.Error(rowsErrMsg)
+			recordFailedTable(tableName, rowsErrMsg)
 		} else {
 			agentErr := CheckAgentErrorsOnSegments()
 			if agentErr != nil {
@@ -1,6 +1,7 @@
 package restore_test
 
 import (
+	"errors"
 	"os/user"
 	"regexp"
 
@@ -48,6 +49,38 @@ var _ = Describe("restore/data tests", func() {
 			restore.CopyTableIn(connection, "public.foo", "(i,j)", filename, true, 0, 3456)
 		})
 	})
+	Describe("RestoreSingleTableData", func() {
+		entry := utils.MasterDataEntry{Schema: "public", Name: "foo", Oid: 3, AttributeString: "(i)", RowsCopied: 10}
+		copyError := errors.New("invalid input syntax for integer")
+		BeforeEach(func() {
+			restore.SetConnection(connection)
+			restore.SetDataOnly(false)
+			restore.SetDisableTriggers(false)
+			restore.SetOnErrorContinue(true)
+			restore.SetFPInfo(utils.NewFilePathInfo(map[int]string{-1: "/data/gpseg-1"}, "", "20170101010101", "gpseg"))
+		})
+		AfterEach(func() {
+			restore.SetOnErrorContinue(false)
+			restore.SetRestoreFailures(&utils.RestoreFailures{})
+		})
+		It("records the table as failed if its COPY fails and onErrorContinue is set", func() {
+			restore.SetBackupConfig(&utils.BackupConfig{})
+			failures := &utils.RestoreFailures{}
+			restore.SetRestoreFailures(failures)
+			mock.ExpectExec("COPY public.foo").WillReturnError(copyError)
+
+			restore.RestoreSingleTableData(entry, 1, 1, 0)
+
+			Expect(failures.FailedTables).To(Equal([]utils.FailedTable{{Table: "public.foo", Error: "invalid input syntax for integer"}}))
+		})
+		It("panics if its COPY fails while restoring a single data file, even if onErrorContinue is set", func() {
+			restore.SetBackupConfig(&utils.BackupConfig{SingleDataFile: true})
+			mock.ExpectExec("COPY public.foo").WillReturnError(copyError)
+
+			defer testhelper.ShouldPanicWithMessage("invalid input syntax for integer")
+			restore.RestoreSingleTableData(entry, 1, 1, 0)
+		})
+	})
 	Describe("CheckRowsRestored", func() {
 		masterSeg := cluster.SegConfig{ContentID: -1, Hostname: "localhost", DataDir: "/data/gpseg-1"}
 		localSegOne := cluster.SegConfig{ContentID: 0, Hostname: "localhost", DataDir: "/data/gpseg0"}
@@ -102,6 +135,16 @@ var _ = Describe("restore/data tests", func() {
 			defer testhelper.ShouldPanicWithMessage("Expected to restore 10 rows to table public.foo, but restored 5 instead")
 			restore.CheckRowsRestored(5, expectedRows, name)
 		})
+		It("records the table as failed if the numbers of rows do not match and onErrorContinue is set", func() {
+			restore.SetOnErrorContinue(true)
+			failures := &utils.RestoreFailures{}
+			restore.SetRestoreFailures(failures)
+			defer restore.SetRestoreFailures(&utils.RestoreFailures{})
+
+			restore.CheckRowsRestored(5, expectedRows, name)
+
+			Expect(failures.FailedTables).To(Equal([]utils.FailedTable{{Table: "public.foo", Error: "Expected to restore 10 rows to table public.foo, but restored 5 instead"}}))
+		})
 		It("prints an error if the numbers of rows do not match and onErrorContinue is set", func() {
 			restore.SetOnErrorContinue(true)
 			testExecutor.ClusterOutput = &cluster.RemoteOutput{
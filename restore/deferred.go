@@ -0,0 +1,123 @@
+package restore
+
+/*
+ * This file contains functions related to creating constraints after table
+ * data is restored, and to restoring each table's post-data objects as soon as
+ * its data is restored, with --defer-constraints.
+ */
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/greenplum-db/gpbackup/utils"
+)
+
+func getConstraintDefinition(statement utils.StatementWithType) string {
+	if statement.ObjectType != "CONSTRAINT" {
+		return ""
+	}
+	addStr := fmt.Sprintf(" ADD CONSTRAINT %s ", statement.Name)
+	index := strings.Index(statement.Statement, addStr)
+	if index == -1 {
+		return ""
+	}
+	return statement.Statement[index+len(addStr):]
+}
+
+// Primary key, unique, and exclusion constraints each build an index on their table.
+func isIndexBackedConstraint(statement utils.StatementWithType) bool {
+	definition := getConstraintDefinition(statement)
+	return strings.HasPrefix(definition, "PRIMARY KEY") || strings.HasPrefix(definition, "UNIQUE") || strings.HasPrefix(definition, "EXCLUDE")
+}
+
+func isForeignKeyConstraint(statement utils.StatementWithType) bool {
+	return strings.HasPrefix(getConstraintDefinition(statement), "FOREIGN KEY")
+}
+
+/*
+ * Index-backed constraints are removed from the pre-data statements so that
+ * their indexes are built after table data is restored, rather than updated
+ * for every row restored.  Foreign keys are removed as well, as they can only
+ * be created once the constraints they reference exist.  A constraint that
+ * another pre-data statement depends on cannot be deferred; since statements
+ * only depend on statements before them, walking the statements backwards
+ * finds every such constraint, including those needed by a constraint that is
+ * itself kept for that reason.
+ *
+ * The index-backed constraints and foreign keys are returned separately from
+ * the remaining pre-data statements, with no dependencies, as they are
+ * restored after all of the pre-data statements.
+ */
+func SplitDeferredConstraints(statements []utils.StatementWithType) ([]utils.StatementWithType, []utils.StatementWithType, []utils.StatementWithType) {
+	deferred := make([]bool, len(statements))
+	for i, statement := range statements {
+		deferred[i] = isIndexBackedConstraint(statement) || isForeignKeyConstraint(statement)
+	}
+	for i := len(statements) - 1; i >= 0; i-- {
+		if deferred[i] {
+			continue
+		}
+		for _, dependency := range statements[i].Dependencies {
+			if dependency >= 0 && dependency < i {
+				deferred[dependency] = false
+			}
+		}
+	}
+	indexConstraints := make([]utils.StatementWithType, 0)
+	foreignKeys := make([]utils.StatementWithType, 0)
+	for i, statement := range statements {
+		if !deferred[i] {
+			continue
+		}
+		statement.Dependencies = nil
+		if isForeignKeyConstraint(statement) {
+			foreignKeys = append(foreignKeys, statement)
+		} else {
+			indexConstraints = append(indexConstraints, statement)
+		}
+	}
+	remaining := keepStatements(statements, func(i int) bool { return !deferred[i] })
+	return remaining, indexConstraints, foreignKeys
+}
+
+/*
+ * Post-data statements that apply to a table whose data is being restored are
+ * grouped by that table, so that they can be restored as soon as its data is
+ * restored.  Foreign keys are never grouped, as the constraints they reference
+ * may be on tables whose data has not been restored yet.  All other statements
+ * are returned in their original order, to be restored after all table data.
+ */
+func GroupPostdataByTable(statements []utils.StatementWithType, entries []utils.MasterDataEntry) (map[string][]utils.StatementWithType, []utils.StatementWithType) {
+	restoredTables := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		restoredTables[utils.MakeFQN(entry.Schema, entry.Name)] = true
+	}
+	tableStatements := make(map[string][]utils.StatementWithType, 0)
+	remaining := make([]utils.StatementWithType, 0)
+	for _, statement := range statements {
+		if restoredTables[statement.ReferenceObject] && !isForeignKeyConstraint(statement) {
+			statement.Dependencies = nil
+			tableStatements[statement.ReferenceObject] = append(tableStatements[statement.ReferenceObject], statement)
+		} else {
+			remaining = append(remaining, statement)
+		}
+	}
+	return tableStatements, remaining
+}
+
+/*
+ * A table's post-data statements are executed one at a time on the connection
+ * that restored its data, so that no two indexes are ever built on the same
+ * table at once.  The return value is the number of errors encountered.
+ */
+func restoreTablePostdata(statements []utils.StatementWithType, whichConn int) uint32 {
+	var numErrors uint32
+	for _, statement := range statements {
+		if wasTerminated {
+			break
+		}
+		numErrors += executeStatement(statement, utils.PB_NONE, whichConn)
+	}
+	return numErrors
+}
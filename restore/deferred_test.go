@@ -0,0 +1,72 @@
+package restore_test
+
+import (
+	"github.com/greenplum-db/gpbackup/restore"
+	"github.com/greenplum-db/gpbackup/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("restore/deferred tests", func() {
+	createTable1 := utils.StatementWithType{ObjectType: "TABLE", Schema: "public", Name: "table1", Statement: "CREATE TABLE public.table1 (i int);"}
+	createTable2 := utils.StatementWithType{ObjectType: "TABLE", Schema: "public", Name: "table2", Statement: "CREATE TABLE public.table2 (i int, j int);"}
+	primaryKey := utils.StatementWithType{ObjectType: "CONSTRAINT", Schema: "public", Name: "table1_pkey", ReferenceObject: "public.table1",
+		Statement: "\n\nALTER TABLE ONLY public.table1 ADD CONSTRAINT table1_pkey PRIMARY KEY (i);\n", Dependencies: []int{0}}
+	uniqueConstraint := utils.StatementWithType{ObjectType: "CONSTRAINT", Schema: "public", Name: "table2_j_key", ReferenceObject: "public.table2",
+		Statement: "\n\nALTER TABLE ONLY public.table2 ADD CONSTRAINT table2_j_key UNIQUE (j);\n", Dependencies: []int{1}}
+	checkConstraint := utils.StatementWithType{ObjectType: "CONSTRAINT", Schema: "public", Name: "table2_j_check", ReferenceObject: "public.table2",
+		Statement: "\n\nALTER TABLE ONLY public.table2 ADD CONSTRAINT table2_j_check CHECK (j > 0);\n", Dependencies: []int{1}}
+	foreignKey := utils.StatementWithType{ObjectType: "CONSTRAINT", Schema: "public", Name: "table2_i_fkey", ReferenceObject: "public.table2",
+		Statement: "\n\nALTER TABLE ONLY public.table2 ADD CONSTRAINT table2_i_fkey FOREIGN KEY (i) REFERENCES public.table1(i);\n", Dependencies: []int{1, 2}}
+	Describe("SplitDeferredConstraints", func() {
+		It("removes index-backed constraints and foreign keys from the pre-data statements", func() {
+			statements := []utils.StatementWithType{createTable1, createTable2, primaryKey, uniqueConstraint, checkConstraint, foreignKey}
+
+			remaining, indexConstraints, foreignKeys := restore.SplitDeferredConstraints(statements)
+
+			expectedCheck := checkConstraint
+			expectedCheck.Dependencies = []int{1}
+			Expect(remaining).To(Equal([]utils.StatementWithType{createTable1, createTable2, expectedCheck}))
+			expectedPrimaryKey, expectedUnique, expectedForeignKey := primaryKey, uniqueConstraint, foreignKey
+			expectedPrimaryKey.Dependencies, expectedUnique.Dependencies, expectedForeignKey.Dependencies = nil, nil, nil
+			Expect(indexConstraints).To(Equal([]utils.StatementWithType{expectedPrimaryKey, expectedUnique}))
+			Expect(foreignKeys).To(Equal([]utils.StatementWithType{expectedForeignKey}))
+		})
+		It("does not defer a constraint that another pre-data statement depends on", func() {
+			createView := utils.StatementWithType{ObjectType: "VIEW", Schema: "public", Name: "view1",
+				Statement: "CREATE VIEW public.view1 AS SELECT i, j FROM public.table2 GROUP BY i;", Dependencies: []int{3}}
+			foreignKeyOnPrimaryKey := foreignKey
+			foreignKeyOnPrimaryKey.Dependencies = []int{1, 2}
+			keptPrimaryKey := primaryKey
+			keptPrimaryKey.Dependencies = []int{0}
+			statements := []utils.StatementWithType{createTable1, createTable2, keptPrimaryKey, foreignKeyOnPrimaryKey, createView}
+
+			remaining, indexConstraints, foreignKeys := restore.SplitDeferredConstraints(statements)
+
+			Expect(remaining).To(Equal(statements))
+			Expect(indexConstraints).To(BeEmpty())
+			Expect(foreignKeys).To(BeEmpty())
+		})
+	})
+	Describe("GroupPostdataByTable", func() {
+		index1 := utils.StatementWithType{ObjectType: "INDEX", Schema: "public", Name: "table1_idx", ReferenceObject: "public.table1", Statement: "CREATE INDEX table1_idx ON public.table1 USING btree (i);"}
+		trigger1 := utils.StatementWithType{ObjectType: "TRIGGER", Schema: "public", Name: "table1_trigger", ReferenceObject: "public.table1", Statement: "CREATE TRIGGER table1_trigger AFTER INSERT ON public.table1 FOR EACH ROW EXECUTE PROCEDURE public.func1();", Dependencies: []int{1}}
+		index3 := utils.StatementWithType{ObjectType: "INDEX", Schema: "public", Name: "table3_idx", ReferenceObject: "public.table3", Statement: "CREATE INDEX table3_idx ON public.table3 USING btree (i);"}
+		eventTrigger := utils.StatementWithType{ObjectType: "EVENT TRIGGER", Name: "event_trigger1", Statement: "CREATE EVENT TRIGGER event_trigger1 ON ddl_command_start EXECUTE PROCEDURE public.func2();"}
+		entries := []utils.MasterDataEntry{{Schema: "public", Name: "table1"}, {Schema: "public", Name: "table2"}}
+		It("groups the statements for each restored table and returns all other statements", func() {
+			statements := []utils.StatementWithType{primaryKey, uniqueConstraint, foreignKey, index1, trigger1, index3, eventTrigger}
+
+			tableStatements, remaining := restore.GroupPostdataByTable(statements, entries)
+
+			expectedPrimaryKey, expectedUnique, expectedTrigger := primaryKey, uniqueConstraint, trigger1
+			expectedPrimaryKey.Dependencies, expectedUnique.Dependencies, expectedTrigger.Dependencies = nil, nil, nil
+			Expect(tableStatements).To(Equal(map[string][]utils.StatementWithType{
+				"public.table1": {expectedPrimaryKey, index1, expectedTrigger},
+				"public.table2": {expectedUnique},
+			}))
+			Expect(remaining).To(Equal([]utils.StatementWithType{foreignKey, index3, eventTrigger}))
+		})
+	})
+})
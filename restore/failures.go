@@ -0,0 +1,93 @@
+package restore
+
+/*
+ * This file contains functions related to recording the statements and tables
+ * that fail to restore with --on-error-continue, and to restoring only those
+ * statements and tables with --retry-failed.
+ */
+
+import (
+	"fmt"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"github.com/greenplum-db/gpbackup/utils"
+)
+
+func recordFailedStatement(statement utils.StatementWithType, err error) {
+	restoreFailuresLock.Lock()
+	defer restoreFailuresLock.Unlock()
+	restoreFailures.AddFailedStatement(statement, err)
+}
+
+func recordFailedTable(table string, errMsg string) {
+	restoreFailuresLock.Lock()
+	defer restoreFailuresLock.Unlock()
+	restoreFailures.AddFailedTable(table, errMsg)
+}
+
+func WriteRestoreFailuresFile(filename string) {
+	if restoreFailures.IsEmpty() {
+		return
+	}
+	restoreFailures.WriteToFile(filename)
+	gplog.Warn("%d statement(s) and %d table(s) failed to restore and were recorded in %s; use --retry-failed %s to retry them",
+		len(restoreFailures.FailedStatements), len(restoreFailures.FailedTables), filename, filename)
+}
+
+func getStatementKey(objectType string, schema string, name string, referenceObject string) string {
+	return fmt.Sprintf("%s|%s|%s|%s", objectType, schema, name, referenceObject)
+}
+
+/*
+ * Failed statements are matched to the statements being restored after those
+ * statements have been filtered and rewritten, so --retry-failed must be used
+ * with the same flags as the run that recorded the failures.  Session GUCs are
+ * always kept, as they are needed to restore anything else.  Dependencies on
+ * statements that are filtered out are dropped, as those statements were
+ * already restored.
+ */
+func FilterFailedStatements(statements []utils.StatementWithType) []utils.StatementWithType {
+	failedStatements := make(map[string]bool, len(retryFailures.FailedStatements))
+	for _, failed := range retryFailures.FailedStatements {
+		failedStatements[getStatementKey(failed.ObjectType, failed.Schema, failed.Name, failed.ReferenceObject)] = true
+	}
+	return keepStatements(statements, func(i int) bool {
+		statement := statements[i]
+		return statement.ObjectType == "SESSION GUCS" || failedStatements[getStatementKey(statement.ObjectType, statement.Schema, statement.Name, statement.ReferenceObject)]
+	})
+}
+
+func FilterFailedTables(entries []utils.MasterDataEntry) []utils.MasterDataEntry {
+	failedTables := make(map[string]bool, len(retryFailures.FailedTables))
+	for _, failed := range retryFailures.FailedTables {
+		failedTables[failed.Table] = true
+	}
+	filteredEntries := make([]utils.MasterDataEntry, 0)
+	for _, entry := range entries {
+		if failedTables[utils.MakeFQN(entry.Schema, entry.Name)] {
+			filteredEntries = append(filteredEntries, entry)
+		}
+	}
+	return filteredEntries
+}
+
+/*
+ * A table whose row count did not match the backup may have been partially
+ * loaded, so tables are truncated before their data is restored again.  In a
+ * data-only restore, the tables were not created by gprestore and may hold
+ * rows that were not restored from the backup, so they are never truncated.
+ */
+func TruncateTablesForRetry(entries []utils.MasterDataEntry) {
+	if isDataOnlyRestore() {
+		gplog.Warn("Tables are not truncated before their data is restored again in a data-only restore, as they may contain rows that are not from the backup; any rows already restored to them will be restored again")
+		return
+	}
+	for _, entry := range entries {
+		table := utils.MakeFQN(entry.Schema, entry.Name)
+		gplog.Verbose("Truncating table %s before restoring its data again", table)
+		_, err := connection.Exec(fmt.Sprintf("TRUNCATE %s;", table))
+		if err != nil {
+			gplog.Fatal(err, "Error truncating table %s", table)
+		}
+	}
+}
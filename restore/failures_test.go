@@ -0,0 +1,103 @@
+package restore_test
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/greenplum-db/gp-common-go-libs/testhelper"
+	"github.com/greenplum-db/gpbackup/restore"
+	"github.com/greenplum-db/gpbackup/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+var _ = Describe("restore/failures tests", func() {
+	createTable := utils.StatementWithType{ObjectType: "TABLE", Schema: "public", Name: "foo", Statement: "CREATE TABLE public.foo (i int);"}
+	createIndex := utils.StatementWithType{ObjectType: "INDEX", Schema: "public", Name: "foo_idx", ReferenceObject: "public.foo", Statement: "CREATE INDEX foo_idx ON public.foo USING btree (i);"}
+	createView := utils.StatementWithType{ObjectType: "VIEW", Schema: "public", Name: "foo_view", Statement: "CREATE VIEW public.foo_view AS SELECT * FROM public.foo;"}
+	AfterEach(func() {
+		restore.SetRetryFailures(nil)
+		restore.SetRestoreFailures(&utils.RestoreFailures{})
+	})
+	Describe("ExecuteStatements", func() {
+		It("records statements that fail with --on-error-continue", func() {
+			restore.SetConnection(connection)
+			restore.SetOnErrorContinue(true)
+			defer restore.SetOnErrorContinue(false)
+			failures := &utils.RestoreFailures{}
+			restore.SetRestoreFailures(failures)
+			mock.ExpectExec(regexp.QuoteMeta(createTable.Statement)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(createIndex.Statement)).WillReturnError(errors.New(`relation "foo_idx" already exists`))
+
+			restore.ExecuteStatementsAndCreateProgressBar([]utils.StatementWithType{createTable, createIndex}, "", utils.PB_NONE, false)
+
+			Expect(failures.FailedStatements).To(Equal([]utils.FailedStatement{{ObjectType: "INDEX", Schema: "public", Name: "foo_idx", ReferenceObject: "public.foo",
+				Statement: createIndex.Statement, Error: `relation "foo_idx" already exists`}}))
+		})
+	})
+	Describe("FilterFailedStatements", func() {
+		It("keeps only failed statements and session GUCs, and updates dependencies", func() {
+			restore.SetRetryFailures(&utils.RestoreFailures{FailedStatements: []utils.FailedStatement{
+				{ObjectType: "VIEW", Schema: "public", Name: "foo_view"},
+				{ObjectType: "INDEX", Schema: "public", Name: "foo_idx", ReferenceObject: "public.foo"},
+			}})
+			gucs := utils.StatementWithType{ObjectType: "SESSION GUCS", Statement: "SET client_encoding = 'UTF8';"}
+			otherView := utils.StatementWithType{ObjectType: "VIEW", Schema: "public", Name: "bar_view", Statement: "CREATE VIEW public.bar_view AS SELECT 1;"}
+			statements := []utils.StatementWithType{gucs, createTable, otherView, createView, createIndex}
+			statements[3].Dependencies = []int{1, 2}
+			statements[4].Dependencies = []int{3}
+
+			filteredStatements := restore.FilterFailedStatements(statements)
+
+			expectedView := createView
+			expectedIndex := createIndex
+			expectedIndex.Dependencies = []int{1}
+			Expect(filteredStatements).To(Equal([]utils.StatementWithType{gucs, expectedView, expectedIndex}))
+		})
+	})
+	Describe("FilterFailedTables", func() {
+		It("keeps only tables that failed to restore", func() {
+			restore.SetRetryFailures(&utils.RestoreFailures{FailedTables: []utils.FailedTable{{Table: "public.bar"}}})
+			entries := []utils.MasterDataEntry{{Schema: "public", Name: "foo", Oid: 1}, {Schema: "public", Name: "bar", Oid: 2}}
+
+			Expect(restore.FilterFailedTables(entries)).To(Equal([]utils.MasterDataEntry{{Schema: "public", Name: "bar", Oid: 2}}))
+		})
+	})
+	Describe("TruncateTablesForRetry", func() {
+		entries := []utils.MasterDataEntry{{Schema: "public", Name: "foo", Oid: 1}}
+		BeforeEach(func() {
+			restore.SetConnection(connection)
+			restore.SetBackupConfig(&utils.BackupConfig{})
+		})
+		AfterEach(func() {
+			restore.SetDataOnly(false)
+		})
+		It("truncates tables before their data is restored again", func() {
+			restore.SetDataOnly(false)
+			mock.ExpectExec("TRUNCATE public.foo;").WillReturnResult(sqlmock.NewResult(0, 0))
+
+			restore.TruncateTablesForRetry(entries)
+
+			Expect(mock.ExpectationsWereMet()).To(Succeed())
+		})
+		It("does not truncate tables in a data-only restore", func() {
+			restore.SetDataOnly(true)
+
+			restore.TruncateTablesForRetry(entries)
+
+			Expect(mock.ExpectationsWereMet()).To(Succeed())
+			testhelper.ExpectRegexp(logfile, "Tables are not truncated before their data is restored again in a data-only restore")
+		})
+		It("does not truncate tables when restoring a data-only backup", func() {
+			restore.SetDataOnly(false)
+			restore.SetBackupConfig(&utils.BackupConfig{DataOnly: true})
+
+			restore.TruncateTablesForRetry(entries)
+
+			Expect(mock.ExpectationsWereMet()).To(Succeed())
+			testhelper.ExpectRegexp(logfile, "Tables are not truncated before their data is restored again in a data-only restore")
+		})
+	})
+})
@@ -18,15 +18,25 @@ import (
  */
 
 var (
-	backupConfig     *utils.BackupConfig
-	connection       *dbconn.DBConn
-	globalCluster    cluster.Cluster
-	globalFPInfo     utils.FilePathInfo
-	globalTOC        *utils.TOC
-	pluginConfig     *utils.PluginConfig
-	restoreStartTime string
-	version          string
-	wasTerminated    bool
+	analyzeResults      *utils.AnalyzeResults
+	backupConfig        *utils.BackupConfig
+	connection          *dbconn.DBConn
+	droppedForeignKeys  []utils.StatementWithType
+	globalCluster       cluster.Cluster
+	globalFPInfo        utils.FilePathInfo
+	globalTOC           *utils.TOC
+	pluginConfig        *utils.PluginConfig
+	priorityTables      []string
+	restoreFailures     = &utils.RestoreFailures{}
+	restoreFailuresLock sync.Mutex
+	restoreStartTime    string
+	retryFailures       *utils.RestoreFailures
+	retryPolicy         RetryPolicy
+	roleMap             map[string]string
+	tableRenameMap      map[string]string
+	tablespaceMap       map[string]string
+	version             string
+	wasTerminated       bool
 
 	/*
 	 * Used for synchronizing DoCleanup.  In DoInit() we increment the group
@@ -41,25 +51,45 @@ var (
  */
 
 var (
-	backupDir        *string
-	createDB         *bool
-	debug            *bool
-	excludeSchemas   utils.ArrayFlags
-	excludeTableFile *string
-	excludeTables    utils.ArrayFlags
-	includeSchemas   utils.ArrayFlags
-	includeTableFile *string
-	includeTables    utils.ArrayFlags
-	numJobs          *int
-	onErrorContinue  *bool
-	pluginConfigFile *string
-	printVersion     *bool
-	quiet            *bool
-	redirect         *string
-	restoreGlobals   *bool
-	timestamp        *string
-	verbose          *bool
-	withStats        *bool
+	backupDir          *string
+	clean              *bool
+	createDB           *bool
+	dataOnly           *bool
+	debug              *bool
+	deferConstraints   *bool
+	disableTriggers    *bool
+	excludeObjectTypes utils.ArrayFlags
+	excludeSchemas     utils.ArrayFlags
+	excludeTableFile   *string
+	excludeTables      utils.ArrayFlags
+	ifExists           *bool
+	includeObjectTypes utils.ArrayFlags
+	includeSchemas     utils.ArrayFlags
+	includeTableFile   *string
+	includeTables      utils.ArrayFlags
+	noOwner            *bool
+	noPrivileges       *bool
+	numJobs            *int
+	onErrorContinue    *bool
+	outputFile         *string
+	pluginConfigFile   *string
+	printVersion       *bool
+	priorityTableFile  *string
+	quiet              *bool
+	redirect           *string
+	restoreGlobals     *bool
+	retryAttempts      *int
+	retryBackoff       *int
+	retryFailedFile    *string
+	retrySQLStates     utils.ArrayFlags
+	roleMapFile        *string
+	runAnalyze         *bool
+	syncSequences      *string
+	tableRenameMapFile *string
+	tablespaceMapFile  *string
+	timestamp          *string
+	verbose            *bool
+	withStats          *bool
 )
 
 /*
@@ -74,22 +104,82 @@ func SetConnection(conn *dbconn.DBConn) {
 	connection = conn
 }
 
+func SetClean(cleanObjects bool) {
+	clean = &cleanObjects
+}
+
 func SetCluster(cluster cluster.Cluster) {
 	globalCluster = cluster
 }
 
+func SetDataOnly(restoreDataOnly bool) {
+	dataOnly = &restoreDataOnly
+}
+
+func SetDeferConstraints(shouldDefer bool) {
+	deferConstraints = &shouldDefer
+}
+
+func SetDisableTriggers(disable bool) {
+	disableTriggers = &disable
+}
+
+func SetExcludeObjectTypes(objectTypes []string) {
+	excludeObjectTypes = objectTypes
+}
+
 func SetFPInfo(fpInfo utils.FilePathInfo) {
 	globalFPInfo = fpInfo
 }
 
+func SetIfExists(useIfExists bool) {
+	ifExists = &useIfExists
+}
+
+func SetIncludeObjectTypes(objectTypes []string) {
+	includeObjectTypes = objectTypes
+}
+
 func SetOnErrorContinue(errContinue bool) {
 	onErrorContinue = &errContinue
 }
 
+func SetNoOwner(skipOwner bool) {
+	noOwner = &skipOwner
+}
+
+func SetNoPrivileges(skipPrivileges bool) {
+	noPrivileges = &skipPrivileges
+}
+
 func SetNumJobs(jobs int) {
 	numJobs = &jobs
 }
 
+func SetRestoreFailures(failures *utils.RestoreFailures) {
+	restoreFailures = failures
+}
+
+func SetRetryFailures(failures *utils.RestoreFailures) {
+	retryFailures = failures
+}
+
+func SetRetryPolicy(policy RetryPolicy) {
+	retryPolicy = policy
+}
+
+func SetRoleMap(newRoleMap map[string]string) {
+	roleMap = newRoleMap
+}
+
+func SetTableRenameMap(newTableRenameMap map[string]string) {
+	tableRenameMap = newTableRenameMap
+}
+
+func SetTablespaceMap(newTablespaceMap map[string]string) {
+	tablespaceMap = newTablespaceMap
+}
+
 func SetTOC(toc *utils.TOC) {
 	globalTOC = toc
 }
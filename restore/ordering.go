@@ -0,0 +1,44 @@
+package restore
+
+/*
+ * This file contains functions related to the order in which table data is
+ * restored, including with --priority-table-file.
+ */
+
+import (
+	"sort"
+
+	"github.com/greenplum-db/gpbackup/utils"
+)
+
+/*
+ * Tables listed in the priority file are restored first, in the order they
+ * are listed, so that they are available as soon as possible.  The remaining
+ * tables are restored largest first, so that a large table is not started
+ * last and left restoring on its own after all of the others are done.  Table
+ * size is the size recorded at the time of the backup, falling back to the
+ * number of rows backed up for backups that did not record sizes, and tables
+ * of the same size are restored in the order they were backed up.
+ */
+func SortDataEntriesForRestore(entries []utils.MasterDataEntry, priorityTables []string) []utils.MasterDataEntry {
+	priorities := make(map[string]int, len(priorityTables))
+	for i, table := range priorityTables {
+		if _, ok := priorities[table]; !ok {
+			priorities[table] = i
+		}
+	}
+	sortedEntries := make([]utils.MasterDataEntry, len(entries))
+	copy(sortedEntries, entries)
+	sort.SliceStable(sortedEntries, func(i, j int) bool {
+		iPriority, iIsPriority := priorities[utils.MakeFQN(sortedEntries[i].Schema, sortedEntries[i].Name)]
+		jPriority, jIsPriority := priorities[utils.MakeFQN(sortedEntries[j].Schema, sortedEntries[j].Name)]
+		if iIsPriority || jIsPriority {
+			return iIsPriority && (!jIsPriority || iPriority < jPriority)
+		}
+		if sortedEntries[i].TableSize != sortedEntries[j].TableSize {
+			return sortedEntries[i].TableSize > sortedEntries[j].TableSize
+		}
+		return sortedEntries[i].RowsCopied > sortedEntries[j].RowsCopied
+	})
+	return sortedEntries
+}
@@ -0,0 +1,53 @@
+package restore_test
+
+import (
+	"github.com/greenplum-db/gpbackup/restore"
+	"github.com/greenplum-db/gpbackup/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("restore/ordering tests", func() {
+	Describe("SortDataEntriesForRestore", func() {
+		small := utils.MasterDataEntry{Schema: "public", Name: "small", Oid: 1, RowsCopied: 10, TableSize: 32768}
+		large := utils.MasterDataEntry{Schema: "public", Name: "large", Oid: 2, RowsCopied: 1000, TableSize: 1048576}
+		medium := utils.MasterDataEntry{Schema: "schema2", Name: `"Medium"`, Oid: 3, RowsCopied: 100, TableSize: 131072}
+		empty := utils.MasterDataEntry{Schema: "public", Name: "empty", Oid: 4}
+		entries := []utils.MasterDataEntry{small, large, medium, empty}
+		It("sorts tables from largest to smallest", func() {
+			sortedEntries := restore.SortDataEntriesForRestore(entries, []string{})
+			Expect(sortedEntries).To(Equal([]utils.MasterDataEntry{large, medium, small, empty}))
+		})
+		It("does not change the order of the entries passed in", func() {
+			restore.SortDataEntriesForRestore(entries, []string{})
+			Expect(entries).To(Equal([]utils.MasterDataEntry{small, large, medium, empty}))
+		})
+		It("sorts tables by rows backed up if their sizes were not recorded", func() {
+			noSizes := []utils.MasterDataEntry{}
+			for _, entry := range entries {
+				entry.TableSize = 0
+				noSizes = append(noSizes, entry)
+			}
+			sortedEntries := restore.SortDataEntriesForRestore(noSizes, []string{})
+			Expect(sortedEntries).To(Equal([]utils.MasterDataEntry{noSizes[1], noSizes[2], noSizes[0], noSizes[3]}))
+		})
+		It("keeps tables of the same size in the order they were backed up", func() {
+			other := utils.MasterDataEntry{Schema: "public", Name: "other", Oid: 5, RowsCopied: 10, TableSize: 32768}
+			sortedEntries := restore.SortDataEntriesForRestore([]utils.MasterDataEntry{other, large, small}, []string{})
+			Expect(sortedEntries).To(Equal([]utils.MasterDataEntry{large, other, small}))
+		})
+		It("sorts priority tables first in the order they are listed", func() {
+			sortedEntries := restore.SortDataEntriesForRestore(entries, []string{"public.empty", "public.small"})
+			Expect(sortedEntries).To(Equal([]utils.MasterDataEntry{empty, small, large, medium}))
+		})
+		It("matches priority tables with quoted names", func() {
+			sortedEntries := restore.SortDataEntriesForRestore(entries, []string{`schema2."Medium"`})
+			Expect(sortedEntries).To(Equal([]utils.MasterDataEntry{medium, large, small, empty}))
+		})
+		It("ignores priority tables that are not being restored", func() {
+			sortedEntries := restore.SortDataEntriesForRestore(entries, []string{"public.missing", "public.small", "public.small"})
+			Expect(sortedEntries).To(Equal([]utils.MasterDataEntry{small, large, medium, empty}))
+		})
+	})
+})
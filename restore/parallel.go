@@ -20,11 +20,18 @@ import (
  * an error code.
  */
 func executeStatement(statement utils.StatementWithType, showProgressBar int, whichConn int) uint32 {
+	if strings.TrimSpace(statement.Statement) == "" {
+		return 0
+	}
 	whichConn = connection.ValidateConnNum(whichConn)
-	_, err := connection.Exec(statement.Statement, whichConn)
+	err := ExecuteWithRetry(getStatementDescription(statement), whichConn, func() error {
+		_, err := connection.Exec(statement.Statement, whichConn)
+		return err
+	}, nil)
 	if err != nil {
 		gplog.Verbose("Error encountered when executing statement: %s Error was: %s", strings.TrimSpace(statement.Statement), err.Error())
 		if *onErrorContinue {
+			recordFailedStatement(statement, err)
 			return 1
 		}
 		if showProgressBar >= utils.PB_INFO && gplog.GetVerbosity() == gplog.LOGINFO {
@@ -81,6 +88,99 @@ func ExecuteStatementsAndCreateProgressBar(statements []utils.StatementWithType,
 	progressBar.Finish()
 }
 
+/*
+ * This function executes statements across all connections while ensuring that
+ * no statement is executed until every statement it depends on has finished.
+ * Each statement's Dependencies are indexes into the statements slice; a
+ * statement becomes ready once its last dependency has been executed, and any
+ * ready statement may be picked up by any idle connection.
+ */
+func ExecuteStatementsInDependencyOrder(statements []utils.StatementWithType, progressBar utils.ProgressBar, showProgressBar int) {
+	var numErrors uint32
+	remaining := make([]int, len(statements))
+	dependents := make([][]int, len(statements))
+	for i, statement := range statements {
+		for _, dependency := range statement.Dependencies {
+			// Statements only ever depend on statements before them, so ignoring anything else guarantees we can't deadlock
+			if dependency >= 0 && dependency < i {
+				remaining[i]++
+				dependents[dependency] = append(dependents[dependency], i)
+			}
+		}
+	}
+
+	ready := make(chan int, len(statements))
+	var pending sync.WaitGroup
+	var mutex sync.Mutex
+	pending.Add(len(statements))
+	for i := range statements {
+		if remaining[i] == 0 {
+			ready <- i
+		}
+	}
+	go func() {
+		pending.Wait()
+		close(ready)
+	}()
+
+	var workerPool sync.WaitGroup
+	for i := 0; i < connection.NumConns; i++ {
+		workerPool.Add(1)
+		go func(whichConn int) {
+			for index := range ready {
+				if !wasTerminated {
+					atomic.AddUint32(&numErrors, executeStatement(statements[index], showProgressBar, whichConn))
+					progressBar.Increment()
+				}
+				mutex.Lock()
+				for _, dependent := range dependents[index] {
+					remaining[dependent]--
+					if remaining[dependent] == 0 {
+						ready <- dependent
+					}
+				}
+				mutex.Unlock()
+				pending.Done()
+			}
+			workerPool.Done()
+		}(i)
+	}
+	workerPool.Wait()
+	if numErrors > 0 {
+		gplog.Error("Encountered %d errors during metadata restore; see log file %s for a list of failed statements.", numErrors, gplog.GetLogFilePath())
+	}
+}
+
+/*
+ * This function returns the statements for whose indexes keep returns true.
+ * Each statement's Dependencies are translated into indexes into the returned
+ * slice, and dependencies on statements that are not kept are dropped.
+ */
+func keepStatements(statements []utils.StatementWithType, keep func(int) bool) []utils.StatementWithType {
+	newIndexes := make(map[int]int, 0)
+	keptStatements := make([]utils.StatementWithType, 0)
+	for i, statement := range statements {
+		if !keep(i) {
+			continue
+		}
+		newIndexes[i] = len(keptStatements)
+		keptStatements = append(keptStatements, statement)
+	}
+	for i := range keptStatements {
+		dependencies := make([]int, 0)
+		for _, dependency := range keptStatements[i].Dependencies {
+			if newIndex, ok := newIndexes[dependency]; ok {
+				dependencies = append(dependencies, newIndex)
+			}
+		}
+		if len(dependencies) == 0 {
+			dependencies = nil
+		}
+		keptStatements[i].Dependencies = dependencies
+	}
+	return keptStatements
+}
+
 /*
  *   There is an existing bug in Greenplum where creating indexes in parallel
  *   on an AO table that didn't have any indexes previously can cause
@@ -91,6 +191,8 @@ func ExecuteStatementsAndCreateProgressBar(statements []utils.StatementWithType,
  *   restores them in parallel (which has no possibility of deadlock) and
  *   then the second restores all other postdata objects in parallel. After
  *   each table has at least one index, there is no more risk of deadlock.
+ *   Constraints deferred with --defer-constraints build indexes as well, so
+ *   they are batched the same way.
  */
 func BatchPostdataStatements(statements []utils.StatementWithType) ([]utils.StatementWithType, []utils.StatementWithType) {
 	indexMap := make(map[string]bool, 0)
@@ -98,7 +200,7 @@ func BatchPostdataStatements(statements []utils.StatementWithType) ([]utils.Stat
 	secondBatch := make([]utils.StatementWithType, 0)
 	for _, statement := range statements {
 		_, tableIndexPresent := indexMap[statement.ReferenceObject]
-		if statement.ObjectType == "INDEX" && !tableIndexPresent {
+		if (statement.ObjectType == "INDEX" || isIndexBackedConstraint(statement)) && !tableIndexPresent {
 			indexMap[statement.ReferenceObject] = true
 			firstBatch = append(firstBatch, statement)
 		} else {
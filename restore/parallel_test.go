@@ -1,11 +1,14 @@
 package restore_test
 
 import (
+	"regexp"
+
 	"github.com/greenplum-db/gpbackup/restore"
 	"github.com/greenplum-db/gpbackup/utils"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
 )
 
 var _ = Describe("restore/validate tests", func() {
@@ -32,6 +35,67 @@ var _ = Describe("restore/validate tests", func() {
 			Expect(firstBatch).To(Equal([]utils.StatementWithType{index1}))
 			Expect(secondBatch).To(Equal([]utils.StatementWithType{index1, trigger}))
 		})
+		It("places the first index-backed constraint or index for a table in first batch", func() {
+			primaryKey := utils.StatementWithType{ObjectType: "CONSTRAINT", Name: "table1_pkey", ReferenceObject: "public.table1", Statement: `ALTER TABLE ONLY public.table1 ADD CONSTRAINT table1_pkey PRIMARY KEY (i);`}
+			statements := []utils.StatementWithType{primaryKey, index1, index2}
+			firstBatch, secondBatch := restore.BatchPostdataStatements(statements)
+			Expect(firstBatch).To(Equal([]utils.StatementWithType{primaryKey, index2}))
+			Expect(secondBatch).To(Equal([]utils.StatementWithType{index1}))
+		})
+
+	})
+	Describe("ExecuteStatementsInDependencyOrder", func() {
+		createType := utils.StatementWithType{ObjectType: "TYPE", Statement: "CREATE TYPE public.type1 AS (i int);"}
+		createFunction := utils.StatementWithType{ObjectType: "FUNCTION", Statement: "CREATE FUNCTION public.func1() RETURNS int AS 'SELECT 1' LANGUAGE sql;"}
+		createTable := utils.StatementWithType{ObjectType: "TABLE", Statement: "CREATE TABLE public.table1 (t public.type1);"}
+		createView := utils.StatementWithType{ObjectType: "VIEW", Statement: "CREATE VIEW public.view1 AS SELECT * FROM public.table1;"}
+		BeforeEach(func() {
+			restore.SetConnection(connection)
+			restore.SetOnErrorContinue(false)
+		})
+		It("executes each statement after the statements it depends on", func() {
+			statements := []utils.StatementWithType{createType, createFunction, createTable, createView}
+			statements[2].Dependencies = []int{0}
+			statements[3].Dependencies = []int{2}
+			mock.ExpectExec(regexp.QuoteMeta(createType.Statement)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(createFunction.Statement)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(createTable.Statement)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(createView.Statement)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+			restore.ExecuteStatementsInDependencyOrder(statements, utils.NewProgressBar(len(statements), "", utils.PB_NONE), utils.PB_NONE)
+
+			Expect(mock.ExpectationsWereMet()).To(Succeed())
+		})
+		It("executes independent statements before statements that depend on an earlier statement", func() {
+			statements := []utils.StatementWithType{createType, createFunction, createTable}
+			statements[1].Dependencies = []int{0}
+			mock.ExpectExec(regexp.QuoteMeta(createType.Statement)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(createTable.Statement)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(createFunction.Statement)).WillReturnResult(sqlmock.NewResult(0, 0))
 
+			restore.ExecuteStatementsInDependencyOrder(statements, utils.NewProgressBar(len(statements), "", utils.PB_NONE), utils.PB_NONE)
+
+			Expect(mock.ExpectationsWereMet()).To(Succeed())
+		})
+		It("ignores dependencies on later statements", func() {
+			statements := []utils.StatementWithType{createType, createFunction}
+			statements[0].Dependencies = []int{1}
+			mock.ExpectExec(regexp.QuoteMeta(createType.Statement)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(createFunction.Statement)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+			restore.ExecuteStatementsInDependencyOrder(statements, utils.NewProgressBar(len(statements), "", utils.PB_NONE), utils.PB_NONE)
+
+			Expect(mock.ExpectationsWereMet()).To(Succeed())
+		})
+		It("skips statements with no SQL to execute", func() {
+			statements := []utils.StatementWithType{createType, {ObjectType: "DEFAULT PRIVILEGES", Statement: "\n\n"}, createTable}
+			statements[2].Dependencies = []int{1}
+			mock.ExpectExec(regexp.QuoteMeta(createType.Statement)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta(createTable.Statement)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+			restore.ExecuteStatementsInDependencyOrder(statements, utils.NewProgressBar(len(statements), "", utils.PB_NONE), utils.PB_NONE)
+
+			Expect(mock.ExpectationsWereMet()).To(Succeed())
+		})
 	})
 })
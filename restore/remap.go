@@ -0,0 +1,387 @@
+package restore
+
+/*
+ * This file contains functions related to rewriting metadata statements from
+ * the backup before they are restored, so that a backup can be restored into
+ * an environment that differs from the one in which it was taken.
+ */
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"github.com/greenplum-db/gp-common-go-libs/operating"
+	"github.com/greenplum-db/gpbackup/utils"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+var (
+	roleIdentifier = `("(?:[^"]|"")+"|[^\s;"]+)`
+	ownerRegex     = regexp.MustCompile(`^ALTER .+ OWNER TO ` + roleIdentifier + `;$`)
+	// This matches GRANT and REVOKE statements on objects, including default privileges, but not role membership grants
+	privilegesRegex = regexp.MustCompile(`^(?:ALTER DEFAULT PRIVILEGES FOR ROLE ` + roleIdentifier + ` .*?)?(?:GRANT|REVOKE) .+ ON .+ (?:TO|FROM) ` + roleIdentifier + `(?: WITH GRANT OPTION)?;$`)
+
+	/*
+	 * Tablespace names follow the TABLESPACE keyword in CREATE TABLESPACE and
+	 * CREATE TABLE statements, ALTER ... SET TABLESPACE, USING INDEX TABLESPACE,
+	 * and privilege, owner, and comment statements for tablespaces.  This is
+	 * case-sensitive, as the keyword is always uppercase in generated DDL and
+	 * this avoids matching unquoted column names.
+	 */
+	tablespaceRegex       = regexp.MustCompile(`\bTABLESPACE\s+("(?:[^"]|"")+"|[A-Za-z_][A-Za-z0-9_$]*)`)
+	createTablespaceRegex = regexp.MustCompile(`^CREATE TABLESPACE \S+ (LOCATION|FILESPACE) (.+);$`)
+)
+
+/*
+ * Map files are YAML files mapping the names of objects in the backup to the
+ * values that should replace them, e.g. "old_role: new_role".  Names are given
+ * without quotes.
+ */
+func readMapFile(filename string, mapDescription string) map[string]string {
+	contents, err := operating.System.ReadFile(filename)
+	gplog.FatalOnError(err)
+	nameMap := make(map[string]string, 0)
+	err = yaml.Unmarshal(contents, &nameMap)
+	if err != nil {
+		gplog.Fatal(errors.Errorf("Could not parse %s file %s: %v", mapDescription, filename, err), "")
+	}
+	for oldName, newValue := range nameMap {
+		if oldName == "" || newValue == "" {
+			gplog.Fatal(errors.Errorf("The %s file %s contains an empty name", mapDescription, filename), "")
+		}
+	}
+	return nameMap
+}
+
+func ReadRoleMapFile(filename string) map[string]string {
+	return readMapFile(filename, "role map")
+}
+
+/*
+ * Each tablespace in the tablespace map file is mapped to one of the following:
+ * - "default", to create the objects in it in the default tablespace instead
+ * - an absolute path, to create the tablespace at that location instead
+ * - any other value, to rename the tablespace
+ */
+func ReadTablespaceMapFile(filename string) map[string]string {
+	return readMapFile(filename, "tablespace map")
+}
+
+/*
+ * Owner and privilege statements are printed in the same TOC entry as the
+ * object they apply to, so they are removed or rewritten by splitting each
+ * statement into its component SQL statements.  Statements are modified in
+ * place rather than removed so that dependency indexes remain valid; a
+ * statement left with no SQL to execute is skipped by executeStatement.
+ */
+func ApplyOwnerAndPrivilegeOptions(statements []utils.StatementWithType) []utils.StatementWithType {
+	if !*noOwner && !*noPrivileges && len(roleMap) == 0 {
+		return statements
+	}
+	for i := range statements {
+		statements[i].Statement = rewriteOwnerAndPrivilegeStatements(statements[i].Statement)
+	}
+	return statements
+}
+
+func rewriteOwnerAndPrivilegeStatements(statement string) string {
+	var rewritten bytes.Buffer
+	for _, sql := range utils.SplitSQLStatements(statement) {
+		trimmed := strings.TrimLeftFunc(sql, unicode.IsSpace)
+		prefix := sql[:len(sql)-len(trimmed)]
+		var roleMatches []int
+		if matches := ownerRegex.FindStringSubmatchIndex(trimmed); matches != nil {
+			if *noOwner {
+				continue
+			}
+			roleMatches = matches
+		} else if matches := privilegesRegex.FindStringSubmatchIndex(trimmed); matches != nil {
+			if *noPrivileges {
+				continue
+			}
+			roleMatches = matches
+		}
+		if roleMatches != nil && len(roleMap) > 0 {
+			trimmed = replaceMappedRoles(trimmed, roleMatches)
+		}
+		rewritten.WriteString(prefix)
+		rewritten.WriteString(trimmed)
+	}
+	return rewritten.String()
+}
+
+// Roles are replaced starting from the end of the statement, so that earlier match indexes remain valid.
+func replaceMappedRoles(sql string, matches []int) string {
+	for group := len(matches)/2 - 1; group >= 1; group-- {
+		start, end := matches[2*group], matches[2*group+1]
+		if start < 0 {
+			continue
+		}
+		if newRole, ok := roleMap[utils.UnquoteIdent(sql[start:end])]; ok {
+			sql = sql[:start] + utils.QuoteIdent(newRole) + sql[end:]
+		}
+	}
+	return sql
+}
+
+func getTablespaceMapping(tablespace string) (string, bool) {
+	newValue, ok := tablespaceMap[utils.UnquoteIdent(tablespace)]
+	return newValue, ok
+}
+
+/*
+ * Tablespaces mapped to the default tablespace are not created, and objects in
+ * them are created in pg_default instead.  The statements for such tablespaces
+ * are emptied rather than removed so that dependency indexes remain valid.
+ */
+func ApplyTablespaceMap(statements []utils.StatementWithType) []utils.StatementWithType {
+	if len(tablespaceMap) == 0 {
+		return statements
+	}
+	for i := range statements {
+		if statements[i].ObjectType == "TABLESPACE" || statements[i].ObjectType == "TABLESPACE METADATA" {
+			if newValue, ok := getTablespaceMapping(statements[i].Name); ok && newValue == "default" {
+				statements[i].Statement = ""
+				continue
+			}
+		}
+		statements[i].Statement = rewriteTablespaces(statements[i].Statement)
+	}
+	return statements
+}
+
+func rewriteTablespaces(statement string) string {
+	var rewritten bytes.Buffer
+	for _, sql := range utils.SplitSQLStatements(statement) {
+		masked := utils.MaskQuotedSQL(sql)
+		if matches := tablespaceRegex.FindStringSubmatchIndex(masked); matches != nil {
+			if strings.HasPrefix(strings.TrimLeftFunc(masked, unicode.IsSpace), "CREATE TABLESPACE ") {
+				sql = rewriteTablespaceLocation(sql, sql[matches[2]:matches[3]])
+				masked = utils.MaskQuotedSQL(sql)
+			}
+		}
+		allMatches := tablespaceRegex.FindAllStringSubmatchIndex(masked, -1)
+		for j := len(allMatches) - 1; j >= 0; j-- {
+			start, end := allMatches[j][2], allMatches[j][3]
+			newValue, ok := getTablespaceMapping(sql[start:end])
+			if !ok || strings.HasPrefix(newValue, "/") {
+				continue
+			}
+			newTablespace := "pg_default"
+			if newValue != "default" {
+				newTablespace = utils.QuoteIdent(newValue)
+			}
+			sql = sql[:start] + newTablespace + sql[end:]
+		}
+		rewritten.WriteString(sql)
+	}
+	return rewritten.String()
+}
+
+func rewriteTablespaceLocation(sql string, tablespace string) string {
+	newValue, ok := getTablespaceMapping(tablespace)
+	if !ok || !strings.HasPrefix(newValue, "/") {
+		return sql
+	}
+	trimmed := strings.TrimLeftFunc(sql, unicode.IsSpace)
+	prefix := sql[:len(sql)-len(trimmed)]
+	matches := createTablespaceRegex.FindStringSubmatchIndex(trimmed)
+	if matches == nil {
+		return sql
+	}
+	if trimmed[matches[2]:matches[3]] == "FILESPACE" {
+		gplog.Fatal(errors.Errorf("Tablespace %s is stored in a filespace and cannot be mapped to a location", tablespace), "")
+	}
+	location := fmt.Sprintf("'%s'", strings.Replace(newValue, "'", "''", -1))
+	return prefix + trimmed[:matches[4]] + location + trimmed[matches[5]:]
+}
+
+var (
+	fqnRegex            = regexp.MustCompile(`^("(?:[^"]|"")+"|[^".]+)\.("(?:[^"]|"")+"|[^"]+)$`)
+	identifierRegex     = regexp.MustCompile(`^(?:"(?:[^"]|"")+"|[a-z_][a-z0-9_$]*)$`)
+	partitionChildRegex = regexp.MustCompile(`^_\d+_prt_`)
+)
+
+/*
+ * The table rename map file maps fully-qualified table names in the backup,
+ * quoted as for --include-table, to new names for those tables in the same
+ * schema, e.g. "public.orders: orders_20171016".  Quotes in new names are
+ * preserved, so names requiring quotes must be quoted in the file.
+ */
+func ReadTableRenameMapFile(filename string) map[string]string {
+	renameMap := readMapFile(filename, "table rename map")
+	oldTables := make([]string, 0)
+	for oldTable, newName := range renameMap {
+		oldTables = append(oldTables, oldTable)
+		if !identifierRegex.MatchString(newName) {
+			gplog.Fatal(errors.Errorf("New name %s for table %s is not a valid identifier.  Please ensure it is quoted appropriately.", newName, oldTable), "")
+		}
+	}
+	utils.ValidateFQNs(oldTables)
+	return renameMap
+}
+
+func splitFQN(fqn string) (string, string, bool) {
+	matches := fqnRegex.FindStringSubmatch(fqn)
+	if matches == nil {
+		return "", "", false
+	}
+	return matches[1], matches[2], true
+}
+
+/*
+ * Returns the new name of a renamed table, or of a partition of a renamed
+ * table, as the names of partitions are derived from the name of their parent.
+ */
+func getRenamedTableName(schema string, table string) (string, bool) {
+	if len(tableRenameMap) == 0 {
+		return "", false
+	}
+	if newName, ok := tableRenameMap[utils.MakeFQN(schema, table)]; ok {
+		return newName, true
+	}
+	unquotedSchema := utils.UnquoteIdent(schema)
+	unquotedTable := utils.UnquoteIdent(table)
+	for oldFQN, newName := range tableRenameMap {
+		oldSchema, oldTable, _ := splitFQN(oldFQN)
+		if utils.UnquoteIdent(oldSchema) != unquotedSchema {
+			continue
+		}
+		if suffix, ok := getNameSuffix(unquotedTable, utils.UnquoteIdent(oldTable)); ok && partitionChildRegex.MatchString(suffix) {
+			return utils.QuoteIdent(utils.UnquoteIdent(newName) + suffix), true
+		}
+	}
+	return "", false
+}
+
+func getNameSuffix(name string, prefix string) (string, bool) {
+	if len(name) > len(prefix) && strings.HasPrefix(name, prefix) {
+		return name[len(prefix):], true
+	}
+	return "", false
+}
+
+func getRenamedTableFQN(fqn string) (string, bool) {
+	schema, table, ok := splitFQN(fqn)
+	if !ok {
+		return "", false
+	}
+	newName, ok := getRenamedTableName(schema, table)
+	if !ok {
+		return "", false
+	}
+	return utils.MakeFQN(schema, newName), true
+}
+
+func RenameTableList(tables []string) []string {
+	renamedTables := make([]string, len(tables))
+	for i, table := range tables {
+		renamedTables[i] = table
+		if newFQN, ok := getRenamedTableFQN(table); ok {
+			renamedTables[i] = newFQN
+		}
+	}
+	return renamedTables
+}
+
+func RenameDataEntries(entries []utils.MasterDataEntry) []utils.MasterDataEntry {
+	for i := range entries {
+		if newName, ok := getRenamedTableName(entries[i].Schema, entries[i].Name); ok {
+			entries[i].Name = newName
+		}
+	}
+	return entries
+}
+
+/*
+ * Statements for renamed tables and their partitions, and for the constraints,
+ * indexes, triggers, and rules on them, are rewritten to use the new table
+ * names.  Constraints and indexes are named uniquely within a schema, so any
+ * whose names begin with the old table name are renamed to begin with the new
+ * table name instead, which keeps them from colliding with those of the
+ * original table if it still exists.
+ */
+func ApplyTableRenameMap(statements []utils.StatementWithType) []utils.StatementWithType {
+	if len(tableRenameMap) == 0 {
+		return statements
+	}
+	for i := range statements {
+		owningTable := statements[i].ReferenceObject
+		switch statements[i].ObjectType {
+		case "TABLE", "EXCHANGE PARTITION", "STATISTICS":
+			owningTable = utils.MakeFQN(statements[i].Schema, statements[i].Name)
+		}
+		newOwningTable, ok := getRenamedTableFQN(owningTable)
+		if !ok {
+			continue
+		}
+		statement := &statements[i]
+		statement.Statement = renameTablesInStatement(statement.Statement, statement.Schema)
+		switch statement.ObjectType {
+		case "TABLE", "EXCHANGE PARTITION":
+			_, statement.Name, _ = splitFQN(newOwningTable)
+		case "STATISTICS":
+			statement.Statement = renameTableInStatistics(statement.Statement, owningTable, newOwningTable)
+			_, statement.Name, _ = splitFQN(newOwningTable)
+		case "CONSTRAINT", "INDEX":
+			statement.ReferenceObject = newOwningTable
+			_, oldTable, _ := splitFQN(owningTable)
+			_, newTable, _ := splitFQN(newOwningTable)
+			if suffix, ok := getNameSuffix(utils.UnquoteIdent(statement.Name), utils.UnquoteIdent(oldTable)); ok {
+				newName := utils.QuoteIdent(utils.UnquoteIdent(newTable) + suffix)
+				statement.Statement = replaceIdentifier(statement.Statement, statement.Name, newName)
+				statement.Name = newName
+			}
+		default:
+			statement.ReferenceObject = newOwningTable
+		}
+	}
+	return statements
+}
+
+// Table names are only replaced where they are schema-qualified and not quoted.
+func renameTablesInStatement(statement string, schema string) string {
+	masked := utils.MaskQuotedSQL(statement)
+	tableRegex := regexp.MustCompile(`(?:^|[^A-Za-z0-9_$".])(` + regexp.QuoteMeta(schema) + `\.("(?:[^"]|"")+"|[A-Za-z_][A-Za-z0-9_$]*))`)
+	allMatches := tableRegex.FindAllStringSubmatchIndex(statement, -1)
+	for j := len(allMatches) - 1; j >= 0; j-- {
+		start, end := allMatches[j][2], allMatches[j][3]
+		if masked[start] != statement[start] {
+			continue
+		}
+		if newFQN, ok := getRenamedTableFQN(statement[start:end]); ok {
+			statement = statement[:start] + newFQN + statement[end:]
+		}
+	}
+	return statement
+}
+
+func replaceIdentifier(statement string, oldName string, newName string) string {
+	masked := utils.MaskQuotedSQL(statement)
+	nameRegex := regexp.MustCompile(`(?:^|[^A-Za-z0-9_$"])(` + regexp.QuoteMeta(oldName) + `)(?:[^A-Za-z0-9_$"]|$)`)
+	allMatches := nameRegex.FindAllStringSubmatchIndex(statement, -1)
+	for j := len(allMatches) - 1; j >= 0; j-- {
+		start, end := allMatches[j][2], allMatches[j][3]
+		if masked[start] != statement[start] {
+			continue
+		}
+		statement = statement[:start] + newName + statement[end:]
+	}
+	return statement
+}
+
+// Statistics statements identify tables using string literals rather than identifiers.
+func renameTableInStatistics(statement string, oldFQN string, newFQN string) string {
+	_, oldTable, _ := splitFQN(oldFQN)
+	_, newTable, _ := splitFQN(newFQN)
+	escapeLiteral := func(str string) string {
+		return strings.Replace(str, "'", "''", -1)
+	}
+	statement = strings.Replace(statement, fmt.Sprintf("relname = '%s'", escapeLiteral(utils.UnquoteIdent(oldTable))), fmt.Sprintf("relname = '%s'", escapeLiteral(utils.UnquoteIdent(newTable))), -1)
+	return strings.Replace(statement, fmt.Sprintf("'%s'::regclass", escapeLiteral(oldFQN)), fmt.Sprintf("'%s'::regclass", escapeLiteral(newFQN)), -1)
+}
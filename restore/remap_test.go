@@ -0,0 +1,293 @@
+package restore_test
+
+import (
+	"github.com/greenplum-db/gp-common-go-libs/testhelper"
+	"github.com/greenplum-db/gpbackup/restore"
+	"github.com/greenplum-db/gpbackup/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("restore/remap tests", func() {
+	Describe("ApplyOwnerAndPrivilegeOptions", func() {
+		tableStatement := `
+
+CREATE TABLE public.foo (
+	i integer
+) DISTRIBUTED RANDOMLY;
+
+COMMENT ON TABLE public.foo IS 'This is a table comment.';
+
+ALTER TABLE public.foo OWNER TO testrole;
+
+REVOKE ALL ON TABLE public.foo FROM PUBLIC;
+REVOKE ALL ON TABLE public.foo FROM testrole;
+GRANT ALL ON TABLE public.foo TO testrole;
+GRANT SELECT ON TABLE public.foo TO "Other Role" WITH GRANT OPTION;`
+		functionStatement := `
+
+CREATE FUNCTION public.grant_access() RETURNS void AS $$
+BEGIN
+	GRANT ALL ON TABLE public.foo TO testrole;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER FUNCTION public.grant_access() OWNER TO testrole;`
+		defaultPrivilegesStatement := `
+
+ALTER DEFAULT PRIVILEGES FOR ROLE testrole REVOKE ALL ON TABLES FROM PUBLIC;
+ALTER DEFAULT PRIVILEGES FOR ROLE testrole GRANT SELECT ON TABLES TO "Other Role";`
+		var statements []utils.StatementWithType
+		BeforeEach(func() {
+			restore.SetNoOwner(false)
+			restore.SetNoPrivileges(false)
+			restore.SetRoleMap(map[string]string{})
+			statements = []utils.StatementWithType{
+				{Schema: "public", Name: "foo", ObjectType: "TABLE", Statement: tableStatement},
+				{Schema: "public", Name: "grant_access()", ObjectType: "FUNCTION", Statement: functionStatement},
+				{Schema: "", Name: "testrole ON TABLES", ObjectType: "DEFAULT PRIVILEGES", Statement: defaultPrivilegesStatement},
+			}
+		})
+		AfterEach(func() {
+			restore.SetNoOwner(false)
+			restore.SetNoPrivileges(false)
+			restore.SetRoleMap(map[string]string{})
+		})
+		It("does not modify statements if no options are set", func() {
+			result := restore.ApplyOwnerAndPrivilegeOptions(statements)
+			Expect(result[0].Statement).To(Equal(tableStatement))
+			Expect(result[1].Statement).To(Equal(functionStatement))
+			Expect(result[2].Statement).To(Equal(defaultPrivilegesStatement))
+		})
+		It("removes owner statements if --no-owner is set", func() {
+			restore.SetNoOwner(true)
+			result := restore.ApplyOwnerAndPrivilegeOptions(statements)
+			Expect(result[0].Statement).To(Equal(`
+
+CREATE TABLE public.foo (
+	i integer
+) DISTRIBUTED RANDOMLY;
+
+COMMENT ON TABLE public.foo IS 'This is a table comment.';
+
+REVOKE ALL ON TABLE public.foo FROM PUBLIC;
+REVOKE ALL ON TABLE public.foo FROM testrole;
+GRANT ALL ON TABLE public.foo TO testrole;
+GRANT SELECT ON TABLE public.foo TO "Other Role" WITH GRANT OPTION;`))
+			Expect(result[1].Statement).To(Equal(`
+
+CREATE FUNCTION public.grant_access() RETURNS void AS $$
+BEGIN
+	GRANT ALL ON TABLE public.foo TO testrole;
+END;
+$$ LANGUAGE plpgsql;`))
+			Expect(result[2].Statement).To(Equal(defaultPrivilegesStatement))
+		})
+		It("removes privilege statements if --no-privileges is set", func() {
+			restore.SetNoPrivileges(true)
+			result := restore.ApplyOwnerAndPrivilegeOptions(statements)
+			Expect(result[0].Statement).To(Equal(`
+
+CREATE TABLE public.foo (
+	i integer
+) DISTRIBUTED RANDOMLY;
+
+COMMENT ON TABLE public.foo IS 'This is a table comment.';
+
+ALTER TABLE public.foo OWNER TO testrole;`))
+			Expect(result[1].Statement).To(Equal(functionStatement))
+			Expect(result[2].Statement).To(Equal(""))
+		})
+		It("rewrites mapped roles in owner and privilege statements", func() {
+			restore.SetRoleMap(map[string]string{"testrole": "newrole", "Other Role": "another_role"})
+			result := restore.ApplyOwnerAndPrivilegeOptions(statements)
+			Expect(result[0].Statement).To(Equal(`
+
+CREATE TABLE public.foo (
+	i integer
+) DISTRIBUTED RANDOMLY;
+
+COMMENT ON TABLE public.foo IS 'This is a table comment.';
+
+ALTER TABLE public.foo OWNER TO newrole;
+
+REVOKE ALL ON TABLE public.foo FROM PUBLIC;
+REVOKE ALL ON TABLE public.foo FROM newrole;
+GRANT ALL ON TABLE public.foo TO newrole;
+GRANT SELECT ON TABLE public.foo TO another_role WITH GRANT OPTION;`))
+			Expect(result[1].Statement).To(Equal(`
+
+CREATE FUNCTION public.grant_access() RETURNS void AS $$
+BEGIN
+	GRANT ALL ON TABLE public.foo TO testrole;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER FUNCTION public.grant_access() OWNER TO newrole;`))
+			Expect(result[2].Statement).To(Equal(`
+
+ALTER DEFAULT PRIVILEGES FOR ROLE newrole REVOKE ALL ON TABLES FROM PUBLIC;
+ALTER DEFAULT PRIVILEGES FOR ROLE newrole GRANT SELECT ON TABLES TO another_role;`))
+		})
+		It("quotes mapped roles that are reserved keywords", func() {
+			restore.SetRoleMap(map[string]string{"testrole": "user"})
+			result := restore.ApplyOwnerAndPrivilegeOptions(statements)
+			Expect(result[1].Statement).To(HaveSuffix(`ALTER FUNCTION public.grant_access() OWNER TO "user";`))
+			Expect(result[2].Statement).To(HavePrefix(`
+
+ALTER DEFAULT PRIVILEGES FOR ROLE "user" REVOKE ALL ON TABLES FROM PUBLIC;`))
+		})
+		It("does not rewrite roles in role membership grants", func() {
+			restore.SetRoleMap(map[string]string{"testrole": "newrole"})
+			roleGrant := "\n\nGRANT testrole TO otherrole;"
+			result := restore.ApplyOwnerAndPrivilegeOptions([]utils.StatementWithType{{Name: "otherrole", ObjectType: "ROLE GRANT", Statement: roleGrant}})
+			Expect(result[0].Statement).To(Equal(roleGrant))
+		})
+	})
+	Describe("ApplyTablespaceMap", func() {
+		createTablespace := utils.StatementWithType{Name: "test_tablespace", ObjectType: "TABLESPACE", Statement: "\n\nCREATE TABLESPACE test_tablespace LOCATION '/data/dir';"}
+		tablespaceMetadata := utils.StatementWithType{Name: "test_tablespace", ObjectType: "TABLESPACE METADATA", Statement: "\n\nCOMMENT ON TABLESPACE test_tablespace IS 'TABLESPACE test_tablespace';\n\nALTER TABLESPACE test_tablespace OWNER TO testrole;"}
+		createTable := utils.StatementWithType{Schema: "public", Name: "foo", ObjectType: "TABLE", Statement: `
+
+CREATE TABLE public.foo (
+	tablespace text DEFAULT 'TABLESPACE test_tablespace',
+	CONSTRAINT foo_pkey PRIMARY KEY (tablespace) USING INDEX TABLESPACE test_tablespace
+) TABLESPACE test_tablespace DISTRIBUTED BY (tablespace);`}
+		createIndex := utils.StatementWithType{Schema: "public", Name: "foo_idx", ObjectType: "INDEX", Statement: "\n\nCREATE INDEX foo_idx ON public.foo USING btree (tablespace);\nALTER INDEX public.foo_idx SET TABLESPACE test_tablespace;"}
+		var statements []utils.StatementWithType
+		BeforeEach(func() {
+			statements = []utils.StatementWithType{createTablespace, tablespaceMetadata, createTable, createIndex}
+		})
+		AfterEach(func() {
+			restore.SetTablespaceMap(map[string]string{})
+		})
+		It("does not modify statements for unmapped tablespaces", func() {
+			restore.SetTablespaceMap(map[string]string{"other_tablespace": "default"})
+			result := restore.ApplyTablespaceMap(statements)
+			Expect(result).To(Equal([]utils.StatementWithType{createTablespace, tablespaceMetadata, createTable, createIndex}))
+		})
+		It("renames a tablespace everywhere it is referenced outside of quoted text", func() {
+			restore.SetTablespaceMap(map[string]string{"test_tablespace": "New Tablespace"})
+			result := restore.ApplyTablespaceMap(statements)
+			Expect(result[0].Statement).To(Equal("\n\nCREATE TABLESPACE \"New Tablespace\" LOCATION '/data/dir';"))
+			Expect(result[1].Statement).To(Equal("\n\nCOMMENT ON TABLESPACE \"New Tablespace\" IS 'TABLESPACE test_tablespace';\n\nALTER TABLESPACE \"New Tablespace\" OWNER TO testrole;"))
+			Expect(result[2].Statement).To(Equal(`
+
+CREATE TABLE public.foo (
+	tablespace text DEFAULT 'TABLESPACE test_tablespace',
+	CONSTRAINT foo_pkey PRIMARY KEY (tablespace) USING INDEX TABLESPACE "New Tablespace"
+) TABLESPACE "New Tablespace" DISTRIBUTED BY (tablespace);`))
+			Expect(result[3].Statement).To(Equal("\n\nCREATE INDEX foo_idx ON public.foo USING btree (tablespace);\nALTER INDEX public.foo_idx SET TABLESPACE \"New Tablespace\";"))
+		})
+		It("changes the location of a tablespace mapped to a path", func() {
+			restore.SetTablespaceMap(map[string]string{"test_tablespace": "/new/data/dir"})
+			result := restore.ApplyTablespaceMap(statements)
+			Expect(result[0].Statement).To(Equal("\n\nCREATE TABLESPACE test_tablespace LOCATION '/new/data/dir';"))
+			Expect(result[1:]).To(Equal([]utils.StatementWithType{tablespaceMetadata, createTable, createIndex}))
+		})
+		It("panics if a tablespace stored in a filespace is mapped to a path", func() {
+			restore.SetTablespaceMap(map[string]string{"test_tablespace": "/new/data/dir"})
+			filespaceTablespace := utils.StatementWithType{Name: "test_tablespace", ObjectType: "TABLESPACE", Statement: "\n\nCREATE TABLESPACE test_tablespace FILESPACE test_filespace;"}
+			defer testhelper.ShouldPanicWithMessage("Tablespace test_tablespace is stored in a filespace and cannot be mapped to a location")
+			restore.ApplyTablespaceMap([]utils.StatementWithType{filespaceTablespace})
+		})
+		It("does not create a tablespace mapped to the default tablespace, and uses pg_default for objects in it", func() {
+			restore.SetTablespaceMap(map[string]string{"test_tablespace": "default"})
+			result := restore.ApplyTablespaceMap(statements)
+			Expect(result[0].Statement).To(Equal(""))
+			Expect(result[1].Statement).To(Equal(""))
+			Expect(result[2].Statement).To(Equal(`
+
+CREATE TABLE public.foo (
+	tablespace text DEFAULT 'TABLESPACE test_tablespace',
+	CONSTRAINT foo_pkey PRIMARY KEY (tablespace) USING INDEX TABLESPACE pg_default
+) TABLESPACE pg_default DISTRIBUTED BY (tablespace);`))
+			Expect(result[3].Statement).To(Equal("\n\nCREATE INDEX foo_idx ON public.foo USING btree (tablespace);\nALTER INDEX public.foo_idx SET TABLESPACE pg_default;"))
+		})
+	})
+	Describe("table rename map", func() {
+		BeforeEach(func() {
+			restore.SetTableRenameMap(map[string]string{"public.orders": "orders_20171016"})
+		})
+		AfterEach(func() {
+			restore.SetTableRenameMap(map[string]string{})
+		})
+		Describe("ApplyTableRenameMap", func() {
+			It("renames a table everywhere it is referenced outside of quoted text", func() {
+				createTable := utils.StatementWithType{Schema: "public", Name: "orders", ObjectType: "TABLE", Statement: `
+
+CREATE TABLE public.orders (
+	id integer DEFAULT nextval('public.orders_id_seq'::regclass),
+	status text DEFAULT 'public.orders'
+) DISTRIBUTED BY (id) PARTITION BY LIST(status) (PARTITION open VALUES('open'));
+
+COMMENT ON TABLE public.orders IS 'Copy of public.orders';
+
+COMMENT ON COLUMN public.orders.id IS 'Order id';
+
+ALTER TABLE public.orders OWNER TO testrole;`}
+				result := restore.ApplyTableRenameMap([]utils.StatementWithType{createTable})
+				Expect(result[0].Name).To(Equal("orders_20171016"))
+				Expect(result[0].Statement).To(Equal(`
+
+CREATE TABLE public.orders_20171016 (
+	id integer DEFAULT nextval('public.orders_id_seq'::regclass),
+	status text DEFAULT 'public.orders'
+) DISTRIBUTED BY (id) PARTITION BY LIST(status) (PARTITION open VALUES('open'));
+
+COMMENT ON TABLE public.orders_20171016 IS 'Copy of public.orders';
+
+COMMENT ON COLUMN public.orders_20171016.id IS 'Order id';
+
+ALTER TABLE public.orders_20171016 OWNER TO testrole;`))
+			})
+			It("renames constraints and indexes whose names begin with the table name", func() {
+				constraint := utils.StatementWithType{Schema: "public", Name: "orders_pkey", ObjectType: "CONSTRAINT", ReferenceObject: "public.orders", Statement: "\n\nALTER TABLE ONLY public.orders ADD CONSTRAINT orders_pkey PRIMARY KEY (id);"}
+				index := utils.StatementWithType{Schema: "public", Name: "status_idx", ObjectType: "INDEX", ReferenceObject: "public.orders", Statement: "\n\nCREATE INDEX status_idx ON public.orders USING btree (status);"}
+				childIndex := utils.StatementWithType{Schema: "public", Name: "orders_1_prt_open_status_idx", ObjectType: "INDEX", ReferenceObject: "public.orders_1_prt_open", Statement: "\n\nCREATE INDEX orders_1_prt_open_status_idx ON public.orders_1_prt_open USING btree (status);\nALTER INDEX public.orders_1_prt_open_status_idx SET TABLESPACE test_tablespace;"}
+				trigger := utils.StatementWithType{Schema: "public", Name: "orders_trigger", ObjectType: "TRIGGER", ReferenceObject: "public.orders", Statement: "\n\nCREATE TRIGGER orders_trigger AFTER INSERT ON public.orders FOR EACH ROW EXECUTE PROCEDURE public.log_order();"}
+
+				result := restore.ApplyTableRenameMap([]utils.StatementWithType{constraint, index, childIndex, trigger})
+
+				Expect(result).To(Equal([]utils.StatementWithType{
+					{Schema: "public", Name: "orders_20171016_pkey", ObjectType: "CONSTRAINT", ReferenceObject: "public.orders_20171016", Statement: "\n\nALTER TABLE ONLY public.orders_20171016 ADD CONSTRAINT orders_20171016_pkey PRIMARY KEY (id);"},
+					{Schema: "public", Name: "status_idx", ObjectType: "INDEX", ReferenceObject: "public.orders_20171016", Statement: "\n\nCREATE INDEX status_idx ON public.orders_20171016 USING btree (status);"},
+					{Schema: "public", Name: "orders_20171016_1_prt_open_status_idx", ObjectType: "INDEX", ReferenceObject: "public.orders_20171016_1_prt_open", Statement: "\n\nCREATE INDEX orders_20171016_1_prt_open_status_idx ON public.orders_20171016_1_prt_open USING btree (status);\nALTER INDEX public.orders_20171016_1_prt_open_status_idx SET TABLESPACE test_tablespace;"},
+					{Schema: "public", Name: "orders_trigger", ObjectType: "TRIGGER", ReferenceObject: "public.orders_20171016", Statement: "\n\nCREATE TRIGGER orders_trigger AFTER INSERT ON public.orders_20171016 FOR EACH ROW EXECUTE PROCEDURE public.log_order();"},
+				}))
+			})
+			It("renames tables in statistics statements", func() {
+				statistics := utils.StatementWithType{Schema: "public", Name: "orders", ObjectType: "STATISTICS", Statement: "\n\nUPDATE pg_class\nSET\n\trelpages = 1::int,\n\treltuples = 1.000000::real\nWHERE relname = 'orders'\nAND relnamespace = 2200;\n\nDELETE FROM pg_statistic WHERE starelid = 'public.orders'::regclass::oid AND staattnum = 1;"}
+				result := restore.ApplyTableRenameMap([]utils.StatementWithType{statistics})
+				Expect(result[0].Name).To(Equal("orders_20171016"))
+				Expect(result[0].Statement).To(Equal("\n\nUPDATE pg_class\nSET\n\trelpages = 1::int,\n\treltuples = 1.000000::real\nWHERE relname = 'orders_20171016'\nAND relnamespace = 2200;\n\nDELETE FROM pg_statistic WHERE starelid = 'public.orders_20171016'::regclass::oid AND staattnum = 1;"))
+			})
+			It("does not modify statements for other tables", func() {
+				otherTable := utils.StatementWithType{Schema: "public", Name: "orders_archive", ObjectType: "TABLE", Statement: "\n\nCREATE TABLE public.orders_archive (LIKE public.orders);"}
+				otherSchema := utils.StatementWithType{Schema: "schema2", Name: "orders", ObjectType: "TABLE", Statement: "\n\nCREATE TABLE schema2.orders (id integer);"}
+				result := restore.ApplyTableRenameMap([]utils.StatementWithType{otherTable, otherSchema})
+				Expect(result).To(Equal([]utils.StatementWithType{otherTable, otherSchema}))
+			})
+		})
+		Describe("RenameDataEntries", func() {
+			It("renames data entries for renamed tables and their partitions", func() {
+				entries := []utils.MasterDataEntry{
+					{Schema: "public", Name: "orders", Oid: 1},
+					{Schema: "public", Name: "orders_1_prt_open", Oid: 2},
+					{Schema: "public", Name: "orders_archive", Oid: 3},
+				}
+				Expect(restore.RenameDataEntries(entries)).To(Equal([]utils.MasterDataEntry{
+					{Schema: "public", Name: "orders_20171016", Oid: 1},
+					{Schema: "public", Name: "orders_20171016_1_prt_open", Oid: 2},
+					{Schema: "public", Name: "orders_archive", Oid: 3},
+				}))
+			})
+		})
+		Describe("RenameTableList", func() {
+			It("renames tables in the list that are in the table rename map", func() {
+				Expect(restore.RenameTableList([]string{"public.orders", "public.sales"})).To(Equal([]string{"public.orders_20171016", "public.sales"}))
+			})
+		})
+	})
+})
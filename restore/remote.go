@@ -2,7 +2,6 @@ package restore
 
 import (
 	"fmt"
-	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -26,6 +25,22 @@ func VerifyBackupDirectoriesExistOnAllHosts() {
 	})
 }
 
+/*
+ * When restoring with more than one job, the restore agent may write to the
+ * pipes for several tables at once, so the pipes for all tables in the oid
+ * list are created before any data is restored.
+ */
+func CreateAllSegmentPipesOnAllHostsForRestore() {
+	remoteOutput := globalCluster.GenerateAndExecuteCommand("Creating segment data pipes", func(contentID int) string {
+		pipeName := globalFPInfo.GetSegmentPipeFilePathWithPID(contentID)
+		oidFile := globalFPInfo.GetSegmentHelperFilePath(contentID, "oid")
+		return fmt.Sprintf(`set -o pipefail; sed "s|^|%s_|" %s | xargs mkfifo`, pipeName, oidFile)
+	}, cluster.ON_SEGMENTS)
+	globalCluster.CheckClusterError(remoteOutput, "Unable to create segment data pipes", func(contentID int) string {
+		return "Unable to create segment data pipes"
+	})
+}
+
 func CreateSegmentPipesOnAllHostsForRestore(oid uint32) {
 	remoteOutput := globalCluster.GenerateAndExecuteCommand("Creating segment data pipes", func(contentID int) string {
 		pipeName := globalFPInfo.GetSegmentPipeFilePathWithPID(contentID)
@@ -46,16 +61,15 @@ func WriteToSegmentPipes() {
 		backupFile := globalFPInfo.GetTableBackupFilePath(contentID, 0, true)
 		gphomePath := operating.System.Getenv("GPHOME")
 		pluginStr := ""
-		if *pluginConfigFile != "" {
-			_, configFilename := filepath.Split(*pluginConfigFile)
-			pluginStr = fmt.Sprintf(" --plugin-config /tmp/%s", configFilename)
+		if pluginConfig != nil {
+			pluginStr = fmt.Sprintf(" --plugin-config %s", pluginConfig.ConfigPath)
 		}
 		return fmt.Sprintf(`cat << HEREDOC > %s
 #!/bin/bash
-%s/bin/gpbackup_helper --restore-agent --toc-file %s --oid-file %s --pipe-file %s --data-file %s --content %d%s
+%s/bin/gpbackup_helper --restore-agent --toc-file %s --oid-file %s --pipe-file %s --data-file %s --content %d --jobs %d%s
 HEREDOC
 
-chmod +x %s; (nohup %s > /dev/null 2>&1 &) &`, scriptFile, gphomePath, tocFile, oidFile, pipeFile, backupFile, contentID, pluginStr, scriptFile, scriptFile)
+chmod +x %s; (nohup %s > /dev/null 2>&1 &) &`, scriptFile, gphomePath, tocFile, oidFile, pipeFile, backupFile, contentID, *numJobs, pluginStr, scriptFile, scriptFile)
 	}, cluster.ON_SEGMENTS)
 	globalCluster.CheckClusterError(remoteOutput, "Unable to write to segment data pipes", func(contentID int) string {
 		return fmt.Sprintf("Unable to write to data pipe for segment %d on host %s", contentID, globalCluster.GetHostForContent(contentID))
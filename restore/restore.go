@@ -21,21 +21,41 @@ import (
  */
 func initializeFlags() {
 	backupDir = flag.String("backup-dir", "", "The absolute path of the directory in which the backup files to be restored are located")
+	clean = flag.Bool("clean", false, "Drop existing objects in the restore database before restoring them")
 	createDB = flag.Bool("create-db", false, "Create the database before metadata restore")
+	dataOnly = flag.Bool("data-only", false, "Only restore data, do not restore metadata; the tables being restored must already exist")
 	debug = flag.Bool("debug", false, "Print verbose and debug log messages")
+	deferConstraints = flag.Bool("defer-constraints", false, "Create primary key, unique, and foreign key constraints after table data is restored, and restore each table's indexes as soon as its data is restored")
+	disableTriggers = flag.Bool("disable-triggers", false, "Disable triggers on each table and drop its foreign key constraints while its data is restored in a data-only restore")
+	flag.Var(&excludeObjectTypes, "exclude-object-type", "Restore all metadata except objects of the specified type(s), e.g. INDEX. Table data and statistics are not restored if TABLE is excluded. --exclude-object-type can be specified multiple times.")
 	flag.Var(&excludeSchemas, "exclude-schema", "Restore all metadata except objects in the specified schema(s). --exclude-schema can be specified multiple times.")
 	flag.Var(&excludeTables, "exclude-table", "Restore all metadata except the specified table(s). --exclude-table can be specified multiple times.")
 	excludeTableFile = flag.String("exclude-table-file", "", "A file containing a list of fully-qualified tables that will not be restored")
+	ifExists = flag.Bool("if-exists", false, "Use IF EXISTS when dropping objects with --clean, so that objects that do not exist are not an error")
+	flag.Var(&includeObjectTypes, "include-object-type", "Restore only objects of the specified type(s), e.g. FUNCTION. Table data and statistics are only restored if TABLE is included. --include-object-type can be specified multiple times.")
 	flag.Var(&includeSchemas, "include-schema", "Restore only the specified schema(s). --include-schema can be specified multiple times.")
 	flag.Var(&includeTables, "include-table", "Restore only the specified table(s). --include-table can be specified multiple times.")
 	includeTableFile = flag.String("include-table-file", "", "A file containing a list of fully-qualified tables that will be restored")
-	numJobs = flag.Int("jobs", 1, "Number of parallel connections to use when restoring table data and post-data")
+	noOwner = flag.Bool("no-owner", false, "Do not restore object ownership; objects will be owned by the user running the restore")
+	noPrivileges = flag.Bool("no-privileges", false, "Do not restore object privileges (GRANT and REVOKE statements)")
+	numJobs = flag.Int("jobs", 1, "Number of parallel connections to use when restoring table data and post-data. Table data in a single data file per segment is only restored in parallel if it is uncompressed and is either local or read through a plugin that supports restore_data_range.")
 	onErrorContinue = flag.Bool("on-error-continue", false, "Log errors and continue restore, instead of exiting on first error")
+	outputFile = flag.String("output-file", "", "Write the restore to the specified SQL script instead of executing it; no database connection is made")
 	pluginConfigFile = flag.String("plugin-config", "", "The configuration file to use for a plugin")
 	printVersion = flag.Bool("version", false, "Print version number and exit")
+	priorityTableFile = flag.String("priority-table-file", "", "A file containing a list of fully-qualified tables whose data will be restored first, in the order listed, before the remaining tables are restored largest first. Ignored for table data in a single data file per segment that is compressed or read through a plugin that does not support restore_data_range.")
 	quiet = flag.Bool("quiet", false, "Suppress non-warning, non-error log messages")
 	redirect = flag.String("redirect-db", "", "Restore to the specified database instead of the database that was backed up")
 	restoreGlobals = flag.Bool("with-globals", false, "Restore global metadata")
+	retryAttempts = flag.Int("retry-attempts", 0, "Number of times to retry a data COPY or metadata statement that fails with a retryable error")
+	retryBackoff = flag.Int("retry-backoff", 1, "Number of seconds to wait before the first retry of a failed statement, doubling for each retry after that")
+	retryFailedFile = flag.String("retry-failed", "", "Restore only the statements and tables recorded as failed in the specified error file from a previous restore with the same flags")
+	flag.Var(&retrySQLStates, "retry-sqlstate", "A SQLSTATE code or two-character SQLSTATE class of errors to retry with --retry-attempts, instead of connection, deadlock, lock timeout, and shutdown errors. --retry-sqlstate can be specified multiple times.")
+	roleMapFile = flag.String("role-map", "", "A YAML file mapping role names in the backup to role names to use in restored owner and privilege statements")
+	runAnalyze = flag.Bool("run-analyze", false, "Analyze restored tables after their data is restored, using --jobs connections, unless their statistics are restored with --with-stats")
+	syncSequences = flag.String("sync-sequences", "", "In a data-only restore, set each sequence owned by a restored table to its value in the backup (\"backup\") or to the largest value in the column that owns it (\"max\")")
+	tableRenameMapFile = flag.String("table-rename-map", "", "A YAML file mapping fully-qualified tables in the backup to new names under which they will be restored")
+	tablespaceMapFile = flag.String("tablespace-map", "", "A YAML file mapping tablespace names in the backup to new tablespace names, new locations, or \"default\"")
 	timestamp = flag.String("timestamp", "", "The timestamp to be restored, in the format YYYYMMDDHHMMSS")
 	verbose = flag.Bool("verbose", false, "Print verbose log messages")
 	withStats = flag.Bool("with-stats", false, "Restore query plan statistics")
@@ -65,8 +85,16 @@ func DoValidation() {
 		os.Exit(0)
 	}
 	ValidateFlagCombinations()
+	ValidateFilterObjectTypes(includeObjectTypes)
+	ValidateFilterObjectTypes(excludeObjectTypes)
 	utils.ValidateFullPath(*backupDir)
+	utils.ValidateFullPath(*outputFile)
 	utils.ValidateFullPath(*pluginConfigFile)
+	utils.ValidateFullPath(*priorityTableFile)
+	utils.ValidateFullPath(*retryFailedFile)
+	utils.ValidateFullPath(*roleMapFile)
+	utils.ValidateFullPath(*tablespaceMapFile)
+	utils.ValidateFullPath(*tableRenameMapFile)
 	if !utils.IsValidTimestamp(*timestamp) {
 		gplog.Fatal(errors.Errorf("Timestamp %s is invalid.  Timestamps must be in the format YYYYMMDDHHMMSS.", *timestamp), "")
 	}
@@ -78,6 +106,28 @@ func DoSetup() {
 	restoreStartTime = utils.CurrentTimestamp()
 	gplog.Info("Restore Key = %s", *timestamp)
 
+	retryPolicy = NewRetryPolicy(*retryAttempts, *retryBackoff, retrySQLStates)
+	if *roleMapFile != "" {
+		roleMap = ReadRoleMapFile(*roleMapFile)
+	}
+	if *tablespaceMapFile != "" {
+		tablespaceMap = ReadTablespaceMapFile(*tablespaceMapFile)
+	}
+	if *tableRenameMapFile != "" {
+		tableRenameMap = ReadTableRenameMapFile(*tableRenameMapFile)
+	}
+	if *retryFailedFile != "" {
+		retryFailures = utils.ReadRestoreFailuresFile(*retryFailedFile)
+	}
+	if *priorityTableFile != "" {
+		priorityTables = utils.ReadLinesFromFile(*priorityTableFile)
+		utils.ValidateFQNs(priorityTables)
+	}
+	if *outputFile != "" {
+		InitializeForOutputFile()
+		return
+	}
+
 	InitializeConnection("postgres")
 	segConfig := cluster.GetSegmentConfiguration(connection)
 	globalCluster = cluster.NewCluster(segConfig)
@@ -93,14 +143,14 @@ func DoSetup() {
 
 	BackupConfigurationValidation()
 	metadataFilename := globalFPInfo.GetMetadataFilePath()
-	if !backupConfig.DataOnly {
+	if !isDataOnlyRestore() {
 		gplog.Verbose("Metadata will be restored from %s", metadataFilename)
 	}
 	restoreDatabase := backupConfig.DatabaseName
 	if *redirect != "" {
 		restoreDatabase = *redirect
 	}
-	ValidateDatabaseExistence(restoreDatabase, *createDB, backupConfig.IncludeTableFiltered || backupConfig.DataOnly)
+	ValidateDatabaseExistence(restoreDatabase, *createDB, backupConfig.IncludeTableFiltered || isDataOnlyRestore())
 	if *createDB {
 		createDatabase(metadataFilename)
 	}
@@ -113,20 +163,35 @@ func DoSetup() {
 	/*
 	 * We don't need to validate anything if we're creating the database; we
 	 * should not error out for validation reasons once the restore database exists.
+	 * Data-only restores load data into tables that already exist.
 	 */
-	if !*createDB {
-		ValidateFilterTablesInRestoreDatabase(connection, includeTables)
+	if !*createDB && !*clean && retryFailures == nil && !isDataOnlyRestore() {
+		ValidateFilterTablesInRestoreDatabase(connection, RenameTableList(includeTables))
 	}
 }
 
 func DoRestore() {
+	if *outputFile != "" {
+		WriteRestoreScript(*outputFile)
+		return
+	}
 	gucStatements := setGUCsForConnection(nil, 0)
 	metadataFilename := globalFPInfo.GetMetadataFilePath()
-	if !backupConfig.DataOnly {
-		restorePredata(metadataFilename)
+	if *clean && !isDataOnlyRestore() {
+		cleanMetadata(metadataFilename)
+	}
+	var postdataStatements, foreignKeys []utils.StatementWithType
+	if !isDataOnlyRestore() {
+		var deferredConstraints []utils.StatementWithType
+		deferredConstraints, foreignKeys = restorePredata(metadataFilename, gucStatements)
+		postdataStatements = append(deferredConstraints, GetRestoreMetadataStatements("postdata", metadataFilename, []string{}, []string{}, true, true, true)...)
 	}
 
-	if !backupConfig.MetadataOnly {
+	restoreTables := ObjectTypeIsRestored("TABLE")
+	if !backupConfig.MetadataOnly && !restoreTables {
+		gplog.Info("Skipping data restore, as TABLE objects are filtered out by object type")
+	}
+	if !backupConfig.MetadataOnly && restoreTables {
 		if *pluginConfigFile == "" {
 			backupFileCount := 2 // 1 for the actual data file, 1 for the segment TOC file
 			if !backupConfig.SingleDataFile {
@@ -134,22 +199,31 @@ func DoRestore() {
 			}
 			VerifyBackupFileCountOnSegments(backupFileCount)
 		}
-		restoreData(gucStatements)
+		postdataStatements = restoreData(gucStatements, postdataStatements)
+	}
+
+	if !isDataOnlyRestore() {
+		restorePostdata(postdataStatements, foreignKeys)
+	}
+
+	if *syncSequences != "" && !backupConfig.MetadataOnly && restoreTables {
+		syncSequenceValues(metadataFilename)
 	}
 
-	if !backupConfig.DataOnly {
-		restorePostdata(metadataFilename)
+	var restoredStatistics map[string]bool
+	if *withStats && backupConfig.WithStatistics && restoreTables {
+		restoredStatistics = restoreStatistics()
 	}
 
-	if *withStats && backupConfig.WithStatistics {
-		restoreStatistics()
+	if *runAnalyze && !backupConfig.MetadataOnly && restoreTables {
+		analyzeRestoredTables(restoredStatistics)
 	}
 }
 
 func createDatabase(metadataFilename string) {
 	objectTypes := []string{"SESSION GUCS", "DATABASE GUC", "DATABASE", "DATABASE METADATA"}
 	gplog.Info("Creating database")
-	statements := GetRestoreMetadataStatements("global", metadataFilename, objectTypes, []string{}, false, false)
+	statements := GetRestoreMetadataStatements("global", metadataFilename, objectTypes, []string{}, false, false, false)
 	if *redirect != "" {
 		statements = utils.SubstituteRedirectDatabaseInStatements(statements, backupConfig.DatabaseName, *redirect)
 	}
@@ -157,10 +231,11 @@ func createDatabase(metadataFilename string) {
 	gplog.Info("Database creation complete")
 }
 
+var globalObjectTypes = []string{"SESSION GUCS", "DATABASE GUC", "DATABASE METADATA", "RESOURCE QUEUE", "RESOURCE GROUP", "ROLE", "ROLE GRANT", "TABLESPACE", "SECURITY LABEL"}
+
 func restoreGlobal(metadataFilename string) {
-	objectTypes := []string{"SESSION GUCS", "DATABASE GUC", "DATABASE METADATA", "RESOURCE QUEUE", "RESOURCE GROUP", "ROLE", "ROLE GRANT", "TABLESPACE"}
 	gplog.Info("Restoring global metadata")
-	statements := GetRestoreMetadataStatements("global", metadataFilename, objectTypes, []string{}, false, false)
+	statements := GetRestoreMetadataStatements("global", metadataFilename, globalObjectTypes, []string{}, false, false, false)
 	if *redirect != "" {
 		statements = utils.SubstituteRedirectDatabaseInStatements(statements, backupConfig.DatabaseName, *redirect)
 	}
@@ -169,39 +244,110 @@ func restoreGlobal(metadataFilename string) {
 	gplog.Info("Global database metadata restore complete")
 }
 
-func restorePredata(metadataFilename string) {
+func cleanMetadata(metadataFilename string) {
 	if wasTerminated {
 		return
 	}
+	gplog.Info("Dropping existing objects")
+	predataStatements := GetRestoreMetadataStatements("predata", metadataFilename, []string{}, []string{"SCHEMA"}, true, true, true)
+	postdataStatements := GetRestoreMetadataStatements("postdata", metadataFilename, []string{}, []string{}, true, true, true)
+	ExecuteCleanStatements(GetCleanStatements(predataStatements, postdataStatements, GetExistingForeignKeys(connection)))
+	gplog.Info("Existing objects dropped")
+}
+
+/*
+ * With --defer-constraints, the index-backed constraints and foreign keys
+ * removed from the pre-data statements are returned to be restored later.
+ */
+func restorePredata(metadataFilename string, gucStatements []utils.StatementWithType) ([]utils.StatementWithType, []utils.StatementWithType) {
+	if wasTerminated {
+		return nil, nil
+	}
 	gplog.Info("Restoring pre-data metadata")
 
-	schemaStatements := GetRestoreMetadataStatements("predata", metadataFilename, []string{"SCHEMA"}, []string{}, true, false)
-	statements := GetRestoreMetadataStatements("predata", metadataFilename, []string{}, []string{"SCHEMA"}, true, true)
+	schemaStatements := GetRestoreMetadataStatements("predata", metadataFilename, []string{"SCHEMA"}, []string{}, true, false, true)
+	statements := GetRestoreMetadataStatements("predata", metadataFilename, []string{}, []string{"SCHEMA"}, true, true, true)
+	var deferredConstraints, foreignKeys []utils.StatementWithType
+	if *deferConstraints {
+		statements, deferredConstraints, foreignKeys = SplitDeferredConstraints(statements)
+		gplog.Verbose("Deferring %d index-backed constraint(s) and %d foreign key(s) until after table data is restored", len(deferredConstraints), len(foreignKeys))
+	}
 
 	progressBar := utils.NewProgressBar(len(schemaStatements)+len(statements), "Pre-data objects restored: ", utils.PB_VERBOSE)
 	progressBar.Start()
 
 	restoreSchemas(schemaStatements, progressBar)
-	ExecuteRestoreMetadataStatements(statements, "Pre-data objects", progressBar, utils.PB_VERBOSE, false)
+	/*
+	 * Backups taken before entry dependencies were recorded in the TOC can
+	 * only be restored in the order in which they were written.
+	 */
+	if connection.NumConns > 1 && statementsHaveDependencies(statements) {
+		for i := 1; i < connection.NumConns; i++ {
+			setGUCsForConnection(gucStatements, i)
+		}
+		ExecuteStatementsInDependencyOrder(statements, progressBar, utils.PB_VERBOSE)
+	} else {
+		ExecuteRestoreMetadataStatements(statements, "Pre-data objects", progressBar, utils.PB_VERBOSE, false)
+	}
 
 	progressBar.Finish()
 	gplog.Info("Pre-data metadata restore complete")
+	return deferredConstraints, foreignKeys
 }
 
-func restoreData(gucStatements []utils.StatementWithType) {
+/*
+ * With --defer-constraints, the post-data statements for each table are
+ * restored as soon as that table's data is restored, ahead of the data of any
+ * tables still waiting to be restored.  The post-data statements that were not
+ * restored are returned.
+ */
+func restoreData(gucStatements []utils.StatementWithType, postdataStatements []utils.StatementWithType) []utils.StatementWithType {
 	if wasTerminated {
-		return
+		return postdataStatements
 	}
 	gplog.Info("Restoring data")
-	filteredMasterDataEntries := globalTOC.GetDataEntriesMatching(includeSchemas, excludeSchemas, includeTables, excludeTables)
+	filteredMasterDataEntries := RenameDataEntries(globalTOC.GetDataEntriesMatching(includeSchemas, excludeSchemas, includeTables, excludeTables))
+	if retryFailures != nil {
+		filteredMasterDataEntries = FilterFailedTables(filteredMasterDataEntries)
+	}
+	if len(filteredMasterDataEntries) == 0 {
+		gplog.Info("No table data to restore")
+		return postdataStatements
+	}
+	// Without ranged reads, the agents read a single data file in the order it was written, so its tables must be restored in that order
+	if !backupConfig.SingleDataFile || canReadTableRanges() {
+		filteredMasterDataEntries = SortDataEntriesForRestore(filteredMasterDataEntries, RenameTableList(priorityTables))
+	}
+	ValidateDistributionPoliciesInRestoreDatabase(connection, filteredMasterDataEntries)
+	if retryFailures != nil {
+		TruncateTablesForRetry(filteredMasterDataEntries)
+	}
+	if *disableTriggers {
+		if backupConfig.DataOnly {
+			gplog.Warn("Foreign key constraints cannot be dropped while restoring a data-only backup, as it does not include their definitions")
+		} else {
+			constraints := GetRestoreMetadataStatements("predata", globalFPInfo.GetMetadataFilePath(), []string{"CONSTRAINT"}, []string{}, false, false, false)
+			DropForeignKeys(GetForeignKeysToDrop(constraints, filteredMasterDataEntries, GetExistingForeignKeys(connection)))
+		}
+	}
 	if backupConfig.SingleDataFile {
 		gplog.Verbose("Initializing pipes and gpbackup_helper on segments for single data file restore")
 		VerifyHelperVersionOnSegments(version)
 		WriteOidListToSegments(filteredMasterDataEntries)
-		firstOid := filteredMasterDataEntries[0].Oid
-		CreateSegmentPipesOnAllHostsForRestore(firstOid)
+		if *numJobs > 1 {
+			CreateAllSegmentPipesOnAllHostsForRestore()
+		} else {
+			firstOid := filteredMasterDataEntries[0].Oid
+			CreateSegmentPipesOnAllHostsForRestore(firstOid)
+		}
 		WriteToSegmentPipes()
 	}
+	tablePostdata := make(map[string][]utils.StatementWithType, 0)
+	if *deferConstraints {
+		tablePostdata, postdataStatements = GroupPostdataByTable(postdataStatements, filteredMasterDataEntries)
+		gplog.Verbose("Post-data metadata for %d table(s) will be restored as soon as their data is restored", len(tablePostdata))
+	}
+	var numPostdataErrors uint32
 
 	totalTables := len(filteredMasterDataEntries)
 	dataProgressBar := utils.NewProgressBar(totalTables, "Tables restored: ", utils.PB_INFO)
@@ -216,26 +362,64 @@ func restoreData(gucStatements []utils.StatementWithType) {
 		for i, entry := range filteredMasterDataEntries {
 			if wasTerminated {
 				dataProgressBar.(*pb.ProgressBar).NotPrint = true
-				return
+				return postdataStatements
 			}
-			restoreSingleTableData(entry, uint32(i)+1, totalTables, 0)
+			RestoreSingleTableData(entry, uint32(i)+1, totalTables, 0)
 			dataProgressBar.Increment()
+			numPostdataErrors += restoreTablePostdata(tablePostdata[utils.MakeFQN(entry.Schema, entry.Name)], 0)
 		}
 	} else {
 		var tableNum uint32 = 1
 		tasks := make(chan utils.MasterDataEntry, totalTables)
+		/*
+		 * A table's post-data statements are queued once its data is restored,
+		 * and workers take queued post-data statements before any more data so
+		 * that indexes are built while the table's data is still cached.
+		 */
+		postdataTasks := make(chan []utils.StatementWithType, totalTables)
+		var dataRestored sync.WaitGroup
+		dataRestored.Add(totalTables)
+		go func() {
+			dataRestored.Wait()
+			close(postdataTasks)
+		}()
 		var workerPool sync.WaitGroup
 		for i := 0; i < connection.NumConns; i++ {
 			workerPool.Add(1)
 			go func(whichConn int) {
 				setGUCsForConnection(gucStatements, whichConn)
-				for entry := range tasks {
-					if wasTerminated {
-						break
+				dataTasks, queuedPostdata := tasks, postdataTasks
+				restoreQueuedPostdata := func(statements []utils.StatementWithType, ok bool) {
+					if !ok {
+						queuedPostdata = nil
+					} else if !wasTerminated {
+						atomic.AddUint32(&numPostdataErrors, restoreTablePostdata(statements, whichConn))
+					}
+				}
+				for dataTasks != nil || queuedPostdata != nil {
+					select {
+					case statements, ok := <-queuedPostdata:
+						restoreQueuedPostdata(statements, ok)
+						continue
+					default:
+					}
+					select {
+					case statements, ok := <-queuedPostdata:
+						restoreQueuedPostdata(statements, ok)
+					case entry, ok := <-dataTasks:
+						if !ok {
+							dataTasks = nil
+							continue
+						}
+						if !wasTerminated {
+							RestoreSingleTableData(entry, atomic.AddUint32(&tableNum, 1)-1, totalTables, whichConn)
+							dataProgressBar.Increment()
+							if statements, ok := tablePostdata[utils.MakeFQN(entry.Schema, entry.Name)]; ok {
+								postdataTasks <- statements
+							}
+						}
+						dataRestored.Done()
 					}
-					restoreSingleTableData(entry, tableNum, totalTables, whichConn)
-					atomic.AddUint32(&tableNum, 1)
-					dataProgressBar.Increment()
 				}
 				workerPool.Done()
 			}(i)
@@ -246,7 +430,11 @@ func restoreData(gucStatements []utils.StatementWithType) {
 		close(tasks)
 		workerPool.Wait()
 	}
+	if numPostdataErrors > 0 {
+		gplog.Error("Encountered %d errors during metadata restore; see log file %s for a list of failed statements.", numPostdataErrors, gplog.GetLogFilePath())
+	}
 	dataProgressBar.Finish()
+	RecreateForeignKeys()
 	err := CheckAgentErrorsOnSegments()
 	if err != nil {
 		errMsg := "Error restoring data for one or more tables"
@@ -257,32 +445,77 @@ func restoreData(gucStatements []utils.StatementWithType) {
 		}
 	}
 	gplog.Info("Data restore complete")
+	return postdataStatements
 }
 
-func restorePostdata(metadataFilename string) {
+/*
+ * Foreign keys deferred with --defer-constraints are restored one at a time
+ * after all other post-data statements, as each one locks both the table it
+ * is on and the table it references.
+ */
+func restorePostdata(statements []utils.StatementWithType, foreignKeys []utils.StatementWithType) {
 	if wasTerminated {
 		return
 	}
 	gplog.Info("Restoring post-data metadata")
-	statements := GetRestoreMetadataStatements("postdata", metadataFilename, []string{}, []string{}, true, true)
 	firstBatch, secondBatch := BatchPostdataStatements(statements)
-	progressBar := utils.NewProgressBar(len(statements), "Post-data objects restored: ", utils.PB_VERBOSE)
+	progressBar := utils.NewProgressBar(len(statements)+len(foreignKeys), "Post-data objects restored: ", utils.PB_VERBOSE)
 	progressBar.Start()
 	ExecuteRestoreMetadataStatements(firstBatch, "", progressBar, utils.PB_VERBOSE, connection.NumConns > 1)
 	ExecuteRestoreMetadataStatements(secondBatch, "", progressBar, utils.PB_VERBOSE, connection.NumConns > 1)
+	ExecuteRestoreMetadataStatements(foreignKeys, "", progressBar, utils.PB_VERBOSE, false)
 	progressBar.Finish()
 	gplog.Info("Post-data metadata restore complete")
 }
 
-func restoreStatistics() {
+// The returned map contains the tables whose statistics were restored.
+func restoreStatistics() map[string]bool {
 	if wasTerminated {
-		return
+		return nil
 	}
 	statisticsFilename := globalFPInfo.GetStatisticsFilePath()
 	gplog.Info("Restoring query planner statistics from %s", statisticsFilename)
-	statements := GetRestoreMetadataStatements("statistics", statisticsFilename, []string{}, []string{}, true, false)
+	statements := GetRestoreMetadataStatements("statistics", statisticsFilename, []string{}, []string{}, true, false, false)
 	ExecuteRestoreMetadataStatements(statements, "Table statistics", nil, utils.PB_VERBOSE, false)
 	gplog.Info("Query planner statistics restore complete")
+	restoredStatistics := make(map[string]bool, 0)
+	for _, statement := range statements {
+		restoredStatistics[utils.MakeFQN(statement.Schema, statement.Name)] = true
+	}
+	return restoredStatistics
+}
+
+func syncSequenceValues(metadataFilename string) {
+	if wasTerminated {
+		return
+	}
+	gplog.Info("Setting values of sequences owned by restored tables")
+	filteredMasterDataEntries := RenameDataEntries(globalTOC.GetDataEntriesMatching(includeSchemas, excludeSchemas, includeTables, excludeTables))
+	sequenceOwners := GetSequenceOwners(connection)
+	var statements []utils.StatementWithType
+	if *syncSequences == "backup" {
+		sequenceStatements := GetRestoreMetadataStatements("predata", metadataFilename, []string{"SEQUENCE"}, []string{}, false, false, false)
+		statements = GetBackupSequenceValueStatements(sequenceStatements, sequenceOwners, filteredMasterDataEntries)
+	} else {
+		statements = GetMaxColumnSequenceValueStatements(sequenceOwners, filteredMasterDataEntries)
+	}
+	ExecuteRestoreMetadataStatements(statements, "Sequence values", nil, utils.PB_VERBOSE, false)
+	gplog.Info("Sequence value restore complete")
+}
+
+func analyzeRestoredTables(restoredStatistics map[string]bool) {
+	if wasTerminated {
+		return
+	}
+	gplog.Info("Analyzing restored tables")
+	filteredMasterDataEntries := RenameDataEntries(globalTOC.GetDataEntriesMatching(includeSchemas, excludeSchemas, includeTables, excludeTables))
+	tables, numSkipped := GetTablesToAnalyze(filteredMasterDataEntries, GetPartitionRoots(connection), restoredStatistics)
+	if numSkipped > 0 {
+		gplog.Verbose("Skipping %d table(s) whose statistics were restored", numSkipped)
+	}
+	numAnalyzed, numFailed := AnalyzeTables(tables)
+	analyzeResults = &utils.AnalyzeResults{NumAnalyzed: numAnalyzed, NumSkipped: uint32(numSkipped), NumFailed: numFailed}
+	gplog.Info("Analyze of restored tables complete")
 }
 
 func DoTeardown() {
@@ -306,12 +539,14 @@ func DoTeardown() {
 	errMsg := utils.ParseErrorMessage(errStr)
 	errorCode := gplog.GetErrorCode()
 
-	if globalFPInfo.Timestamp != "" {
+	if globalFPInfo.Timestamp != "" && *outputFile == "" {
 		reportFilename := globalFPInfo.GetRestoreReportFilePath(restoreStartTime)
-		utils.WriteRestoreReportFile(reportFilename, globalFPInfo.Timestamp, restoreStartTime, connection, version, errMsg)
+		utils.WriteRestoreReportFile(reportFilename, globalFPInfo.Timestamp, restoreStartTime, connection, version, errMsg, analyzeResults)
+		WriteRestoreFailuresFile(globalFPInfo.GetRestoreErrorFilePath(restoreStartTime))
 		utils.EmailReport(globalCluster, globalFPInfo.Timestamp, reportFilename, "gprestore")
 		if pluginConfig != nil {
 			pluginConfig.CleanupPluginForRestoreOnAllHosts(globalCluster)
+			pluginConfig.DeletePluginConfigFromAllHosts(globalCluster)
 		}
 	}
 
@@ -329,7 +564,8 @@ func DoCleanup() {
 		CleanupGroup.Done()
 	}()
 	gplog.Verbose("Beginning cleanup")
-	if backupConfig != nil && backupConfig.SingleDataFile {
+	WarnForeignKeysNotRecreated()
+	if backupConfig != nil && backupConfig.SingleDataFile && *outputFile == "" {
 		CleanUpHelperFilesOnAllHosts()
 		CleanUpSegmentHelperProcesses()
 		if wasTerminated { // These should all end on their own in a successful restore
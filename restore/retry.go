@@ -0,0 +1,140 @@
+package restore
+
+/*
+ * This file contains functions related to retrying data and metadata restore
+ * statements that fail because of transient errors.
+ */
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/greenplum-db/gp-common-go-libs/dbconn"
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"github.com/greenplum-db/gpbackup/utils"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+/*
+ * By default, errors caused by lost connections (including GPDB interconnect
+ * errors), serialization failures, deadlocks, lock timeouts, and server
+ * shutdowns or restarts, such as during a segment failover, are retried.
+ */
+var DefaultRetrySQLStates = []string{"08", "40001", "40P01", "55P03", "57P01", "57P02", "57P03"}
+
+var sqlStateRegex = regexp.MustCompile(`^[0-9A-Z]{2}(?:[0-9A-Z]{3})?$`)
+
+/*
+ * A statement that fails with a retryable error is retried up to Attempts
+ * times, waiting Backoff before the first retry and twice as long before each
+ * retry after that.  SQLStates may contain five-character SQLSTATE codes or
+ * two-character SQLSTATE classes.  The zero value retries nothing.
+ */
+type RetryPolicy struct {
+	Attempts  int
+	Backoff   time.Duration
+	SQLStates []string
+}
+
+func NewRetryPolicy(attempts int, backoffSeconds int, sqlStates []string) RetryPolicy {
+	if attempts < 0 {
+		gplog.Fatal(errors.Errorf("--retry-attempts must be at least 0"), "")
+	}
+	if backoffSeconds < 0 {
+		gplog.Fatal(errors.Errorf("--retry-backoff must be at least 0"), "")
+	}
+	if len(sqlStates) == 0 {
+		sqlStates = DefaultRetrySQLStates
+	}
+	normalizedStates := make([]string, len(sqlStates))
+	for i, sqlState := range sqlStates {
+		normalizedStates[i] = strings.ToUpper(sqlState)
+		if !sqlStateRegex.MatchString(normalizedStates[i]) {
+			gplog.Fatal(errors.Errorf("%s is not a valid SQLSTATE code or class", sqlState), "")
+		}
+	}
+	return RetryPolicy{Attempts: attempts, Backoff: time.Duration(backoffSeconds) * time.Second, SQLStates: normalizedStates}
+}
+
+/*
+ * Errors that do not come from the database, such as a connection being reset
+ * or closed, are treated as connection failures (SQLSTATE 08006).
+ */
+func (policy RetryPolicy) IsRetryable(err error) bool {
+	sqlState := "08006"
+	if pqErr, ok := err.(*pq.Error); ok {
+		sqlState = string(pqErr.Code)
+	}
+	for _, retryableState := range policy.SQLStates {
+		if sqlState == retryableState || (len(retryableState) == 2 && strings.HasPrefix(sqlState, retryableState)) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ * The connection is reset before each retry, as a connection that saw a
+ * transient error may no longer be usable.  If prepareRetry is not nil, it is
+ * called after the connection is reset to undo any effects of the failed
+ * attempt.  Statements executed in a transaction are never retried, as the
+ * transaction is aborted by the error.
+ */
+func ExecuteWithRetry(description string, whichConn int, execute func() error, prepareRetry func() error) error {
+	err := execute()
+	for attempt := 1; err != nil && attempt <= retryPolicy.Attempts && retryPolicy.IsRetryable(err); attempt++ {
+		if wasTerminated || connection.Tx[whichConn] != nil {
+			break
+		}
+		backoff := retryPolicy.Backoff * time.Duration(1<<uint(attempt-1))
+		gplog.Warn("Error %s: %v.  Retrying in %v (retry %d of %d)", description, err, backoff, attempt, retryPolicy.Attempts)
+		time.Sleep(backoff)
+		if err = ResetConnection(whichConn); err != nil {
+			continue
+		}
+		if prepareRetry != nil {
+			if err = prepareRetry(); err != nil {
+				continue
+			}
+		}
+		err = execute()
+	}
+	return err
+}
+
+func ResetConnection(whichConn int) error {
+	gplog.Verbose("Resetting connection %d", whichConn)
+	newConnection := &dbconn.DBConn{Driver: connection.Driver, User: connection.User, DBName: connection.DBName, Host: connection.Host, Port: connection.Port}
+	err := newConnection.Connect(1)
+	if err != nil {
+		return err
+	}
+	connection.ConnPool[whichConn].Close()
+	connection.ConnPool[whichConn] = newConnection.ConnPool[0]
+	_, err = connection.Exec(getSessionSetupQuery(), whichConn)
+	if err != nil {
+		return err
+	}
+	gucStatements := GetRestoreMetadataStatements("global", globalFPInfo.GetMetadataFilePath(), []string{"SESSION GUCS"}, []string{}, false, false, false)
+	for _, statement := range gucStatements {
+		_, err = connection.Exec(statement.Statement, whichConn)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getStatementDescription(statement utils.StatementWithType) string {
+	name := statement.Name
+	if statement.Schema != "" && statement.Schema != statement.Name {
+		name = utils.MakeFQN(statement.Schema, statement.Name)
+	}
+	if name == "" {
+		return fmt.Sprintf("restoring %s", strings.ToLower(statement.ObjectType))
+	}
+	return fmt.Sprintf("restoring %s %s", strings.ToLower(statement.ObjectType), name)
+}
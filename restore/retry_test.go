@@ -0,0 +1,196 @@
+package restore_test
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/greenplum-db/gp-common-go-libs/operating"
+	"github.com/greenplum-db/gp-common-go-libs/testhelper"
+	"github.com/greenplum-db/gpbackup/restore"
+	"github.com/greenplum-db/gpbackup/testutils"
+	"github.com/greenplum-db/gpbackup/utils"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+type stringReadCloserAt struct {
+	*strings.Reader
+}
+
+func (reader stringReadCloserAt) Close() error {
+	return nil
+}
+
+var _ = Describe("restore/retry tests", func() {
+	Describe("NewRetryPolicy", func() {
+		It("uses the default SQLSTATEs if none are given", func() {
+			policy := restore.NewRetryPolicy(3, 2, []string{})
+			Expect(policy).To(Equal(restore.RetryPolicy{Attempts: 3, Backoff: 2 * time.Second, SQLStates: restore.DefaultRetrySQLStates}))
+		})
+		It("accepts SQLSTATE codes and classes in any case", func() {
+			policy := restore.NewRetryPolicy(1, 0, []string{"40p01", "53"})
+			Expect(policy.SQLStates).To(Equal([]string{"40P01", "53"}))
+		})
+		It("panics on an invalid SQLSTATE", func() {
+			defer testhelper.ShouldPanicWithMessage("4000 is not a valid SQLSTATE code or class")
+			restore.NewRetryPolicy(1, 0, []string{"4000"})
+		})
+		It("panics on a negative number of attempts", func() {
+			defer testhelper.ShouldPanicWithMessage("--retry-attempts must be at least 0")
+			restore.NewRetryPolicy(-1, 0, []string{})
+		})
+		It("panics on a negative backoff", func() {
+			defer testhelper.ShouldPanicWithMessage("--retry-backoff must be at least 0")
+			restore.NewRetryPolicy(1, -1, []string{})
+		})
+	})
+	Describe("IsRetryable", func() {
+		policy := restore.RetryPolicy{SQLStates: []string{"40P01", "08"}}
+		It("retries an error with a matching SQLSTATE code", func() {
+			Expect(policy.IsRetryable(&pq.Error{Code: "40P01"})).To(BeTrue())
+		})
+		It("retries an error with a SQLSTATE in a matching class", func() {
+			Expect(policy.IsRetryable(&pq.Error{Code: "08001"})).To(BeTrue())
+		})
+		It("does not retry an error with a different SQLSTATE", func() {
+			Expect(policy.IsRetryable(&pq.Error{Code: "42P07"})).To(BeFalse())
+		})
+		It("treats an error not from the database as a connection failure", func() {
+			Expect(policy.IsRetryable(errors.New("driver: bad connection"))).To(BeTrue())
+			Expect(restore.RetryPolicy{SQLStates: []string{"40P01"}}.IsRetryable(errors.New("driver: bad connection"))).To(BeFalse())
+		})
+	})
+	Describe("ExecuteWithRetry", func() {
+		deadlock := &pq.Error{Code: "40P01", Message: "deadlock detected"}
+		AfterEach(func() {
+			restore.SetRetryPolicy(restore.RetryPolicy{})
+			operating.System = operating.InitializeSystemFunctions()
+		})
+		It("does not retry if no retries are configured", func() {
+			numCalls := 0
+			err := restore.ExecuteWithRetry("restoring table public.foo", 0, func() error {
+				numCalls++
+				return deadlock
+			}, nil)
+			Expect(err).To(Equal(deadlock))
+			Expect(numCalls).To(Equal(1))
+		})
+		It("does not retry an error that is not retryable", func() {
+			restore.SetRetryPolicy(restore.RetryPolicy{Attempts: 3, SQLStates: []string{"40P01"}})
+			numCalls := 0
+			err := restore.ExecuteWithRetry("restoring table public.foo", 0, func() error {
+				numCalls++
+				return &pq.Error{Code: "42P07"}
+			}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(numCalls).To(Equal(1))
+		})
+		It("resets the connection and prepares the retry before retrying", func() {
+			restore.SetRetryPolicy(restore.RetryPolicy{Attempts: 3, SQLStates: []string{"40P01"}})
+			gucStatement := "SET client_encoding = 'UTF8';\n"
+			toc, backupfile := testutils.InitializeTestTOC(buffer, "global")
+			backupfile.ByteCount = uint64(len(gucStatement))
+			toc.AddGlobalEntry("", "", "SESSION GUCS", 0, backupfile)
+			restore.SetTOC(toc)
+			restore.SetBackupConfig(&utils.BackupConfig{})
+			restore.SetNoOwner(false)
+			restore.SetNoPrivileges(false)
+			restore.SetFPInfo(utils.NewFilePathInfo(map[int]string{-1: "/data/gpseg-1"}, "", "20170101010101", "gpseg"))
+			operating.System.OpenFileRead = func(name string, flag int, perm os.FileMode) (operating.ReadCloserAt, error) {
+				return stringReadCloserAt{strings.NewReader(gucStatement)}, nil
+			}
+			newMockDB, newMock, _ := sqlmock.New()
+			connection.Driver = testhelper.TestDriver{DB: sqlx.NewDb(newMockDB, "sqlmock")}
+			newMock.ExpectExec("SET application_name TO 'gprestore'").WillReturnResult(sqlmock.NewResult(0, 0))
+			newMock.ExpectExec(regexp.QuoteMeta(gucStatement)).WillReturnResult(sqlmock.NewResult(0, 0))
+			newMock.ExpectExec("TRUNCATE public.foo").WillReturnResult(sqlmock.NewResult(0, 0))
+			newMock.ExpectExec("COPY public.foo").WillReturnResult(sqlmock.NewResult(0, 10))
+			mock.ExpectExec("COPY public.foo").WillReturnError(deadlock)
+
+			err := restore.ExecuteWithRetry("loading data into table public.foo", 0, func() error {
+				_, err := connection.Exec("COPY public.foo FROM '/data/file';", 0)
+				return err
+			}, func() error {
+				_, err := connection.Exec("TRUNCATE public.foo;", 0)
+				return err
+			})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(newMock.ExpectationsWereMet()).To(Succeed())
+			testhelper.ExpectRegexp(logfile, "Error loading data into table public.foo: pq: deadlock detected")
+			testhelper.ExpectRegexp(logfile, "Retrying in 0s (retry 1 of 3)")
+		})
+		It("does not retry a statement executed in a transaction", func() {
+			restore.SetRetryPolicy(restore.RetryPolicy{Attempts: 3, SQLStates: []string{"40P01"}})
+			mock.ExpectBegin()
+			connection.MustBegin(0)
+			numCalls := 0
+			err := restore.ExecuteWithRetry("restoring table public.foo", 0, func() error {
+				numCalls++
+				return deadlock
+			}, nil)
+			Expect(err).To(Equal(deadlock))
+			Expect(numCalls).To(Equal(1))
+		})
+	})
+	Describe("RestoreSingleTableData", func() {
+		deadlock := &pq.Error{Code: "40P01", Message: "deadlock detected"}
+		entry := utils.MasterDataEntry{Schema: "public", Name: "foo", Oid: 3, AttributeString: "(i)", RowsCopied: 10}
+		gucStatement := "SET client_encoding = 'UTF8';\n"
+		var newMock sqlmock.Sqlmock
+		BeforeEach(func() {
+			restore.SetRetryPolicy(restore.RetryPolicy{Attempts: 1, SQLStates: []string{"40P01"}})
+			toc, backupfile := testutils.InitializeTestTOC(buffer, "global")
+			backupfile.ByteCount = uint64(len(gucStatement))
+			toc.AddGlobalEntry("", "", "SESSION GUCS", 0, backupfile)
+			restore.SetTOC(toc)
+			restore.SetConnection(connection)
+			restore.SetDisableTriggers(false)
+			restore.SetOnErrorContinue(false)
+			restore.SetNoOwner(false)
+			restore.SetNoPrivileges(false)
+			restore.SetFPInfo(utils.NewFilePathInfo(map[int]string{-1: "/data/gpseg-1"}, "", "20170101010101", "gpseg"))
+			operating.System.OpenFileRead = func(name string, flag int, perm os.FileMode) (operating.ReadCloserAt, error) {
+				return stringReadCloserAt{strings.NewReader(gucStatement)}, nil
+			}
+			var newMockDB *sql.DB
+			newMockDB, newMock, _ = sqlmock.New()
+			connection.Driver = testhelper.TestDriver{DB: sqlx.NewDb(newMockDB, "sqlmock")}
+			newMock.ExpectExec("SET application_name TO 'gprestore'").WillReturnResult(sqlmock.NewResult(0, 0))
+			newMock.ExpectExec(regexp.QuoteMeta(gucStatement)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec("COPY public.foo").WillReturnError(deadlock)
+		})
+		AfterEach(func() {
+			restore.SetRetryPolicy(restore.RetryPolicy{})
+			restore.SetDataOnly(false)
+			operating.System = operating.InitializeSystemFunctions()
+		})
+		It("truncates any partial data from a failed COPY before retrying it", func() {
+			restore.SetBackupConfig(&utils.BackupConfig{})
+			restore.SetDataOnly(false)
+			newMock.ExpectExec("TRUNCATE public.foo;").WillReturnResult(sqlmock.NewResult(0, 0))
+			newMock.ExpectExec("COPY public.foo").WillReturnResult(sqlmock.NewResult(0, 10))
+
+			restore.RestoreSingleTableData(entry, 1, 1, 0)
+
+			Expect(newMock.ExpectationsWereMet()).To(Succeed())
+		})
+		It("does not truncate a table before retrying a failed COPY in a data-only restore", func() {
+			restore.SetBackupConfig(&utils.BackupConfig{})
+			restore.SetDataOnly(true)
+			newMock.ExpectExec("COPY public.foo").WillReturnResult(sqlmock.NewResult(0, 10))
+
+			restore.RestoreSingleTableData(entry, 1, 1, 0)
+
+			Expect(newMock.ExpectationsWereMet()).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,141 @@
+package restore
+
+/*
+ * This file contains functions related to writing a restore to a SQL script
+ * instead of executing it against the restore database.
+ */
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"github.com/greenplum-db/gpbackup/utils"
+	"github.com/pkg/errors"
+)
+
+/*
+ * No database connection is made when writing a restore script, so the master
+ * backup directory is found using --backup-dir or MASTER_DATA_DIRECTORY, and
+ * table data is located using the segment directory placeholders that COPY ...
+ * ON SEGMENT substitutes when the script is run.
+ */
+func InitializeForOutputFile() {
+	segDirMap := make(map[int]string, 0)
+	if *backupDir == "" {
+		masterDataDir := os.Getenv("MASTER_DATA_DIRECTORY")
+		if masterDataDir == "" {
+			gplog.Fatal(errors.Errorf("MASTER_DATA_DIRECTORY must be set to use --output-file without --backup-dir"), "")
+		}
+		segDirMap[-1] = masterDataDir
+	}
+	segPrefix := utils.ParseSegPrefix(*backupDir)
+	globalFPInfo = utils.NewFilePathInfo(segDirMap, *backupDir, *timestamp, segPrefix)
+
+	VerifyMetadataFilePaths(*withStats)
+	backupConfig = utils.ReadConfigFile(globalFPInfo.GetConfigFilePath())
+	utils.InitializeCompressionParameters(backupConfig.Compressed, 0)
+	utils.EnsureBackupVersionCompatibility(backupConfig.BackupVersion, version)
+	InitializeFilterLists()
+	globalTOC = utils.NewTOC(globalFPInfo.GetTOCFilePath())
+	globalTOC.InitializeEntryMap()
+	ValidateBackupFlagCombinations()
+	validateFilterListsInBackupSet()
+}
+
+/*
+ * Statements are written in the order in which gprestore would execute them,
+ * after the same filtering and rewriting, so running the script restores the
+ * same objects and data that running gprestore with the same flags would.
+ */
+func WriteRestoreScript(filename string) {
+	gplog.Info("Writing restore script to %s", filename)
+	metadataFilename := globalFPInfo.GetMetadataFilePath()
+	scriptFile := utils.MustOpenFileForWriting(filename)
+	defer scriptFile.Close()
+
+	restoreDatabase := backupConfig.DatabaseName
+	if *redirect != "" {
+		restoreDatabase = *redirect
+	}
+	utils.MustPrintf(scriptFile, "--\n-- Restore script for backup %s of database %s\n-- This script should be run in database %s\n--\n", *timestamp, backupConfig.DatabaseName, restoreDatabase)
+	utils.MustPrintf(scriptFile, "%s", sessionSetupQuery)
+	gucStatements := GetRestoreMetadataStatements("global", metadataFilename, []string{"SESSION GUCS"}, []string{}, false, false, false)
+	writeScriptStatements(scriptFile, "", gucStatements)
+
+	if *restoreGlobals {
+		statements := GetRestoreMetadataStatements("global", metadataFilename, globalObjectTypes, []string{}, false, false, false)
+		if *redirect != "" {
+			statements = utils.SubstituteRedirectDatabaseInStatements(statements, backupConfig.DatabaseName, *redirect)
+		}
+		statements = utils.RemoveActiveRole(os.Getenv("USER"), statements)
+		writeScriptStatements(scriptFile, "Global metadata", statements)
+	}
+
+	var deferredConstraints, foreignKeys []utils.StatementWithType
+	if !isDataOnlyRestore() {
+		schemaStatements := GetRestoreMetadataStatements("predata", metadataFilename, []string{"SCHEMA"}, []string{}, true, false, true)
+		statements := GetRestoreMetadataStatements("predata", metadataFilename, []string{}, []string{"SCHEMA"}, true, true, true)
+		if *deferConstraints {
+			statements, deferredConstraints, foreignKeys = SplitDeferredConstraints(statements)
+		}
+		writeScriptStatements(scriptFile, "Pre-data metadata", append(schemaStatements, statements...))
+	}
+
+	restoreTables := ObjectTypeIsRestored("TABLE")
+	if !backupConfig.MetadataOnly && restoreTables {
+		writeScriptCopyStatements(scriptFile)
+	}
+
+	if !isDataOnlyRestore() {
+		statements := GetRestoreMetadataStatements("postdata", metadataFilename, []string{}, []string{}, true, true, true)
+		writeScriptStatements(scriptFile, "Post-data metadata", append(deferredConstraints, statements...))
+		writeScriptStatements(scriptFile, "Foreign key constraints", foreignKeys)
+	}
+
+	if *withStats && backupConfig.WithStatistics && restoreTables {
+		statements := GetRestoreMetadataStatements("statistics", globalFPInfo.GetStatisticsFilePath(), []string{}, []string{}, true, false, false)
+		writeScriptStatements(scriptFile, "Query planner statistics", statements)
+	}
+	utils.MustPrintln(scriptFile)
+	gplog.Info("Restore script written to %s", filename)
+}
+
+func writeScriptStatements(scriptFile io.Writer, sectionTitle string, statements []utils.StatementWithType) {
+	if len(statements) == 0 {
+		return
+	}
+	if sectionTitle != "" {
+		utils.MustPrintf(scriptFile, "\n\n--\n-- %s\n--", sectionTitle)
+	}
+	for _, statement := range statements {
+		if strings.TrimSpace(statement.Statement) == "" {
+			continue
+		}
+		utils.MustPrintf(scriptFile, "%s", strings.TrimRight(statement.Statement, "\n"))
+	}
+}
+
+/*
+ * Data in a single data file can only be read on the segments through the
+ * gpbackup_helper agents that gprestore starts, so no COPY commands can be
+ * written for it.
+ */
+func writeScriptCopyStatements(scriptFile io.Writer) {
+	if backupConfig.SingleDataFile {
+		gplog.Warn("Table data in backup %s was written to a single data file per segment and cannot be restored by the restore script", *timestamp)
+		utils.MustPrintf(scriptFile, "\n\n--\n-- Table data was backed up to a single data file per segment and must be restored with gprestore\n--")
+		return
+	}
+	dataEntries := RenameDataEntries(globalTOC.GetDataEntriesMatching(includeSchemas, excludeSchemas, includeTables, excludeTables))
+	dataEntries = SortDataEntriesForRestore(dataEntries, RenameTableList(priorityTables))
+	if len(dataEntries) == 0 {
+		return
+	}
+	utils.MustPrintf(scriptFile, "\n\n--\n-- Table data\n--")
+	for _, entry := range dataEntries {
+		backupFile := globalFPInfo.GetTableBackupFilePathForCopyCommand(entry.Oid, false)
+		utils.MustPrintf(scriptFile, "\n\n%s", GetCopyTableInQuery(utils.MakeFQN(entry.Schema, entry.Name), entry.AttributeString, backupFile, false, entry.Oid))
+	}
+}
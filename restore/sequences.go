@@ -0,0 +1,106 @@
+package restore
+
+/*
+ * This file contains functions related to setting the values of sequences
+ * owned by restored tables after a data-only restore, with --sync-sequences.
+ */
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/greenplum-db/gp-common-go-libs/dbconn"
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"github.com/greenplum-db/gpbackup/utils"
+)
+
+var setvalRegex = regexp.MustCompile(`SELECT pg_catalog\.setval\('(?:[^']|'')*', -?\d+, (?:true|false)\);`)
+
+type SequenceOwner struct {
+	Schema     string
+	Name       string
+	TableName  string
+	ColumnName string
+}
+
+/*
+ * Sequence ownership is read from the restore database rather than the
+ * backup, as data-only backups do not include it and a data-only restore only
+ * changes sequences that exist in the restore database.
+ */
+func GetSequenceOwners(connection *dbconn.DBConn) []SequenceOwner {
+	query := `
+SELECT
+	quote_ident(sn.nspname) AS schema,
+	quote_ident(s.relname) AS name,
+	quote_ident(tn.nspname) || '.' || quote_ident(t.relname) AS tablename,
+	quote_ident(a.attname) AS columnname
+FROM pg_depend d
+JOIN pg_class s ON s.oid = d.objid
+JOIN pg_namespace sn ON sn.oid = s.relnamespace
+JOIN pg_class t ON t.oid = d.refobjid
+JOIN pg_namespace tn ON tn.oid = t.relnamespace
+JOIN pg_attribute a ON a.attrelid = d.refobjid AND a.attnum = d.refobjsubid
+WHERE s.relkind = 'S'
+AND d.classid = 'pg_class'::regclass
+AND d.refclassid = 'pg_class'::regclass
+AND d.deptype = 'a'
+ORDER BY schema, name;`
+	results := make([]SequenceOwner, 0)
+	err := connection.Select(&results, query)
+	gplog.FatalOnError(err)
+	return results
+}
+
+func getRestoredSequenceOwners(sequenceOwners []SequenceOwner, entries []utils.MasterDataEntry) []SequenceOwner {
+	restoredTables := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		restoredTables[utils.MakeFQN(entry.Schema, entry.Name)] = true
+	}
+	restoredOwners := make([]SequenceOwner, 0)
+	for _, owner := range sequenceOwners {
+		if restoredTables[owner.TableName] {
+			restoredOwners = append(restoredOwners, owner)
+		}
+	}
+	return restoredOwners
+}
+
+/*
+ * The setval call printed with each sequence's definition in the backup is
+ * used to set the sequences owned by restored tables back to their values at
+ * the time of the backup.
+ */
+func GetBackupSequenceValueStatements(sequenceStatements []utils.StatementWithType, sequenceOwners []SequenceOwner, entries []utils.MasterDataEntry) []utils.StatementWithType {
+	setvalStatements := make(map[string]string, len(sequenceStatements))
+	for _, statement := range sequenceStatements {
+		if setval := setvalRegex.FindString(statement.Statement); setval != "" {
+			setvalStatements[utils.MakeFQN(statement.Schema, statement.Name)] = setval
+		}
+	}
+	statements := make([]utils.StatementWithType, 0)
+	for _, owner := range getRestoredSequenceOwners(sequenceOwners, entries) {
+		setval, ok := setvalStatements[utils.MakeFQN(owner.Schema, owner.Name)]
+		if !ok {
+			continue
+		}
+		statements = append(statements, utils.StatementWithType{ObjectType: "SEQUENCE", Schema: owner.Schema, Name: owner.Name, Statement: setval})
+	}
+	return statements
+}
+
+/*
+ * Sequences owned by restored tables are set to the largest value in their
+ * owning column, so that the next value they return is not already in use.
+ * A sequence whose column has no values is left unchanged.
+ */
+func GetMaxColumnSequenceValueStatements(sequenceOwners []SequenceOwner, entries []utils.MasterDataEntry) []utils.StatementWithType {
+	statements := make([]utils.StatementWithType, 0)
+	for _, owner := range getRestoredSequenceOwners(sequenceOwners, entries) {
+		seqFQN := strings.Replace(utils.MakeFQN(owner.Schema, owner.Name), "'", "''", -1)
+		setval := fmt.Sprintf("SELECT pg_catalog.setval('%s', max(%s), true) FROM %s HAVING max(%s) IS NOT NULL;", seqFQN, owner.ColumnName, owner.TableName, owner.ColumnName)
+		statements = append(statements, utils.StatementWithType{ObjectType: "SEQUENCE", Schema: owner.Schema, Name: owner.Name, Statement: setval})
+	}
+	return statements
+}
@@ -0,0 +1,56 @@
+package restore_test
+
+import (
+	"github.com/greenplum-db/gpbackup/restore"
+	"github.com/greenplum-db/gpbackup/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+var _ = Describe("restore/sequences tests", func() {
+	owner1 := restore.SequenceOwner{Schema: "public", Name: "table1_id_seq", TableName: "public.table1", ColumnName: "id"}
+	owner2 := restore.SequenceOwner{Schema: "public", Name: `"Table2_id_seq"`, TableName: `public."Table2"`, ColumnName: "id"}
+	owner3 := restore.SequenceOwner{Schema: "schema2", Name: "table3_id_seq", TableName: "schema2.table3", ColumnName: "id"}
+	sequenceOwners := []restore.SequenceOwner{owner1, owner2, owner3}
+	entries := []utils.MasterDataEntry{{Schema: "public", Name: "table1"}, {Schema: "public", Name: `"Table2"`}}
+	Describe("GetSequenceOwners", func() {
+		It("returns the sequences owned by table columns", func() {
+			ownerRows := sqlmock.NewRows([]string{"schema", "name", "tablename", "columnname"}).
+				AddRow("public", "table1_id_seq", "public.table1", "id").AddRow("schema2", "table3_id_seq", "schema2.table3", "id")
+			mock.ExpectQuery("SELECT (.*)").WillReturnRows(ownerRows)
+
+			owners := restore.GetSequenceOwners(connection)
+
+			Expect(owners).To(Equal([]restore.SequenceOwner{owner1, owner3}))
+		})
+	})
+	Describe("GetBackupSequenceValueStatements", func() {
+		It("returns the backed-up setval calls for sequences owned by restored tables", func() {
+			sequenceStatements := []utils.StatementWithType{
+				{ObjectType: "SEQUENCE", Schema: "public", Name: "table1_id_seq", Statement: "\n\nCREATE SEQUENCE public.table1_id_seq\n\tINCREMENT BY 1\n\tNO MAXVALUE\n\tNO MINVALUE\n\tCACHE 1;\n\nSELECT pg_catalog.setval('public.table1_id_seq', 42, true);\n\n\nALTER TABLE public.table1_id_seq OWNER TO testrole;\n"},
+				{ObjectType: "SEQUENCE", Schema: "public", Name: "unowned_seq", Statement: "\n\nCREATE SEQUENCE public.unowned_seq\n\tINCREMENT BY 1\n\tNO MAXVALUE\n\tNO MINVALUE\n\tCACHE 1;\n\nSELECT pg_catalog.setval('public.unowned_seq', 7, true);\n"},
+				{ObjectType: "SEQUENCE", Schema: "schema2", Name: "table3_id_seq", Statement: "\n\nCREATE SEQUENCE schema2.table3_id_seq\n\tINCREMENT BY 1\n\tNO MAXVALUE\n\tNO MINVALUE\n\tCACHE 1;\n\nSELECT pg_catalog.setval('schema2.table3_id_seq', 3, true);\n"},
+			}
+
+			statements := restore.GetBackupSequenceValueStatements(sequenceStatements, sequenceOwners, entries)
+
+			Expect(statements).To(Equal([]utils.StatementWithType{
+				{ObjectType: "SEQUENCE", Schema: "public", Name: "table1_id_seq", Statement: "SELECT pg_catalog.setval('public.table1_id_seq', 42, true);"},
+			}))
+		})
+	})
+	Describe("GetMaxColumnSequenceValueStatements", func() {
+		It("sets each sequence owned by a restored table to the largest value in its column", func() {
+			statements := restore.GetMaxColumnSequenceValueStatements(sequenceOwners, entries)
+
+			Expect(statements).To(Equal([]utils.StatementWithType{
+				{ObjectType: "SEQUENCE", Schema: "public", Name: "table1_id_seq",
+					Statement: "SELECT pg_catalog.setval('public.table1_id_seq', max(id), true) FROM public.table1 HAVING max(id) IS NOT NULL;"},
+				{ObjectType: "SEQUENCE", Schema: "public", Name: `"Table2_id_seq"`,
+					Statement: `SELECT pg_catalog.setval('public."Table2_id_seq"', max(id), true) FROM public."Table2" HAVING max(id) IS NOT NULL;`},
+			}))
+		})
+	})
+})
@@ -0,0 +1,151 @@
+package restore
+
+/*
+ * This file contains functions related to disabling triggers and dropping
+ * foreign key constraints on tables while their data is restored in a
+ * data-only restore, with --disable-triggers.
+ */
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/greenplum-db/gp-common-go-libs/dbconn"
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"github.com/greenplum-db/gpbackup/utils"
+)
+
+// Each foreign key constraint in the restore database is identified by its table and name.
+func GetExistingForeignKeys(connection *dbconn.DBConn) map[string]bool {
+	query := `
+SELECT
+	quote_ident(n.nspname) || '.' || quote_ident(c.relname) AS tablename,
+	quote_ident(con.conname) AS name
+FROM pg_constraint con
+JOIN pg_class c ON con.conrelid = c.oid
+JOIN pg_namespace n ON c.relnamespace = n.oid
+WHERE con.contype = 'f';`
+	results := make([]struct {
+		TableName string
+		Name      string
+	}, 0)
+	err := connection.Select(&results, query)
+	gplog.FatalOnError(err)
+	foreignKeys := make(map[string]bool, len(results))
+	for _, result := range results {
+		foreignKeys[getForeignKeyKey(result.TableName, result.Name)] = true
+	}
+	return foreignKeys
+}
+
+func getForeignKeyKey(table string, name string) string {
+	return fmt.Sprintf("%s|%s", table, name)
+}
+
+/*
+ * Only foreign keys on tables whose data is being restored are dropped, as
+ * foreign keys are only checked when rows are added to the table they are on.
+ * Foreign keys in the backup that do not exist in the restore database are
+ * skipped, so that restoring data does not add constraints to the schema.
+ */
+func GetForeignKeysToDrop(statements []utils.StatementWithType, entries []utils.MasterDataEntry, existingForeignKeys map[string]bool) []utils.StatementWithType {
+	restoredTables := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		restoredTables[utils.MakeFQN(entry.Schema, entry.Name)] = true
+	}
+	foreignKeys := make([]utils.StatementWithType, 0)
+	for _, statement := range statements {
+		if !isForeignKeyConstraint(statement) || !restoredTables[statement.ReferenceObject] {
+			continue
+		}
+		if !existingForeignKeys[getForeignKeyKey(statement.ReferenceObject, statement.Name)] {
+			continue
+		}
+		statement.Dependencies = nil
+		foreignKeys = append(foreignKeys, statement)
+	}
+	return foreignKeys
+}
+
+func GetDropForeignKeyStatement(foreignKey utils.StatementWithType) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", foreignKey.ReferenceObject, foreignKey.Name)
+}
+
+/*
+ * The foreign keys are dropped in a single transaction, so that either all of
+ * them or none of them are dropped.  Dropped foreign keys are tracked until
+ * they are recreated, so that any that are not recreated because the restore
+ * fails can be reported.
+ */
+func DropForeignKeys(foreignKeys []utils.StatementWithType) {
+	if len(foreignKeys) == 0 {
+		return
+	}
+	gplog.Verbose("Dropping %d foreign key constraint(s) until table data is restored", len(foreignKeys))
+	connection.MustBegin()
+	for _, foreignKey := range foreignKeys {
+		_, err := connection.Exec(GetDropForeignKeyStatement(foreignKey))
+		if err != nil {
+			_ = connection.Rollback()
+			gplog.Fatal(err, "Error dropping foreign key constraint %s on table %s; no foreign key constraints were dropped", foreignKey.Name, foreignKey.ReferenceObject)
+		}
+	}
+	connection.MustCommit()
+	droppedForeignKeys = foreignKeys
+}
+
+func RecreateForeignKeys() {
+	if len(droppedForeignKeys) == 0 {
+		return
+	}
+	gplog.Verbose("Recreating %d foreign key constraint(s)", len(droppedForeignKeys))
+	for len(droppedForeignKeys) > 0 {
+		if wasTerminated {
+			return
+		}
+		executeStatement(droppedForeignKeys[0], utils.PB_NONE, 0)
+		droppedForeignKeys = droppedForeignKeys[1:]
+	}
+}
+
+func WarnForeignKeysNotRecreated() {
+	if len(droppedForeignKeys) == 0 {
+		return
+	}
+	gplog.Warn("%d foreign key constraint(s) dropped for --disable-triggers were not recreated:", len(droppedForeignKeys))
+	for _, foreignKey := range droppedForeignKeys {
+		gplog.Warn("%s", strings.TrimSpace(foreignKey.Statement))
+	}
+}
+
+/*
+ * If a table's triggers cannot be disabled with --on-error-continue, its data
+ * is still restored, with its triggers enabled.
+ */
+func disableTableTriggers(table string, whichConn int) bool {
+	err := alterTableTriggers(table, "DISABLE", whichConn)
+	if err != nil {
+		if !*onErrorContinue {
+			gplog.Fatal(err, "Error disabling triggers on table %s", table)
+		}
+		gplog.Error("Error disabling triggers on table %s: %v", table, err)
+		return false
+	}
+	return true
+}
+
+func enableTableTriggers(table string, whichConn int) {
+	err := alterTableTriggers(table, "ENABLE", whichConn)
+	if err != nil {
+		if !*onErrorContinue {
+			gplog.Fatal(err, "Error enabling triggers on table %s", table)
+		}
+		gplog.Error("Error enabling triggers on table %s: %v", table, err)
+	}
+}
+
+// The action is either DISABLE or ENABLE.
+func alterTableTriggers(table string, action string, whichConn int) error {
+	_, err := connection.Exec(fmt.Sprintf("ALTER TABLE %s %s TRIGGER ALL;", table, action), whichConn)
+	return err
+}
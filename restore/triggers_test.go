@@ -0,0 +1,90 @@
+package restore_test
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/greenplum-db/gp-common-go-libs/testhelper"
+	"github.com/greenplum-db/gpbackup/restore"
+	"github.com/greenplum-db/gpbackup/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+var _ = Describe("restore/triggers tests", func() {
+	foreignKey1 := utils.StatementWithType{ObjectType: "CONSTRAINT", Schema: "public", Name: "table1_j_fkey", ReferenceObject: "public.table1",
+		Statement: "\n\nALTER TABLE ONLY public.table1 ADD CONSTRAINT table1_j_fkey FOREIGN KEY (j) REFERENCES public.table2(i);\n", Dependencies: []int{0}}
+	foreignKey2 := utils.StatementWithType{ObjectType: "CONSTRAINT", Schema: "public", Name: "table3_j_fkey", ReferenceObject: "public.table3",
+		Statement: "\n\nALTER TABLE ONLY public.table3 ADD CONSTRAINT table3_j_fkey FOREIGN KEY (j) REFERENCES public.table2(i);\n"}
+	primaryKey := utils.StatementWithType{ObjectType: "CONSTRAINT", Schema: "public", Name: "table2_pkey", ReferenceObject: "public.table2",
+		Statement: "\n\nALTER TABLE ONLY public.table2 ADD CONSTRAINT table2_pkey PRIMARY KEY (i);\n"}
+	Describe("GetExistingForeignKeys", func() {
+		It("returns the foreign keys in the restore database by table and name", func() {
+			fkRows := sqlmock.NewRows([]string{"tablename", "name"}).AddRow("public.table1", "table1_j_fkey").AddRow("public.table3", "table3_j_fkey")
+			mock.ExpectQuery("SELECT (.*)").WillReturnRows(fkRows)
+
+			foreignKeys := restore.GetExistingForeignKeys(connection)
+
+			Expect(foreignKeys).To(Equal(map[string]bool{"public.table1|table1_j_fkey": true, "public.table3|table3_j_fkey": true}))
+		})
+	})
+	Describe("GetForeignKeysToDrop", func() {
+		existingForeignKeys := map[string]bool{"public.table1|table1_j_fkey": true, "public.table3|table3_j_fkey": true}
+		It("returns existing foreign keys on restored tables", func() {
+			statements := []utils.StatementWithType{foreignKey1, primaryKey, foreignKey2}
+			entries := []utils.MasterDataEntry{{Schema: "public", Name: "table1"}, {Schema: "public", Name: "table2"}}
+
+			foreignKeys := restore.GetForeignKeysToDrop(statements, entries, existingForeignKeys)
+
+			expectedForeignKey := foreignKey1
+			expectedForeignKey.Dependencies = nil
+			Expect(foreignKeys).To(Equal([]utils.StatementWithType{expectedForeignKey}))
+		})
+		It("does not return foreign keys that do not exist in the restore database", func() {
+			entries := []utils.MasterDataEntry{{Schema: "public", Name: "table1"}, {Schema: "public", Name: "table3"}}
+
+			foreignKeys := restore.GetForeignKeysToDrop([]utils.StatementWithType{foreignKey1, foreignKey2}, entries, map[string]bool{"public.table3|table3_j_fkey": true})
+
+			Expect(foreignKeys).To(Equal([]utils.StatementWithType{foreignKey2}))
+		})
+	})
+	Describe("DropForeignKeys", func() {
+		It("drops the foreign keys in a single transaction", func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE public.table1 DROP CONSTRAINT table1_j_fkey;")).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE public.table3 DROP CONSTRAINT table3_j_fkey;")).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectCommit()
+
+			restore.DropForeignKeys([]utils.StatementWithType{foreignKey1, foreignKey2})
+
+			Expect(mock.ExpectationsWereMet()).To(Succeed())
+		})
+		It("rolls back and panics if a foreign key cannot be dropped", func() {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE public.table1 DROP CONSTRAINT table1_j_fkey;")).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE public.table3 DROP CONSTRAINT table3_j_fkey;")).WillReturnError(errors.New("permission denied"))
+			mock.ExpectRollback()
+
+			defer testhelper.ShouldPanicWithMessage("permission denied: Error dropping foreign key constraint table3_j_fkey on table public.table3; no foreign key constraints were dropped")
+			restore.DropForeignKeys([]utils.StatementWithType{foreignKey1, foreignKey2})
+		})
+	})
+	Describe("RecreateForeignKeys", func() {
+		It("recreates the dropped foreign keys", func() {
+			restore.SetOnErrorContinue(false)
+			mock.ExpectBegin()
+			mock.ExpectExec("DROP CONSTRAINT table1_j_fkey").WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectCommit()
+			mock.ExpectExec(regexp.QuoteMeta("ADD CONSTRAINT table1_j_fkey FOREIGN KEY (j)")).WillReturnResult(sqlmock.NewResult(0, 0))
+			restore.DropForeignKeys([]utils.StatementWithType{foreignKey1})
+
+			restore.RecreateForeignKeys()
+
+			Expect(mock.ExpectationsWereMet()).To(Succeed())
+			restore.WarnForeignKeysNotRecreated()
+			testhelper.NotExpectRegexp(logfile, "were not recreated")
+		})
+	})
+})
@@ -18,6 +18,12 @@ import (
 func validateFilterListsInBackupSet() {
 	ValidateFilterSchemasInBackupSet(includeSchemas)
 	ValidateFilterTablesInBackupSet(includeTables)
+	renamedTables := make([]string, 0)
+	for table := range tableRenameMap {
+		renamedTables = append(renamedTables, table)
+	}
+	ValidateFilterTablesInBackupSet(renamedTables)
+	ValidateFilterTablesInBackupSet(priorityTables)
 }
 
 func ValidateFilterSchemasInBackupSet(schemaList utils.ArrayFlags) {
@@ -75,6 +81,50 @@ WHERE quote_ident(n.nspname) || '.' || quote_ident(c.relname) IN (%s)`, quotedTa
 	}
 }
 
+/*
+ * Data is restored with COPY ... ON SEGMENT, which loads each segment's rows
+ * back onto that segment, so it is only correctly distributed if each table
+ * has the distribution policy it had when it was backed up.  Backups that did
+ * not record distribution policies are not checked.
+ */
+func ValidateDistributionPoliciesInRestoreDatabase(connection *dbconn.DBConn, entries []utils.MasterDataEntry) {
+	backupPolicies := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if entry.DistributionPolicy != "" {
+			backupPolicies[utils.MakeFQN(entry.Schema, entry.Name)] = entry.DistributionPolicy
+		}
+	}
+	if len(backupPolicies) == 0 {
+		return
+	}
+	query := fmt.Sprintf(`
+SELECT
+	quote_ident(n.nspname) || '.' || quote_ident(c.relname) AS name,
+	%s AS policy
+FROM gp_distribution_policy p
+JOIN pg_class c ON p.localoid = c.oid
+JOIN pg_namespace n ON c.relnamespace = n.oid
+ORDER BY n.nspname, c.relname;`, utils.DistributionPolicyExpression(connection))
+	results := make([]struct {
+		Name   string
+		Policy string
+	}, 0)
+	err := connection.Select(&results, query)
+	gplog.FatalOnError(err)
+
+	numMismatches := 0
+	for _, result := range results {
+		backupPolicy, ok := backupPolicies[result.Name]
+		if ok && backupPolicy != result.Policy {
+			gplog.Error("Table %s is %s, but was %s when it was backed up", result.Name, result.Policy, backupPolicy)
+			numMismatches++
+		}
+	}
+	if numMismatches > 0 {
+		gplog.Fatal(errors.Errorf("Distribution policies of %d table(s) do not match the backup", numMismatches), "")
+	}
+}
+
 func ValidateFilterTablesInBackupSet(tableList utils.ArrayFlags) {
 	tableMap := make(map[string]bool, len(tableList))
 	for _, table := range tableList {
@@ -137,16 +187,36 @@ END AS string;`, dbname)))
 }
 
 func ValidateBackupFlagCombinations() {
-	if backupConfig.SingleDataFile {
-		if *numJobs != 1 {
-			gplog.Fatal(errors.Errorf("Cannot use jobs flag when restoring backups with a single data file per segment."), "")
+	if backupConfig.SingleDataFile && *numJobs > 1 && !canReadTableRanges() {
+		if backupConfig.Compressed {
+			gplog.Warn("Table data in backup %s is in a compressed single data file per segment, so it will be restored one table at a time; --jobs will only be used to restore post-data metadata", globalFPInfo.Timestamp)
+		} else {
+			gplog.Warn("Plugin %s does not support restore_data_range, so table data in a single data file per segment will be restored one table at a time; --jobs will only be used to restore post-data metadata", pluginConfig.ExecutablePath)
 		}
 	}
+	if backupConfig.SingleDataFile && *priorityTableFile != "" && !canReadTableRanges() {
+		gplog.Warn("The --priority-table-file flag will be ignored, as table data in a single data file per segment that is compressed or read through a plugin that does not support restore_data_range must be restored in the order it was backed up")
+	}
 	if backupConfig.IncludeTableFiltered || backupConfig.DataOnly {
 		if *restoreGlobals {
 			gplog.Fatal(errors.Errorf("Global metadata is not backed up in table-filtered or data-only backups."), "")
 		}
 	}
+	if backupConfig.DataOnly && *clean {
+		gplog.Fatal(errors.Errorf("The --clean flag cannot be used to restore a data-only backup."), "")
+	}
+	if backupConfig.MetadataOnly && *dataOnly {
+		gplog.Fatal(errors.Errorf("The --data-only flag cannot be used to restore a metadata-only backup."), "")
+	}
+	if *disableTriggers && !isDataOnlyRestore() {
+		gplog.Fatal(errors.Errorf("The --disable-triggers flag can only be used with --data-only or to restore a data-only backup."), "")
+	}
+	if *syncSequences != "" && !isDataOnlyRestore() {
+		gplog.Fatal(errors.Errorf("The --sync-sequences flag can only be used with --data-only or to restore a data-only backup."), "")
+	}
+	if *syncSequences == "backup" && backupConfig.DataOnly {
+		gplog.Fatal(errors.Errorf(`Sequence values are not backed up in data-only backups. Use --sync-sequences max instead.`), "")
+	}
 	if backupConfig.Plugin != "" && *pluginConfigFile == "" {
 		gplog.Fatal(errors.Errorf("Backup was taken with plugin %s. The --plugin-config flag must be used to restore.", backupConfig.Plugin), "")
 	} else if backupConfig.Plugin == "" && *pluginConfigFile != "" {
@@ -154,6 +224,57 @@ func ValidateBackupFlagCombinations() {
 	}
 }
 
+/*
+ * These are the object types that can be passed to --include-object-type and
+ * --exclude-object-type.  Global objects are only restored with --with-globals,
+ * so they cannot be filtered by type.
+ */
+var FilterObjectTypes = []string{"AGGREGATE", "CAST", "COLLATION", "CONSTRAINT", "CONVERSION", "DEFAULT PRIVILEGES", "DOMAIN",
+	"EVENT TRIGGER", "EXTENSION", "FOREIGN DATA WRAPPER", "FOREIGN SERVER", "FUNCTION", "INDEX", "OPERATOR", "OPERATOR CLASS",
+	"OPERATOR FAMILY", "PROCEDURAL LANGUAGE", "PROTOCOL", "RULE", "SCHEMA", "SECURITY LABEL", "SEQUENCE", "TABLE",
+	"TEXT SEARCH CONFIGURATION", "TEXT SEARCH DICTIONARY", "TEXT SEARCH PARSER", "TEXT SEARCH TEMPLATE", "TRIGGER", "TYPE",
+	"USER MAPPING", "VIEW"}
+
+/*
+ * Some objects are written to more than one TOC entry with different object
+ * types, and the additional entries cannot be restored without the object they
+ * belong to, so they are filtered along with that object's type.
+ */
+var dependentObjectTypes = map[string][]string{
+	"SEQUENCE": {"SEQUENCE OWNER"},
+	"TABLE":    {"EXCHANGE PARTITION"},
+}
+
+func normalizeObjectType(objectType string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(objectType), " "))
+}
+
+func ValidateFilterObjectTypes(objectTypes utils.ArrayFlags) {
+	validTypes := make(map[string]bool, len(FilterObjectTypes))
+	for _, objectType := range FilterObjectTypes {
+		validTypes[objectType] = true
+	}
+	invalidTypes := make([]string, 0)
+	for _, objectType := range objectTypes {
+		if !validTypes[normalizeObjectType(objectType)] {
+			invalidTypes = append(invalidTypes, objectType)
+		}
+	}
+	if len(invalidTypes) > 0 {
+		gplog.Fatal(errors.Errorf("Unrecognized object type(s): %s.  Valid object types are: %s", strings.Join(invalidTypes, ", "), strings.Join(FilterObjectTypes, ", ")), "")
+	}
+}
+
+func ExpandFilterObjectTypes(objectTypes utils.ArrayFlags) utils.ArrayFlags {
+	expandedTypes := make(utils.ArrayFlags, 0)
+	for _, objectType := range objectTypes {
+		objectType = normalizeObjectType(objectType)
+		expandedTypes = append(expandedTypes, objectType)
+		expandedTypes = append(expandedTypes, dependentObjectTypes[objectType]...)
+	}
+	return expandedTypes
+}
+
 func ValidateFlagCombinations() {
 	utils.CheckMandatoryFlags("timestamp")
 	utils.CheckExclusiveFlags("debug", "quiet", "verbose")
@@ -161,4 +282,24 @@ func ValidateFlagCombinations() {
 	utils.CheckExclusiveFlags("exclude-schema", "include-schema")
 	utils.CheckExclusiveFlags("exclude-schema", "exclude-table", "include-table", "exclude-table-file", "include-table-file")
 	utils.CheckExclusiveFlags("exclude-table", "exclude-table-file", "leaf-partition-data")
+	utils.CheckExclusiveFlags("include-object-type", "exclude-object-type")
+	utils.CheckExclusiveFlags("clean", "create-db")
+	utils.CheckExclusiveFlags("retry-failed", "clean")
+	utils.CheckExclusiveFlags("retry-failed", "create-db")
+	utils.CheckExclusiveFlags("output-file", "clean")
+	utils.CheckExclusiveFlags("output-file", "create-db")
+	utils.CheckExclusiveFlags("output-file", "plugin-config")
+	utils.CheckExclusiveFlags("output-file", "run-analyze")
+	utils.CheckExclusiveFlags("data-only", "clean")
+	utils.CheckExclusiveFlags("data-only", "create-db")
+	utils.CheckExclusiveFlags("data-only", "defer-constraints")
+	utils.CheckExclusiveFlags("data-only", "with-globals")
+	utils.CheckExclusiveFlags("disable-triggers", "output-file")
+	utils.CheckExclusiveFlags("sync-sequences", "output-file")
+	if *ifExists && !*clean {
+		gplog.Fatal(errors.Errorf("The --if-exists flag can only be used with --clean"), "")
+	}
+	if *syncSequences != "" && *syncSequences != "backup" && *syncSequences != "max" {
+		gplog.Fatal(errors.Errorf(`The --sync-sequences flag must be "backup" or "max"`), "")
+	}
 }
@@ -8,6 +8,7 @@ import (
 	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
 
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
 )
 
 var _ = Describe("restore/validate tests", func() {
@@ -28,10 +29,10 @@ var _ = Describe("restore/validate tests", func() {
 			toc, backupfile = testutils.InitializeTestTOC(buffer, "predata")
 			backupfile.ByteCount = table1Len
 			toc.AddPredataEntry("schema1", "table1", "", "TABLE", 0, backupfile)
-			toc.AddMasterDataEntry("schema1", "table1", 1, "(i)", 0)
+			toc.AddMasterDataEntry("schema1", "table1", 1, "(i)", 0, 0, "")
 			backupfile.ByteCount += table2Len
 			toc.AddPredataEntry("schema2", "table2", "TABLE", "", table1Len, backupfile)
-			toc.AddMasterDataEntry("schema2", "table2", 2, "(j)", 0)
+			toc.AddMasterDataEntry("schema2", "table2", 2, "(j)", 0, 0, "")
 			backupfile.ByteCount += sequenceLen
 			toc.AddPredataEntry("schema", "somesequence", "SEQUENCE", "", table1Len+table2Len, backupfile)
 			restore.SetTOC(toc)
@@ -86,6 +87,31 @@ var _ = Describe("restore/validate tests", func() {
 			restore.ValidateFilterTablesInRestoreDatabase(connection, filterList)
 		})
 	})
+	Describe("ValidateDistributionPoliciesInRestoreDatabase", func() {
+		entries := []utils.MasterDataEntry{
+			{Schema: "public", Name: "table1", Oid: 1, DistributionPolicy: "DISTRIBUTED BY (i)"},
+			{Schema: "public", Name: "table2", Oid: 2, DistributionPolicy: "DISTRIBUTED REPLICATED"},
+		}
+		It("passes if restored tables have the distribution policies recorded in the backup", func() {
+			policyRows := sqlmock.NewRows([]string{"name", "policy"}).
+				AddRow("public.table1", "DISTRIBUTED BY (i)").AddRow("public.table2", "DISTRIBUTED REPLICATED").AddRow("public.table3", "DISTRIBUTED RANDOMLY")
+			mock.ExpectQuery("SELECT (.*)").WillReturnRows(policyRows)
+			restore.ValidateDistributionPoliciesInRestoreDatabase(connection, entries)
+		})
+		It("does not check distribution policies if the backup did not record them", func() {
+			restore.ValidateDistributionPoliciesInRestoreDatabase(connection, []utils.MasterDataEntry{{Schema: "public", Name: "table1", Oid: 1}})
+		})
+		It("panics if a restored table has a different distribution policy", func() {
+			policyRows := sqlmock.NewRows([]string{"name", "policy"}).
+				AddRow("public.table1", "DISTRIBUTED BY (i)").AddRow("public.table2", "DISTRIBUTED RANDOMLY")
+			mock.ExpectQuery("SELECT (.*)").WillReturnRows(policyRows)
+			defer func() {
+				testhelper.ExpectRegexp(logfile, "Table public.table2 is DISTRIBUTED RANDOMLY, but was DISTRIBUTED REPLICATED when it was backed up")
+			}()
+			defer testhelper.ShouldPanicWithMessage("Distribution policies of 1 table(s) do not match the backup")
+			restore.ValidateDistributionPoliciesInRestoreDatabase(connection, entries)
+		})
+	})
 	Describe("ValidateFilterTablesInBackupSet", func() {
 		sequence := utils.StatementWithType{ObjectType: "SEQUENCE", Statement: "CREATE SEQUENCE schema1.somesequence"}
 		sequenceLen := uint64(len(sequence.Statement))
@@ -99,10 +125,10 @@ var _ = Describe("restore/validate tests", func() {
 			toc, backupfile = testutils.InitializeTestTOC(buffer, "predata")
 			backupfile.ByteCount = table1Len
 			toc.AddPredataEntry("schema1", "table1", "TABLE", "", 0, backupfile)
-			toc.AddMasterDataEntry("schema1", "table1", 1, "(i)", 0)
+			toc.AddMasterDataEntry("schema1", "table1", 1, "(i)", 0, 0, "")
 			backupfile.ByteCount += table2Len
 			toc.AddPredataEntry("schema2", "table2", "TABLE", "", table1Len, backupfile)
-			toc.AddMasterDataEntry("schema2", "table2", 2, "(j)", 0)
+			toc.AddMasterDataEntry("schema2", "table2", 2, "(j)", 0, 0, "")
 			backupfile.ByteCount += sequenceLen
 			toc.AddPredataEntry("schema1", "somesequence", "SEQUENCE", "", table1Len+table2Len, backupfile)
 			restore.SetTOC(toc)
@@ -167,4 +193,22 @@ var _ = Describe("restore/validate tests", func() {
 			restore.ValidateDatabaseExistence("testdb", false, false)
 		})
 	})
+	Describe("ValidateFilterObjectTypes", func() {
+		It("passes if there are no filter object types", func() {
+			restore.ValidateFilterObjectTypes([]string{})
+		})
+		It("passes if all object types are valid, regardless of case and spacing", func() {
+			restore.ValidateFilterObjectTypes([]string{"FUNCTION", "index", "Text  Search Parser"})
+		})
+		It("panics if an object type is not recognized", func() {
+			defer testhelper.ShouldPanicWithMessage("Unrecognized object type(s): FUNCTION2, ROLE.  Valid object types are: AGGREGATE, CAST")
+			restore.ValidateFilterObjectTypes([]string{"FUNCTION", "FUNCTION2", "ROLE"})
+		})
+	})
+	Describe("ExpandFilterObjectTypes", func() {
+		It("normalizes object types and adds the object types that depend on them", func() {
+			objectTypes := restore.ExpandFilterObjectTypes([]string{"function", "Sequence", "TABLE"})
+			Expect(objectTypes).To(Equal(utils.ArrayFlags{"FUNCTION", "SEQUENCE", "SEQUENCE OWNER", "TABLE", "EXCHANGE PARTITION"}))
+		})
+	})
 })
@@ -2,11 +2,13 @@ package restore
 
 import (
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/greenplum-db/gp-common-go-libs/dbconn"
 	"github.com/greenplum-db/gp-common-go-libs/gplog"
 	"github.com/greenplum-db/gpbackup/utils"
+	"github.com/pkg/errors"
 )
 
 /*
@@ -30,11 +32,7 @@ func SetLoggerVerbosity() {
 	}
 }
 
-func InitializeConnection(dbname string) {
-	connection = dbconn.NewDBConn(dbname)
-	connection.MustConnect(*numJobs)
-	utils.SetDatabaseVersion(connection)
-	setupQuery := `
+const sessionSetupQuery = `
 SET application_name TO 'gprestore';
 SET search_path TO pg_catalog;
 SET gp_enable_segment_copy_checking TO false;
@@ -45,14 +43,25 @@ SET client_min_messages = error;
 SET standard_conforming_strings = on;
 SET default_with_oids = off;
 `
-	if connection.Version.Before("5") {
-		setupQuery += "SET gp_strict_xml_parse = off;\n"
-	}
+
+func InitializeConnection(dbname string) {
+	connection = dbconn.NewDBConn(dbname)
+	connection.MustConnect(*numJobs)
+	utils.SetDatabaseVersion(connection)
+	setupQuery := getSessionSetupQuery()
 	for i := 0; i < connection.NumConns; i++ {
 		connection.MustExec(setupQuery, i)
 	}
 }
 
+func getSessionSetupQuery() string {
+	setupQuery := sessionSetupQuery
+	if connection.Version.Before("5") {
+		setupQuery += "SET gp_strict_xml_parse = off;\n"
+	}
+	return setupQuery
+}
+
 func InitializeBackupConfig() {
 	backupConfig = utils.ReadConfigFile(globalFPInfo.GetConfigFilePath())
 	utils.InitializeCompressionParameters(backupConfig.Compressed, 0)
@@ -67,6 +76,8 @@ func InitializeFilterLists() {
 	if *includeTableFile != "" {
 		includeTables = utils.ReadLinesFromFile(*includeTableFile)
 	}
+	includeObjectTypes = ExpandFilterObjectTypes(includeObjectTypes)
+	excludeObjectTypes = ExpandFilterObjectTypes(excludeObjectTypes)
 }
 
 func BackupConfigurationValidation() {
@@ -75,28 +86,61 @@ func BackupConfigurationValidation() {
 	gplog.Verbose("Gathering information on backup directories")
 	VerifyBackupDirectoriesExistOnAllHosts()
 
-	VerifyMetadataFilePaths(*withStats)
-
 	tocFilename := globalFPInfo.GetTOCFilePath()
-	globalTOC = utils.NewTOC(tocFilename)
+	if backupConfig.StreamedMetadata {
+		if *withStats && !backupConfig.WithStatistics {
+			gplog.Fatal(errors.Errorf(`Backup %s does not include statistics. Note that the "-with-stats" flag must be passed to gpbackup to generate a statistics file.`, globalFPInfo.Timestamp), "")
+		}
+		globalTOC = utils.NewTOCFromPlugin(pluginConfig, tocFilename)
+	} else {
+		VerifyMetadataFilePaths(*withStats)
+		globalTOC = utils.NewTOC(tocFilename)
+	}
 	globalTOC.InitializeEntryMap()
 	ValidateBackupFlagCombinations()
 
 	validateFilterListsInBackupSet()
 }
 
+// Metadata is not restored from a data-only backup, or with --data-only.
+func isDataOnlyRestore() bool {
+	return backupConfig.DataOnly || *dataOnly
+}
+
+/*
+ * The restore agents can only read each table's data from a single data file
+ * by its offsets, restoring tables in parallel and in the order listed, if the
+ * data file is not compressed and is either local or read through a plugin
+ * that supports restore_data_range.  Otherwise, they read the data file from
+ * start to end, one table at a time.  This matches helper.CanReadTableRanges.
+ */
+func canReadTableRanges() bool {
+	if backupConfig.Compressed {
+		return false
+	}
+	return pluginConfig == nil || pluginConfig.HasCapability("restore_data_range")
+}
+
 func RecoverMetadataFilesUsingPlugin() {
-	pluginConfig := utils.ReadPluginConfig(*pluginConfigFile)
+	pluginConfig = utils.ReadPluginConfig(*pluginConfigFile)
 	pluginConfig.CheckPluginExistsOnAllHosts(globalCluster)
+	pluginConfig.ValidateOptions(pluginConfig.GetPluginSchema())
 	pluginConfig.CopyPluginConfigToAllHosts(globalCluster, *pluginConfigFile)
 	pluginConfig.SetupPluginForRestoreOnAllHosts(globalCluster, pluginConfig.ConfigPath, globalFPInfo.GetDirForContent(-1))
 	pluginConfig.RestoreFile(globalFPInfo.GetConfigFilePath())
 
 	InitializeBackupConfig()
 
-	metadataFiles := []string{globalFPInfo.GetMetadataFilePath(), globalFPInfo.GetTOCFilePath(), globalFPInfo.GetBackupReportFilePath()}
-	if *withStats {
-		metadataFiles = append(metadataFiles, globalFPInfo.GetStatisticsFilePath())
+	/*
+	 * Metadata files streamed to the plugin during backup are read from the
+	 * plugin as needed instead of being restored to the master.
+	 */
+	metadataFiles := []string{globalFPInfo.GetBackupReportFilePath()}
+	if !backupConfig.StreamedMetadata {
+		metadataFiles = append(metadataFiles, globalFPInfo.GetMetadataFilePath(), globalFPInfo.GetTOCFilePath())
+		if *withStats {
+			metadataFiles = append(metadataFiles, globalFPInfo.GetStatisticsFilePath())
+		}
 	}
 	for _, filename := range metadataFiles {
 		pluginConfig.RestoreFile(filename)
@@ -110,10 +154,24 @@ func RecoverMetadataFilesUsingPlugin() {
  * Metadata and/or data restore wrapper functions
  */
 
-func GetRestoreMetadataStatements(section string, filename string, includeObjectTypes []string, excludeObjectTypes []string, filterSchemas bool, filterTables bool) []utils.StatementWithType {
-	metadataFile := utils.MustOpenFileForReading(filename)
+func GetRestoreMetadataStatements(section string, filename string, includeTypes []string, excludeTypes []string, filterSchemas bool, filterTables bool, filterObjectTypes bool) []utils.StatementWithType {
+	if filterObjectTypes {
+		var restoreAny bool
+		includeTypes, excludeTypes, restoreAny = CombineObjectTypeFilters(includeTypes, excludeTypes)
+		if !restoreAny {
+			return []utils.StatementWithType{}
+		}
+	}
+	var metadataFile io.ReaderAt
+	if backupConfig.StreamedMetadata {
+		pluginReader := utils.NewPluginReaderAt(pluginConfig, filename)
+		defer pluginReader.Close()
+		metadataFile = pluginReader
+	} else {
+		metadataFile = utils.MustOpenFileForReading(filename)
+	}
 	var statements []utils.StatementWithType
-	if len(includeObjectTypes) > 0 || len(excludeObjectTypes) > 0 || filterSchemas || filterTables {
+	if len(includeTypes) > 0 || len(excludeTypes) > 0 || filterSchemas || filterTables {
 		var inSchemas, exSchemas, inTables, exTables []string
 		if filterSchemas {
 			inSchemas = includeSchemas
@@ -123,13 +181,69 @@ func GetRestoreMetadataStatements(section string, filename string, includeObject
 			inTables = includeTables
 			exTables = excludeTables
 		}
-		statements = globalTOC.GetSQLStatementForObjectTypes(section, metadataFile, includeObjectTypes, excludeObjectTypes, inSchemas, exSchemas, inTables, exTables)
+		statements = globalTOC.GetSQLStatementForObjectTypes(section, metadataFile, includeTypes, excludeTypes, inSchemas, exSchemas, inTables, exTables)
 	} else {
 		statements = globalTOC.GetAllSQLStatements(section, metadataFile)
 	}
+	statements = ApplyOwnerAndPrivilegeOptions(statements)
+	statements = ApplyTablespaceMap(statements)
+	statements = ApplyTableRenameMap(statements)
+	if retryFailures != nil {
+		statements = FilterFailedStatements(statements)
+	}
 	return statements
 }
 
+/*
+ * The object types that gprestore always includes or excludes for a set of
+ * statements are combined with those passed to --include-object-type or
+ * --exclude-object-type.  The returned boolean is false if no object types
+ * remain to be included, since passing an empty include list to the TOC would
+ * include every object type instead.
+ */
+func CombineObjectTypeFilters(includeTypes []string, excludeTypes []string) ([]string, []string, bool) {
+	if len(includeObjectTypes) == 0 && len(excludeObjectTypes) == 0 {
+		return includeTypes, excludeTypes, true
+	}
+	combinedExcludes := make([]string, 0)
+	combinedExcludes = append(combinedExcludes, excludeTypes...)
+	combinedExcludes = append(combinedExcludes, excludeObjectTypes...)
+	excludeSet := make(map[string]bool, len(combinedExcludes))
+	for _, objectType := range combinedExcludes {
+		excludeSet[objectType] = true
+	}
+	var candidates []string
+	if len(includeTypes) > 0 && len(includeObjectTypes) > 0 {
+		userIncludeSet := make(map[string]bool, len(includeObjectTypes))
+		for _, objectType := range includeObjectTypes {
+			userIncludeSet[objectType] = true
+		}
+		for _, objectType := range includeTypes {
+			if userIncludeSet[objectType] {
+				candidates = append(candidates, objectType)
+			}
+		}
+	} else if len(includeTypes) > 0 {
+		candidates = includeTypes
+	} else if len(includeObjectTypes) > 0 {
+		candidates = includeObjectTypes
+	} else {
+		return []string{}, combinedExcludes, true
+	}
+	combinedIncludes := make([]string, 0)
+	for _, objectType := range candidates {
+		if !excludeSet[objectType] {
+			combinedIncludes = append(combinedIncludes, objectType)
+		}
+	}
+	return combinedIncludes, []string{}, len(combinedIncludes) > 0
+}
+
+func ObjectTypeIsRestored(objectType string) bool {
+	_, _, restored := CombineObjectTypeFilters([]string{objectType}, []string{})
+	return restored
+}
+
 func ExecuteRestoreMetadataStatements(statements []utils.StatementWithType, objectsTitle string, progressBar utils.ProgressBar, showProgressBar int, executeInParallel bool) {
 	if progressBar == nil {
 		ExecuteStatementsAndCreateProgressBar(statements, objectsTitle, showProgressBar, executeInParallel)
@@ -138,6 +252,15 @@ func ExecuteRestoreMetadataStatements(statements []utils.StatementWithType, obje
 	}
 }
 
+func statementsHaveDependencies(statements []utils.StatementWithType) bool {
+	for _, statement := range statements {
+		if len(statement.Dependencies) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 /*
  * The first time this function is called, it retrieves the session GUCs from the
  * predata file and processes them appropriately, then it returns them so they
@@ -146,7 +269,7 @@ func ExecuteRestoreMetadataStatements(statements []utils.StatementWithType, obje
 func setGUCsForConnection(gucStatements []utils.StatementWithType, whichConn int) []utils.StatementWithType {
 	if gucStatements == nil {
 		objectTypes := []string{"SESSION GUCS"}
-		gucStatements = GetRestoreMetadataStatements("global", globalFPInfo.GetMetadataFilePath(), objectTypes, []string{}, false, false)
+		gucStatements = GetRestoreMetadataStatements("global", globalFPInfo.GetMetadataFilePath(), objectTypes, []string{}, false, false, false)
 	}
 	ExecuteStatementsAndCreateProgressBar(gucStatements, "", utils.PB_NONE, false, whichConn)
 	return gucStatements
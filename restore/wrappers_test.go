@@ -0,0 +1,75 @@
+package restore_test
+
+import (
+	"github.com/greenplum-db/gpbackup/restore"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("restore/wrappers tests", func() {
+	Describe("CombineObjectTypeFilters", func() {
+		AfterEach(func() {
+			restore.SetIncludeObjectTypes([]string{})
+			restore.SetExcludeObjectTypes([]string{})
+		})
+		It("returns the given filters if no object type flags were passed", func() {
+			includeTypes, excludeTypes, restoreAny := restore.CombineObjectTypeFilters([]string{}, []string{"SCHEMA"})
+			Expect(includeTypes).To(Equal([]string{}))
+			Expect(excludeTypes).To(Equal([]string{"SCHEMA"}))
+			Expect(restoreAny).To(BeTrue())
+		})
+		It("combines exclude filters", func() {
+			restore.SetExcludeObjectTypes([]string{"INDEX"})
+			includeTypes, excludeTypes, restoreAny := restore.CombineObjectTypeFilters([]string{}, []string{"SCHEMA"})
+			Expect(includeTypes).To(Equal([]string{}))
+			Expect(excludeTypes).To(Equal([]string{"SCHEMA", "INDEX"}))
+			Expect(restoreAny).To(BeTrue())
+		})
+		It("removes excluded types from included object types", func() {
+			restore.SetIncludeObjectTypes([]string{"FUNCTION", "SCHEMA", "AGGREGATE"})
+			includeTypes, excludeTypes, restoreAny := restore.CombineObjectTypeFilters([]string{}, []string{"SCHEMA"})
+			Expect(includeTypes).To(Equal([]string{"FUNCTION", "AGGREGATE"}))
+			Expect(excludeTypes).To(Equal([]string{}))
+			Expect(restoreAny).To(BeTrue())
+		})
+		It("intersects include filters", func() {
+			restore.SetIncludeObjectTypes([]string{"FUNCTION", "SCHEMA"})
+			includeTypes, excludeTypes, restoreAny := restore.CombineObjectTypeFilters([]string{"SCHEMA"}, []string{})
+			Expect(includeTypes).To(Equal([]string{"SCHEMA"}))
+			Expect(excludeTypes).To(Equal([]string{}))
+			Expect(restoreAny).To(BeTrue())
+		})
+		It("removes excluded object types from the given include filter", func() {
+			restore.SetExcludeObjectTypes([]string{"SCHEMA"})
+			includeTypes, excludeTypes, restoreAny := restore.CombineObjectTypeFilters([]string{"SCHEMA"}, []string{})
+			Expect(includeTypes).To(Equal([]string{}))
+			Expect(excludeTypes).To(Equal([]string{}))
+			Expect(restoreAny).To(BeFalse())
+		})
+		It("restores nothing if no included object types remain", func() {
+			restore.SetIncludeObjectTypes([]string{"SCHEMA"})
+			_, _, restoreAny := restore.CombineObjectTypeFilters([]string{}, []string{"SCHEMA"})
+			Expect(restoreAny).To(BeFalse())
+		})
+	})
+	Describe("ObjectTypeIsRestored", func() {
+		AfterEach(func() {
+			restore.SetIncludeObjectTypes([]string{})
+			restore.SetExcludeObjectTypes([]string{})
+		})
+		It("returns true if no object type flags were passed", func() {
+			Expect(restore.ObjectTypeIsRestored("TABLE")).To(BeTrue())
+		})
+		It("returns whether the object type is included", func() {
+			restore.SetIncludeObjectTypes([]string{"FUNCTION"})
+			Expect(restore.ObjectTypeIsRestored("FUNCTION")).To(BeTrue())
+			Expect(restore.ObjectTypeIsRestored("TABLE")).To(BeFalse())
+		})
+		It("returns whether the object type is not excluded", func() {
+			restore.SetExcludeObjectTypes([]string{"INDEX"})
+			Expect(restore.ObjectTypeIsRestored("INDEX")).To(BeFalse())
+			Expect(restore.ObjectTypeIsRestored("TABLE")).To(BeTrue())
+		})
+	})
+})
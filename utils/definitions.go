@@ -0,0 +1,118 @@
+package utils
+
+/*
+ * This file contains structs and functions related to recording the structured
+ * definition of each object alongside its TOC entry, so that tools can inspect
+ * the contents of a backup without parsing the metadata file.
+ */
+
+import (
+	"encoding/json"
+
+	"github.com/greenplum-db/gp-common-go-libs/gplog"
+	"github.com/greenplum-db/gp-common-go-libs/operating"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+type ObjectDefinitions struct {
+	GlobalDefinitions   []ObjectDefinition
+	PredataDefinitions  []ObjectDefinition
+	PostdataDefinitions []ObjectDefinition
+}
+
+/*
+ * TOCIndex is the index of the entry in the corresponding section of the TOC,
+ * and Definition is the struct from which that entry's statement was printed.
+ */
+type ObjectDefinition struct {
+	TOCIndex   int
+	Schema     string
+	Name       string
+	ObjectType string
+	Definition interface{}
+}
+
+var ValidObjectDefinitionFormats = []string{"yaml", "json"}
+
+func ValidateObjectDefinitionFormat(format string) {
+	if format == "" {
+		return
+	}
+	for _, validFormat := range ValidObjectDefinitionFormats {
+		if format == validFormat {
+			return
+		}
+	}
+	gplog.Fatal(errors.Errorf("Object definition format must be one of %v", ValidObjectDefinitionFormats), "")
+}
+
+/*
+ * Definitions are only recorded once this function has been called, so that
+ * backups that don't need them don't keep them around in memory.
+ */
+func (toc *TOC) InitializeObjectDefinitions() {
+	toc.objectDefinitions = &ObjectDefinitions{
+		GlobalDefinitions:   []ObjectDefinition{},
+		PredataDefinitions:  []ObjectDefinition{},
+		PostdataDefinitions: []ObjectDefinition{},
+	}
+	toc.definitionMap = make(map[string]*[]ObjectDefinition, 3)
+	toc.definitionMap["global"] = &toc.objectDefinitions.GlobalDefinitions
+	toc.definitionMap["predata"] = &toc.objectDefinitions.PredataDefinitions
+	toc.definitionMap["postdata"] = &toc.objectDefinitions.PostdataDefinitions
+}
+
+// This function records the definition of the most recently added entry in the given section.
+func (toc *TOC) AddObjectDefinition(section string, definition interface{}) {
+	if toc.objectDefinitions == nil {
+		return
+	}
+	entries := *toc.metadataEntryMap[section]
+	if len(entries) == 0 {
+		return
+	}
+	entry := entries[len(entries)-1]
+	*toc.definitionMap[section] = append(*toc.definitionMap[section], ObjectDefinition{TOCIndex: len(entries) - 1, Schema: entry.Schema, Name: entry.Name, ObjectType: entry.ObjectType, Definition: definition})
+}
+
+func (toc *TOC) AddGlobalDefinition(definition interface{}) {
+	toc.AddObjectDefinition("global", definition)
+}
+
+func (toc *TOC) AddPredataDefinition(definition interface{}) {
+	toc.AddObjectDefinition("predata", definition)
+}
+
+func (toc *TOC) AddPostdataDefinition(definition interface{}) {
+	toc.AddObjectDefinition("postdata", definition)
+}
+
+func (toc *TOC) GetObjectDefinitions() *ObjectDefinitions {
+	return toc.objectDefinitions
+}
+
+func MarshalObjectDefinitions(definitions *ObjectDefinitions, format string) []byte {
+	var contents []byte
+	var err error
+	if format == "json" {
+		contents, err = json.MarshalIndent(definitions, "", "  ")
+	} else {
+		contents, err = yaml.Marshal(definitions)
+	}
+	gplog.FatalOnError(err)
+	return contents
+}
+
+func (toc *TOC) WriteObjectDefinitionsToFile(filename string, format string) {
+	defer operating.System.Chmod(filename, 0444)
+	definitionsFile := MustOpenFileForWriting(filename)
+	defer definitionsFile.Close()
+	MustPrintBytes(definitionsFile, MarshalObjectDefinitions(toc.objectDefinitions, format))
+}
+
+func (toc *TOC) WriteObjectDefinitionsToPlugin(plugin *PluginConfig, filename string, format string) {
+	definitionsFile := NewFileWithByteCountFromPlugin(plugin, filename)
+	defer definitionsFile.Close()
+	MustPrintBytes(definitionsFile.writer, MarshalObjectDefinitions(toc.objectDefinitions, format))
+}
@@ -0,0 +1,110 @@
+package utils_test
+
+import (
+	"github.com/greenplum-db/gp-common-go-libs/testhelper"
+	"github.com/greenplum-db/gpbackup/testutils"
+	"github.com/greenplum-db/gpbackup/utils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("utils/definitions tests", func() {
+	type testObject struct {
+		Oid  uint32
+		Name string
+	}
+	BeforeEach(func() {
+		toc, backupfile = testutils.InitializeTestTOC(buffer, "metadata")
+	})
+	Describe("AddObjectDefinition", func() {
+		It("records the definition of the most recently added entry in each section", func() {
+			toc.InitializeObjectDefinitions()
+			toc.AddPredataEntry("public", "table1", "TABLE", "", 0, backupfile)
+			toc.AddPredataEntry("public", "table2", "TABLE", "", 0, backupfile)
+			toc.AddPredataDefinition(testObject{Oid: 2, Name: "table2"})
+			toc.AddPostdataEntry("public", "index1", "INDEX", "public.table2", 0, backupfile)
+			toc.AddPostdataDefinition(testObject{Oid: 3, Name: "index1"})
+			toc.AddGlobalEntry("", "role1", "ROLE", 0, backupfile)
+			toc.AddGlobalDefinition(testObject{Oid: 4, Name: "role1"})
+
+			definitions := toc.GetObjectDefinitions()
+			Expect(definitions.PredataDefinitions).To(Equal([]utils.ObjectDefinition{
+				{TOCIndex: 1, Schema: "public", Name: "table2", ObjectType: "TABLE", Definition: testObject{Oid: 2, Name: "table2"}},
+			}))
+			Expect(definitions.PostdataDefinitions).To(Equal([]utils.ObjectDefinition{
+				{TOCIndex: 0, Schema: "public", Name: "index1", ObjectType: "INDEX", Definition: testObject{Oid: 3, Name: "index1"}},
+			}))
+			Expect(definitions.GlobalDefinitions).To(Equal([]utils.ObjectDefinition{
+				{TOCIndex: 0, Schema: "", Name: "role1", ObjectType: "ROLE", Definition: testObject{Oid: 4, Name: "role1"}},
+			}))
+		})
+		It("does not record definitions if object definitions were not initialized", func() {
+			toc.AddPredataEntry("public", "table1", "TABLE", "", 0, backupfile)
+			toc.AddPredataDefinition(testObject{Oid: 1, Name: "table1"})
+
+			Expect(toc.GetObjectDefinitions()).To(BeNil())
+		})
+		It("does not record a definition if the section has no entries", func() {
+			toc.InitializeObjectDefinitions()
+			toc.AddPredataDefinition(testObject{Oid: 1, Name: "table1"})
+
+			Expect(toc.GetObjectDefinitions().PredataDefinitions).To(BeEmpty())
+		})
+	})
+	Describe("MarshalObjectDefinitions", func() {
+		var definitions *utils.ObjectDefinitions
+		BeforeEach(func() {
+			definitions = &utils.ObjectDefinitions{
+				GlobalDefinitions:   []utils.ObjectDefinition{},
+				PredataDefinitions:  []utils.ObjectDefinition{{TOCIndex: 1, Schema: "public", Name: "table1", ObjectType: "TABLE", Definition: testObject{Oid: 1, Name: "table1"}}},
+				PostdataDefinitions: []utils.ObjectDefinition{},
+			}
+		})
+		It("writes definitions in YAML format", func() {
+			contents := utils.MarshalObjectDefinitions(definitions, "yaml")
+			Expect(string(contents)).To(Equal(`globaldefinitions: []
+predatadefinitions:
+- tocindex: 1
+  schema: public
+  name: table1
+  objecttype: TABLE
+  definition:
+    oid: 1
+    name: table1
+postdatadefinitions: []
+`))
+		})
+		It("writes definitions in JSON format", func() {
+			contents := utils.MarshalObjectDefinitions(definitions, "json")
+			Expect(string(contents)).To(Equal(`{
+  "GlobalDefinitions": [],
+  "PredataDefinitions": [
+    {
+      "TOCIndex": 1,
+      "Schema": "public",
+      "Name": "table1",
+      "ObjectType": "TABLE",
+      "Definition": {
+        "Oid": 1,
+        "Name": "table1"
+      }
+    }
+  ],
+  "PostdataDefinitions": []
+}`))
+		})
+	})
+	Describe("ValidateObjectDefinitionFormat", func() {
+		It("does not panic when the flag is not set", func() {
+			utils.ValidateObjectDefinitionFormat("")
+		})
+		It("does not panic when given a valid format", func() {
+			utils.ValidateObjectDefinitionFormat("yaml")
+			utils.ValidateObjectDefinitionFormat("json")
+		})
+		It("panics when given an invalid format", func() {
+			defer testhelper.ShouldPanicWithMessage("Object definition format must be one of [yaml json]")
+			utils.ValidateObjectDefinitionFormat("xml")
+		})
+	})
+})
@@ -113,6 +113,10 @@ func (backupFPInfo *FilePathInfo) GetTOCFilePath() string {
 	return backupFPInfo.GetBackupFilePath("table of contents")
 }
 
+func (backupFPInfo *FilePathInfo) GetObjectDefinitionsFilePath(format string) string {
+	return path.Join(backupFPInfo.GetDirForContent(-1), fmt.Sprintf("gpbackup_%s_definitions.%s", backupFPInfo.Timestamp, format))
+}
+
 func (backupFPInfo *FilePathInfo) GetBackupReportFilePath() string {
 	return backupFPInfo.GetBackupFilePath("report")
 }
@@ -121,6 +125,10 @@ func (backupFPInfo *FilePathInfo) GetRestoreReportFilePath(restoreTimestamp stri
 	return path.Join(backupFPInfo.GetDirForContent(-1), fmt.Sprintf("gprestore_%s_%s_report", backupFPInfo.Timestamp, restoreTimestamp))
 }
 
+func (backupFPInfo *FilePathInfo) GetRestoreErrorFilePath(restoreTimestamp string) string {
+	return path.Join(backupFPInfo.GetDirForContent(-1), fmt.Sprintf("gprestore_%s_%s_errors.yaml", backupFPInfo.Timestamp, restoreTimestamp))
+}
+
 func (backupFPInfo *FilePathInfo) GetConfigFilePath() string {
 	return backupFPInfo.GetBackupFilePath("config")
 }
@@ -1,25 +1,53 @@
 package utils
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/blang/semver"
 	"github.com/greenplum-db/gp-common-go-libs/cluster"
 	"github.com/greenplum-db/gp-common-go-libs/gplog"
 	"github.com/greenplum-db/gp-common-go-libs/operating"
+	"github.com/pkg/errors"
 	yaml "gopkg.in/yaml.v2"
 )
 
 type PluginConfig struct {
 	ExecutablePath string
-	ConfigPath     string
+	ConfigPath     string `yaml:"-"`
 	Options        map[string]string
+	capabilities   map[string]bool
 }
 
+/*
+ * A plugin may describe the options it accepts by printing a schema in the
+ * following format in response to the plugin_config_schema command:
+ *
+ * options:
+ *   option_name:
+ *     required: true
+ *     secret: false
+ */
+type PluginSchema struct {
+	Options map[string]PluginOptionSchema
+}
+
+type PluginOptionSchema struct {
+	Required bool
+	Secret   bool
+}
+
+const secretFilePrefix = "file://"
+
+var envReferenceRegex = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
 func ReadPluginConfig(configFile string) *PluginConfig {
 	config := &PluginConfig{}
 	contents, err := operating.System.ReadFile(configFile)
@@ -28,11 +56,112 @@ func ReadPluginConfig(configFile string) *PluginConfig {
 	gplog.FatalOnError(err)
 	config.ExecutablePath = os.ExpandEnv(config.ExecutablePath)
 	ValidateFullPath(config.ExecutablePath)
-	_, configFilename := filepath.Split(configFile)
-	config.ConfigPath = filepath.Join("/tmp", configFilename)
+	config.ConfigPath = configFile
 	return config
 }
 
+/*
+ * Option values may reference an environment variable as ${VARIABLE} or the
+ * contents of a file as file:///path/to/file, so that credentials do not
+ * need to be stored in the config file itself.  References are resolved on
+ * each host, as the environment and secret files may differ between hosts.
+ */
+func (plugin *PluginConfig) ResolveSecrets() {
+	for key, value := range plugin.Options {
+		plugin.Options[key] = resolveOptionValue(key, value)
+	}
+}
+
+func resolveOptionValue(key string, value string) string {
+	if strings.HasPrefix(value, secretFilePrefix) {
+		secretFile := strings.TrimPrefix(value, secretFilePrefix)
+		contents, err := operating.System.ReadFile(secretFile)
+		if err != nil {
+			gplog.Fatal(errors.Errorf("Unable to read secret file %s for plugin option %s: %v", secretFile, key, err), "")
+		}
+		return strings.TrimRight(string(contents), "\r\n")
+	}
+	return envReferenceRegex.ReplaceAllStringFunc(value, func(reference string) string {
+		variable := envReferenceRegex.FindStringSubmatch(reference)[1]
+		envValue := operating.System.Getenv(variable)
+		if envValue == "" {
+			gplog.Fatal(errors.Errorf("Environment variable %s referenced by plugin option %s is not set or is empty", variable, key), "")
+		}
+		return envValue
+	})
+}
+
+func isSecretReference(value string) bool {
+	return strings.HasPrefix(value, secretFilePrefix) || envReferenceRegex.MatchString(value)
+}
+
+/*
+ * Plugins that do not implement plugin_config_schema are not validated, so
+ * that plugins written before the command was introduced continue to work.
+ * Plugins that ignore unknown commands exit successfully with no output, so
+ * no output is treated the same as a failed command.
+ */
+func (plugin *PluginConfig) GetPluginSchema() *PluginSchema {
+	command := fmt.Sprintf("%s plugin_config_schema", plugin.ExecutablePath)
+	output, err := exec.Command("bash", "-c", command).Output()
+	if err != nil {
+		gplog.Verbose("Plugin %s does not provide a configuration schema; skipping option validation", plugin.ExecutablePath)
+		return nil
+	}
+	if strings.TrimSpace(string(output)) == "" {
+		gplog.Verbose("Plugin %s does not provide a configuration schema; skipping option validation", plugin.ExecutablePath)
+		return nil
+	}
+	schema := &PluginSchema{}
+	err = yaml.Unmarshal(output, schema)
+	if err != nil {
+		gplog.Fatal(errors.Errorf("Unable to parse configuration schema for plugin %s: %v", plugin.ExecutablePath, err), "")
+	}
+	return schema
+}
+
+func (plugin *PluginConfig) ValidateOptions(schema *PluginSchema) {
+	if schema == nil {
+		return
+	}
+	for key, value := range plugin.Options {
+		optionSchema, ok := schema.Options[key]
+		if !ok {
+			gplog.Fatal(errors.Errorf("Plugin option %s in %s is not supported by plugin %s", key, plugin.ConfigPath, plugin.ExecutablePath), "")
+		}
+		if optionSchema.Secret && !isSecretReference(value) {
+			gplog.Warn("Plugin option %s is a secret stored in plain text in %s; consider using an ${ENVIRONMENT_VARIABLE} or %s reference instead", key, plugin.ConfigPath, secretFilePrefix)
+		}
+	}
+	for key, optionSchema := range schema.Options {
+		if _, ok := plugin.Options[key]; optionSchema.Required && !ok {
+			gplog.Fatal(errors.Errorf("Required plugin option %s is missing from %s", key, plugin.ConfigPath), "")
+		}
+	}
+}
+
+func (plugin *PluginConfig) WriteToFile(configFile string) {
+	contents, err := yaml.Marshal(plugin)
+	gplog.FatalOnError(err)
+	configFileHandle, err := operating.System.OpenFileWrite(configFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		gplog.Fatal(err, "Unable to create or open file for writing")
+	}
+	_, err = configFileHandle.Write(contents)
+	gplog.FatalOnError(err)
+	err = configFileHandle.Close()
+	gplog.FatalOnError(err)
+}
+
+/*
+ * Each gpbackup or gprestore process copies the plugin config to its own
+ * directory, readable only by the current user, so that resolved secrets are
+ * not exposed to other users and the copy can be removed during teardown.
+ */
+func GetPluginConfigDir() string {
+	return fmt.Sprintf("/tmp/gpbackup_plugin_config_%d", operating.System.Getpid())
+}
+
 func (plugin *PluginConfig) BackupFile(filenamePath string, noFatal ...bool) {
 	command := fmt.Sprintf("%s backup_file %s %s", plugin.ExecutablePath, plugin.ConfigPath, filenamePath)
 	output, err := exec.Command("bash", "-c", command).CombinedOutput()
@@ -51,6 +180,249 @@ func (plugin *PluginConfig) RestoreFile(filenamePath string) {
 	gplog.FatalOnError(err, string(output))
 }
 
+func (plugin *PluginConfig) RestoreData(filenamePath string) []byte {
+	command := fmt.Sprintf("%s restore_data %s %s", plugin.ExecutablePath, plugin.ConfigPath, filenamePath)
+	cmd := exec.Command("bash", "-c", command)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	gplog.FatalOnError(err, stderr.String())
+	return output
+}
+
+/*
+ * Optional plugin features are listed one per line in response to the
+ * plugin_capabilities command.  The only such feature at present is
+ * restore_data_range, which takes a config path, file path, offset, and
+ * length, and writes only that range of the file to stdout.
+ */
+func (plugin *PluginConfig) HasCapability(capability string) bool {
+	if plugin.capabilities == nil {
+		plugin.capabilities = make(map[string]bool, 0)
+		command := fmt.Sprintf("%s plugin_capabilities", plugin.ExecutablePath)
+		output, err := exec.Command("bash", "-c", command).Output()
+		if err != nil {
+			gplog.Verbose("Plugin %s does not report any optional capabilities", plugin.ExecutablePath)
+		} else {
+			for _, line := range strings.Split(string(output), "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					plugin.capabilities[line] = true
+				}
+			}
+		}
+	}
+	return plugin.capabilities[capability]
+}
+
+type pluginWriteCloser struct {
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	writer   *bufio.Writer
+	stderr   bytes.Buffer
+	filename string
+	closed   bool
+}
+
+func (pipe *pluginWriteCloser) Write(p []byte) (int, error) {
+	return pipe.writer.Write(p)
+}
+
+/*
+ * The file is only complete once the plugin exits, so any failure here is
+ * fatal.  Close may be called more than once, as FileWithByteCount is often
+ * closed both explicitly and in a deferred call.
+ */
+func (pipe *pluginWriteCloser) Close() error {
+	if pipe.closed {
+		return nil
+	}
+	pipe.closed = true
+	err := pipe.writer.Flush()
+	gplog.FatalOnError(err)
+	err = pipe.stdin.Close()
+	gplog.FatalOnError(err)
+	err = pipe.cmd.Wait()
+	if err != nil {
+		gplog.Fatal(errors.Errorf("Plugin failed to process %s: %v %s", pipe.filename, err, pipe.stderr.String()), "")
+	}
+	return nil
+}
+
+/*
+ * Returns a FileWithByteCount that streams its contents to the plugin with
+ * backup_data, so that the file is never written to local disk.  Byte counts
+ * are tracked exactly as for a local file, so TOC offsets remain valid.
+ */
+func NewFileWithByteCountFromPlugin(plugin *PluginConfig, filename string) *FileWithByteCount {
+	command := fmt.Sprintf("%s backup_data %s %s", plugin.ExecutablePath, plugin.ConfigPath, filename)
+	pipe := &pluginWriteCloser{cmd: exec.Command("bash", "-c", command), filename: filename}
+	pipe.cmd.Stderr = &pipe.stderr
+	stdin, err := pipe.cmd.StdinPipe()
+	gplog.FatalOnError(err)
+	err = pipe.cmd.Start()
+	gplog.FatalOnError(err)
+	pipe.stdin = stdin
+	pipe.writer = bufio.NewWriter(stdin)
+	return &FileWithByteCount{"", pipe, pipe, 0}
+}
+
+type pluginReadCloser struct {
+	cmd       *exec.Cmd
+	stdout    io.ReadCloser
+	stderr    bytes.Buffer
+	filename  string
+	remaining uint64
+}
+
+func (pipe *pluginReadCloser) Read(p []byte) (int, error) {
+	n, err := pipe.stdout.Read(p)
+	pipe.remaining -= uint64(n)
+	return n, err
+}
+
+/*
+ * If the range has not been read in full, the caller has already hit an error
+ * and the plugin is killed; otherwise, Close reports whether the plugin exited
+ * successfully.
+ */
+func (pipe *pluginReadCloser) Close() error {
+	if pipe.remaining > 0 {
+		_ = pipe.cmd.Process.Kill()
+		_ = pipe.cmd.Wait()
+		return nil
+	}
+	err := pipe.cmd.Wait()
+	if err != nil {
+		return errors.Errorf("Plugin failed to read range of %s: %v %s", pipe.filename, err, pipe.stderr.String())
+	}
+	return nil
+}
+
+/*
+ * Returns a stream of length bytes of the file starting at offset, using the
+ * plugin's restore_data_range command.  Callers should check that the plugin
+ * has the restore_data_range capability first.
+ */
+func (plugin *PluginConfig) RestoreDataRange(filename string, offset uint64, length uint64) (io.ReadCloser, error) {
+	command := fmt.Sprintf("%s restore_data_range %s %s %d %d", plugin.ExecutablePath, plugin.ConfigPath, filename, offset, length)
+	pipe := &pluginReadCloser{cmd: exec.Command("bash", "-c", command), filename: filename, remaining: length}
+	pipe.cmd.Stderr = &pipe.stderr
+	stdout, err := pipe.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	err = pipe.cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+	pipe.stdout = stdout
+	return pipe, nil
+}
+
+/*
+ * PluginReaderAt reads pieces of a file stored by a plugin without restoring
+ * the entire file to local disk.  If the plugin supports restore_data_range,
+ * each read fetches a chunk of at least pluginReadChunkSize bytes, which is
+ * cached as TOC entries are generally read in file order.  Otherwise, the
+ * file is read as a single restore_data stream, which is restarted only if
+ * an earlier offset is requested.
+ */
+type PluginReaderAt struct {
+	plugin      *PluginConfig
+	filename    string
+	useRanges   bool
+	chunk       []byte
+	chunkOffset int64
+	cmd         *exec.Cmd
+	stream      *bufio.Reader
+	position    int64
+}
+
+const pluginReadChunkSize = 16 * 1024 * 1024
+
+func NewPluginReaderAt(plugin *PluginConfig, filename string) *PluginReaderAt {
+	return &PluginReaderAt{plugin: plugin, filename: filename, useRanges: plugin.HasCapability("restore_data_range")}
+}
+
+func (reader *PluginReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if reader.useRanges {
+		return reader.readRange(p, off)
+	}
+	return reader.readStream(p, off)
+}
+
+func (reader *PluginReaderAt) readRange(p []byte, off int64) (int, error) {
+	chunkEnd := reader.chunkOffset + int64(len(reader.chunk))
+	if reader.chunk == nil || off < reader.chunkOffset || off+int64(len(p)) > chunkEnd {
+		length := int64(len(p))
+		if length < pluginReadChunkSize {
+			length = pluginReadChunkSize
+		}
+		command := fmt.Sprintf("%s restore_data_range %s %s %d %d", reader.plugin.ExecutablePath, reader.plugin.ConfigPath, reader.filename, off, length)
+		cmd := exec.Command("bash", "-c", command)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		output, err := cmd.Output()
+		if err != nil {
+			return 0, errors.Errorf("Plugin failed to read range of %s: %v %s", reader.filename, err, stderr.String())
+		}
+		reader.chunk = output
+		reader.chunkOffset = off
+	}
+	n := copy(p, reader.chunk[off-reader.chunkOffset:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (reader *PluginReaderAt) readStream(p []byte, off int64) (int, error) {
+	if reader.stream == nil || off < reader.position {
+		err := reader.startStream()
+		if err != nil {
+			return 0, err
+		}
+	}
+	discarded, err := reader.stream.Discard(int(off - reader.position))
+	reader.position += int64(discarded)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(reader.stream, p)
+	reader.position += int64(n)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (reader *PluginReaderAt) startStream() error {
+	reader.Close()
+	command := fmt.Sprintf("%s restore_data %s %s", reader.plugin.ExecutablePath, reader.plugin.ConfigPath, reader.filename)
+	reader.cmd = exec.Command("bash", "-c", command)
+	stdout, err := reader.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	err = reader.cmd.Start()
+	if err != nil {
+		return err
+	}
+	reader.stream = bufio.NewReader(stdout)
+	reader.position = 0
+	return nil
+}
+
+func (reader *PluginReaderAt) Close() {
+	if reader.cmd != nil && reader.cmd.Process != nil {
+		_ = reader.cmd.Process.Kill()
+		_ = reader.cmd.Wait()
+	}
+	reader.cmd = nil
+	reader.stream = nil
+	reader.chunk = nil
+}
+
 func (plugin *PluginConfig) CheckPluginExistsOnAllHosts(c cluster.Cluster) {
 	remoteOutput := c.GenerateAndExecuteCommand("Checking that plugin exists on all hosts", func(contentID int) string {
 		return fmt.Sprintf("%s plugin_api_version", plugin.ExecutablePath)
@@ -113,14 +485,29 @@ func (plugin *PluginConfig) CleanupPluginForRestoreOnAllHosts(c cluster.Cluster)
 }
 
 func (plugin *PluginConfig) CopyPluginConfigToAllHosts(c cluster.Cluster, configPath string) {
+	configDir := GetPluginConfigDir()
+	_, configFilename := filepath.Split(configPath)
+	plugin.ConfigPath = filepath.Join(configDir, configFilename)
+	gphomePath := operating.System.Getenv("GPHOME")
 	remoteOutput := c.GenerateAndExecuteCommand("Copying plugin config to all hosts", func(contentID int) string {
-		return fmt.Sprintf("rsync %s:%s /tmp/.", c.GetHostForContent(-1), configPath)
+		return fmt.Sprintf("umask 077; mkdir -p %s && test -O %s && chmod 700 %s && rsync %s:%s %s && chmod 600 %s && %s/bin/gpbackup_helper --resolve-plugin-config --plugin-config %s",
+			configDir, configDir, configDir, c.GetHostForContent(-1), configPath, plugin.ConfigPath, plugin.ConfigPath, gphomePath, plugin.ConfigPath)
 	}, cluster.ON_HOSTS_AND_MASTER)
 	c.CheckClusterError(remoteOutput, "Unable to copy plugin config", func(contentID int) string {
 		return "Unable to copy plugin config"
 	})
 }
 
+func (plugin *PluginConfig) DeletePluginConfigFromAllHosts(c cluster.Cluster) {
+	configDir := GetPluginConfigDir()
+	remoteOutput := c.GenerateAndExecuteCommand("Removing plugin config from all hosts", func(contentID int) string {
+		return fmt.Sprintf("rm -rf %s", configDir)
+	}, cluster.ON_HOSTS_AND_MASTER)
+	c.CheckClusterError(remoteOutput, "Unable to remove plugin config", func(contentID int) string {
+		return "Unable to remove plugin config"
+	}, true)
+}
+
 func (plugin *PluginConfig) BackupSegmentTOCs(c cluster.Cluster, fpInfo FilePathInfo) {
 	remoteOutput := c.GenerateAndExecuteCommand("Processing segment TOC files with plugin", func(contentID int) string {
 		tocFilename := fmt.Sprintf("gpbackup_%d_%s_toc.yaml", contentID, fpInfo.Timestamp)
@@ -0,0 +1,250 @@
+package utils_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/greenplum-db/gp-common-go-libs/operating"
+	"github.com/greenplum-db/gp-common-go-libs/testhelper"
+	"github.com/greenplum-db/gpbackup/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("utils/plugin tests", func() {
+	var pluginConfig *utils.PluginConfig
+	BeforeEach(func() {
+		pluginConfig = &utils.PluginConfig{
+			ExecutablePath: "/usr/local/bin/my_plugin",
+			ConfigPath:     "/home/gpadmin/my_plugin_config.yaml",
+			Options:        map[string]string{},
+		}
+	})
+	Describe("ResolveSecrets", func() {
+		It("leaves options without references unchanged", func() {
+			pluginConfig.Options["bucket"] = "my_bucket"
+			pluginConfig.ResolveSecrets()
+			Expect(pluginConfig.Options["bucket"]).To(Equal("my_bucket"))
+		})
+		It("replaces environment variable references with their values", func() {
+			operating.System.Getenv = func(key string) string {
+				Expect(key).To(Equal("SECRET_KEY"))
+				return "abc123"
+			}
+			pluginConfig.Options["secret_key"] = "${SECRET_KEY}"
+			pluginConfig.Options["path"] = "prefix/${SECRET_KEY}/suffix"
+			pluginConfig.ResolveSecrets()
+			Expect(pluginConfig.Options["secret_key"]).To(Equal("abc123"))
+			Expect(pluginConfig.Options["path"]).To(Equal("prefix/abc123/suffix"))
+		})
+		It("replaces file references with the contents of the file", func() {
+			operating.System.ReadFile = func(filename string) ([]byte, error) {
+				Expect(filename).To(Equal("/home/gpadmin/.secret_key"))
+				return []byte("abc123\n"), nil
+			}
+			pluginConfig.Options["secret_key"] = "file:///home/gpadmin/.secret_key"
+			pluginConfig.ResolveSecrets()
+			Expect(pluginConfig.Options["secret_key"]).To(Equal("abc123"))
+		})
+		It("panics if a referenced environment variable is not set or is empty", func() {
+			operating.System.Getenv = func(key string) string { return "" }
+			pluginConfig.Options["secret_key"] = "${SECRET_KEY}"
+			defer testhelper.ShouldPanicWithMessage("Environment variable SECRET_KEY referenced by plugin option secret_key is not set or is empty")
+			pluginConfig.ResolveSecrets()
+		})
+		It("panics if a referenced secret file cannot be read", func() {
+			operating.System.ReadFile = func(filename string) ([]byte, error) {
+				return nil, errors.New("permission denied")
+			}
+			pluginConfig.Options["secret_key"] = "file:///home/gpadmin/.secret_key"
+			defer testhelper.ShouldPanicWithMessage("Unable to read secret file /home/gpadmin/.secret_key for plugin option secret_key: permission denied")
+			pluginConfig.ResolveSecrets()
+		})
+	})
+	Describe("GetPluginSchema", func() {
+		var tempDir string
+		BeforeEach(func() {
+			tempDir, _ = ioutil.TempDir("", "plugin_test")
+			pluginConfig.ExecutablePath = filepath.Join(tempDir, "test_plugin.sh")
+		})
+		AfterEach(func() {
+			os.RemoveAll(tempDir)
+		})
+		It("returns the schema printed by the plugin", func() {
+			_ = ioutil.WriteFile(pluginConfig.ExecutablePath, []byte(`#!/bin/bash
+case "$1" in
+  plugin_config_schema) printf "options:\n  bucket:\n    required: true\n" ;;
+esac
+`), 0755)
+			schema := pluginConfig.GetPluginSchema()
+			Expect(schema).To(Equal(&utils.PluginSchema{Options: map[string]utils.PluginOptionSchema{"bucket": {Required: true}}}))
+		})
+		It("returns no schema if the plugin does not implement the command", func() {
+			_ = ioutil.WriteFile(pluginConfig.ExecutablePath, []byte("#!/bin/bash\n\"$@\"\n"), 0755)
+			Expect(pluginConfig.GetPluginSchema()).To(BeNil())
+		})
+		It("returns no schema if the plugin ignores the command and prints nothing", func() {
+			_ = ioutil.WriteFile(pluginConfig.ExecutablePath, []byte(`#!/bin/bash
+case "$1" in
+  backup_file) echo "backing up $3" ;;
+esac
+`), 0755)
+			Expect(pluginConfig.GetPluginSchema()).To(BeNil())
+		})
+	})
+	Describe("ValidateOptions", func() {
+		var schema *utils.PluginSchema
+		BeforeEach(func() {
+			schema = &utils.PluginSchema{Options: map[string]utils.PluginOptionSchema{
+				"bucket":     {Required: true},
+				"region":     {},
+				"secret_key": {Required: true, Secret: true},
+			}}
+		})
+		It("does not validate options if the plugin provides no schema", func() {
+			pluginConfig.Options["unknown_option"] = "value"
+			pluginConfig.ValidateOptions(nil)
+		})
+		It("accepts options that match the schema", func() {
+			pluginConfig.Options["bucket"] = "my_bucket"
+			pluginConfig.Options["secret_key"] = "${SECRET_KEY}"
+			pluginConfig.ValidateOptions(schema)
+			testhelper.NotExpectRegexp(logfile, "secret stored in plain text")
+		})
+		It("warns if a secret option is stored in plain text", func() {
+			pluginConfig.Options["bucket"] = "my_bucket"
+			pluginConfig.Options["secret_key"] = "abc123"
+			pluginConfig.ValidateOptions(schema)
+			testhelper.ExpectRegexp(logfile, "Plugin option secret_key is a secret stored in plain text in /home/gpadmin/my_plugin_config.yaml")
+		})
+		It("panics if an option is not in the schema", func() {
+			pluginConfig.Options["bucket"] = "my_bucket"
+			pluginConfig.Options["secret_key"] = "${SECRET_KEY}"
+			pluginConfig.Options["unknown_option"] = "value"
+			defer testhelper.ShouldPanicWithMessage("Plugin option unknown_option in /home/gpadmin/my_plugin_config.yaml is not supported by plugin /usr/local/bin/my_plugin")
+			pluginConfig.ValidateOptions(schema)
+		})
+		It("panics if a required option is missing", func() {
+			pluginConfig.Options["secret_key"] = "${SECRET_KEY}"
+			defer testhelper.ShouldPanicWithMessage("Required plugin option bucket is missing from /home/gpadmin/my_plugin_config.yaml")
+			pluginConfig.ValidateOptions(schema)
+		})
+	})
+	Describe("plugin data streams", func() {
+		var tempDir string
+		BeforeEach(func() {
+			tempDir, _ = ioutil.TempDir("", "plugin_test")
+			pluginConfig.ExecutablePath = filepath.Join(tempDir, "test_plugin.sh")
+			pluginScript := fmt.Sprintf(`#!/bin/bash
+backup_data() {
+  cat - > %[1]s/$(basename "$2")
+}
+restore_data() {
+  cat %[1]s/$(basename "$2")
+}
+restore_data_range() {
+  echo "$3 $4" >> %[1]s/ranges
+  tail -c +$(($3+1)) %[1]s/$(basename "$2") | head -c $4
+}
+plugin_capabilities() {
+  [ -f %[1]s/capabilities ] && cat %[1]s/capabilities
+}
+"$@"
+`, tempDir)
+			_ = ioutil.WriteFile(pluginConfig.ExecutablePath, []byte(pluginScript), 0755)
+			_ = ioutil.WriteFile(filepath.Join(tempDir, "data_file"), []byte("0123456789"), 0644)
+		})
+		AfterEach(func() {
+			os.RemoveAll(tempDir)
+		})
+		Describe("NewFileWithByteCountFromPlugin", func() {
+			It("streams data to the plugin and counts the bytes written", func() {
+				file := utils.NewFileWithByteCountFromPlugin(pluginConfig, "/backups/streamed_file")
+				file.MustPrintf("CREATE SCHEMA %s;\n", "schema1")
+				file.MustPrintln("CREATE SCHEMA schema2;")
+				file.Close()
+				file.Close()
+				Expect(file.ByteCount).To(Equal(uint64(46)))
+				contents, _ := ioutil.ReadFile(filepath.Join(tempDir, "streamed_file"))
+				Expect(string(contents)).To(Equal("CREATE SCHEMA schema1;\nCREATE SCHEMA schema2;\n"))
+			})
+			It("panics if the plugin fails", func() {
+				_ = os.Mkdir(filepath.Join(tempDir, "directory"), 0755)
+				file := utils.NewFileWithByteCountFromPlugin(pluginConfig, "/backups/directory")
+				file.MustPrintln("CREATE SCHEMA schema1;")
+				defer testhelper.ShouldPanicWithMessage("Plugin failed to process /backups/directory")
+				file.Close()
+			})
+		})
+		Describe("HasCapability", func() {
+			It("returns true for a capability the plugin reports", func() {
+				_ = ioutil.WriteFile(filepath.Join(tempDir, "capabilities"), []byte("restore_data_range\n"), 0644)
+				Expect(pluginConfig.HasCapability("restore_data_range")).To(BeTrue())
+			})
+			It("returns false if the plugin does not report capabilities", func() {
+				Expect(pluginConfig.HasCapability("restore_data_range")).To(BeFalse())
+			})
+		})
+		Describe("RestoreDataRange", func() {
+			It("streams the requested range of a file from the plugin", func() {
+				reader, err := pluginConfig.RestoreDataRange("/backups/data_file", 3, 4)
+				Expect(err).ToNot(HaveOccurred())
+				contents, _ := ioutil.ReadAll(reader)
+				Expect(string(contents)).To(Equal("3456"))
+				Expect(reader.Close()).To(Succeed())
+				ranges, _ := ioutil.ReadFile(filepath.Join(tempDir, "ranges"))
+				Expect(string(ranges)).To(Equal("3 4\n"))
+			})
+			It("returns an error on close if the plugin fails", func() {
+				_ = ioutil.WriteFile(pluginConfig.ExecutablePath, []byte("#!/bin/bash\necho 'file not found' >&2\nexit 1\n"), 0755)
+				reader, err := pluginConfig.RestoreDataRange("/backups/data_file", 3, 0)
+				Expect(err).ToNot(HaveOccurred())
+				_, _ = ioutil.ReadAll(reader)
+				err = reader.Close()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Plugin failed to read range of /backups/data_file: exit status 1 file not found"))
+			})
+		})
+		Describe("PluginReaderAt", func() {
+			It("reads pieces of a file with ranged reads if the plugin supports them", func() {
+				_ = ioutil.WriteFile(filepath.Join(tempDir, "capabilities"), []byte("restore_data_range\n"), 0644)
+				reader := utils.NewPluginReaderAt(pluginConfig, "/backups/data_file")
+				defer reader.Close()
+				contents := make([]byte, 3)
+				_, err := reader.ReadAt(contents, 2)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(contents)).To(Equal("234"))
+				_, err = reader.ReadAt(contents, 6)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(contents)).To(Equal("678"))
+				ranges, _ := ioutil.ReadFile(filepath.Join(tempDir, "ranges"))
+				Expect(string(ranges)).To(Equal("2 16777216\n"))
+			})
+			It("reads pieces of a file from a single stream if the plugin does not support ranged reads", func() {
+				reader := utils.NewPluginReaderAt(pluginConfig, "/backups/data_file")
+				defer reader.Close()
+				contents := make([]byte, 3)
+				_, err := reader.ReadAt(contents, 6)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(contents)).To(Equal("678"))
+				_, err = reader.ReadAt(contents, 1)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(contents)).To(Equal("123"))
+				Expect(utils.FileExistsAndIsReadable(filepath.Join(tempDir, "ranges"))).To(BeFalse())
+			})
+			It("returns EOF if a read extends past the end of the file", func() {
+				reader := utils.NewPluginReaderAt(pluginConfig, "/backups/data_file")
+				defer reader.Close()
+				contents := make([]byte, 3)
+				n, err := reader.ReadAt(contents, 8)
+				Expect(err).To(Equal(io.EOF))
+				Expect(n).To(Equal(2))
+			})
+		})
+	})
+})
@@ -30,9 +30,22 @@ type BackupConfig struct {
 	ExcludeSchemaFiltered bool
 	ExcludeTableFiltered  bool
 	MetadataOnly          bool
+	ObjectDefinitions     string `yaml:",omitempty"`
 	Plugin                string
 	SingleDataFile        bool
+	StreamedMetadata      bool
 	WithStatistics        bool
+	Copies                []BackupCopy `yaml:",omitempty"`
+}
+
+/*
+ * A BackupCopy records an additional location to which a backup has been
+ * copied by gpbackup_replicate.
+ */
+type BackupCopy struct {
+	Plugin       string
+	PluginConfig string
+	Timestamp    string
 }
 
 /*
@@ -139,6 +152,24 @@ func (report *Report) WriteConfigFile(configFilename string) {
 	MustPrintBytes(configFile, configContents)
 }
 
+/*
+ * The config file is made read-only after a backup, so it must be made
+ * writable before it can be updated.
+ */
+func UpdateConfigFile(configFilename string, config *BackupConfig) {
+	err := operating.System.Chmod(configFilename, 0644)
+	gplog.FatalOnError(err)
+	defer operating.System.Chmod(configFilename, 0444)
+	configFile, err := operating.System.OpenFileWrite(configFilename, os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		gplog.Fatal(err, "Unable to open file for writing")
+	}
+	configContents, _ := yaml.Marshal(config)
+	MustPrintBytes(configFile, configContents)
+	err = configFile.Close()
+	gplog.FatalOnError(err)
+}
+
 func (report *Report) WriteBackupReportFile(reportFilename string, timestamp string, objectCounts map[string]int, errMsg string) {
 	reportFile := MustOpenFileForWriting(reportFilename)
 	defer operating.System.Chmod(reportFilename, 0444)
@@ -179,7 +210,14 @@ Backup Status: %s
 	PrintObjectCounts(reportFile, objectCounts)
 }
 
-func WriteRestoreReportFile(reportFilename string, backupTimestamp string, startTimestamp string, connection *dbconn.DBConn, restoreVersion string, errMsg string) {
+// These are the results of analyzing restored tables with gprestore --run-analyze
+type AnalyzeResults struct {
+	NumAnalyzed uint32
+	NumSkipped  uint32
+	NumFailed   uint32
+}
+
+func WriteRestoreReportFile(reportFilename string, backupTimestamp string, startTimestamp string, connection *dbconn.DBConn, restoreVersion string, errMsg string, analyzeResults *AnalyzeResults) {
 	reportFile := MustOpenFileForWriting(reportFilename)
 	defer operating.System.Chmod(reportFilename, 0444)
 	reportFileTemplate := `Greenplum Database Restore Report
@@ -212,6 +250,76 @@ Restore Status: %s`
 		connection.DBName, gprestoreCommandLine,
 		start, end, duration, restoreStatus)
 
+	if analyzeResults != nil {
+		MustPrintf(reportFile, `
+
+Tables Analyzed: %d
+Tables Not Analyzed Because Statistics Were Restored: %d
+Tables Failed To Analyze: %d`, analyzeResults.NumAnalyzed, analyzeResults.NumSkipped, analyzeResults.NumFailed)
+	}
+}
+
+/*
+ * These structs hold the metadata statements and tables that failed to restore
+ * when gprestore is run with --on-error-continue.  They are written to an error
+ * file alongside the restore report, which can be passed to --retry-failed to
+ * restore only those statements and tables.
+ */
+type RestoreFailures struct {
+	FailedStatements []FailedStatement `yaml:",omitempty"`
+	FailedTables     []FailedTable     `yaml:",omitempty"`
+}
+
+type FailedStatement struct {
+	ObjectType      string
+	Schema          string
+	Name            string
+	ReferenceObject string `yaml:",omitempty"`
+	Statement       string
+	Error           string
+}
+
+type FailedTable struct {
+	Table string
+	Error string
+}
+
+const statementExcerptLength = 200
+
+func (failures *RestoreFailures) AddFailedStatement(statement StatementWithType, err error) {
+	excerpt := strings.TrimSpace(statement.Statement)
+	if excerptRunes := []rune(excerpt); len(excerptRunes) > statementExcerptLength {
+		excerpt = string(excerptRunes[:statementExcerptLength]) + "..."
+	}
+	failures.FailedStatements = append(failures.FailedStatements, FailedStatement{ObjectType: statement.ObjectType, Schema: statement.Schema,
+		Name: statement.Name, ReferenceObject: statement.ReferenceObject, Statement: excerpt, Error: err.Error()})
+}
+
+func (failures *RestoreFailures) AddFailedTable(table string, errMsg string) {
+	failures.FailedTables = append(failures.FailedTables, FailedTable{Table: table, Error: errMsg})
+}
+
+func (failures *RestoreFailures) IsEmpty() bool {
+	return len(failures.FailedStatements) == 0 && len(failures.FailedTables) == 0
+}
+
+func (failures *RestoreFailures) WriteToFile(filename string) {
+	failuresFile := MustOpenFileForWriting(filename)
+	defer operating.System.Chmod(filename, 0444)
+	defer failuresFile.Close()
+	failuresContents, _ := yaml.Marshal(failures)
+	MustPrintBytes(failuresFile, failuresContents)
+}
+
+func ReadRestoreFailuresFile(filename string) *RestoreFailures {
+	failures := &RestoreFailures{}
+	contents, err := operating.System.ReadFile(filename)
+	gplog.FatalOnError(err)
+	err = yaml.Unmarshal(contents, failures)
+	if err != nil {
+		gplog.Fatal(errors.Errorf("Could not parse error file %s: %v", filename, err), "")
+	}
+	return failures
 }
 
 func GetDurationInfo(timestamp string, endTime time.Time) (string, string, string) {
@@ -5,6 +5,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/blang/semver"
@@ -173,7 +174,7 @@ types                        1000`))
 
 		It("writes a report for a failed restore", func() {
 			gplog.SetErrorCode(2)
-			utils.WriteRestoreReportFile("filename", timestamp, restoreStartTime, connection, restoreVersion, "Cannot access /tmp/backups: Permission denied")
+			utils.WriteRestoreReportFile("filename", timestamp, restoreStartTime, connection, restoreVersion, "Cannot access /tmp/backups: Permission denied", nil)
 			Expect(buffer).To(gbytes.Say(`Greenplum Database Restore Report
 
 Timestamp Key: 20170101010101
@@ -192,7 +193,7 @@ Restore Error: Cannot access /tmp/backups: Permission denied`))
 		})
 		It("writes a report for a successful restore", func() {
 			gplog.SetErrorCode(0)
-			utils.WriteRestoreReportFile("filename", timestamp, restoreStartTime, connection, restoreVersion, "")
+			utils.WriteRestoreReportFile("filename", timestamp, restoreStartTime, connection, restoreVersion, "", nil)
 			Expect(buffer).To(gbytes.Say(`Greenplum Database Restore Report
 
 Timestamp Key: 20170101010101
@@ -210,7 +211,7 @@ Restore Status: Success`))
 		})
 		It("writes a report for a successful restore with errors", func() {
 			gplog.SetErrorCode(1)
-			utils.WriteRestoreReportFile("filename", timestamp, restoreStartTime, connection, restoreVersion, "")
+			utils.WriteRestoreReportFile("filename", timestamp, restoreStartTime, connection, restoreVersion, "", nil)
 			Expect(buffer).To(gbytes.Say(`Greenplum Database Restore Report
 
 Timestamp Key: 20170101010101
@@ -226,6 +227,59 @@ Duration: 4:03:01
 
 Restore Status: Success but non-fatal errors occurred. See log file .+ for details.`))
 		})
+		It("writes a report for a successful restore that analyzed tables", func() {
+			gplog.SetErrorCode(0)
+			utils.WriteRestoreReportFile("filename", timestamp, restoreStartTime, connection, restoreVersion, "", &utils.AnalyzeResults{NumAnalyzed: 10, NumSkipped: 2, NumFailed: 0})
+			Expect(buffer).To(gbytes.Say(`Restore Status: Success
+
+Tables Analyzed: 10
+Tables Not Analyzed Because Statistics Were Restored: 2
+Tables Failed To Analyze: 0`))
+		})
+	})
+	Describe("RestoreFailures", func() {
+		It("records an excerpt of a failed statement", func() {
+			failures := &utils.RestoreFailures{}
+			statement := utils.StatementWithType{ObjectType: "FUNCTION", Schema: "public", Name: "func1()", Statement: "\n\nCREATE FUNCTION public.func1() RETURNS integer AS $$SELECT 1$$ LANGUAGE sql;\n"}
+			failures.AddFailedStatement(statement, errors.New(`type "integer" does not exist`))
+			Expect(failures.FailedStatements).To(Equal([]utils.FailedStatement{{ObjectType: "FUNCTION", Schema: "public", Name: "func1()",
+				Statement: "CREATE FUNCTION public.func1() RETURNS integer AS $$SELECT 1$$ LANGUAGE sql;", Error: `type "integer" does not exist`}}))
+		})
+		It("truncates long statements", func() {
+			failures := &utils.RestoreFailures{}
+			statement := utils.StatementWithType{ObjectType: "VIEW", Schema: "public", Name: "view1", Statement: "CREATE VIEW public.view1 AS SELECT " + strings.Repeat("1, ", 100) + "1;"}
+			failures.AddFailedStatement(statement, errors.New("error"))
+			Expect(failures.FailedStatements[0].Statement).To(HaveLen(203))
+			Expect(failures.FailedStatements[0].Statement).To(HaveSuffix("1, ..."))
+		})
+		It("is empty only if no statements or tables failed", func() {
+			failures := &utils.RestoreFailures{}
+			Expect(failures.IsEmpty()).To(BeTrue())
+			failures.AddFailedTable("public.foo", "error")
+			Expect(failures.IsEmpty()).To(BeFalse())
+		})
+		It("reads failures from an error file", func() {
+			operating.System.ReadFile = func(filename string) ([]byte, error) {
+				return []byte(`failedstatements:
+- objecttype: INDEX
+  schema: public
+  name: foo_idx
+  referenceobject: public.foo
+  statement: CREATE INDEX foo_idx ON public.foo USING btree (i);
+  error: relation "public.foo" does not exist
+failedtables:
+- table: public.bar
+  error: Expected to restore 10 rows to table public.bar, but restored 5 instead
+`), nil
+			}
+			defer operating.InitializeSystemFunctions()
+			failures := utils.ReadRestoreFailuresFile("errors.yaml")
+			Expect(failures).To(Equal(&utils.RestoreFailures{
+				FailedStatements: []utils.FailedStatement{{ObjectType: "INDEX", Schema: "public", Name: "foo_idx", ReferenceObject: "public.foo",
+					Statement: "CREATE INDEX foo_idx ON public.foo USING btree (i);", Error: `relation "public.foo" does not exist`}},
+				FailedTables: []utils.FailedTable{{Table: "public.bar", Error: "Expected to restore 10 rows to table public.bar, but restored 5 instead"}},
+			}))
+		})
 	})
 	Describe("SetBackupParamFromFlags", func() {
 		var backupReport *utils.Report
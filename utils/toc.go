@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"sort"
 
 	"github.com/greenplum-db/gp-common-go-libs/gplog"
 	"github.com/greenplum-db/gp-common-go-libs/operating"
@@ -12,6 +13,8 @@ import (
 
 type TOC struct {
 	metadataEntryMap  map[string]*[]MetadataEntry
+	definitionMap     map[string]*[]ObjectDefinition
+	objectDefinitions *ObjectDefinitions
 	GlobalEntries     []MetadataEntry
 	PredataEntries    []MetadataEntry
 	PostdataEntries   []MetadataEntry
@@ -31,14 +34,17 @@ type MetadataEntry struct {
 	ReferenceObject string
 	StartByte       uint64
 	EndByte         uint64
+	Dependencies    []int `yaml:",omitempty"`
 }
 
 type MasterDataEntry struct {
-	Schema          string
-	Name            string
-	Oid             uint32
-	AttributeString string
-	RowsCopied      int64
+	Schema             string
+	Name               string
+	Oid                uint32
+	AttributeString    string
+	RowsCopied         int64
+	TableSize          int64
+	DistributionPolicy string
 }
 
 type SegmentDataEntry struct {
@@ -55,6 +61,14 @@ func NewTOC(filename string) *TOC {
 	return toc
 }
 
+func NewTOCFromPlugin(plugin *PluginConfig, filename string) *TOC {
+	toc := &TOC{}
+	contents := plugin.RestoreData(filename)
+	err := yaml.Unmarshal(contents, toc)
+	gplog.FatalOnError(err)
+	return toc
+}
+
 func NewSegmentTOC(filename string) *SegmentTOC {
 	toc := &SegmentTOC{}
 	contents, err := operating.System.ReadFile(filename)
@@ -75,6 +89,13 @@ func (toc *TOC) WriteToFile(filename string) {
 	MustPrintBytes(tocFile, tocContents)
 }
 
+func (toc *TOC) WriteToPlugin(plugin *PluginConfig, filename string) {
+	tocFile := NewFileWithByteCountFromPlugin(plugin, filename)
+	defer tocFile.Close()
+	tocContents, _ := yaml.Marshal(toc)
+	MustPrintBytes(tocFile.writer, tocContents)
+}
+
 func (toc *SegmentTOC) WriteToFile(filename string) {
 	tocFile := MustOpenFileForWriting(filename)
 	tocContents, _ := yaml.Marshal(toc)
@@ -87,23 +108,74 @@ type StatementWithType struct {
 	ObjectType      string
 	ReferenceObject string
 	Statement       string
+	Dependencies    []int
 }
 
 func (toc *TOC) GetSQLStatementForObjectTypes(section string, metadataFile io.ReaderAt, includeObjectTypes []string, excludeObjectTypes []string, includeSchemas []string, excludeSchemas []string, includeTables []string, excludeTables []string) []StatementWithType {
 	entries := *toc.metadataEntryMap[section]
 	objectSet, schemaSet, tableSet := constructFilterSets(includeObjectTypes, excludeObjectTypes, includeSchemas, excludeSchemas, includeTables, excludeTables)
 	statements := make([]StatementWithType, 0)
-	for _, entry := range entries {
+	statementIndexes := make(map[int]int, 0)
+	for i, entry := range entries {
 		if shouldIncludeStatement(entry, objectSet, schemaSet, tableSet) {
-			contents := make([]byte, entry.EndByte-entry.StartByte)
-			_, err := metadataFile.ReadAt(contents, int64(entry.StartByte))
-			gplog.FatalOnError(err)
-			statements = append(statements, StatementWithType{Schema: entry.Schema, Name: entry.Name, ObjectType: entry.ObjectType, ReferenceObject: entry.ReferenceObject, Statement: string(contents)})
+			statementIndexes[i] = len(statements)
+			statements = append(statements, getStatementForEntry(entry, metadataFile))
 		}
 	}
+	resolveStatementDependencies(entries, statements, statementIndexes)
 	return statements
 }
 
+func getStatementForEntry(entry MetadataEntry, metadataFile io.ReaderAt) StatementWithType {
+	contents := make([]byte, entry.EndByte-entry.StartByte)
+	_, err := metadataFile.ReadAt(contents, int64(entry.StartByte))
+	gplog.FatalOnError(err)
+	return StatementWithType{Schema: entry.Schema, Name: entry.Name, ObjectType: entry.ObjectType, ReferenceObject: entry.ReferenceObject, Statement: string(contents)}
+}
+
+/*
+ * Entry dependencies are stored as indexes into the full list of entries for a
+ * section, so they must be translated into indexes into the list of statements
+ * being returned.  If a statement depends on an entry that was filtered out, it
+ * inherits that entry's dependencies instead, so that ordering constraints that
+ * pass through excluded objects are still respected.
+ */
+func resolveStatementDependencies(entries []MetadataEntry, statements []StatementWithType, statementIndexes map[int]int) {
+	resolvedExcluded := make(map[int][]int, 0)
+	var resolve func(entryIndex int) []int
+	resolve = func(entryIndex int) []int {
+		if statementIndex, ok := statementIndexes[entryIndex]; ok {
+			return []int{statementIndex}
+		}
+		if resolved, ok := resolvedExcluded[entryIndex]; ok {
+			return resolved
+		}
+		resolved := make([]int, 0)
+		for _, dependency := range entries[entryIndex].Dependencies {
+			resolved = append(resolved, resolve(dependency)...)
+		}
+		resolvedExcluded[entryIndex] = resolved
+		return resolved
+	}
+	for entryIndex, statementIndex := range statementIndexes {
+		if len(entries[entryIndex].Dependencies) == 0 {
+			continue
+		}
+		dependencySet := make(map[int]bool, 0)
+		for _, dependency := range entries[entryIndex].Dependencies {
+			for _, resolved := range resolve(dependency) {
+				dependencySet[resolved] = true
+			}
+		}
+		dependencies := make([]int, 0)
+		for dependency := range dependencySet {
+			dependencies = append(dependencies, dependency)
+		}
+		sort.Ints(dependencies)
+		statements[statementIndex].Dependencies = dependencies
+	}
+}
+
 func constructFilterSets(includeObjectTypes []string, excludeObjectTypes []string, includeSchemas []string, excludeSchemas []string, includeTables []string, excludeTables []string) (*FilterSet, *FilterSet, *FilterSet) {
 	var objectSet, schemaSet, tableSet *FilterSet
 	if len(includeObjectTypes) > 0 {
@@ -138,10 +210,9 @@ func (toc *TOC) GetAllSQLStatements(section string, metadataFile io.ReaderAt) []
 	entries := *toc.metadataEntryMap[section]
 	statements := make([]StatementWithType, 0)
 	for _, entry := range entries {
-		contents := make([]byte, entry.EndByte-entry.StartByte)
-		_, err := metadataFile.ReadAt(contents, int64(entry.StartByte))
-		gplog.FatalOnError(err)
-		statements = append(statements, StatementWithType{Schema: entry.Schema, Name: entry.Name, ObjectType: entry.ObjectType, ReferenceObject: entry.ReferenceObject, Statement: string(contents)})
+		statement := getStatementForEntry(entry, metadataFile)
+		statement.Dependencies = entry.Dependencies
+		statements = append(statements, statement)
 	}
 	return statements
 }
@@ -210,7 +281,7 @@ func (toc *TOC) InitializeEntryMap() {
 }
 
 func (toc *TOC) AddMetadataEntry(schema string, name string, objectType string, referenceObject string, start uint64, file *FileWithByteCount, section string) {
-	*toc.metadataEntryMap[section] = append(*toc.metadataEntryMap[section], MetadataEntry{schema, name, objectType, referenceObject, start, file.ByteCount})
+	*toc.metadataEntryMap[section] = append(*toc.metadataEntryMap[section], MetadataEntry{Schema: schema, Name: name, ObjectType: objectType, ReferenceObject: referenceObject, StartByte: start, EndByte: file.ByteCount})
 }
 
 func (toc *TOC) AddGlobalEntry(schema string, name string, objectType string, start uint64, file *FileWithByteCount) {
@@ -221,6 +292,35 @@ func (toc *TOC) AddPredataEntry(schema string, name string, objectType string, r
 	toc.AddMetadataEntry(schema, name, objectType, referenceObject, start, file, "predata")
 }
 
+/*
+ * Entries whose Dependencies are still nil were not placed in a dependency
+ * graph when they were written, so they must be restored in the order in which
+ * they appear.  Each such entry is made to depend on every preceding entry that
+ * nothing else depends on yet, which serializes it against everything before it
+ * while leaving entries with explicitly recorded dependencies free to run in
+ * parallel with each other.
+ */
+func (toc *TOC) SetSerialDependencies(section string) {
+	entries := *toc.metadataEntryMap[section]
+	frontier := make(map[int]bool, 0)
+	for i := range entries {
+		if entries[i].Dependencies == nil {
+			dependencies := make([]int, 0)
+			for dependency := range frontier {
+				dependencies = append(dependencies, dependency)
+			}
+			sort.Ints(dependencies)
+			entries[i].Dependencies = dependencies
+			frontier = make(map[int]bool, 0)
+		} else {
+			for _, dependency := range entries[i].Dependencies {
+				delete(frontier, dependency)
+			}
+		}
+		frontier[i] = true
+	}
+}
+
 func (toc *TOC) AddPostdataEntry(schema string, name string, objectType string, referenceObject string, start uint64, file *FileWithByteCount) {
 	toc.AddMetadataEntry(schema, name, objectType, referenceObject, start, file, "postdata")
 }
@@ -229,8 +329,8 @@ func (toc *TOC) AddStatisticsEntry(schema string, name string, objectType string
 	toc.AddMetadataEntry(schema, name, objectType, "", start, file, "statistics")
 }
 
-func (toc *TOC) AddMasterDataEntry(schema string, name string, oid uint32, attributeString string, rowsCopied int64) {
-	toc.DataEntries = append(toc.DataEntries, MasterDataEntry{schema, name, oid, attributeString, rowsCopied})
+func (toc *TOC) AddMasterDataEntry(schema string, name string, oid uint32, attributeString string, rowsCopied int64, tableSize int64, distributionPolicy string) {
+	toc.DataEntries = append(toc.DataEntries, MasterDataEntry{schema, name, oid, attributeString, rowsCopied, tableSize, distributionPolicy})
 }
 
 func (toc *SegmentTOC) AddSegmentDataEntry(oid uint, startByte uint64, endByte uint64) {
@@ -168,39 +168,70 @@ var _ = Describe("utils/toc tests", func() {
 
 			Expect(statements).To(Equal([]utils.StatementWithType{}))
 		})
+		It("resolves dependencies on filtered-out entries to the dependencies of those entries", func() {
+			backupfile.ByteCount = table1Len
+			toc.AddMetadataEntry("schema", "table1", "TABLE", "", 0, backupfile, "global")
+			backupfile.ByteCount += sequenceLen
+			toc.AddMetadataEntry("schema", "somesequence", "SEQUENCE", "", table1Len, backupfile, "global")
+			backupfile.ByteCount += table2Len
+			toc.AddMetadataEntry("schema2", "table2", "TABLE", "", table1Len+sequenceLen, backupfile, "global")
+			toc.GlobalEntries[1].Dependencies = []int{0}
+			toc.GlobalEntries[2].Dependencies = []int{1}
+
+			metadataFile := bytes.NewReader([]byte(table1.Statement + sequence.Statement + table2.Statement))
+			statements := toc.GetSQLStatementForObjectTypes("global", metadataFile, []string{"TABLE"}, noExObj, noInSchema, noExSchema, noInTable, noExTable)
+
+			table2WithDependencies := table2
+			table2WithDependencies.Dependencies = []int{0}
+			Expect(statements).To(Equal([]utils.StatementWithType{table1, table2WithDependencies}))
+		})
+		It("drops dependencies that only pass through filtered-out entries with no dependencies", func() {
+			backupfile.ByteCount = sequenceLen
+			toc.AddMetadataEntry("schema", "somesequence", "SEQUENCE", "", 0, backupfile, "global")
+			backupfile.ByteCount += table1Len
+			toc.AddMetadataEntry("schema", "table1", "TABLE", "", sequenceLen, backupfile, "global")
+			toc.GlobalEntries[1].Dependencies = []int{0}
+
+			metadataFile := bytes.NewReader([]byte(sequence.Statement + table1.Statement))
+			statements := toc.GetSQLStatementForObjectTypes("global", metadataFile, []string{"TABLE"}, noExObj, noInSchema, noExSchema, noInTable, noExTable)
+
+			table1WithDependencies := table1
+			table1WithDependencies.Dependencies = []int{}
+			Expect(statements).To(Equal([]utils.StatementWithType{table1WithDependencies}))
+		})
 	})
 	Context("GetDataEntriesMatching", func() {
 		It("returns matching entry on include schema", func() {
 			includeSchemas := []string{"schema1"}
-			toc.AddMasterDataEntry("schema1", "table1", 1, "(i)", 0)
-			toc.AddMasterDataEntry("schema2", "table2", 1, "(i)", 0)
+			toc.AddMasterDataEntry("schema1", "table1", 1, "(i)", 0, 0, "")
+			toc.AddMasterDataEntry("schema2", "table2", 1, "(i)", 0, 0, "")
 			matchingEntries := toc.GetDataEntriesMatching(includeSchemas, []string{}, []string{}, []string{})
 			Expect(matchingEntries).To(Equal([]utils.MasterDataEntry{{Schema: "schema1", Name: "table1", Oid: 1, AttributeString: "(i)"}}))
 		})
 		It("returns matching entry on exclude schema", func() {
 			excludeSchemas := []string{"schema2"}
-			toc.AddMasterDataEntry("schema1", "table1", 1, "(i)", 0)
-			toc.AddMasterDataEntry("schema2", "table2", 1, "(i)", 0)
+			toc.AddMasterDataEntry("schema1", "table1", 1, "(i)", 0, 0, "")
+			toc.AddMasterDataEntry("schema2", "table2", 1, "(i)", 0, 0, "")
 			matchingEntries := toc.GetDataEntriesMatching([]string{}, excludeSchemas, []string{}, []string{})
 			Expect(matchingEntries).To(Equal([]utils.MasterDataEntry{{Schema: "schema1", Name: "table1", Oid: 1, AttributeString: "(i)"}}))
 		})
 		It("returns matching entry on include table", func() {
 			includeTables := []string{"schema1.table1"}
-			toc.AddMasterDataEntry("schema1", "table1", 1, "(i)", 0)
-			toc.AddMasterDataEntry("schema2", "table2", 1, "(i)", 0)
+			toc.AddMasterDataEntry("schema1", "table1", 1, "(i)", 0, 0, "")
+			toc.AddMasterDataEntry("schema2", "table2", 1, "(i)", 0, 0, "")
 			matchingEntries := toc.GetDataEntriesMatching([]string{}, []string{}, includeTables, []string{})
 			Expect(matchingEntries).To(Equal([]utils.MasterDataEntry{{Schema: "schema1", Name: "table1", Oid: 1, AttributeString: "(i)"}}))
 		})
 		It("returns matching entry on exclude table", func() {
 			excludeTables := []string{"schema2.table2"}
-			toc.AddMasterDataEntry("schema1", "table1", 1, "(i)", 0)
-			toc.AddMasterDataEntry("schema2", "table2", 1, "(i)", 0)
+			toc.AddMasterDataEntry("schema1", "table1", 1, "(i)", 0, 0, "")
+			toc.AddMasterDataEntry("schema2", "table2", 1, "(i)", 0, 0, "")
 			matchingEntries := toc.GetDataEntriesMatching([]string{}, []string{}, []string{}, excludeTables)
 			Expect(matchingEntries).To(Equal([]utils.MasterDataEntry{{Schema: "schema1", Name: "table1", Oid: 1, AttributeString: "(i)"}}))
 		})
 		It("returns all entries when not schema-filtered or table-filtered", func() {
-			toc.AddMasterDataEntry("schema1", "table1", 1, "(i)", 0)
-			toc.AddMasterDataEntry("schema2", "table2", 1, "(i)", 0)
+			toc.AddMasterDataEntry("schema1", "table1", 1, "(i)", 0, 0, "")
+			toc.AddMasterDataEntry("schema2", "table2", 1, "(i)", 0, 0, "")
 			matchingEntries := toc.GetDataEntriesMatching([]string{}, []string{}, []string{}, []string{})
 			Expect(matchingEntries).To(Equal([]utils.MasterDataEntry{{Schema: "schema1", Name: "table1", Oid: 1, AttributeString: "(i)"}, {Schema: "schema2", Name: "table2", Oid: 1, AttributeString: "(i)"}}))
 		})
@@ -235,6 +266,48 @@ var _ = Describe("utils/toc tests", func() {
 
 			Expect(statements).To(Equal([]utils.StatementWithType{}))
 		})
+		It("returns statements with their entry dependencies", func() {
+			backupfile.ByteCount = role1Len
+			toc.AddMetadataEntry("", "somerole1", "ROLE", "", 0, backupfile, "global")
+			backupfile.ByteCount += role2Len
+			toc.AddMetadataEntry("", "somerole2", "ROLE", "", role1Len, backupfile, "global")
+			toc.GlobalEntries[1].Dependencies = []int{0}
+
+			metadataFile := bytes.NewReader([]byte(role1.Statement + role2.Statement))
+			statements := toc.GetAllSQLStatements("global", metadataFile)
+
+			role2WithDependencies := role2
+			role2WithDependencies.Dependencies = []int{0}
+			Expect(statements).To(Equal([]utils.StatementWithType{role1, role2WithDependencies}))
+		})
+	})
+	Context("SetSerialDependencies", func() {
+		BeforeEach(func() {
+			for i := 0; i < 6; i++ {
+				toc.AddMetadataEntry("schema", "object", "TABLE", "", 0, backupfile, "global")
+			}
+		})
+		It("makes each entry depend on the previous entry when no dependencies were recorded", func() {
+			toc.SetSerialDependencies("global")
+
+			Expect(toc.GlobalEntries[0].Dependencies).To(Equal([]int{}))
+			for i := 1; i < 6; i++ {
+				Expect(toc.GlobalEntries[i].Dependencies).To(Equal([]int{i - 1}))
+			}
+		})
+		It("does not modify recorded dependencies and makes the next unrecorded entry depend on all unreferenced entries", func() {
+			toc.GlobalEntries[2].Dependencies = []int{1}
+			toc.GlobalEntries[3].Dependencies = []int{1}
+			toc.GlobalEntries[4].Dependencies = []int{2}
+
+			toc.SetSerialDependencies("global")
+
+			Expect(toc.GlobalEntries[1].Dependencies).To(Equal([]int{0}))
+			Expect(toc.GlobalEntries[2].Dependencies).To(Equal([]int{1}))
+			Expect(toc.GlobalEntries[3].Dependencies).To(Equal([]int{1}))
+			Expect(toc.GlobalEntries[4].Dependencies).To(Equal([]int{2}))
+			Expect(toc.GlobalEntries[5].Dependencies).To(Equal([]int{3, 4}))
+		})
 	})
 	Context("SubstituteRedirectDatabaseInStatements", func() {
 		wrongCreate := utils.StatementWithType{ObjectType: "TABLE", Statement: "CREATE DATABASE somedatabase;\n"}
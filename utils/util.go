@@ -106,3 +106,193 @@ func validateGPDBVersionCompatibility(connection *dbconn.DBConn) {
 		gplog.Fatal(errors.Errorf(`GPDB version %s is not supported. Please upgrade to GPDB %s or later.`, connection.Version.VersionString, MINIMUM_GPDB5_VERSION), "")
 	}
 }
+
+/*
+ * Returns a SQL expression that reconstructs the DISTRIBUTED clause of a table
+ * from its gp_distribution_policy row, aliased as p.  This is shared by backup
+ * and restore so that restored policies can be compared to backed up ones.
+ * On GPDB 6, a hash operator class is only included if it is not the default
+ * for the column's type.
+ */
+func DistributionPolicyExpression(connection *dbconn.DBConn) string {
+	if connection.Version.Before("6") {
+		return `CASE
+		WHEN coalesce(array_upper(p.attrnums, 1), 0) = 0 THEN 'DISTRIBUTED RANDOMLY'
+		ELSE 'DISTRIBUTED BY (' || array_to_string(ARRAY(
+			SELECT quote_ident(a.attname)
+			FROM generate_series(1, array_upper(p.attrnums, 1)) AS k(position)
+			JOIN pg_attribute a ON (a.attrelid, a.attnum) = (p.localoid, p.attrnums[k.position])
+			ORDER BY k.position), ', ') || ')'
+	END`
+	}
+	return `CASE
+		WHEN p.policytype = 'r' THEN 'DISTRIBUTED REPLICATED'
+		WHEN coalesce(array_length(p.distkey::int2[], 1), 0) = 0 THEN 'DISTRIBUTED RANDOMLY'
+		ELSE 'DISTRIBUTED BY (' || array_to_string(ARRAY(
+			SELECT quote_ident(a.attname) || CASE WHEN o.opcdefault THEN '' ELSE ' ' || quote_ident(n.nspname) || '.' || quote_ident(o.opcname) END
+			FROM unnest(p.distkey::int2[], p.distclass::oid[]) WITH ORDINALITY AS k(attnum, opclass, position)
+			JOIN pg_attribute a ON (a.attrelid, a.attnum) = (p.localoid, k.attnum)
+			JOIN pg_opclass o ON o.oid = k.opclass
+			JOIN pg_namespace n ON n.oid = o.opcnamespace
+			ORDER BY k.position), ', ') || ')'
+	END`
+}
+
+var dollarQuoteTagRegex = regexp.MustCompile(`^\$(?:[A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+/*
+ * If a quoted identifier, string literal, dollar-quoted string, or comment
+ * begins at index i of sql, this function returns the index of its last byte;
+ * otherwise it returns -1.  Unterminated constructs extend to the end of sql.
+ */
+func skipQuotedSQL(sql string, i int) int {
+	switch {
+	case sql[i] == '\'' || sql[i] == '"':
+		quote := sql[i]
+		escapeBackslashes := quote == '\'' && i > 0 && (sql[i-1] == 'E' || sql[i-1] == 'e')
+		for i++; i < len(sql); i++ {
+			if escapeBackslashes && sql[i] == '\\' {
+				i++
+			} else if sql[i] == quote {
+				if i+1 < len(sql) && sql[i+1] == quote {
+					i++
+				} else {
+					return i
+				}
+			}
+		}
+		return len(sql) - 1
+	case sql[i] == '$' && (i == 0 || !isIdentifierByte(sql[i-1])):
+		tag := dollarQuoteTagRegex.FindString(sql[i:])
+		if tag == "" {
+			return -1
+		}
+		if end := strings.Index(sql[i+len(tag):], tag); end >= 0 {
+			return i + len(tag) + end + len(tag) - 1
+		}
+		return len(sql) - 1
+	case strings.HasPrefix(sql[i:], "--"):
+		if end := strings.Index(sql[i:], "\n"); end >= 0 {
+			return i + end - 1
+		}
+		return len(sql) - 1
+	case strings.HasPrefix(sql[i:], "/*"):
+		depth := 0
+		for ; i < len(sql); i++ {
+			if strings.HasPrefix(sql[i:], "/*") {
+				depth++
+				i++
+			} else if strings.HasPrefix(sql[i:], "*/") {
+				depth--
+				i++
+				if depth == 0 {
+					return i
+				}
+			}
+		}
+		return len(sql) - 1
+	}
+	return -1
+}
+
+func isIdentifierByte(char byte) bool {
+	return char == '_' || char == '$' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9')
+}
+
+/*
+ * Splits a string containing one or more SQL statements into the individual
+ * statements, each including its terminating semicolon and any whitespace
+ * preceding it, so that concatenating the results reproduces the input.
+ * Semicolons inside quoted identifiers, string literals, dollar-quoted strings,
+ * and comments do not end a statement.  Any text after the last semicolon is
+ * returned as a final element.
+ */
+func SplitSQLStatements(sql string) []string {
+	statements := make([]string, 0)
+	start := 0
+	for i := 0; i < len(sql); i++ {
+		if end := skipQuotedSQL(sql, i); end >= 0 {
+			i = end
+		} else if sql[i] == ';' {
+			statements = append(statements, sql[start:i+1])
+			start = i + 1
+		}
+	}
+	if start < len(sql) {
+		statements = append(statements, sql[start:])
+	}
+	return statements
+}
+
+/*
+ * Returns a copy of sql in which the contents of string literals, dollar-quoted
+ * strings, and comments are replaced with spaces and the contents of quoted
+ * identifiers are replaced with underscores, so that keywords can be found with
+ * regular expressions without matching quoted text.  The result has the same
+ * length as the input, so match indexes in it can be used to modify the input.
+ */
+func MaskQuotedSQL(sql string) string {
+	masked := []byte(sql)
+	for i := 0; i < len(sql); i++ {
+		if end := skipQuotedSQL(sql, i); end >= 0 {
+			if sql[i] == '"' {
+				for j := i + 1; j < end; j++ {
+					masked[j] = '_'
+				}
+			} else {
+				for j := i; j <= end; j++ {
+					masked[j] = ' '
+				}
+			}
+			i = end
+		}
+	}
+	return string(masked)
+}
+
+// This function removes the quotes from an identifier, if it is quoted.
+func UnquoteIdent(ident string) string {
+	if len(ident) >= 2 && strings.HasPrefix(ident, `"`) && strings.HasSuffix(ident, `"`) {
+		return strings.Replace(ident[1:len(ident)-1], `""`, `"`, -1)
+	}
+	return ident
+}
+
+/*
+ * These are the keywords that GPDB does not allow as unquoted identifiers:
+ * every keyword that is not an unreserved keyword, as in quote_ident().
+ */
+var nonUnreservedKeywords = map[string]bool{
+	"all": true, "analyse": true, "analyze": true, "and": true, "any": true, "array": true, "as": true, "asc": true,
+	"asymmetric": true, "authorization": true, "between": true, "bigint": true, "binary": true, "bit": true,
+	"boolean": true, "both": true, "case": true, "cast": true, "char": true, "character": true, "check": true,
+	"coalesce": true, "collate": true, "collation": true, "column": true, "concurrently": true, "constraint": true,
+	"create": true, "cross": true, "current_catalog": true, "current_date": true, "current_role": true,
+	"current_schema": true, "current_time": true, "current_timestamp": true, "current_user": true, "dec": true,
+	"decimal": true, "decode": true, "default": true, "deferrable": true, "desc": true, "distinct": true,
+	"distributed": true, "do": true, "else": true, "end": true, "except": true, "exclude": true, "exists": true,
+	"extract": true, "false": true, "fetch": true, "filter": true, "float": true, "following": true, "for": true,
+	"foreign": true, "freeze": true, "from": true, "full": true, "grant": true, "greatest": true, "group": true,
+	"having": true, "ilike": true, "in": true, "initially": true, "inner": true, "inout": true, "int": true,
+	"integer": true, "intersect": true, "interval": true, "into": true, "is": true, "isnull": true, "join": true,
+	"lateral": true, "leading": true, "least": true, "left": true, "like": true, "limit": true, "localtime": true,
+	"localtimestamp": true, "log": true, "national": true, "natural": true, "nchar": true, "none": true, "not": true,
+	"notnull": true, "null": true, "nullif": true, "numeric": true, "offset": true, "on": true, "only": true, "or": true,
+	"order": true, "out": true, "outer": true, "over": true, "overlaps": true, "overlay": true, "partition": true,
+	"placing": true, "position": true, "preceding": true, "precision": true, "primary": true, "range": true, "real": true,
+	"references": true, "returning": true, "right": true, "row": true, "rows": true, "scatter": true, "select": true,
+	"session_user": true, "setof": true, "similar": true, "smallint": true, "some": true, "substring": true,
+	"symmetric": true, "table": true, "then": true, "time": true, "timestamp": true, "to": true, "trailing": true,
+	"treat": true, "trim": true, "true": true, "unbounded": true, "union": true, "unique": true, "user": true,
+	"using": true, "values": true, "varchar": true, "variadic": true, "verbose": true, "when": true, "where": true,
+	"window": true, "with": true, "xmlattributes": true, "xmlconcat": true, "xmlelement": true, "xmlexists": true,
+	"xmlforest": true, "xmlparse": true, "xmlpi": true, "xmlroot": true, "xmlserialize": true,
+}
+
+// Like quote_ident() in GPDB, quoting identifiers that are keywords as well as those with uppercase or special characters.
+func QuoteIdent(ident string) string {
+	if regexp.MustCompile(`^[a-z_][a-z0-9_$]*$`).MatchString(ident) && !nonUnreservedKeywords[ident] {
+		return ident
+	}
+	return fmt.Sprintf(`"%s"`, strings.Replace(ident, `"`, `""`, -1))
+}
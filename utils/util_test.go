@@ -47,6 +47,64 @@ var _ = Describe("utils/util tests", func() {
 			Expect(actual).To(Equal(expected))
 		})
 	})
+	Describe("SplitSQLStatements", func() {
+		It("splits statements on semicolons, keeping preceding whitespace with each statement", func() {
+			statements := utils.SplitSQLStatements("\n\nCREATE TABLE public.foo (i int);\n\nALTER TABLE public.foo OWNER TO testrole;")
+			Expect(statements).To(Equal([]string{"\n\nCREATE TABLE public.foo (i int);", "\n\nALTER TABLE public.foo OWNER TO testrole;"}))
+		})
+		It("returns text after the last semicolon as a final statement", func() {
+			statements := utils.SplitSQLStatements("SELECT 1;\n")
+			Expect(statements).To(Equal([]string{"SELECT 1;", "\n"}))
+		})
+		It("does not split on semicolons in quoted identifiers and string literals", func() {
+			statements := utils.SplitSQLStatements(`CREATE TABLE "foo;bar" (i int);COMMENT ON TABLE "foo;bar" IS 'it''s; a comment';SELECT E'a\';b';`)
+			Expect(statements).To(Equal([]string{`CREATE TABLE "foo;bar" (i int);`, `COMMENT ON TABLE "foo;bar" IS 'it''s; a comment';`, `SELECT E'a\';b';`}))
+		})
+		It("does not split on semicolons in dollar-quoted strings", func() {
+			function := "CREATE FUNCTION public.f() RETURNS void AS $_$\nBEGIN\n\tGRANT ALL ON TABLE public.foo TO testrole;\nEND;\n$_$ LANGUAGE plpgsql;"
+			statements := utils.SplitSQLStatements(function + "\nALTER FUNCTION public.f() OWNER TO testrole;")
+			Expect(statements).To(Equal([]string{function, "\nALTER FUNCTION public.f() OWNER TO testrole;"}))
+		})
+		It("does not treat dollar signs in identifiers or parameters as dollar quotes", func() {
+			statements := utils.SplitSQLStatements("SELECT a$b$ FROM t;SELECT $1;")
+			Expect(statements).To(Equal([]string{"SELECT a$b$ FROM t;", "SELECT $1;"}))
+		})
+		It("does not split on semicolons in comments", func() {
+			statements := utils.SplitSQLStatements("SELECT 1; -- one; two\nSELECT /* three; /* four; */ */ 2;")
+			Expect(statements).To(Equal([]string{"SELECT 1;", " -- one; two\nSELECT /* three; /* four; */ */ 2;"}))
+		})
+	})
+	Describe("MaskQuotedSQL", func() {
+		It("masks string literals, dollar-quoted strings, comments, and quoted identifier contents", func() {
+			sql := `SELECT 'a;b', $$c$$, "D e" -- f` + "\n/* g */ FROM t;"
+			Expect(utils.MaskQuotedSQL(sql)).To(Equal(`SELECT      ,      , "___"     ` + "\n        FROM t;"))
+		})
+	})
+	Describe("QuoteIdent", func() {
+		It("does not quote identifiers that do not need it", func() {
+			Expect(utils.QuoteIdent("test_role1")).To(Equal("test_role1"))
+		})
+		It("quotes identifiers with uppercase or special characters", func() {
+			Expect(utils.QuoteIdent("Test Role")).To(Equal(`"Test Role"`))
+			Expect(utils.QuoteIdent(`test"role`)).To(Equal(`"test""role"`))
+		})
+		It("quotes identifiers that are reserved keywords", func() {
+			Expect(utils.QuoteIdent("user")).To(Equal(`"user"`))
+			Expect(utils.QuoteIdent("select")).To(Equal(`"select"`))
+			Expect(utils.QuoteIdent("group")).To(Equal(`"group"`))
+		})
+		It("does not quote identifiers that are unreserved keywords", func() {
+			Expect(utils.QuoteIdent("role")).To(Equal("role"))
+		})
+	})
+	Describe("UnquoteIdent", func() {
+		It("returns an unquoted identifier unchanged", func() {
+			Expect(utils.UnquoteIdent("testrole")).To(Equal("testrole"))
+		})
+		It("removes quotes from a quoted identifier", func() {
+			Expect(utils.UnquoteIdent(`"test""role"`)).To(Equal(`test"role`))
+		})
+	})
 	Describe("ValidateFQNs", func() {
 		It("validates an unquoted string", func() {
 			testStrings := []string{`schemaname.tablename`}